@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/samber/do"
+	"os"
+	"strings"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+)
+
+// runCommand runs the command given after the options, e.g.
+// go-restful-dns [options] import [-mode merge|replace] <zone> <file>
+func runCommand(injector *do.Injector, args []string) error {
+	switch args[0] {
+	case "import":
+		return importZone(injector, args[1:])
+	default:
+		return fmt.Errorf("unknown command %s", args[0])
+	}
+}
+
+func importZone(injector *do.Injector, args []string) error {
+	flagSet := flag.NewFlagSet("import", flag.ContinueOnError)
+	mode := flagSet.String("mode", string(domain.ImportMerge), "Import mode, merge or replace")
+	flagSet.Usage = func() {
+		_, _ = fmt.Fprintf(
+			os.Stderr, "usage: %s [command line options] import [-mode merge|replace] <zone> <file>\n", os.Args[0],
+		)
+		flagSet.PrintDefaults()
+	}
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flagSet.NArg() != 2 {
+		flagSet.Usage()
+		return fmt.Errorf("import needs the zone and the zone file")
+	}
+
+	file, err := os.Open(flagSet.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	zoneUseCase := do.MustInvoke[domain.ZoneUseCase](injector)
+	result, err := zoneUseCase.ImportZone(context.Background(), flagSet.Arg(0), file, domain.ImportMode(*mode))
+	if err != nil {
+		if e, ok := err.(*domain.Error); ok && len(e.Details) > 0 {
+			return fmt.Errorf("%s\n%s", e.Message, strings.Join(e.Details, "\n"))
+		}
+		return err
+	}
+
+	output, _ := json.Marshal(result)
+	fmt.Println(string(output))
+	return nil
+}
@@ -28,21 +28,50 @@ func main() {
 	pkgDo.ProvideUseCase(injector)
 	pkgDo.ProvideController(injector)
 
+	if args := do.MustInvoke[*domain.Options](injector).Args; len(args) > 0 {
+		err := runCommand(injector, args)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	err := do.MustInvoke[domain.InitHandler](injector).Initialize(context.Background())
 	if err != nil {
 		panic(err)
 	}
 
-	dnsServer := do.MustInvoke[*dns.Server](injector)
+	dnsServers := do.MustInvoke[[]*dns.Server](injector)
 	httpServer := do.MustInvoke[*http.Server](injector)
+	secondaryUseCase := do.MustInvoke[domain.SecondaryUseCase](injector)
+	upstreamUseCase := do.MustInvoke[domain.UpstreamUseCase](injector)
+	ctx, cancel := context.WithCancel(context.Background())
 
-	startServer(dnsServer.ListenAndServe, httpServer.ListenAndServe)
-	startWaitForShutdown(
+	start := []func() error{
+		httpServer.ListenAndServe,
+		func() error {
+			return secondaryUseCase.Run(ctx)
+		},
+		func() error {
+			return upstreamUseCase.Run(ctx)
+		},
+	}
+	shutdown := []func() error{
 		func() error {
 			return httpServer.Shutdown(context.Background())
 		},
-		dnsServer.Shutdown,
-	)
+		func() error {
+			cancel()
+			return nil
+		},
+	}
+	for _, dnsServer := range dnsServers {
+		start = append(start, dnsServer.ListenAndServe)
+		shutdown = append(shutdown, dnsServer.Shutdown)
+	}
+
+	startServer(start...)
+	startWaitForShutdown(shutdown...)
 }
 
 func startServer(start ...func() error) {
@@ -57,7 +86,7 @@ func startServer(start ...func() error) {
 }
 
 func startWaitForShutdown(shutdown ...func() error) {
-	sigChan := make(chan os.Signal)
+	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
 
 	for {
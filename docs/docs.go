@@ -15,9 +15,313 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/dns-query": {
+            "get": {
+                "description": "Resolve a query with RFC 8484 GET (dns=base64url) or the JSON flavor (name, type)",
+                "produces": [
+                    "application/dns-message",
+                    "application/dns-json"
+                ],
+                "tags": [
+                    "DoH"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Base64url encoded DNS message without padding",
+                        "name": "dns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Domain Name, JSON flavor only",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Record Type, JSON flavor only",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.DoHJSONResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Resolve a query with RFC 8484 POST",
+                "consumes": [
+                    "application/dns-message"
+                ],
+                "produces": [
+                    "application/dns-message"
+                ],
+                "tags": [
+                    "DoH"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "DNS message in wire format",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/export": {
+            "get": {
+                "description": "Export the records, including the SOA and NS records of the zones, as a BIND master\nfile, as JSON in the record API schema or as CSV. The records are in the canonical order\nand the output is streamed zone by zone.",
+                "produces": [
+                    "text/plain",
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Export"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bind",
+                        "description": "Export Format, bind, json or csv",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only export the records of the zone",
+                        "name": "zone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only export the records owned by the name",
+                        "name": "name",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "bind: a BIND master file, json: an array of records in the record API schema, csv: the name, ttl, class, type and rdata of a record per row",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/forwarding-rules": {
+            "get": {
+                "description": "List all forwarding rules",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Forwarding Rule"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.ForwardingRule"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a forwarding rule, which forwards the queries of the names under its suffix to its upstream\nforwarders, e.g. corp.example. to the AD DNS servers. The rule of the longest matching suffix wins,\nand the strategy is sequential unless given.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Forwarding Rule"
+                ],
+                "parameters": [
+                    {
+                        "description": "The example of forwarding rule request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.ForwardingRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.ForwardingRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/forwarding-rules/{suffix}": {
+            "get": {
+                "description": "Get forwarding rule by suffix",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Forwarding Rule"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain Suffix",
+                        "name": "suffix",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.ForwardingRule"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update the upstream forwarders and the strategy of an existed forwarding rule",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Forwarding Rule"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain Suffix",
+                        "name": "suffix",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The example of forwarding rule request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.ForwardingRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.ForwardingRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete forwarding rule by suffix, its names are forwarded by the next longest suffix",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Forwarding Rule"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain Suffix",
+                        "name": "suffix",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
         "/record": {
             "get": {
-                "description": "Get dns record by name, qtype, qclass",
+                "description": "Get all records of the RRset by name, qtype, qclass",
                 "consumes": [
                     "application/json"
                 ],
@@ -51,7 +355,10 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.A"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.A"
+                            }
                         }
                     },
                     "400": {
@@ -63,7 +370,7 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Delete dns record by name, qtype, qclass",
+                "description": "Delete the whole RRset by name, qtype, qclass",
                 "consumes": [
                     "application/json"
                 ],
@@ -71,6 +378,12 @@ const docTemplate = `{
                     "Record"
                 ],
                 "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Maintain the PTR records of A and AAAA records",
+                        "name": "createPtr",
+                        "in": "query"
+                    },
                     {
                         "description": "The example of Question request body",
                         "name": "body",
@@ -99,7 +412,7 @@ const docTemplate = `{
         },
         "/record/{recordType}": {
             "put": {
-                "description": "Update an existed dns record",
+                "description": "Replace an existed RRset of any type with the single record",
                 "consumes": [
                     "application/json"
                 ],
@@ -107,6 +420,19 @@ const docTemplate = `{
                     "Record"
                 ],
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Record Type, e.g. a, mx, txt",
+                        "name": "recordType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Maintain the PTR records of A and AAAA records",
+                        "name": "createPtr",
+                        "in": "query"
+                    },
                     {
                         "description": "The example of A record request body",
                         "name": "body",
@@ -133,7 +459,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a new dns record",
+                "description": "Add a new dns record of any type to its RRset, the rdata is given either as the fields\nof the record, e.g. \"preference\" and \"mx\" of MX, or as \"rdata\" in presentation format",
                 "consumes": [
                     "application/json"
                 ],
@@ -141,6 +467,19 @@ const docTemplate = `{
                     "Record"
                 ],
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Record Type, e.g. a, mx, txt",
+                        "name": "recordType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Maintain the PTR records of A and AAAA records",
+                        "name": "createPtr",
+                        "in": "query"
+                    },
                     {
                         "description": "The example of A record request body",
                         "name": "body",
@@ -165,29 +504,823 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/records": {
-            "get": {
-                "description": "List all dns record",
+            },
+            "delete": {
+                "description": "Remove a single record from its RRset",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Record"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Record Type, e.g. a, mx, txt",
+                        "name": "recordType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Maintain the PTR records of A and AAAA records",
+                        "name": "createPtr",
+                        "in": "query"
+                    },
+                    {
+                        "description": "The example of A record request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.A"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/records": {
+            "get": {
+                "description": "List all dns record",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Record"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.A"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/rrset/{recordType}": {
+            "put": {
+                "description": "Replace the whole RRset with the records, which share the same name, type and class",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Record"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Record Type, e.g. a, mx, txt",
+                        "name": "recordType",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Maintain the PTR records of A and AAAA records",
+                        "name": "createPtr",
+                        "in": "query"
+                    },
+                    {
+                        "description": "The example of A RRset request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.A"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.A"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/tsig-keys": {
+            "get": {
+                "description": "List all TSIG keys",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TSIG Key"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.TsigKey"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a TSIG key, which signs the dynamic updates (RFC 2136) of the zones it's given, or of all\nzones when none is given. The algorithm is hmac-sha256 and the secret is generated unless given.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TSIG Key"
+                ],
+                "parameters": [
+                    {
+                        "description": "The example of TSIG key request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.TsigKey"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.TsigKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/tsig-keys/{name}": {
+            "get": {
+                "description": "Get TSIG key by name",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TSIG Key"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.TsigKey"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update the algorithm, secret and zones of an existed TSIG key, the secret is generated again\nunless given, which rotates the key",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TSIG Key"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The example of TSIG key request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.TsigKey"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.TsigKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete TSIG key by name, the messages signed by it are no longer accepted",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TSIG Key"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/upstreams": {
+            "get": {
+                "description": "List the health of the upstream forwarders, with their smoothed RTTs and failures. An upstream\nfailing in a row is ejected for a while, and is tried last until a query or a health probe succeeds.\nThe default upstream forwarders come first without a suffix, followed by the ones of the forwarding\nrules by their suffixes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Upstream"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.UpstreamGroup"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/zones": {
+            "get": {
+                "description": "List all zones",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Zone"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Zone"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new authoritative zone, the serial is assigned by the server. A secondary zone only needs\nthe address of its primary, its records are transferred from the primary and are read-only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Zone"
+                ],
+                "parameters": [
+                    {
+                        "description": "The example of zone request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Zone"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Zone"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/zones/{zone}": {
+            "get": {
+                "description": "Get zone by name",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Zone"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone Name",
+                        "name": "zone",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Zone"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update the SOA fields and NS set of an existed zone, the serial is increased",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Zone"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone Name",
+                        "name": "zone",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The example of zone request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Zone"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Zone"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete zone by name, the records inside the zone are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Zone"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone Name",
+                        "name": "zone",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/zones/{zone}/dnssec": {
+            "get": {
+                "description": "Get the DNSSEC policy of the zone with its keys and DS records",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Zone"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone Name",
+                        "name": "zone",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Dnssec"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Sign the zone by a new KSK and ZSK, the denial is proven by NSEC unless NSEC3 is given. The body\nis optional, the algorithm is ECDSAP256SHA256 when not given. The DS records in the response\nshould be added to the parent zone.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Zone"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone Name",
+                        "name": "zone",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The example of DNSSEC request body",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Dnssec"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Dnssec"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete the keys of the zone, which is answered unsigned at once. The DS records should be\nremoved from the parent zone first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Zone"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone Name",
+                        "name": "zone",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/zones/{zone}/dnssec/ds": {
+            "get": {
+                "description": "List the DS records of the published KSKs of the zone, which are added to the parent zone",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Zone"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone Name",
+                        "name": "zone",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/zones/{zone}/dnssec/rollover": {
+            "post": {
+                "description": "Publish a new key of the type, which replaces the active keys at the given time, or after the\nDNSKEY TTL when not given. The replaced keys are removed after another DNSKEY TTL.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Zone"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone Name",
+                        "name": "zone",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The example of rollover request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.DnssecRollover"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Dnssec"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/zones/{zone}/import": {
+            "post": {
+                "description": "Import a master file (RFC 1035 5) into the zone, the zone is created by the SOA record\nof the file when it isn't existed. The merge mode replaces the RRsets given by the file,\nthe replace mode replaces all records of the zone. Nothing is written when any RR is\ninvalid, the errors are reported per line in details.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Zone"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone Name",
+                        "name": "zone",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "merge",
+                        "description": "Import Mode, merge or replace",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "description": "The zone file",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.ZoneImport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/zones/{zone}/notify": {
+            "get": {
+                "description": "List the delivery of the latest NOTIFY of the zone to each of its targets, a pending\nNOTIFY is retried with backoff until it's delivered or failed",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Zone"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone Name",
+                        "name": "zone",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.NotifyStatus"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Send NOTIFY of the zone to its targets at once, the delivery is reported by the notify\nstatus API. The changes of the records notify the targets as well.",
                 "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "Record"
+                    "Zone"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Zone Name",
+                        "name": "zone",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.A"
+                                "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.NotifyStatus"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Error"
                         }
@@ -247,9 +1380,213 @@ const docTemplate = `{
                 "ClassANY"
             ]
         },
+        "github_com_cewuandy_go-restful-dns_internal_domain.Dnssec": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "description": "ECDSAP256SHA256 when not given, or ED25519",
+                    "type": "string"
+                },
+                "ds": {
+                    "description": "DS are the records of the published KSKs, which are given to the parent zone",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "iterations": {
+                    "description": "of NSEC3, 0 is recommended (RFC 9276)",
+                    "type": "integer"
+                },
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.DnssecKey"
+                    }
+                },
+                "nsec3": {
+                    "description": "Nsec3 proves the denial by NSEC3 (RFC 5155) instead of NSEC, both are minimally covering\nrecords (RFC 4470), so that the zone cannot be walked",
+                    "type": "boolean"
+                },
+                "salt": {
+                    "description": "of NSEC3 in hex, none is recommended (RFC 9276)",
+                    "type": "string"
+                },
+                "zone": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.DnssecKey": {
+            "type": "object",
+            "properties": {
+                "activate": {
+                    "type": "string"
+                },
+                "dnskey": {
+                    "description": "presentation format",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "keyTag": {
+                    "type": "integer"
+                },
+                "publish": {
+                    "type": "string"
+                },
+                "remove": {
+                    "type": "string"
+                },
+                "retire": {
+                    "type": "string"
+                },
+                "state": {
+                    "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.DnssecKeyState"
+                },
+                "type": {
+                    "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.DnssecKeyType"
+                },
+                "zone": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.DnssecKeyState": {
+            "type": "string",
+            "enum": [
+                "published",
+                "active",
+                "retired",
+                "removed"
+            ],
+            "x-enum-varnames": [
+                "DnssecKeyPublished",
+                "DnssecKeyActive",
+                "DnssecKeyRetired",
+                "DnssecKeyRemoved"
+            ]
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.DnssecKeyType": {
+            "type": "string",
+            "enum": [
+                "ksk",
+                "zsk"
+            ],
+            "x-enum-varnames": [
+                "DnssecKSK",
+                "DnssecZSK"
+            ]
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.DnssecRollover": {
+            "type": "object",
+            "required": [
+                "type"
+            ],
+            "properties": {
+                "at": {
+                    "description": "At is when the new key starts signing and the old keys retire, the DNSKEY TTL from now when\nnot given. The DS of a new KSK should be at the parent by then.",
+                    "type": "string"
+                },
+                "type": {
+                    "enum": [
+                        "ksk",
+                        "zsk"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.DnssecKeyType"
+                        }
+                    ]
+                }
+            }
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.DoHAnswer": {
+            "type": "object",
+            "properties": {
+                "TTL": {
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.DoHJSONResponse": {
+            "type": "object",
+            "properties": {
+                "AD": {
+                    "type": "boolean"
+                },
+                "Additional": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.DoHAnswer"
+                    }
+                },
+                "Answer": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.DoHAnswer"
+                    }
+                },
+                "Authority": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.DoHAnswer"
+                    }
+                },
+                "CD": {
+                    "type": "boolean"
+                },
+                "Question": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.DoHQuestion"
+                    }
+                },
+                "RA": {
+                    "type": "boolean"
+                },
+                "RD": {
+                    "type": "boolean"
+                },
+                "Status": {
+                    "type": "integer"
+                },
+                "TC": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.DoHQuestion": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "integer"
+                }
+            }
+        },
         "github_com_cewuandy_go-restful-dns_internal_domain.Error": {
             "type": "object",
             "properties": {
+                "details": {
+                    "description": "Details are the individual problems, e.g. the per-line errors of a zone file",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "message": {
                     "type": "string"
                 },
@@ -258,6 +1595,75 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_cewuandy_go-restful-dns_internal_domain.ForwardingRule": {
+            "type": "object",
+            "required": [
+                "suffix",
+                "upstreams"
+            ],
+            "properties": {
+                "strategy": {
+                    "description": "sequential when not given",
+                    "type": "string"
+                },
+                "suffix": {
+                    "type": "string"
+                },
+                "upstreams": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.ImportMode": {
+            "type": "string",
+            "enum": [
+                "merge",
+                "replace"
+            ],
+            "x-enum-varnames": [
+                "ImportMerge",
+                "ImportReplace"
+            ]
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.NotifyState": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "delivered",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "NotifyPending",
+                "NotifyDelivered",
+                "NotifyFailed"
+            ]
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.NotifyStatus": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "serial": {
+                    "type": "integer"
+                },
+                "state": {
+                    "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.NotifyState"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "updated": {
+                    "type": "string"
+                }
+            }
+        },
         "github_com_cewuandy_go-restful-dns_internal_domain.RRType": {
             "type": "string",
             "enum": [
@@ -464,6 +1870,182 @@ const docTemplate = `{
                     "type": "integer"
                 }
             }
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.TsigKey": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "hmac-sha256 when not given",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "secret": {
+                    "description": "base64, generated when not given",
+                    "type": "string"
+                },
+                "zones": {
+                    "description": "Zones are the zones which the key may update, all zones when empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.Upstream": {
+            "type": "object",
+            "properties": {
+                "consecutiveFailures": {
+                    "type": "integer"
+                },
+                "ejectedUntil": {
+                    "type": "string"
+                },
+                "exchanges": {
+                    "type": "integer"
+                },
+                "failures": {
+                    "type": "integer"
+                },
+                "healthy": {
+                    "description": "Healthy is false while the upstream is ejected after failing in a row",
+                    "type": "boolean"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "lastProbe": {
+                    "type": "string"
+                },
+                "rttMs": {
+                    "description": "smoothed RTT, 0 before any answer",
+                    "type": "number"
+                },
+                "upstream": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.UpstreamGroup": {
+            "type": "object",
+            "properties": {
+                "suffix": {
+                    "type": "string"
+                },
+                "upstreams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.Upstream"
+                    }
+                }
+            }
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.Zone": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "expire": {
+                    "type": "integer"
+                },
+                "mbox": {
+                    "type": "string"
+                },
+                "minttl": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nameservers": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "notify": {
+                    "description": "Notify are the addresses of the secondaries which are notified of the changes of the zone",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "primary": {
+                    "description": "Primary is the address of the server which a secondary zone is transferred from",
+                    "type": "string"
+                },
+                "refresh": {
+                    "type": "integer"
+                },
+                "refreshed": {
+                    "description": "Refreshed is when a secondary zone was last found up to date with its primary",
+                    "type": "string"
+                },
+                "retry": {
+                    "type": "integer"
+                },
+                "serial": {
+                    "type": "integer"
+                },
+                "tsigKey": {
+                    "description": "TsigKey is the name of the TSIG key which signs the queries and transfers of a secondary zone\nto its primary, they are unsigned when it's empty",
+                    "type": "string"
+                },
+                "ttl": {
+                    "description": "default TTL of the records in this zone",
+                    "type": "integer"
+                },
+                "type": {
+                    "enum": [
+                        "primary",
+                        "secondary"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.ZoneType"
+                        }
+                    ]
+                }
+            }
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.ZoneImport": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "description": "the RRsets which are removed since the file doesn't have them",
+                    "type": "integer"
+                },
+                "mode": {
+                    "$ref": "#/definitions/github_com_cewuandy_go-restful-dns_internal_domain.ImportMode"
+                },
+                "records": {
+                    "type": "integer"
+                },
+                "rrsets": {
+                    "type": "integer"
+                },
+                "zone": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_cewuandy_go-restful-dns_internal_domain.ZoneType": {
+            "type": "string",
+            "enum": [
+                "primary",
+                "secondary"
+            ],
+            "x-enum-varnames": [
+                "ZonePrimary",
+                "ZoneSecondary"
+            ]
         }
     }
 }`
@@ -2,7 +2,11 @@ package dns
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"strings"
+	"time"
 
 	"github.com/miekg/dns"
 	"github.com/samber/do"
@@ -10,8 +14,42 @@ import (
 	"github.com/cewuandy/go-restful-dns/internal/domain"
 )
 
+// errorRcodes are the rcodes of the errors which the use cases wrap
+var errorRcodes = map[error]int{
+	domain.ErrRefused:  dns.RcodeRefused,
+	domain.ErrNotAuth:  dns.RcodeNotAuth,
+	domain.ErrFormat:   dns.RcodeFormatError,
+	domain.ErrNotZone:  dns.RcodeNotZone,
+	domain.ErrNXDomain: dns.RcodeNameError,
+	domain.ErrYXDomain: dns.RcodeYXDomain,
+	domain.ErrNXRRSet:  dns.RcodeNXRrset,
+	domain.ErrYXRRSet:  dns.RcodeYXRrset,
+}
+
+// transferMsgSize bounds the messages of a zone transfer, which is far below the 64KB limit of TCP
+const transferMsgSize = 16 * 1024
+
 type dnsHandler struct {
 	dnsUseCase domain.DNSUseCase
+
+	transferUseCase domain.TransferUseCase
+
+	secondaryUseCase domain.SecondaryUseCase
+
+	updateUseCase domain.UpdateUseCase
+
+	tsigKeyUseCase domain.TsigKeyUseCase
+
+	dnssecUseCase domain.DnssecUseCase
+
+	// transferAllow are the networks of the clients which may transfer zones
+	transferAllow []*net.IPNet
+
+	// ednsSize is the EDNS0 buffer size advertised to the clients
+	ednsSize uint16
+
+	// cookieSecret signs the server cookies (RFC 7873)
+	cookieSecret []byte
 }
 
 func (d *dnsHandler) ServeDNS(respWriter dns.ResponseWriter, req *dns.Msg) {
@@ -20,43 +58,394 @@ func (d *dnsHandler) ServeDNS(respWriter dns.ResponseWriter, req *dns.Msg) {
 		err  error
 	)
 
-	resp = new(dns.Msg)
+	if respWriter == nil {
+		fmt.Printf("Error: respWriter is nil\n")
+		return
+	}
+
+	// a message with a TSIG which fails is answered with NOTAUTH and the TSIG error (RFC 8945 5.2)
+	if req.IsTsig() != nil && respWriter.TsigStatus() != nil {
+		fmt.Printf("Error verifying the TSIG of %s: %s\n", d.questionName(req), respWriter.TsigStatus().Error())
+		d.writeMsg(respWriter, req, d.rcodeResponse(req, dns.RcodeNotAuth))
+		return
+	}
+
+	if rcode := d.checkEdns(req); rcode != dns.RcodeSuccess {
+		d.writeMsg(respWriter, req, d.rcodeResponse(req, rcode))
+		return
+	}
+
+	if req.Opcode == dns.OpcodeNotify {
+		d.notify(context.Background(), respWriter, req)
+		return
+	}
+
+	if req.Opcode == dns.OpcodeUpdate {
+		d.update(context.Background(), respWriter, req)
+		return
+	}
+
+	if d.isTransfer(req) {
+		d.transfer(context.Background(), respWriter, req)
+		return
+	}
+
+	resp, err = d.resolve(domain.WithClientAddr(context.Background(), respWriter.RemoteAddr()), req)
+	if err != nil {
+		fmt.Printf("Error resolving %s: %s\n", d.questionName(req), err.Error())
+		resp = d.errorResponse(req, err)
+	}
+	d.writeMsg(respWriter, req, resp)
+}
+
+// resolve answers locally hosted zones first, then the redis cache, and falls back to
+// the upstream forwarders
+func (d *dnsHandler) resolve(ctx context.Context, req *dns.Msg) (*dns.Msg, error) {
+	if req.Opcode != dns.OpcodeQuery {
+		return d.rcodeResponse(req, dns.RcodeNotImplemented), nil
+	}
+	if len(req.Question) != 1 {
+		return d.rcodeResponse(req, dns.RcodeFormatError), nil
+	}
+
+	resp, err := d.dnsUseCase.QueryAuthoritative(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil {
+		return d.dnssecUseCase.Sign(ctx, req, resp)
+	}
+
+	resp, err = d.dnsUseCase.QueryRedisCache(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil && (len(resp.Answer) > 0 || len(resp.Ns) > 0 || len(resp.Extra) > 0) {
+		return resp, nil
+	}
+
+	resp, err = d.dnsUseCase.QueryUpstream(ctx, req)
+	if err == nil && resp == nil {
+		err = fmt.Errorf("no response from upstream")
+	}
+	return resp, err
+}
+
+// notify acknowledges the NOTIFY of the primary of a secondary zone, which is refreshed at once
+// instead of waiting for its refresh timer (RFC 1996)
+func (d *dnsHandler) notify(ctx context.Context, respWriter dns.ResponseWriter, req *dns.Msg) {
+	if len(req.Question) != 1 {
+		d.writeMsg(respWriter, req, d.rcodeResponse(req, dns.RcodeFormatError))
+		return
+	}
+
+	err := d.secondaryUseCase.Notify(ctx, req.Question[0].Name, respWriter.RemoteAddr())
+	if err != nil {
+		fmt.Printf("Error notifying %s: %s\n", req.Question[0].Name, err.Error())
+		d.writeMsg(respWriter, req, d.errorResponse(req, err))
+		return
+	}
+
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	resp.Authoritative = true
+	d.writeMsg(respWriter, req, resp)
+}
+
+// update applies the dynamic update (RFC 2136) which is signed by a known TSIG key
+func (d *dnsHandler) update(ctx context.Context, respWriter dns.ResponseWriter, req *dns.Msg) {
+	tsig := req.IsTsig()
+	if tsig == nil {
+		fmt.Printf("Error updating %s: the update isn't signed\n", d.questionName(req))
+		d.writeMsg(respWriter, req, d.rcodeResponse(req, dns.RcodeRefused))
+		return
+	}
+
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	err := d.updateUseCase.Update(ctx, tsig.Hdr.Name, req)
+	if err != nil {
+		fmt.Printf("Error updating %s: %s\n", d.questionName(req), err.Error())
+		resp = d.errorResponse(req, err)
+	}
+	d.writeMsg(respWriter, req, resp)
+}
+
+func (d *dnsHandler) isTransfer(req *dns.Msg) bool {
+	if req.Opcode != dns.OpcodeQuery || len(req.Question) != 1 {
+		return false
+	}
+	return req.Question[0].Qtype == dns.TypeAXFR || req.Question[0].Qtype == dns.TypeIXFR
+}
+
+// transfer serves AXFR and IXFR to the allowed clients over DNS, the records are split into as many
+// messages as they need over TCP
+func (d *dnsHandler) transfer(ctx context.Context, respWriter dns.ResponseWriter, req *dns.Msg) {
+	var (
+		rrs []dns.RR
+		err error
+	)
 
-	defer func() {
-		if respWriter == nil {
-			fmt.Printf("Error: respWriter is nil\n")
+	q := req.Question[0]
+	_, udp := respWriter.RemoteAddr().(*net.UDPAddr)
+	_, single := respWriter.(domain.SingleMsgWriter)
+	switch {
+	case single:
+		// a DoH response holds a single message, and its client address may come from the proxy headers
+		err = fmt.Errorf("transfer over a single message transport: %w", domain.ErrRefused)
+	case !d.transferAllowed(ctx, respWriter.RemoteAddr(), req):
+		err = fmt.Errorf("%s isn't allowed to transfer: %w", respWriter.RemoteAddr(), domain.ErrRefused)
+	case q.Qtype == dns.TypeAXFR && udp:
+		// AXFR is only served over TCP (RFC 5936 4.2)
+		err = fmt.Errorf("AXFR over UDP: %w", domain.ErrRefused)
+	case q.Qtype == dns.TypeAXFR:
+		rrs, err = d.transferUseCase.AXFR(ctx, q.Name)
+	default:
+		// the client gives its serial in the authority section (RFC 1995 3)
+		if len(req.Ns) != 1 || req.Ns[0].Header().Rrtype != dns.TypeSOA {
+			d.writeMsg(respWriter, req, d.rcodeResponse(req, dns.RcodeFormatError))
 			return
 		}
-		if resp == nil {
-			fmt.Printf("Error: resp is nil\n")
-			return
+		rrs, err = d.transferUseCase.IXFR(ctx, q.Name, req.Ns[0].(*dns.SOA).Serial)
+	}
+	if err != nil {
+		fmt.Printf("Error transferring %s: %s\n", q.Name, err.Error())
+		d.writeMsg(respWriter, req, d.errorResponse(req, err))
+		return
+	}
+
+	if udp {
+		resp := d.transferResponse(req, rrs)
+		// the current SOA alone tells the client to retry over TCP (RFC 1995 2)
+		if resp.Len() > d.udpSize(req)-d.tsigSize(req) {
+			resp.Answer = rrs[:1]
 		}
-		if len(resp.Answer) > 0 || len(resp.Ns) > 0 || len(resp.Extra) > 0 {
-			err = respWriter.WriteMsg(resp)
-			if err != nil {
-				fmt.Printf("Error writing response: %s\n", err.Error())
+		d.writeMsg(respWriter, req, resp)
+		return
+	}
+
+	var (
+		chunk []dns.RR
+		size  int
+	)
+	for _, rr := range rrs {
+		if len(chunk) > 0 && size+dns.Len(rr) > transferMsgSize {
+			if !d.writeMsg(respWriter, req, d.transferResponse(req, chunk)) {
+				return
 			}
-		} else {
-			fmt.Printf("Warning: No data to write in response\n")
+			// the following messages are signed with the timers only (RFC 8945 5.3.1)
+			respWriter.TsigTimersOnly(true)
+			chunk, size = nil, 0
+		}
+		chunk = append(chunk, rr)
+		size += dns.Len(rr)
+	}
+	d.writeMsg(respWriter, req, d.transferResponse(req, chunk))
+}
+
+func (d *dnsHandler) transferResponse(req *dns.Msg, rrs []dns.RR) *dns.Msg {
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	resp.Authoritative = true
+	resp.Compress = true
+	resp.Answer = rrs
+	return resp
+}
+
+// transferAllowed allows the clients inside the transfer-allow networks, and the clients which sign
+// the request by a key allowed for the zone
+func (d *dnsHandler) transferAllowed(ctx context.Context, addr net.Addr, req *dns.Msg) bool {
+	if tsig := req.IsTsig(); tsig != nil {
+		key, err := d.tsigKeyUseCase.GetKey(ctx, tsig.Hdr.Name)
+		if err == nil && key.Allows(req.Question[0].Name) {
+			return true
 		}
-	}()
+	}
+
+	ip := remoteIP(addr)
+	for _, network := range d.transferAllow {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
 
-	resp, err = d.dnsUseCase.QueryRedisCache(context.Background(), req)
+// writeMsg answers the EDNS0 of the request, fits the response into the UDP size of the client or pads
+// it over the encrypted transports, and signs it by the key of the request when its TSIG is verified
+// (RFC 8945 5.3), or tells why its TSIG fails
+func (d *dnsHandler) writeMsg(respWriter dns.ResponseWriter, req, resp *dns.Msg) bool {
+	d.setEdns(respWriter, req, resp)
+	if _, ok := respWriter.RemoteAddr().(*net.UDPAddr); ok {
+		d.truncate(req, resp)
+	} else {
+		d.pad(respWriter, req, resp)
+	}
+	if tsig := req.IsTsig(); tsig != nil {
+		resp.SetTsig(tsig.Hdr.Name, tsig.Algorithm, tsig.Fudge, time.Now().Unix())
+		if status := respWriter.TsigStatus(); status != nil {
+			setTsigError(resp.IsTsig(), tsig, status)
+		}
+	}
+	err := respWriter.WriteMsg(resp)
 	if err != nil {
-		fmt.Printf("Error querying Redis cache: %s\n", err.Error())
+		fmt.Printf("Error writing response: %s\n", err.Error())
+		return false
+	}
+	return true
+}
+
+// setTsigError sets the error of the TSIG of the response by the failure of the one of the request,
+// dns.ResponseWriter leaves the BADKEY and BADSIG responses unsigned, and signs the BADTIME ones with
+// the time of the request and the time of the server as the other data (RFC 8945 5.2)
+func setTsigError(tsig *dns.TSIG, req *dns.TSIG, err error) {
+	switch {
+	case errors.Is(err, dns.ErrSecret) || errors.Is(err, dns.ErrKeyAlg) || errors.Is(err, dns.ErrKey):
+		tsig.Error = dns.RcodeBadKey
+	case errors.Is(err, dns.ErrTime):
+		tsig.Error = dns.RcodeBadTime
+		tsig.TimeSigned = req.TimeSigned
+		tsig.OtherLen = 6
+		tsig.OtherData = fmt.Sprintf("%012x", time.Now().Unix())
+	default:
+		tsig.Error = dns.RcodeBadSig
+	}
+}
+
+// errorResponse maps errors from the use case into REFUSED for policy denials, NOTAUTH for
+// zones which aren't hosted here, the rcodes of RFC 2136 for failed updates and SERVFAIL for
+// everything else, so that clients never wait for a timeout, and explains them by extended DNS
+// errors when it can
+func (d *dnsHandler) errorResponse(req *dns.Msg, err error) *dns.Msg {
+	rcode := dns.RcodeServerFailure
+	for target, errorRcode := range errorRcodes {
+		if errors.Is(err, target) {
+			rcode = errorRcode
+			break
+		}
+	}
+
+	resp := d.rcodeResponse(req, rcode)
+	if opt := d.extendedError(err); opt != nil {
+		resp.Extra = append(resp.Extra, opt)
+	}
+	return resp
+}
+
+func (d *dnsHandler) rcodeResponse(req *dns.Msg, rcode int) *dns.Msg {
+	resp := new(dns.Msg)
+	resp.SetRcode(req, rcode)
+	return resp
+}
+
+func (d *dnsHandler) questionName(req *dns.Msg) string {
+	if len(req.Question) == 0 {
+		return "empty question"
+	}
+	return req.Question[0].Name
+}
+
+// truncate fits the response into the UDP size of the client, dns.Msg.Truncate leaves no room
+// for a TSIG so a signed response which doesn't fit keeps the question alone, and the client
+// retries over TCP
+func (d *dnsHandler) truncate(req, resp *dns.Msg) {
+	size := d.udpSize(req)
+	if req.IsTsig() == nil {
+		resp.Truncate(size)
 		return
 	}
-	if resp != nil && (len(resp.Answer) > 0 || len(resp.Ns) > 0 || len(resp.Extra) > 0) {
+	if resp.Len()+d.tsigSize(req) <= size {
 		return
 	}
 
-	resp, err = d.dnsUseCase.QueryUpstream(context.Background(), req)
-	if err != nil {
-		fmt.Printf("Error querying upstream: %s\n", err.Error())
-		return
+	opt := resp.IsEdns0()
+	resp.Truncated = true
+	resp.Answer, resp.Ns, resp.Extra = nil, nil, nil
+	if opt != nil {
+		resp.Extra = []dns.RR{opt}
+	}
+}
+
+// tsigSize returns the room of the TSIG which signs the response, which is as long as the TSIG of
+// the request
+func (d *dnsHandler) tsigSize(req *dns.Msg) int {
+	if tsig := req.IsTsig(); tsig != nil {
+		return dns.Len(tsig)
+	}
+	return 0
+}
+
+// udpSize returns the largest reply the client accepts over UDP, which is the
+// EDNS0 buffer size when advertised, bounded by the one of the server, and 512 bytes otherwise
+func (d *dnsHandler) udpSize(req *dns.Msg) int {
+	if opt := req.IsEdns0(); opt != nil {
+		return max(dns.MinMsgSize, min(int(opt.UDPSize()), int(d.ednsSize)))
 	}
+	return dns.MinMsgSize
+}
+
+// AcceptMsg accepts the dynamic updates (RFC 2136), whose sections have any number of records,
+// the other messages are checked by dns.DefaultMsgAcceptFunc
+func AcceptMsg(dh dns.Header) dns.MsgAcceptAction {
+	const qr = 1 << 15
+	if dh.Bits&qr == 0 && int(dh.Bits>>11)&0xF == dns.OpcodeUpdate {
+		if dh.Qdcount != 1 {
+			return dns.MsgReject
+		}
+		return dns.MsgAccept
+	}
+	return dns.DefaultMsgAcceptFunc(dh)
+}
+
+// parseNetworks parses the comma separated addresses and CIDRs, an address is a network of itself
+func parseNetworks(s string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %s", item)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
 }
 
 func NewDNSHandler(injector *do.Injector) (dns.Handler, error) {
-	return &dnsHandler{do.MustInvoke[domain.DNSUseCase](injector)}, nil
+	env := do.MustInvoke[*domain.Options](injector)
+	transferAllow, err := parseNetworks(env.TransferAllow)
+	if err != nil {
+		return nil, fmt.Errorf("transfer-allow: %w", err)
+	}
+	cookieSecret, err := parseCookieSecret(env.CookieSecret)
+	if err != nil {
+		return nil, fmt.Errorf("cookie-secret: %w", err)
+	}
+
+	return &dnsHandler{
+		do.MustInvoke[domain.DNSUseCase](injector),
+		do.MustInvoke[domain.TransferUseCase](injector),
+		do.MustInvoke[domain.SecondaryUseCase](injector),
+		do.MustInvoke[domain.UpdateUseCase](injector),
+		do.MustInvoke[domain.TsigKeyUseCase](injector),
+		do.MustInvoke[domain.DnssecUseCase](injector),
+		transferAllow,
+		uint16(max(dns.MinMsgSize, min(env.EdnsUdpSize, dns.MaxMsgSize))),
+		cookieSecret,
+	}, nil
 }
@@ -2,6 +2,7 @@ package dns
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"github.com/miekg/dns"
 	"github.com/samber/do"
@@ -15,6 +16,8 @@ import (
 	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
 )
 
+const tsigSecret = "c2VjcmV0LW9mLXRoZS11cGRhdGUta2V5"
+
 type dnsHandlerTestSuite struct {
 	suite.Suite
 
@@ -23,7 +26,18 @@ type dnsHandlerTestSuite struct {
 	dnsServer *dns.Server
 	dnsClient *dns.Client
 
-	dnsUseCase *mocks.DNSUseCase
+	tcpServer *dns.Server
+	tcpClient *dns.Client
+
+	dnsUseCase       *mocks.DNSUseCase
+	transferUseCase  *mocks.TransferUseCase
+	secondaryUseCase *mocks.SecondaryUseCase
+	updateUseCase    *mocks.UpdateUseCase
+	tsigKeyUseCase   *mocks.TsigKeyUseCase
+	dnssecUseCase    *mocks.DnssecUseCase
+
+	// tsigClient signs the requests by the keys of the servers
+	tsigClient *dns.Client
 
 	question dns.Question
 }
@@ -45,30 +59,59 @@ func (t *dnsHandlerTestSuite) SetupSuite() {
 
 	t.dnsUseCase = &mocks.DNSUseCase{}
 	do.ProvideValue[domain.DNSUseCase](injector, t.dnsUseCase)
+	t.transferUseCase = &mocks.TransferUseCase{}
+	do.ProvideValue[domain.TransferUseCase](injector, t.transferUseCase)
+	t.secondaryUseCase = &mocks.SecondaryUseCase{}
+	do.ProvideValue[domain.SecondaryUseCase](injector, t.secondaryUseCase)
+	t.updateUseCase = &mocks.UpdateUseCase{}
+	do.ProvideValue[domain.UpdateUseCase](injector, t.updateUseCase)
+	t.tsigKeyUseCase = &mocks.TsigKeyUseCase{}
+	do.ProvideValue[domain.TsigKeyUseCase](injector, t.tsigKeyUseCase)
+	t.dnssecUseCase = &mocks.DnssecUseCase{}
+	do.ProvideValue[domain.DnssecUseCase](injector, t.dnssecUseCase)
+	do.ProvideValue(
+		injector, &domain.Options{
+			TransferAllow: "127.0.0.1, 10.0.0.0/8",
+			EdnsUdpSize:   4096,
+			CookieSecret:  "000102030405060708090a0b0c0d0e0f",
+		},
+	)
 
 	t.handler, err = NewDNSHandler(injector)
 	t.Nil(err)
 
+	tsigSecrets := map[string]string{"update.": tsigSecret, "dhcp.": tsigSecret}
+	t.tsigClient = &dns.Client{Net: "tcp", DialTimeout: time.Second, TsigSecret: tsigSecrets}
 	t.dnsClient = &dns.Client{Net: "udp", DialTimeout: time.Second}
 	t.dnsServer = &dns.Server{
-		Addr:    "127.0.0.1:53",
-		Net:     "udp",
-		Handler: t.handler,
+		Addr:          "127.0.0.1:53",
+		Net:           "udp",
+		Handler:       t.handler,
+		TsigSecret:    tsigSecrets,
+		MsgAcceptFunc: AcceptMsg,
 	}
-	started := new(bool)
-	*started = false
-	t.dnsServer.NotifyStartedFunc = func() {
-		*started = true
-		fmt.Printf("Listen and serve at localhost:53 for DNS query\n")
+	t.tcpClient = &dns.Client{Net: "tcp", DialTimeout: time.Second}
+	t.tcpServer = &dns.Server{
+		Addr:          "127.0.0.1:53",
+		Net:           "tcp",
+		Handler:       t.handler,
+		TsigSecret:    tsigSecrets,
+		MsgAcceptFunc: AcceptMsg,
 	}
 
-	go func() {
-		err = t.dnsServer.ListenAndServe()
-		t.Nil(err)
-	}()
+	for _, server := range []*dns.Server{t.dnsServer, t.tcpServer} {
+		started := make(chan struct{})
+		server.NotifyStartedFunc = func() {
+			close(started)
+			fmt.Printf("Listen and serve at localhost:53/%s for DNS query\n", server.Net)
+		}
+
+		go func() {
+			err = server.ListenAndServe()
+			t.Nil(err)
+		}()
 
-	for !*started {
-		time.Sleep(time.Second)
+		<-started
 	}
 }
 
@@ -77,14 +120,38 @@ func (t *dnsHandlerTestSuite) SetupTest() {
 		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
 		anyMsg     = mock.AnythingOfType("*dns.Msg")
 	)
+	t.dnsUseCase.
+		On("QueryAuthoritative", anyContext, anyMsg).
+		Return(nil, nil)
 	t.dnsUseCase.
 		On("QueryRedisCache", anyContext, anyMsg).
 		Return(&dns.Msg{Question: []dns.Question{t.question}}, nil)
+	t.dnssecUseCase.ExpectedCalls = nil
+	t.dnssecUseCase.Calls = nil
+	t.dnssecUseCase.
+		On("Sign", anyContext, anyMsg, anyMsg).
+		Return(func(ctx context.Context, req *dns.Msg, resp *dns.Msg) *dns.Msg { return resp }, nil)
+	t.tsigKeyUseCase.ExpectedCalls = nil
+	t.tsigKeyUseCase.
+		On("GetKey", anyContext, "update.").
+		Return(&domain.TsigKey{Name: "update.", Algorithm: dns.HmacSHA256, Secret: tsigSecret}, nil)
+	t.tsigKeyUseCase.
+		On("GetKey", anyContext, "dhcp.").
+		Return(
+			&domain.TsigKey{
+				Name:      "dhcp.",
+				Algorithm: dns.HmacSHA256,
+				Secret:    tsigSecret,
+				Zones:     []string{"other.com."},
+			}, nil,
+		)
 }
 
 func (t *dnsHandlerTestSuite) TearDownSuite() {
 	err := t.dnsServer.Shutdown()
 	t.Nil(err)
+	err = t.tcpServer.Shutdown()
+	t.Nil(err)
 }
 
 func (t *dnsHandlerTestSuite) TestServeDNS() {
@@ -108,6 +175,9 @@ func (t *dnsHandlerTestSuite) TestServeDNS() {
 	t.Run(
 		"redis_success", func() {
 			t.dnsUseCase.ExpectedCalls = nil
+			t.dnsUseCase.
+				On("QueryAuthoritative", anyContext, anyMsg).
+				Return(nil, nil)
 			t.dnsUseCase.
 				On("QueryRedisCache", anyContext, anyMsg).
 				Return(
@@ -129,6 +199,9 @@ func (t *dnsHandlerTestSuite) TestServeDNS() {
 	t.Run(
 		"upstream_success", func() {
 			t.dnsUseCase.ExpectedCalls = nil
+			t.dnsUseCase.
+				On("QueryAuthoritative", anyContext, anyMsg).
+				Return(nil, nil)
 			t.SetupTest()
 			t.dnsUseCase.
 				On("QueryUpstream", anyContext, anyMsg).
@@ -152,29 +225,991 @@ func (t *dnsHandlerTestSuite) TestServeDNS() {
 	t.Run(
 		"redis_error", func() {
 			t.dnsUseCase.ExpectedCalls = nil
+			t.dnsUseCase.
+				On("QueryAuthoritative", anyContext, anyMsg).
+				Return(nil, nil)
 			t.dnsUseCase.
 				On("QueryRedisCache", anyContext, anyMsg).
 				Return(nil, fmt.Errorf("test-error"))
 			resp, _, err := t.dnsClient.Exchange(req, "127.0.0.1:53")
-			t.Nil(resp)
-			t.NotNil(err)
-			t.Contains(err.Error(), "i/o timeout")
+			t.Nil(err)
+			t.Equal(dns.RcodeServerFailure, resp.Rcode)
+			t.Empty(resp.Answer)
 		},
 	)
 
 	t.Run(
 		"upstream_error", func() {
 			t.dnsUseCase.ExpectedCalls = nil
+			t.dnsUseCase.
+				On("QueryAuthoritative", anyContext, anyMsg).
+				Return(nil, nil)
+			t.SetupTest()
+			t.dnsUseCase.
+				On("QueryUpstream", anyContext, anyMsg).
+				Return(nil, fmt.Errorf("test-error"))
+
+			resp, _, err := t.dnsClient.Exchange(req, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeServerFailure, resp.Rcode)
+			t.Empty(resp.Answer)
+		},
+	)
+
+	t.Run(
+		"upstream_refused", func() {
+			t.dnsUseCase.ExpectedCalls = nil
+			t.dnsUseCase.
+				On("QueryAuthoritative", anyContext, anyMsg).
+				Return(nil, nil)
+			t.SetupTest()
+			t.dnsUseCase.
+				On("QueryUpstream", anyContext, anyMsg).
+				Return(nil, domain.Error{Message: "test-error", Err: domain.ErrRefused})
+
+			resp, _, err := t.dnsClient.Exchange(req, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeRefused, resp.Rcode)
+		},
+	)
+
+	t.Run(
+		"upstream_nxdomain", func() {
+			t.dnsUseCase.ExpectedCalls = nil
+			t.dnsUseCase.
+				On("QueryAuthoritative", anyContext, anyMsg).
+				Return(nil, nil)
+			t.SetupTest()
+			soa, _ := dns.NewRR("test.com.\t300\tIN\tSOA\tns.test.com. admin.test.com. 1 7200 3600 86400 300")
+			t.dnsUseCase.
+				On("QueryUpstream", anyContext, anyMsg).
+				Return(
+					&dns.Msg{
+						MsgHdr:   dns.MsgHdr{Rcode: dns.RcodeNameError},
+						Question: []dns.Question{t.question},
+						Ns:       []dns.RR{soa},
+					}, nil,
+				)
+
+			resp, _, err := t.dnsClient.Exchange(req, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeNameError, resp.Rcode)
+			t.Empty(resp.Answer)
+			t.Equal(dns.TypeSOA, resp.Ns[0].Header().Rrtype)
+		},
+	)
+
+	t.Run(
+		"upstream_nodata", func() {
+			t.dnsUseCase.ExpectedCalls = nil
+			t.dnsUseCase.
+				On("QueryAuthoritative", anyContext, anyMsg).
+				Return(nil, nil)
 			t.SetupTest()
 			t.dnsUseCase.
 				On("QueryUpstream", anyContext, anyMsg).
+				Return(&dns.Msg{Question: []dns.Question{t.question}}, nil)
+
+			resp, _, err := t.dnsClient.Exchange(req, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Empty(resp.Answer)
+		},
+	)
+
+	t.Run(
+		"opcode_not_implemented", func() {
+			status := new(dns.Msg)
+			status.SetQuestion("test.com.", dns.TypeSOA)
+			status.Opcode = dns.OpcodeStatus
+
+			resp, _, err := t.dnsClient.Exchange(status, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeNotImplemented, resp.Rcode)
+		},
+	)
+
+	t.Run(
+		"no_question", func() {
+			resp, _, err := t.dnsClient.Exchange(&dns.Msg{}, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeFormatError, resp.Rcode)
+		},
+	)
+
+	t.Run(
+		"authoritative_success", func() {
+			t.dnsUseCase.ExpectedCalls = nil
+			t.dnsUseCase.Calls = nil
+			t.dnsUseCase.
+				On("QueryAuthoritative", anyContext, anyMsg).
+				Return(
+					&dns.Msg{
+						MsgHdr:   dns.MsgHdr{Authoritative: true},
+						Question: []dns.Question{t.question},
+						Answer:   []dns.RR{rr},
+					}, nil,
+				)
+
+			resp, _, err := t.dnsClient.Exchange(req, "127.0.0.1:53")
+			t.Nil(err)
+			t.True(resp.Authoritative)
+			t.Contains(resp.Answer[0].String(), "2.2.2.2")
+			t.dnsUseCase.AssertNotCalled(t.T(), "QueryRedisCache", anyContext, anyMsg)
+			t.dnssecUseCase.AssertCalled(t.T(), "Sign", anyContext, anyMsg, anyMsg)
+		},
+	)
+
+	t.Run(
+		"authoritative_sign_error", func() {
+			t.SetupTest()
+			t.dnsUseCase.ExpectedCalls = nil
+			t.dnsUseCase.
+				On("QueryAuthoritative", anyContext, anyMsg).
+				Return(&dns.Msg{Question: []dns.Question{t.question}, Answer: []dns.RR{rr}}, nil)
+			t.dnssecUseCase.ExpectedCalls = nil
+			t.dnssecUseCase.
+				On("Sign", anyContext, anyMsg, anyMsg).
 				Return(nil, fmt.Errorf("test-error"))
 
 			resp, _, err := t.dnsClient.Exchange(req, "127.0.0.1:53")
-			t.Nil(resp)
-			t.NotNil(err)
-			t.Contains(err.Error(), "i/o timeout")
+			t.Nil(err)
+			t.Equal(dns.RcodeServerFailure, resp.Rcode)
+			t.Empty(resp.Answer)
+			t.SetupTest()
+		},
+	)
+
+	t.Run(
+		"authoritative_error", func() {
+			t.dnsUseCase.ExpectedCalls = nil
+			t.dnsUseCase.
+				On("QueryAuthoritative", anyContext, anyMsg).
+				Return(nil, fmt.Errorf("test-error"))
+
+			resp, _, err := t.dnsClient.Exchange(req, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeServerFailure, resp.Rcode)
+		},
+	)
+
+	t.Run(
+		"tcp_success", func() {
+			t.dnsUseCase.ExpectedCalls = nil
+			t.dnsUseCase.
+				On("QueryAuthoritative", anyContext, anyMsg).
+				Return(nil, nil)
+			t.dnsUseCase.
+				On("QueryRedisCache", anyContext, anyMsg).
+				Return(
+					&dns.Msg{
+						Question: []dns.Question{t.question},
+						Answer:   []dns.RR{rr},
+					}, nil,
+				)
+			resp, _, err := t.tcpClient.Exchange(req, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal("test.com.", resp.Answer[0].Header().Name)
+			t.Contains(resp.Answer[0].String(), "2.2.2.2")
+		},
+	)
+
+	t.Run(
+		"signed_success", func() {
+			t.dnsUseCase.ExpectedCalls = nil
+			t.dnsUseCase.
+				On("QueryAuthoritative", anyContext, anyMsg).
+				Return(
+					&dns.Msg{
+						MsgHdr:   dns.MsgHdr{Authoritative: true},
+						Question: []dns.Question{t.question},
+						Answer:   []dns.RR{rr},
+					}, nil,
+				)
+			signedReq := req.Copy()
+			signedReq.SetTsig("update.", dns.HmacSHA256, 300, time.Now().Unix())
+
+			resp, _, err := t.tsigClient.Exchange(signedReq, "127.0.0.1:53")
+			t.Nil(err)
+			t.Contains(resp.Answer[0].String(), "2.2.2.2")
+			// the client has verified the signature of the response
+			t.NotNil(resp.IsTsig())
+		},
+	)
+
+	t.Run(
+		"signed_bad_signature", func() {
+			t.dnsUseCase.Calls = nil
+			signedReq := req.Copy()
+			signedReq.SetTsig("update.", dns.HmacSHA256, 300, time.Now().Unix())
+			forger := &dns.Client{
+				Net:         "tcp",
+				DialTimeout: time.Second,
+				TsigSecret:  map[string]string{"update.": "Zm9yZ2VkLXNlY3JldA=="},
+			}
+
+			resp, _, err := forger.Exchange(signedReq, "127.0.0.1:53")
+			t.ErrorIs(err, dns.ErrAuth)
+			t.Equal(dns.RcodeNotAuth, resp.Rcode)
+			t.Equal(uint16(dns.RcodeBadSig), resp.IsTsig().Error)
+			t.Zero(resp.IsTsig().MACSize)
+			t.dnsUseCase.AssertNotCalled(t.T(), "QueryAuthoritative", anyContext, anyMsg)
+		},
+	)
+
+	t.Run(
+		"signed_bad_key", func() {
+			t.dnsUseCase.Calls = nil
+			signedReq := req.Copy()
+			signedReq.SetTsig("unknown.", dns.HmacSHA256, 300, time.Now().Unix())
+			client := &dns.Client{
+				Net:         "tcp",
+				DialTimeout: time.Second,
+				TsigSecret:  map[string]string{"unknown.": tsigSecret},
+			}
+
+			resp, _, err := client.Exchange(signedReq, "127.0.0.1:53")
+			t.ErrorIs(err, dns.ErrAuth)
+			t.Equal(dns.RcodeNotAuth, resp.Rcode)
+			t.Equal(uint16(dns.RcodeBadKey), resp.IsTsig().Error)
+			t.Zero(resp.IsTsig().MACSize)
+			t.dnsUseCase.AssertNotCalled(t.T(), "QueryAuthoritative", anyContext, anyMsg)
+		},
+	)
+
+	t.Run(
+		"signed_bad_time", func() {
+			t.dnsUseCase.Calls = nil
+			signed := time.Now().Add(-time.Hour).Unix()
+			signedReq := req.Copy()
+			signedReq.SetTsig("update.", dns.HmacSHA256, 300, signed)
+
+			// the response is signed, and the client is told that the authentication fails
+			resp, _, err := t.tsigClient.Exchange(signedReq, "127.0.0.1:53")
+			t.ErrorIs(err, dns.ErrAuth)
+			t.Equal(dns.RcodeNotAuth, resp.Rcode)
+			tsig := resp.IsTsig()
+			t.Equal(uint16(dns.RcodeBadTime), tsig.Error)
+			t.Equal(uint64(signed), tsig.TimeSigned)
+			t.NotZero(tsig.MACSize)
+			t.Equal(uint16(6), tsig.OtherLen)
+			t.dnsUseCase.AssertNotCalled(t.T(), "QueryAuthoritative", anyContext, anyMsg)
+		},
+	)
+}
+
+func (t *dnsHandlerTestSuite) TestServeDNSTruncate() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyMsg     = mock.AnythingOfType("*dns.Msg")
+		req        = &dns.Msg{Question: []dns.Question{t.question}}
+		answer     []dns.RR
+	)
+
+	for i := 0; i < 64; i++ {
+		rr, _ := dns.NewRR(fmt.Sprintf("test.com.\t1440\tIN\tA\t10.0.0.%d", i))
+		answer = append(answer, rr)
+	}
+
+	t.dnsUseCase.ExpectedCalls = nil
+	t.dnsUseCase.
+		On("QueryAuthoritative", anyContext, anyMsg).
+		Return(nil, nil)
+	t.dnsUseCase.
+		On("QueryRedisCache", anyContext, anyMsg).
+		Return(
+			func(ctx context.Context, req *dns.Msg) *dns.Msg {
+				return &dns.Msg{
+					MsgHdr:   dns.MsgHdr{Id: req.Id, Response: true},
+					Question: []dns.Question{t.question},
+					Answer:   answer,
+				}
+			}, nil,
+		)
+
+	t.Run(
+		"udp_truncated", func() {
+			resp, _, err := t.dnsClient.Exchange(req, "127.0.0.1:53")
+			t.Nil(err)
+			t.True(resp.Truncated)
+			t.Less(len(resp.Answer), len(answer))
+		},
+	)
+
+	t.Run(
+		"udp_edns0_buffer", func() {
+			ednsReq := req.Copy()
+			ednsReq.SetEdns0(4096, false)
+			client := &dns.Client{Net: "udp", DialTimeout: time.Second, UDPSize: 4096}
+			resp, _, err := client.Exchange(ednsReq, "127.0.0.1:53")
+			t.Nil(err)
+			t.False(resp.Truncated)
+			t.Len(resp.Answer, len(answer))
+		},
+	)
+
+	t.Run(
+		"udp_signed_truncated", func() {
+			signedReq := req.Copy()
+			signedReq.SetTsig("update.", dns.HmacSHA256, 300, time.Now().Unix())
+			client := &dns.Client{Net: "udp", DialTimeout: time.Second, TsigSecret: t.tsigClient.TsigSecret}
+			resp, _, err := client.Exchange(signedReq, "127.0.0.1:53")
+			t.Nil(err)
+			t.True(resp.Truncated)
+			t.Empty(resp.Answer)
+			t.NotNil(resp.IsTsig())
+		},
+	)
+
+	t.Run(
+		"tcp_not_truncated", func() {
+			resp, _, err := t.tcpClient.Exchange(req, "127.0.0.1:53")
+			t.Nil(err)
+			t.False(resp.Truncated)
+			t.Len(resp.Answer, len(answer))
+		},
+	)
+}
+
+func (t *dnsHandlerTestSuite) TestEdns() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyMsg     = mock.AnythingOfType("*dns.Msg")
+		req        = &dns.Msg{Question: []dns.Question{t.question}}
+	)
+
+	rr, _ := dns.NewRR("test.com.\t1440\tIN\tA\t2.2.2.2")
+	answer := func() {
+		t.dnsUseCase.ExpectedCalls = nil
+		t.dnsUseCase.
+			On("QueryAuthoritative", anyContext, anyMsg).
+			Return(nil, nil)
+		t.dnsUseCase.
+			On("QueryRedisCache", anyContext, anyMsg).
+			Return(
+				func(ctx context.Context, req *dns.Msg) *dns.Msg {
+					return &dns.Msg{Question: []dns.Question{t.question}, Answer: []dns.RR{rr}}
+				}, nil,
+			)
+	}
+	answer()
+
+	ednsReq := func(options ...dns.EDNS0) *dns.Msg {
+		ednsReq := req.Copy()
+		ednsReq.SetEdns0(1232, true)
+		ednsReq.IsEdns0().Option = options
+		return ednsReq
+	}
+	cookie := func(resp *dns.Msg) string {
+		for _, option := range resp.IsEdns0().Option {
+			if cookie, ok := option.(*dns.EDNS0_COOKIE); ok {
+				return cookie.Cookie
+			}
+		}
+		return ""
+	}
+
+	t.Run(
+		"opt_success", func() {
+			resp, _, err := t.dnsClient.Exchange(ednsReq(), "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Equal(uint16(4096), resp.IsEdns0().UDPSize())
+			t.True(resp.IsEdns0().Do())
+			t.Empty(resp.IsEdns0().Option)
+		},
+	)
+
+	t.Run(
+		"no_opt_success", func() {
+			resp, _, err := t.dnsClient.Exchange(req, "127.0.0.1:53")
+			t.Nil(err)
+			t.Nil(resp.IsEdns0())
+		},
+	)
+
+	t.Run(
+		"badvers_error", func() {
+			badVers := ednsReq()
+			badVers.IsEdns0().SetVersion(1)
+			resp, _, err := t.dnsClient.Exchange(badVers, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeBadVers, resp.Rcode)
+			t.Equal(uint8(0), resp.IsEdns0().Version())
+		},
+	)
+
+	t.Run(
+		"multiple_opt_error", func() {
+			multiple := ednsReq()
+			multiple.Extra = append(multiple.Extra, ednsReq().IsEdns0())
+			resp, _, err := t.dnsClient.Exchange(multiple, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeFormatError, resp.Rcode)
+		},
+	)
+
+	t.Run(
+		"malformed_cookie_error", func() {
+			resp, _, err := t.dnsClient.Exchange(
+				ednsReq(&dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "0102030405060708090a"}), "127.0.0.1:53",
+			)
+			t.Nil(err)
+			t.Equal(dns.RcodeFormatError, resp.Rcode)
+		},
+	)
+
+	t.Run(
+		"cookie_success", func() {
+			client := "0102030405060708"
+			resp, _, err := t.dnsClient.Exchange(
+				ednsReq(&dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: client}), "127.0.0.1:53",
+			)
+			t.Nil(err)
+			server := cookie(resp)
+			t.Len(server, 2*(clientCookieSize+serverCookieSize))
+			t.Equal(client, server[:2*clientCookieSize])
+
+			// the young server cookie is kept
+			resp, _, err = t.dnsClient.Exchange(
+				ednsReq(&dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: server}), "127.0.0.1:53",
+			)
+			t.Nil(err)
+			t.Equal(server, cookie(resp))
+
+			// the forged server cookie is replaced
+			forged := server[:len(server)-2] + "00"
+			if forged == server {
+				forged = server[:len(server)-2] + "01"
+			}
+			resp, _, err = t.dnsClient.Exchange(
+				ednsReq(&dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: forged}), "127.0.0.1:53",
+			)
+			t.Nil(err)
+			t.NotEqual(forged, cookie(resp))
+			t.Equal(client, cookie(resp)[:2*clientCookieSize])
+		},
+	)
+
+	t.Run(
+		"cookie_expired", func() {
+			client := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+			handler := t.handler.(*dnsHandler)
+			ip := net.ParseIP("127.0.0.1")
+			old := handler.serverCookie(client, ip, uint32(time.Now().Add(-cookieRenewal-time.Minute).Unix()))
+			t.False(handler.validCookie(client, old, ip, time.Now().Add(-cookieRenewal)))
+			young := handler.serverCookie(client, ip, uint32(time.Now().Unix()))
+			t.True(handler.validCookie(client, young, ip, time.Now().Add(-cookieRenewal)))
+			t.False(handler.validCookie(client, young, net.ParseIP("127.0.0.2"), time.Now().Add(-cookieRenewal)))
+		},
+	)
+
+	t.Run(
+		"extended_error_success", func() {
+			t.dnsUseCase.ExpectedCalls = nil
+			t.dnsUseCase.
+				On("QueryAuthoritative", anyContext, anyMsg).
+				Return(nil, nil)
+			t.dnsUseCase.
+				On("QueryRedisCache", anyContext, anyMsg).
+				Return(nil, domain.Error{Message: "bogus DNSSEC of test.com.", Err: domain.ErrDnssecBogus})
+			defer answer()
+
+			resp, _, err := t.dnsClient.Exchange(ednsReq(), "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeServerFailure, resp.Rcode)
+			t.Len(resp.IsEdns0().Option, 1)
+			ede := resp.IsEdns0().Option[0].(*dns.EDNS0_EDE)
+			t.Equal(dns.ExtendedErrorCodeDNSBogus, ede.InfoCode)
+			t.Equal("bogus DNSSEC of test.com.", ede.ExtraText)
+
+			// the client without EDNS0 gets the rcode alone
+			resp, _, err = t.dnsClient.Exchange(req, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeServerFailure, resp.Rcode)
+			t.Nil(resp.IsEdns0())
+		},
+	)
+
+	t.Run(
+		"client_subnet_success", func() {
+			subnet := &dns.EDNS0_SUBNET{
+				Code: dns.EDNS0SUBNET, Family: 1, SourceNetmask: 24, SourceScope: 16, Address: net.ParseIP("192.0.2.0"),
+			}
+			t.dnsUseCase.ExpectedCalls = nil
+			t.dnsUseCase.
+				On("QueryAuthoritative", anyContext, anyMsg).
+				Return(nil, nil)
+			t.dnsUseCase.
+				On(
+					"QueryRedisCache", mock.MatchedBy(
+						func(ctx context.Context) bool {
+							// the client address is carried to the use case
+							return remoteIP(domain.ClientAddr(ctx)).Equal(net.ParseIP("127.0.0.1"))
+						},
+					), anyMsg,
+				).
+				Return(
+					func(ctx context.Context, req *dns.Msg) *dns.Msg {
+						return &dns.Msg{
+							Question: []dns.Question{t.question},
+							Answer:   []dns.RR{rr},
+							Extra: []dns.RR{
+								&dns.OPT{Hdr: dns.RR_Header{Name: ".", Rrtype: dns.TypeOPT}, Option: []dns.EDNS0{subnet}},
+							},
+						}
+					}, nil,
+				)
+			defer answer()
+
+			resp, _, err := t.dnsClient.Exchange(ednsReq(subnet), "127.0.0.1:53")
+			t.Nil(err)
+			t.Len(resp.IsEdns0().Option, 1)
+			t.Equal(uint8(16), resp.IsEdns0().Option[0].(*dns.EDNS0_SUBNET).SourceScope)
+		},
+	)
+
+	t.Run(
+		"padding_success", func() {
+			padded := ednsReq(&dns.EDNS0_PADDING{})
+			padded.Id = dns.Id()
+
+			writer := &encryptedResponseWriter{}
+			t.handler.ServeDNS(writer, padded)
+			t.NotNil(writer.msg)
+			t.Zero(writer.msg.Len() % paddingBlockSize)
+
+			// the plain transports aren't padded
+			writer.plain = true
+			t.handler.ServeDNS(writer, padded)
+			t.Empty(writer.msg.IsEdns0().Option)
+		},
+	)
+}
+
+// encryptedResponseWriter captures the responses of a TLS connection
+type encryptedResponseWriter struct {
+	dns.ResponseWriter
+
+	plain bool
+	msg   *dns.Msg
+}
+
+func (w *encryptedResponseWriter) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 853}
+}
+
+func (w *encryptedResponseWriter) WriteMsg(msg *dns.Msg) error {
+	w.msg = msg
+	return nil
+}
+
+func (w *encryptedResponseWriter) ConnectionState() *tls.ConnectionState {
+	if w.plain {
+		return nil
+	}
+	return &tls.ConnectionState{}
+}
+
+// singleMsgResponseWriter captures the single response of a DoH request
+type singleMsgResponseWriter struct {
+	encryptedResponseWriter
+}
+
+func (w *singleMsgResponseWriter) SingleMsg() {}
+
+func (t *dnsHandlerTestSuite) TestTransfer() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		anyUint32  = mock.AnythingOfType("uint32")
+		axfr       = new(dns.Msg)
+		ixfr       = new(dns.Msg)
+		zone       []dns.RR
+	)
+
+	axfr.SetAxfr("test.com.")
+	ixfr.SetIxfr("test.com.", 2024010100, "ns1.test.com.", "admin.test.com.")
+
+	soa, _ := dns.NewRR("test.com.\t3600\tIN\tSOA\tns1.test.com. admin.test.com. 2024010102 7200 3600 1209600 300")
+	zone = append(zone, soa)
+	for i := 0; i < 2000; i++ {
+		rr, _ := dns.NewRR(fmt.Sprintf("host%d.test.com.\t3600\tIN\tA\t10.0.%d.%d", i, i/256, i%256))
+		zone = append(zone, rr)
+	}
+	zone = append(zone, soa)
+
+	setup := func() {
+		t.transferUseCase.ExpectedCalls = nil
+		t.transferUseCase.Calls = nil
+		t.transferUseCase.
+			On("AXFR", anyContext, anyString).
+			Return(zone, nil)
+		t.transferUseCase.
+			On("IXFR", anyContext, anyString, anyUint32).
+			Return(zone, nil)
+	}
+
+	t.Run(
+		"axfr_success", func() {
+			setup()
+			envelopes, err := new(dns.Transfer).In(axfr, "127.0.0.1:53")
+			t.Nil(err)
+
+			var (
+				rrs      []dns.RR
+				messages int
+			)
+			for envelope := range envelopes {
+				t.Nil(envelope.Error)
+				rrs = append(rrs, envelope.RR...)
+				messages++
+			}
+			t.Len(rrs, len(zone))
+			t.Greater(messages, 1)
+			t.transferUseCase.AssertCalled(t.T(), "AXFR", anyContext, "test.com.")
+		},
+	)
+
+	t.Run(
+		"ixfr_success", func() {
+			setup()
+			envelopes, err := new(dns.Transfer).In(ixfr, "127.0.0.1:53")
+			t.Nil(err)
+
+			var rrs []dns.RR
+			for envelope := range envelopes {
+				t.Nil(envelope.Error)
+				rrs = append(rrs, envelope.RR...)
+			}
+			t.Len(rrs, len(zone))
+			t.transferUseCase.AssertCalled(t.T(), "IXFR", anyContext, "test.com.", uint32(2024010100))
+		},
+	)
+
+	t.Run(
+		"ixfr_udp_too_large", func() {
+			setup()
+			resp, _, err := t.dnsClient.Exchange(ixfr, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Len(resp.Answer, 1)
+			t.Equal(soa.String(), resp.Answer[0].String())
+		},
+	)
+
+	t.Run(
+		"ixfr_udp_success", func() {
+			setup()
+			t.transferUseCase.ExpectedCalls = nil
+			t.transferUseCase.
+				On("IXFR", anyContext, anyString, anyUint32).
+				Return([]dns.RR{soa}, nil)
+			resp, _, err := t.dnsClient.Exchange(ixfr, "127.0.0.1:53")
+			t.Nil(err)
+			t.True(resp.Authoritative)
+			t.Len(resp.Answer, 1)
+		},
+	)
+
+	t.Run(
+		"ixfr_without_soa", func() {
+			setup()
+			req := new(dns.Msg)
+			req.SetQuestion("test.com.", dns.TypeIXFR)
+			resp, _, err := t.tcpClient.Exchange(req, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeFormatError, resp.Rcode)
+		},
+	)
+
+	t.Run(
+		"axfr_udp_refused", func() {
+			setup()
+			resp, _, err := t.dnsClient.Exchange(axfr, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeRefused, resp.Rcode)
+			t.transferUseCase.AssertNotCalled(t.T(), "AXFR", anyContext, anyString)
+		},
+	)
+
+	t.Run(
+		"single_msg_refused", func() {
+			setup()
+			for _, req := range []*dns.Msg{axfr, ixfr} {
+				writer := &singleMsgResponseWriter{}
+				t.handler.ServeDNS(writer, req)
+				t.Equal(dns.RcodeRefused, writer.msg.Rcode)
+			}
+			t.transferUseCase.AssertNotCalled(t.T(), "AXFR", anyContext, anyString)
+			t.transferUseCase.AssertNotCalled(t.T(), "IXFR", anyContext, anyString, anyUint32)
+		},
+	)
+
+	t.Run(
+		"not_allowed_refused", func() {
+			setup()
+			handler := t.handler.(*dnsHandler)
+			allowed := handler.transferAllow
+			handler.transferAllow = nil
+			defer func() { handler.transferAllow = allowed }()
+
+			resp, _, err := t.tcpClient.Exchange(axfr, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeRefused, resp.Rcode)
+		},
+	)
+
+	t.Run(
+		"signed_axfr_success", func() {
+			setup()
+			handler := t.handler.(*dnsHandler)
+			allowed := handler.transferAllow
+			handler.transferAllow = nil
+			defer func() { handler.transferAllow = allowed }()
+
+			signed := axfr.Copy()
+			signed.SetTsig("update.", dns.HmacSHA256, 300, time.Now().Unix())
+			transfer := &dns.Transfer{TsigSecret: t.tsigClient.TsigSecret}
+			envelopes, err := transfer.In(signed, "127.0.0.1:53")
+			t.Nil(err)
+
+			// every message of the transfer is verified by the client
+			var (
+				rrs      []dns.RR
+				messages int
+			)
+			for envelope := range envelopes {
+				t.Nil(envelope.Error)
+				rrs = append(rrs, envelope.RR...)
+				messages++
+			}
+			t.Len(rrs, len(zone))
+			t.Greater(messages, 1)
+		},
+	)
+
+	t.Run(
+		"signed_not_allowed_refused", func() {
+			setup()
+			handler := t.handler.(*dnsHandler)
+			allowed := handler.transferAllow
+			handler.transferAllow = nil
+			defer func() { handler.transferAllow = allowed }()
+
+			signed := axfr.Copy()
+			signed.SetTsig("dhcp.", dns.HmacSHA256, 300, time.Now().Unix())
+			resp, _, err := t.tsigClient.Exchange(signed, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeRefused, resp.Rcode)
+			t.NotNil(resp.IsTsig())
+			t.transferUseCase.AssertNotCalled(t.T(), "AXFR", anyContext, anyString)
+		},
+	)
+
+	t.Run(
+		"not_auth", func() {
+			setup()
+			t.transferUseCase.ExpectedCalls = nil
+			t.transferUseCase.
+				On("AXFR", anyContext, anyString).
+				Return(nil, &domain.Error{Message: "test-error", Err: domain.ErrNotAuth})
+			resp, _, err := t.tcpClient.Exchange(axfr, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeNotAuth, resp.Rcode)
+		},
+	)
+}
+
+func (t *dnsHandlerTestSuite) TestParseNetworks() {
+	networks, err := parseNetworks("192.0.2.1, 10.0.0.0/8,2001:db8::1")
+	t.Nil(err)
+	t.Len(networks, 3)
+	t.True(networks[0].Contains(net.ParseIP("192.0.2.1")))
+	t.False(networks[0].Contains(net.ParseIP("192.0.2.2")))
+	t.True(networks[1].Contains(net.ParseIP("10.1.2.3")))
+	t.True(networks[2].Contains(net.ParseIP("2001:db8::1")))
+
+	networks, err = parseNetworks("")
+	t.Nil(err)
+	t.Empty(networks)
+
+	_, err = parseNetworks("192.0.2")
+	t.NotNil(err)
+}
+
+func (t *dnsHandlerTestSuite) TestNotify() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		anyAddr    = mock.AnythingOfType("*net.UDPAddr")
+		notify     = new(dns.Msg)
+	)
+
+	notify.SetNotify("test.com.")
+
+	t.Run(
+		"success", func() {
+			t.secondaryUseCase.ExpectedCalls = nil
+			t.secondaryUseCase.
+				On("Notify", anyContext, anyString, anyAddr).
+				Return(nil)
+			resp, _, err := t.dnsClient.Exchange(notify, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Equal(dns.OpcodeNotify, resp.Opcode)
+			t.True(resp.Authoritative)
+			t.secondaryUseCase.AssertCalled(t.T(), "Notify", anyContext, "test.com.", anyAddr)
+		},
+	)
+
+	t.Run(
+		"signed_success", func() {
+			t.secondaryUseCase.ExpectedCalls = nil
+			t.secondaryUseCase.
+				On("Notify", anyContext, anyString, mock.AnythingOfType("*net.TCPAddr")).
+				Return(nil)
+			signed := notify.Copy()
+			signed.SetTsig("update.", dns.HmacSHA256, 300, time.Now().Unix())
+			resp, _, err := t.tsigClient.Exchange(signed, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.NotNil(resp.IsTsig())
+		},
+	)
+
+	t.Run(
+		"not_auth", func() {
+			t.secondaryUseCase.ExpectedCalls = nil
+			t.secondaryUseCase.
+				On("Notify", anyContext, anyString, anyAddr).
+				Return(&domain.Error{Message: "test-error", Err: domain.ErrNotAuth})
+			resp, _, err := t.dnsClient.Exchange(notify, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeNotAuth, resp.Rcode)
+		},
+	)
+
+	t.Run(
+		"refused", func() {
+			t.secondaryUseCase.ExpectedCalls = nil
+			t.secondaryUseCase.
+				On("Notify", anyContext, anyString, anyAddr).
+				Return(&domain.Error{Message: "test-error", Err: domain.ErrRefused})
+			resp, _, err := t.dnsClient.Exchange(notify, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeRefused, resp.Rcode)
+		},
+	)
+
+	t.Run(
+		"no_question", func() {
+			req := new(dns.Msg)
+			req.Opcode = dns.OpcodeNotify
+			resp, _, err := t.dnsClient.Exchange(req, "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeFormatError, resp.Rcode)
+		},
+	)
+}
+
+func (t *dnsHandlerTestSuite) TestUpdate() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		anyMsg     = mock.AnythingOfType("*dns.Msg")
+		client     = &dns.Client{
+			Net:         "tcp",
+			DialTimeout: time.Second,
+			TsigSecret:  map[string]string{"update.": tsigSecret, "other.": tsigSecret},
+		}
+	)
+
+	rr, _ := dns.NewRR("www.test.com.\t300\tIN\tA\t192.0.2.1")
+	other, _ := dns.NewRR("www.test.com.\t300\tIN\tA\t192.0.2.2")
+	update := func(key string) *dns.Msg {
+		req := new(dns.Msg)
+		req.SetUpdate("test.com.")
+		// the sections of an update have more records than the ones of a query
+		req.NameUsed([]dns.RR{rr})
+		req.RRsetNotUsed([]dns.RR{rr})
+		req.Insert([]dns.RR{rr, other})
+		if key != "" {
+			req.SetTsig(key, dns.HmacSHA256, 300, time.Now().Unix())
+		}
+		return req
+	}
+
+	t.Run(
+		"success", func() {
+			t.updateUseCase.ExpectedCalls = nil
+			t.updateUseCase.Calls = nil
+			t.updateUseCase.
+				On("Update", anyContext, anyString, anyMsg).
+				Return(nil)
+			resp, _, err := client.Exchange(update("update."), "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Equal(dns.OpcodeUpdate, resp.Opcode)
+			// the client has verified the signature of the response
+			t.NotNil(resp.IsTsig())
+			t.updateUseCase.AssertCalled(
+				t.T(), "Update", anyContext, "update.", mock.MatchedBy(
+					func(req *dns.Msg) bool {
+						return len(req.Answer) == 2 && len(req.Ns) == 2 && req.Ns[0].String() == rr.String()
+					},
+				),
+			)
+		},
+	)
+
+	t.Run(
+		"prerequisite_error", func() {
+			t.updateUseCase.ExpectedCalls = nil
+			t.updateUseCase.
+				On("Update", anyContext, anyString, anyMsg).
+				Return(fmt.Errorf("www.test.com. A: %w", domain.ErrNXRRSet))
+			resp, _, err := client.Exchange(update("update."), "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeNXRrset, resp.Rcode)
+			t.NotNil(resp.IsTsig())
 		},
 	)
 
+	t.Run(
+		"unsigned", func() {
+			t.updateUseCase.Calls = nil
+			resp, _, err := t.tcpClient.Exchange(update(""), "127.0.0.1:53")
+			t.Nil(err)
+			t.Equal(dns.RcodeRefused, resp.Rcode)
+			t.updateUseCase.AssertNotCalled(t.T(), "Update", anyContext, anyString, anyMsg)
+		},
+	)
+
+	t.Run(
+		"unknown_key", func() {
+			t.updateUseCase.Calls = nil
+			resp, _, err := client.Exchange(update("other."), "127.0.0.1:53")
+			t.ErrorIs(err, dns.ErrAuth)
+			t.Equal(dns.RcodeNotAuth, resp.Rcode)
+			t.Equal(uint16(dns.RcodeBadKey), resp.IsTsig().Error)
+			t.updateUseCase.AssertNotCalled(t.T(), "Update", anyContext, anyString, anyMsg)
+		},
+	)
+
+	t.Run(
+		"bad_signature", func() {
+			t.updateUseCase.Calls = nil
+			forger := &dns.Client{
+				Net:         "udp",
+				DialTimeout: time.Second,
+				TsigSecret:  map[string]string{"update.": "Zm9yZ2VkLXNlY3JldA=="},
+			}
+			resp, _, err := forger.Exchange(update("update."), "127.0.0.1:53")
+			t.ErrorIs(err, dns.ErrAuth)
+			t.Equal(dns.RcodeNotAuth, resp.Rcode)
+			t.Equal(uint16(dns.RcodeBadSig), resp.IsTsig().Error)
+			t.updateUseCase.AssertNotCalled(t.T(), "Update", anyContext, anyString, anyMsg)
+		},
+	)
 }
@@ -0,0 +1,229 @@
+package dns
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+)
+
+// errorEDEs are the extended DNS errors which explain the errors of the use cases (RFC 8914)
+var errorEDEs = map[error]uint16{
+	domain.ErrRefused:     dns.ExtendedErrorCodeProhibited,
+	domain.ErrNotAuth:     dns.ExtendedErrorCodeNotAuthoritative,
+	domain.ErrDnssecBogus: dns.ExtendedErrorCodeDNSBogus,
+	domain.ErrNoUpstream:  dns.ExtendedErrorCodeNoReachableAuthority,
+}
+
+const (
+	clientCookieSize = 8
+	// serverCookieSize is the size of the server cookies of RFC 9018, which are the version, 3 reserved
+	// bytes, the timestamp and 8 bytes of the HMAC-SHA256 in place of SipHash-2-4
+	serverCookieSize = 16
+	maxCookieSize    = 40
+	cookieVersion    = 1
+	// a server cookie is renewed after half an hour (RFC 9018 4.3)
+	cookieRenewal = 30 * time.Minute
+	cookieSkew    = 5 * time.Minute
+
+	// paddingBlockSize is the block size of the padded responses (RFC 8467 4.1)
+	paddingBlockSize = 468
+)
+
+// checkEdns returns FORMERR for more than one OPT or a malformed cookie, and BADVERS for an EDNS version
+// other than 0 (RFC 6891 6.1.1 and 6.1.3, RFC 7873 5.2.2)
+func (d *dnsHandler) checkEdns(req *dns.Msg) int {
+	var opts []*dns.OPT
+	for _, rr := range req.Extra {
+		if opt, ok := rr.(*dns.OPT); ok {
+			opts = append(opts, opt)
+		}
+	}
+	switch {
+	case len(opts) == 0:
+		return dns.RcodeSuccess
+	case len(opts) > 1:
+		return dns.RcodeFormatError
+	case opts[0].Version() != 0:
+		return dns.RcodeBadVers
+	}
+
+	for _, option := range opts[0].Option {
+		cookie, ok := option.(*dns.EDNS0_COOKIE)
+		if !ok {
+			continue
+		}
+		raw, err := hex.DecodeString(cookie.Cookie)
+		if err != nil || (len(raw) != clientCookieSize && (len(raw) < clientCookieSize+8 || len(raw) > maxCookieSize)) {
+			return dns.RcodeFormatError
+		}
+	}
+	return dns.RcodeSuccess
+}
+
+// setEdns answers the OPT of the request with the buffer size of the server, the DO bit of the request
+// (RFC 3225 3), a server cookie (RFC 7873 5.2), and the extended errors and the client subnet of the
+// response, the OPT of anyone else is removed
+func (d *dnsHandler) setEdns(respWriter dns.ResponseWriter, req, resp *dns.Msg) {
+	var options []dns.EDNS0
+	kept := resp.Extra[:0]
+	for _, rr := range resp.Extra {
+		opt, ok := rr.(*dns.OPT)
+		if !ok {
+			kept = append(kept, rr)
+			continue
+		}
+		for _, option := range opt.Option {
+			switch option.(type) {
+			case *dns.EDNS0_EDE, *dns.EDNS0_SUBNET:
+				options = append(options, option)
+			}
+		}
+	}
+	resp.Extra = kept
+
+	reqOpt := req.IsEdns0()
+	if reqOpt == nil {
+		return
+	}
+	if cookie := d.cookie(reqOpt, respWriter.RemoteAddr()); cookie != nil {
+		options = append(options, cookie)
+	}
+
+	opt := &dns.OPT{Hdr: dns.RR_Header{Name: ".", Rrtype: dns.TypeOPT}, Option: options}
+	opt.SetUDPSize(d.ednsSize)
+	if reqOpt.Do() {
+		opt.SetDo()
+	}
+	resp.Extra = append(resp.Extra, opt)
+}
+
+// cookie returns the client cookie of the request with its server cookie when it's valid and young,
+// or with a fresh one. A missing or bad server cookie is answered as if only the client cookie was
+// sent (RFC 7873 5.2.3).
+func (d *dnsHandler) cookie(opt *dns.OPT, addr net.Addr) *dns.EDNS0_COOKIE {
+	for _, option := range opt.Option {
+		cookie, ok := option.(*dns.EDNS0_COOKIE)
+		if !ok {
+			continue
+		}
+		raw, err := hex.DecodeString(cookie.Cookie)
+		if err != nil || len(raw) < clientCookieSize {
+			return nil
+		}
+
+		ip, now := remoteIP(addr), time.Now()
+		server := raw[clientCookieSize:]
+		if !d.validCookie(raw[:clientCookieSize], server, ip, now.Add(-cookieRenewal)) {
+			server = d.serverCookie(raw[:clientCookieSize], ip, uint32(now.Unix()))
+		}
+		return &dns.EDNS0_COOKIE{
+			Code:   dns.EDNS0COOKIE,
+			Cookie: hex.EncodeToString(append(raw[:clientCookieSize:clientCookieSize], server...)),
+		}
+	}
+	return nil
+}
+
+// serverCookie hashes the client cookie, the version, the timestamp and the address of the client
+func (d *dnsHandler) serverCookie(client []byte, ip net.IP, timestamp uint32) []byte {
+	cookie := make([]byte, serverCookieSize/2)
+	cookie[0] = cookieVersion
+	binary.BigEndian.PutUint32(cookie[4:], timestamp)
+
+	mac := hmac.New(sha256.New, d.cookieSecret)
+	mac.Write(client)
+	mac.Write(cookie)
+	mac.Write(ip)
+	return mac.Sum(cookie)[:serverCookieSize]
+}
+
+// validCookie reports whether the server cookie is made here for the client, and is issued after the
+// given time
+func (d *dnsHandler) validCookie(client, server []byte, ip net.IP, after time.Time) bool {
+	if len(server) != serverCookieSize || server[0] != cookieVersion {
+		return false
+	}
+	timestamp := binary.BigEndian.Uint32(server[4:])
+	issued := time.Unix(int64(timestamp), 0)
+	if issued.Before(after) || issued.After(time.Now().Add(cookieSkew)) {
+		return false
+	}
+	return hmac.Equal(server, d.serverCookie(client, ip, timestamp))
+}
+
+// pad pads the response into blocks over the encrypted transports when the client pads its request
+// (RFC 7830, RFC 8467 4.1)
+func (d *dnsHandler) pad(respWriter dns.ResponseWriter, req, resp *dns.Msg) {
+	if stater, ok := respWriter.(dns.ConnectionStater); !ok || stater.ConnectionState() == nil {
+		return
+	}
+	reqOpt, opt := req.IsEdns0(), resp.IsEdns0()
+	if reqOpt == nil || opt == nil {
+		return
+	}
+	for _, option := range reqOpt.Option {
+		if _, ok := option.(*dns.EDNS0_PADDING); ok {
+			// the padding option has 4 bytes of its code and length
+			size := resp.Len() + 4
+			padding := (paddingBlockSize - size%paddingBlockSize) % paddingBlockSize
+			opt.Option = append(opt.Option, &dns.EDNS0_PADDING{Padding: make([]byte, padding)})
+			return
+		}
+	}
+}
+
+// extendedError explains the error by an OPT carrying its extended DNS error, which is answered by
+// setEdns when the client sends an OPT
+func (d *dnsHandler) extendedError(err error) *dns.OPT {
+	for target, code := range errorEDEs {
+		if !errors.Is(err, target) {
+			continue
+		}
+		text := err.Error()
+		var domainErr domain.Error
+		if errors.As(err, &domainErr) {
+			text = domainErr.Message
+		}
+		return &dns.OPT{
+			Hdr:    dns.RR_Header{Name: ".", Rrtype: dns.TypeOPT},
+			Option: []dns.EDNS0{&dns.EDNS0_EDE{InfoCode: code, ExtraText: text}},
+		}
+	}
+	return nil
+}
+
+func remoteIP(addr net.Addr) net.IP {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+// parseCookieSecret decodes the hex secret of the server cookies, a random secret is made when it
+// isn't given
+func parseCookieSecret(s string) ([]byte, error) {
+	if s == "" {
+		secret := make([]byte, 16)
+		_, err := rand.Read(secret)
+		return secret, err
+	}
+	secret, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) < 16 {
+		return nil, fmt.Errorf("the secret has %d bytes, at least 16 bytes are required", len(secret))
+	}
+	return secret, nil
+}
@@ -0,0 +1,151 @@
+package v1
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"io"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+
+	"github.com/pkg/errors"
+)
+
+type dnssecHandler struct {
+	dnssecUseCase domain.DnssecUseCase
+}
+
+// EnableDnssecAPI ...
+// @title EnableDnssecAPI
+// @description Sign the zone by a new KSK and ZSK, the denial is proven by NSEC unless NSEC3 is given. The body
+// @description is optional, the algorithm is ECDSAP256SHA256 when not given. The DS records in the response
+// @description should be added to the parent zone.
+// @tags Zone
+// @accept json
+// @param zone path string true "Zone Name"
+// @param body body domain.Dnssec false "The example of DNSSEC request body"
+// @success 201 {object} domain.Dnssec
+// @failure 400 {object} domain.Error
+// @failure 404 {object} domain.Error
+// @router /zones/{zone}/dnssec [POST]
+func (d *dnssecHandler) EnableDnssecAPI(ctx *gin.Context) {
+	var dnssec domain.Dnssec
+
+	err := ctx.ShouldBindJSON(&dnssec)
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = &domain.Error{
+			Message:    fmt.Sprintf("Bind JSON error: %s", err.Error()),
+			Err:        errors.New(err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+		_ = ctx.Error(err)
+		return
+	}
+	dnssec.Zone = ctx.Param("zone")
+
+	err = d.dnssecUseCase.EnableDnssec(ctx, &dnssec)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, dnssec)
+}
+
+// GetDnssecAPI ...
+// @title GetDnssecAPI
+// @description Get the DNSSEC policy of the zone with its keys and DS records
+// @tags Zone
+// @accept json
+// @param zone path string true "Zone Name"
+// @success 200 {object} domain.Dnssec
+// @failure 404 {object} domain.Error
+// @router /zones/{zone}/dnssec [GET]
+func (d *dnssecHandler) GetDnssecAPI(ctx *gin.Context) {
+	dnssec, err := d.dnssecUseCase.GetDnssec(ctx, ctx.Param("zone"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dnssec)
+}
+
+// DisableDnssecAPI ...
+// @title DisableDnssecAPI
+// @description Delete the keys of the zone, which is answered unsigned at once. The DS records should be
+// @description removed from the parent zone first.
+// @tags Zone
+// @accept json
+// @param zone path string true "Zone Name"
+// @success 204
+// @failure 404 {object} domain.Error
+// @router /zones/{zone}/dnssec [DELETE]
+func (d *dnssecHandler) DisableDnssecAPI(ctx *gin.Context) {
+	err := d.dnssecUseCase.DisableDnssec(ctx, ctx.Param("zone"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListDSAPI ...
+// @title ListDSAPI
+// @description List the DS records of the published KSKs of the zone, which are added to the parent zone
+// @tags Zone
+// @accept json
+// @param zone path string true "Zone Name"
+// @success 200 {object} []string
+// @failure 404 {object} domain.Error
+// @router /zones/{zone}/dnssec/ds [GET]
+func (d *dnssecHandler) ListDSAPI(ctx *gin.Context) {
+	records, err := d.dnssecUseCase.ListDS(ctx, ctx.Param("zone"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, records)
+}
+
+// RolloverAPI ...
+// @title RolloverAPI
+// @description Publish a new key of the type, which replaces the active keys at the given time, or after the
+// @description DNSKEY TTL when not given. The replaced keys are removed after another DNSKEY TTL.
+// @tags Zone
+// @accept json
+// @param zone path string true "Zone Name"
+// @param body body domain.DnssecRollover true "The example of rollover request body"
+// @success 202 {object} domain.Dnssec
+// @failure 400 {object} domain.Error
+// @failure 404 {object} domain.Error
+// @router /zones/{zone}/dnssec/rollover [POST]
+func (d *dnssecHandler) RolloverAPI(ctx *gin.Context) {
+	var rollover domain.DnssecRollover
+
+	err := ctx.ShouldBindJSON(&rollover)
+	if err != nil {
+		err = &domain.Error{
+			Message:    fmt.Sprintf("Bind JSON error: %s", err.Error()),
+			Err:        errors.New(err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+		_ = ctx.Error(err)
+		return
+	}
+
+	dnssec, err := d.dnssecUseCase.Rollover(ctx, ctx.Param("zone"), &rollover)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, dnssec)
+}
+
+func NewDnssecHandler(injector *do.Injector) (domain.DnssecHandler, error) {
+	return &dnssecHandler{do.MustInvoke[domain.DnssecUseCase](injector)}, nil
+}
@@ -0,0 +1,245 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cewuandy/go-restful-dns/internal/controller/http/middleware"
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+	"github.com/cewuandy/go-restful-dns/pkg/gin/routes"
+)
+
+type dnssecHandlerTestSuite struct {
+	suite.Suite
+
+	dnssecUseCase *mocks.DnssecUseCase
+
+	r *gin.Engine
+}
+
+func TestDnssecHandler(t *testing.T) {
+	suite.Run(t, &dnssecHandlerTestSuite{})
+}
+
+func (t *dnssecHandlerTestSuite) SetupSuite() {
+	injector := do.New()
+	t.dnssecUseCase = &mocks.DnssecUseCase{}
+	do.ProvideValue[domain.DnssecUseCase](injector, t.dnssecUseCase)
+	do.Provide[domain.DnssecHandler](injector, NewDnssecHandler)
+	do.Provide[domain.ErrorHandler](injector, middleware.NewErrorHandler)
+
+	t.r = gin.New()
+	t.r.Use(do.MustInvoke[domain.ErrorHandler](injector).HandleError)
+
+	routes.RegisterDnssecRoutes(t.r, do.MustInvoke[domain.DnssecHandler](injector))
+}
+
+func (t *dnssecHandlerTestSuite) SetupTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		dnssec     = &domain.Dnssec{
+			Zone:      "test.com.",
+			Algorithm: "ECDSAP256SHA256",
+			DS:        []string{"test.com.\t3600\tIN\tDS\t12345 13 2 AABB"},
+		}
+	)
+
+	t.dnssecUseCase.ExpectedCalls = nil
+	t.dnssecUseCase.Calls = nil
+	t.dnssecUseCase.
+		On("EnableDnssec", anyContext, mock.AnythingOfType("*domain.Dnssec")).
+		Return(nil)
+	t.dnssecUseCase.
+		On("GetDnssec", anyContext, anyString).
+		Return(dnssec, nil)
+	t.dnssecUseCase.
+		On("DisableDnssec", anyContext, anyString).
+		Return(nil)
+	t.dnssecUseCase.
+		On("ListDS", anyContext, anyString).
+		Return(dnssec.DS, nil)
+	t.dnssecUseCase.
+		On("Rollover", anyContext, anyString, mock.AnythingOfType("*domain.DnssecRollover")).
+		Return(dnssec, nil)
+}
+
+func (t *dnssecHandlerTestSuite) serve(method, path string, body io.Reader) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(method, "/api/v1/zones/test.com./dnssec"+path, body)
+	t.Nil(err)
+	t.r.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func (t *dnssecHandlerTestSuite) TestEnableDnssecAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			body, _ := json.Marshal(domain.Dnssec{Zone: "other.com.", Algorithm: "ED25519", Nsec3: true})
+			recorder := t.serve(http.MethodPost, "", bytes.NewReader(body))
+			t.Equal(http.StatusCreated, recorder.Code)
+			// the zone comes from the path
+			t.dnssecUseCase.AssertCalled(
+				t.T(), "EnableDnssec", anyContext, &domain.Dnssec{Zone: "test.com.", Algorithm: "ED25519", Nsec3: true},
+			)
+		},
+	)
+
+	t.Run(
+		"empty_body_success", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodPost, "", bytes.NewReader(nil))
+			t.Equal(http.StatusCreated, recorder.Code)
+			t.dnssecUseCase.AssertCalled(t.T(), "EnableDnssec", anyContext, &domain.Dnssec{Zone: "test.com."})
+		},
+	)
+
+	t.Run(
+		"bind_error", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodPost, "", bytes.NewReader([]byte(`{"nsec3":"yes"}`)))
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.dnssecUseCase.AssertNotCalled(t.T(), "EnableDnssec", anyContext, mock.Anything)
+		},
+	)
+
+	t.Run(
+		"EnableDnssec_error", func() {
+			t.SetupTest()
+			t.dnssecUseCase.ExpectedCalls = nil
+			t.dnssecUseCase.
+				On("EnableDnssec", anyContext, mock.AnythingOfType("*domain.Dnssec")).
+				Return(
+					&domain.Error{
+						Message:    "the DNSSEC of the zone is already enabled.",
+						StatusCode: http.StatusBadRequest,
+					},
+				)
+
+			recorder := t.serve(http.MethodPost, "", bytes.NewReader(nil))
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "already enabled")
+		},
+	)
+}
+
+func (t *dnssecHandlerTestSuite) TestGetDnssecAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodGet, "", nil)
+			t.Equal(http.StatusOK, recorder.Code)
+
+			var dnssec domain.Dnssec
+			t.Nil(json.Unmarshal(recorder.Body.Bytes(), &dnssec))
+			t.Equal("ECDSAP256SHA256", dnssec.Algorithm)
+			t.dnssecUseCase.AssertCalled(t.T(), "GetDnssec", anyContext, "test.com.")
+		},
+	)
+
+	t.Run(
+		"GetDnssec_error", func() {
+			t.SetupTest()
+			t.dnssecUseCase.ExpectedCalls = nil
+			t.dnssecUseCase.
+				On("GetDnssec", anyContext, mock.AnythingOfType("string")).
+				Return(nil, &domain.Error{Message: "DB error: record not found", StatusCode: http.StatusNotFound})
+
+			recorder := t.serve(http.MethodGet, "", nil)
+			t.Equal(http.StatusNotFound, recorder.Code)
+		},
+	)
+}
+
+func (t *dnssecHandlerTestSuite) TestDisableDnssecAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodDelete, "", nil)
+			t.Equal(http.StatusNoContent, recorder.Code)
+			t.dnssecUseCase.AssertCalled(t.T(), "DisableDnssec", anyContext, "test.com.")
+		},
+	)
+
+	t.Run(
+		"DisableDnssec_error", func() {
+			t.SetupTest()
+			t.dnssecUseCase.ExpectedCalls = nil
+			t.dnssecUseCase.
+				On("DisableDnssec", anyContext, mock.AnythingOfType("string")).
+				Return(&domain.Error{Message: "DB error: record not found", StatusCode: http.StatusNotFound})
+
+			recorder := t.serve(http.MethodDelete, "", nil)
+			t.Equal(http.StatusNotFound, recorder.Code)
+		},
+	)
+}
+
+func (t *dnssecHandlerTestSuite) TestListDSAPI() {
+	t.SetupTest()
+	recorder := t.serve(http.MethodGet, "/ds", nil)
+	t.Equal(http.StatusOK, recorder.Code)
+
+	var records []string
+	t.Nil(json.Unmarshal(recorder.Body.Bytes(), &records))
+	t.Len(records, 1)
+}
+
+func (t *dnssecHandlerTestSuite) TestRolloverAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodPost, "/rollover", bytes.NewReader([]byte(`{"type":"zsk"}`)))
+			t.Equal(http.StatusAccepted, recorder.Code)
+			t.dnssecUseCase.AssertCalled(
+				t.T(), "Rollover", anyContext, "test.com.", &domain.DnssecRollover{Type: domain.DnssecZSK},
+			)
+		},
+	)
+
+	t.Run(
+		"bind_error", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodPost, "/rollover", bytes.NewReader([]byte(`{"type":"csk"}`)))
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.dnssecUseCase.AssertNotCalled(t.T(), "Rollover", anyContext, mock.Anything, mock.Anything)
+		},
+	)
+
+	t.Run(
+		"Rollover_error", func() {
+			t.SetupTest()
+			t.dnssecUseCase.ExpectedCalls = nil
+			t.dnssecUseCase.
+				On("Rollover", anyContext, mock.AnythingOfType("string"), mock.AnythingOfType("*domain.DnssecRollover")).
+				Return(
+					nil, &domain.Error{
+						Message:    "the rollover cannot be scheduled in the past",
+						StatusCode: http.StatusBadRequest,
+					},
+				)
+
+			recorder := t.serve(http.MethodPost, "/rollover", bytes.NewReader([]byte(`{"type":"ksk"}`)))
+			t.Equal(http.StatusBadRequest, recorder.Code)
+		},
+	)
+}
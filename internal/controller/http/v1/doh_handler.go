@@ -0,0 +1,337 @@
+package v1
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/utils"
+)
+
+type dohHandler struct {
+	dnsHandler dns.Handler
+}
+
+// QueryGetAPI ...
+// @title QueryGetAPI
+// @description Resolve a query with RFC 8484 GET (dns=base64url) or the JSON flavor (name, type)
+// @tags DoH
+// @produce application/dns-message
+// @produce application/dns-json
+// @param dns query string false "Base64url encoded DNS message without padding"
+// @param name query string false "Domain Name, JSON flavor only"
+// @param type query string false "Record Type, JSON flavor only"
+// @success 200 {object} domain.DoHJSONResponse
+// @failure 400 {object} domain.Error
+// @router /dns-query [GET]
+func (d *dohHandler) QueryGetAPI(ctx *gin.Context) {
+	var (
+		req *dns.Msg
+		err error
+	)
+
+	if encoded, ok := ctx.GetQuery("dns"); ok {
+		req, err = d.unpackBase64(encoded)
+		if err != nil {
+			_ = ctx.Error(err)
+			return
+		}
+		d.serveWire(ctx, req)
+		return
+	}
+
+	req, err = d.parseJSONQuery(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	d.serveJSON(ctx, req)
+}
+
+// QueryPostAPI ...
+// @title QueryPostAPI
+// @description Resolve a query with RFC 8484 POST
+// @tags DoH
+// @accept application/dns-message
+// @produce application/dns-message
+// @success 200 {string} string "DNS message in wire format"
+// @failure 400 {object} domain.Error
+// @failure 415 {object} domain.Error
+// @router /dns-query [POST]
+func (d *dohHandler) QueryPostAPI(ctx *gin.Context) {
+	if ctx.ContentType() != domain.DNSMessageContentType {
+		err := domain.Error{
+			Message:    fmt.Sprintf("Content-Type must be %s", domain.DNSMessageContentType),
+			StatusCode: http.StatusUnsupportedMediaType,
+		}
+		_ = ctx.Error(err)
+		return
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(ctx.Request.Body, dns.MaxMsgSize))
+	if err != nil {
+		err = domain.Error{
+			Message:    fmt.Sprintf("Read body error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+		_ = ctx.Error(err)
+		return
+	}
+
+	req, err := d.unpack(raw)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	d.serveWire(ctx, req)
+}
+
+func (d *dohHandler) serveWire(ctx *gin.Context, req *dns.Msg) {
+	resp, err := d.exchange(ctx, req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	packed, err := resp.Pack()
+	if err != nil {
+		err = domain.Error{
+			Message:    fmt.Sprintf("Pack DNS message error: %s", err.Error()),
+			StatusCode: http.StatusInternalServerError,
+		}
+		_ = ctx.Error(err)
+		return
+	}
+
+	d.setCacheControl(ctx, resp)
+	ctx.Data(http.StatusOK, domain.DNSMessageContentType, packed)
+}
+
+func (d *dohHandler) serveJSON(ctx *gin.Context, req *dns.Msg) {
+	resp, err := d.exchange(ctx, req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	output := domain.DoHJSONResponse{
+		Status:     resp.Rcode,
+		TC:         resp.Truncated,
+		RD:         resp.RecursionDesired,
+		RA:         resp.RecursionAvailable,
+		AD:         resp.AuthenticatedData,
+		CD:         resp.CheckingDisabled,
+		Answer:     d.toDoHAnswers(resp.Answer),
+		Authority:  d.toDoHAnswers(resp.Ns),
+		Additional: d.toDoHAnswers(resp.Extra),
+	}
+	for _, q := range resp.Question {
+		output.Question = append(output.Question, domain.DoHQuestion{Name: q.Name, Type: q.Qtype})
+	}
+
+	d.setCacheControl(ctx, resp)
+	ctx.Header("Content-Type", domain.DNSJSONContentType)
+	ctx.JSON(http.StatusOK, output)
+}
+
+// exchange dispatches the request into the same dns.Handler that serves port 53
+func (d *dohHandler) exchange(ctx *gin.Context, req *dns.Msg) (*dns.Msg, error) {
+	writer := &dohResponseWriter{remoteAddr: d.remoteAddr(ctx), connectionState: d.connectionState(ctx)}
+	d.dnsHandler.ServeDNS(writer, req)
+	if writer.msg == nil {
+		return nil, domain.Error{
+			Message:    fmt.Sprintf("cannot resolve %s", req.Question[0].Name),
+			StatusCode: http.StatusBadGateway,
+		}
+	}
+	return writer.msg, nil
+}
+
+func (d *dohHandler) unpackBase64(encoded string) (*dns.Msg, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return nil, domain.Error{
+			Message:    fmt.Sprintf("Decode dns parameter error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	return d.unpack(raw)
+}
+
+func (d *dohHandler) unpack(raw []byte) (*dns.Msg, error) {
+	req := new(dns.Msg)
+	err := req.Unpack(raw)
+	if err != nil {
+		return nil, domain.Error{
+			Message:    fmt.Sprintf("Unpack DNS message error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	if len(req.Question) == 0 {
+		return nil, domain.Error{
+			Message:    "DNS message has no question",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	return req, nil
+}
+
+func (d *dohHandler) parseJSONQuery(ctx *gin.Context) (*dns.Msg, error) {
+	name := ctx.Query("name")
+	if name == "" {
+		return nil, domain.Error{
+			Message:    "Either dns or name query parameter is required",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	qtype := dns.TypeA
+	if t := ctx.Query("type"); t != "" {
+		var ok bool
+		qtype, ok = dns.StringToType[strings.ToUpper(t)]
+		if !ok {
+			v, err := strconv.ParseUint(t, 10, 16)
+			if err != nil {
+				return nil, domain.Error{
+					Message:    fmt.Sprintf("Unknown record type %s", t),
+					StatusCode: http.StatusBadRequest,
+				}
+			}
+			qtype = uint16(v)
+		}
+	}
+
+	req := new(dns.Msg)
+	req.SetQuestion(utils.GetFQDNFromDomainName(name), qtype)
+	req.CheckingDisabled = d.isTrue(ctx.Query("cd"))
+	if d.isTrue(ctx.Query("do")) {
+		req.SetEdns0(dns.DefaultMsgSize, true)
+	}
+	return req, nil
+}
+
+func (d *dohHandler) toDoHAnswers(rrs []dns.RR) []domain.DoHAnswer {
+	var answers []domain.DoHAnswer
+	for _, rr := range rrs {
+		if rr.Header().Rrtype == dns.TypeOPT {
+			continue
+		}
+		answers = append(
+			answers, domain.DoHAnswer{
+				Name: rr.Header().Name,
+				Type: rr.Header().Rrtype,
+				TTL:  rr.Header().Ttl,
+				Data: strings.TrimPrefix(rr.String(), rr.Header().String()),
+			},
+		)
+	}
+	return answers
+}
+
+// setCacheControl advertises the smallest TTL of the reply as RFC 8484 section 5.1 suggests
+func (d *dohHandler) setCacheControl(ctx *gin.Context, resp *dns.Msg) {
+	var (
+		minTTL uint32
+		found  bool
+	)
+	for _, section := range [][]dns.RR{resp.Answer, resp.Ns, resp.Extra} {
+		for _, rr := range section {
+			if rr.Header().Rrtype == dns.TypeOPT {
+				continue
+			}
+			if !found || rr.Header().Ttl < minTTL {
+				minTTL, found = rr.Header().Ttl, true
+			}
+		}
+	}
+	if found {
+		ctx.Header("Cache-Control", fmt.Sprintf("max-age=%d", minTTL))
+	}
+}
+
+func (d *dohHandler) remoteAddr(ctx *gin.Context) net.Addr {
+	addr := &net.TCPAddr{IP: net.ParseIP(ctx.ClientIP())}
+	_, port, err := net.SplitHostPort(ctx.Request.RemoteAddr)
+	if err == nil {
+		addr.Port, _ = strconv.Atoi(port)
+	}
+	return addr
+}
+
+// connectionState returns the TLS of the request, DoH is HTTPS only (RFC 8484 5) so the request which
+// comes in plain HTTP is behind a TLS terminating proxy
+func (d *dohHandler) connectionState(ctx *gin.Context) *tls.ConnectionState {
+	if ctx.Request.TLS != nil {
+		return ctx.Request.TLS
+	}
+	return &tls.ConnectionState{}
+}
+
+func (d *dohHandler) isTrue(value string) bool {
+	v, _ := strconv.ParseBool(value)
+	return v
+}
+
+// dohResponseWriter captures the reply of a dns.Handler instead of writing it to a socket
+type dohResponseWriter struct {
+	remoteAddr      net.Addr
+	connectionState *tls.ConnectionState
+	msg             *dns.Msg
+}
+
+func (w *dohResponseWriter) LocalAddr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+func (w *dohResponseWriter) RemoteAddr() net.Addr {
+	return w.remoteAddr
+}
+
+func (w *dohResponseWriter) WriteMsg(msg *dns.Msg) error {
+	w.msg = msg
+	return nil
+}
+
+func (w *dohResponseWriter) Write(raw []byte) (int, error) {
+	msg := new(dns.Msg)
+	err := msg.Unpack(raw)
+	if err != nil {
+		return 0, err
+	}
+	w.msg = msg
+	return len(raw), nil
+}
+
+func (w *dohResponseWriter) Close() error {
+	return nil
+}
+
+// TsigStatus fails the TSIG of every request, which isn't verified over DoH
+func (w *dohResponseWriter) TsigStatus() error {
+	return dns.ErrAuth
+}
+
+func (w *dohResponseWriter) TsigTimersOnly(bool) {}
+
+// ConnectionState makes the responses padded as the ones of the other encrypted transports
+func (w *dohResponseWriter) ConnectionState() *tls.ConnectionState {
+	return w.connectionState
+}
+
+func (w *dohResponseWriter) Hijack() {}
+
+// SingleMsg tells that the reply is a single HTTP response
+func (w *dohResponseWriter) SingleMsg() {}
+
+func NewDoHHandler(injector *do.Injector) (domain.DoHHandler, error) {
+	return &dohHandler{do.MustInvoke[dns.Handler](injector)}, nil
+}
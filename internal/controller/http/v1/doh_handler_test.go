@@ -0,0 +1,213 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cewuandy/go-restful-dns/internal/controller/http/middleware"
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/pkg/gin/routes"
+)
+
+type dohHandlerTestSuite struct {
+	suite.Suite
+
+	handler domain.DoHHandler
+
+	serveDNS func(w dns.ResponseWriter, req *dns.Msg)
+
+	r *gin.Engine
+}
+
+func TestDoHHandler(t *testing.T) {
+	suite.Run(t, &dohHandlerTestSuite{})
+}
+
+func (t *dohHandlerTestSuite) SetupSuite() {
+	injector := do.New()
+	do.ProvideValue[dns.Handler](
+		injector, dns.HandlerFunc(
+			func(w dns.ResponseWriter, req *dns.Msg) {
+				t.serveDNS(w, req)
+			},
+		),
+	)
+	do.Provide[domain.DoHHandler](injector, NewDoHHandler)
+	do.Provide[domain.ErrorHandler](injector, middleware.NewErrorHandler)
+
+	t.r = gin.New()
+	t.r.Use(do.MustInvoke[domain.ErrorHandler](injector).HandleError)
+
+	routes.RegisterDoHRoutes(t.r, do.MustInvoke[domain.DoHHandler](injector))
+}
+
+func (t *dohHandlerTestSuite) SetupTest() {
+	t.serveDNS = func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		rr, _ := dns.NewRR("test.com.\t1440\tIN\tA\t1.1.1.1")
+		resp.Answer = append(resp.Answer, rr)
+		_ = w.WriteMsg(resp)
+	}
+}
+
+func (t *dohHandlerTestSuite) packQuery() []byte {
+	req := new(dns.Msg)
+	req.SetQuestion("test.com.", dns.TypeA)
+	req.Id = 0
+	raw, err := req.Pack()
+	t.Nil(err)
+	return raw
+}
+
+func (t *dohHandlerTestSuite) TestQueryGetAPI() {
+	t.Run(
+		"wire_success", func() {
+			recorder := httptest.NewRecorder()
+			encoded := base64.RawURLEncoding.EncodeToString(t.packQuery())
+			request, err := http.NewRequest(http.MethodGet, "/dns-query?dns="+encoded, nil)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusOK, recorder.Code)
+			t.Equal(domain.DNSMessageContentType, recorder.Header().Get("Content-Type"))
+			t.Equal("max-age=1440", recorder.Header().Get("Cache-Control"))
+			resp := new(dns.Msg)
+			t.Nil(resp.Unpack(recorder.Body.Bytes()))
+			t.Contains(resp.Answer[0].String(), "1.1.1.1")
+		},
+	)
+
+	t.Run(
+		"json_success", func() {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/dns-query?name=test.com&type=A", nil)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusOK, recorder.Code)
+			t.Equal(domain.DNSJSONContentType, recorder.Header().Get("Content-Type"))
+			var resp domain.DoHJSONResponse
+			t.Nil(json.Unmarshal(recorder.Body.Bytes(), &resp))
+			t.Equal(dns.RcodeSuccess, resp.Status)
+			t.Equal("test.com.", resp.Question[0].Name)
+			t.Equal("1.1.1.1", resp.Answer[0].Data)
+			t.Equal(uint32(1440), resp.Answer[0].TTL)
+		},
+	)
+
+	t.Run(
+		"decode_error", func() {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/dns-query?dns=!!!", nil)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "Decode dns parameter error")
+		},
+	)
+
+	t.Run(
+		"missing_param_error", func() {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/dns-query", nil)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "Either dns or name query parameter is required")
+		},
+	)
+
+	t.Run(
+		"type_error", func() {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/dns-query?name=test.com&type=BOGUS", nil)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "Unknown record type")
+		},
+	)
+
+	t.Run(
+		"no_response_error", func() {
+			t.serveDNS = func(w dns.ResponseWriter, req *dns.Msg) {}
+			defer t.SetupTest()
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/dns-query?name=test.com", nil)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusBadGateway, recorder.Code)
+		},
+	)
+}
+
+func (t *dohHandlerTestSuite) TestQueryPostAPI() {
+	t.Run(
+		"success", func() {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(
+				http.MethodPost, "/dns-query", bytes.NewBuffer(t.packQuery()),
+			)
+			t.Nil(err)
+			request.Header.Set("Content-Type", domain.DNSMessageContentType)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusOK, recorder.Code)
+			resp := new(dns.Msg)
+			t.Nil(resp.Unpack(recorder.Body.Bytes()))
+			t.Equal(uint16(0), resp.Id)
+			t.Contains(resp.Answer[0].String(), "1.1.1.1")
+		},
+	)
+
+	t.Run(
+		"content_type_error", func() {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(
+				http.MethodPost, "/dns-query", bytes.NewBuffer(t.packQuery()),
+			)
+			t.Nil(err)
+			request.Header.Set("Content-Type", "application/json")
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusUnsupportedMediaType, recorder.Code)
+		},
+	)
+
+	t.Run(
+		"unpack_error", func() {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(
+				http.MethodPost, "/dns-query", bytes.NewBuffer([]byte{0x01}),
+			)
+			t.Nil(err)
+			request.Header.Set("Content-Type", domain.DNSMessageContentType)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "Unpack DNS message error")
+		},
+	)
+}
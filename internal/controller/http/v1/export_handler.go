@@ -0,0 +1,262 @@
+package v1
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+
+	"github.com/pkg/errors"
+)
+
+// exportEncoder writes the sections one by one as the use case reads them, Close ends the output
+type exportEncoder interface {
+	WriteSection(section *domain.ExportSection) error
+	Close() error
+}
+
+type exportWriter struct {
+	contentType string
+	newEncoder  func(w io.Writer) exportEncoder
+}
+
+var exportWriters = map[domain.ExportFormat]exportWriter{
+	domain.ExportBind: {"text/plain; charset=utf-8", newBindEncoder},
+	domain.ExportJSON: {"application/json; charset=utf-8", newJSONEncoder},
+	domain.ExportCSV:  {"text/csv; charset=utf-8", newCSVEncoder},
+}
+
+type exportHandler struct {
+	exportUseCase domain.ExportUseCase
+}
+
+// ExportAPI ...
+// @title ExportAPI
+// @description Export the records, including the SOA and NS records of the zones, as a BIND master
+// @description file, as JSON in the record API schema or as CSV. The records are in the canonical order
+// @description and the output is streamed zone by zone.
+// @tags Export
+// @produce plain
+// @produce json
+// @produce text/csv
+// @param format query string false "Export Format, bind, json or csv" default(bind)
+// @param zone query string false "Only export the records of the zone"
+// @param name query string false "Only export the records owned by the name"
+// @success 200 {string} string "bind: a BIND master file, json: an array of records in the record API schema, csv: the name, ttl, class, type and rdata of a record per row"
+// @failure 400 {object} domain.Error
+// @failure 404 {object} domain.Error
+// @router /export [GET]
+func (e *exportHandler) ExportAPI(ctx *gin.Context) {
+	var opts domain.ExportOptions
+
+	err := ctx.ShouldBindQuery(&opts)
+	if err != nil {
+		err = &domain.Error{
+			Message:    fmt.Sprintf("Bind query error: %s", err.Error()),
+			Err:        errors.New(err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+		_ = ctx.Error(err)
+		return
+	}
+
+	if opts.Format == "" {
+		opts.Format = domain.ExportBind
+	}
+	writer, ok := exportWriters[opts.Format]
+	if !ok {
+		err = &domain.Error{
+			Message: fmt.Sprintf(
+				"the export format should be %s, %s or %s", domain.ExportBind, domain.ExportJSON, domain.ExportCSV,
+			),
+			StatusCode: http.StatusBadRequest,
+		}
+		_ = ctx.Error(err)
+		return
+	}
+
+	// the status is sent with the first section, so the errors before it are still returned as usual
+	started := false
+	start := func() {
+		if !started {
+			ctx.Header("Content-Type", writer.contentType)
+			ctx.Status(http.StatusOK)
+			started = true
+		}
+	}
+	encoder := writer.newEncoder(ctx.Writer)
+	err = e.exportUseCase.Export(
+		ctx, opts.ExportFilter, func(section *domain.ExportSection) error {
+			start()
+			err := encoder.WriteSection(section)
+			if err != nil {
+				return err
+			}
+			ctx.Writer.Flush()
+			return nil
+		},
+	)
+	if err != nil && !started {
+		_ = ctx.Error(err)
+		return
+	}
+
+	start()
+	// the status is already sent, the output is cut short when the export fails or the client is gone
+	if err != nil || encoder.Close() != nil {
+		ctx.Abort()
+	}
+}
+
+type bindEncoder struct {
+	w        io.Writer
+	sections int
+}
+
+func newBindEncoder(w io.Writer) exportEncoder {
+	return &bindEncoder{w: w}
+}
+
+func (b *bindEncoder) WriteSection(section *domain.ExportSection) error {
+	header := fmt.Sprintf("$ORIGIN %s\n", section.Origin)
+	if section.Ttl > 0 {
+		header += fmt.Sprintf("$TTL %d\n", section.Ttl)
+	}
+	if b.sections > 0 {
+		header = "\n" + header
+	}
+	b.sections++
+	_, err := io.WriteString(b.w, header)
+	if err != nil {
+		return err
+	}
+
+	for _, rr := range section.RRs {
+		h := rr.Header()
+		_, err = fmt.Fprintf(
+			b.w, "%s\t%d\t%s\t%s\t%s\n", relativeName(h.Name, section.Origin), h.Ttl,
+			dns.Class(h.Class).String(), dns.Type(h.Rrtype).String(), rdataOf(rr),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *bindEncoder) Close() error {
+	return nil
+}
+
+// jsonEncoder writes a record per line, so that the output can be diffed as well
+type jsonEncoder struct {
+	w         io.Writer
+	separator string
+}
+
+func newJSONEncoder(w io.Writer) exportEncoder {
+	return &jsonEncoder{w: w, separator: "[\n"}
+}
+
+func (j *jsonEncoder) WriteSection(section *domain.ExportSection) error {
+	for _, rr := range section.RRs {
+		raw, err := json.Marshal(domain.NewGenericRecord(rr))
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(j.w, j.separator+string(raw))
+		if err != nil {
+			return err
+		}
+		j.separator = ",\n"
+	}
+	return nil
+}
+
+func (j *jsonEncoder) Close() error {
+	end := "\n]\n"
+	if j.separator == "[\n" {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+type csvEncoder struct {
+	writer *csv.Writer
+	header bool
+}
+
+func newCSVEncoder(w io.Writer) exportEncoder {
+	return &csvEncoder{writer: csv.NewWriter(w)}
+}
+
+// writeHeader writes the header once, also when there is no record
+func (c *csvEncoder) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	return c.writer.Write([]string{"name", "ttl", "class", "type", "rdata"})
+}
+
+func (c *csvEncoder) WriteSection(section *domain.ExportSection) error {
+	err := c.writeHeader()
+	if err != nil {
+		return err
+	}
+
+	for _, rr := range section.RRs {
+		h := rr.Header()
+		err = c.writer.Write(
+			[]string{
+				h.Name, strconv.FormatUint(uint64(h.Ttl), 10), dns.Class(h.Class).String(),
+				dns.Type(h.Rrtype).String(), rdataOf(rr),
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func (c *csvEncoder) Close() error {
+	err := c.writeHeader()
+	if err != nil {
+		return err
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// relativeName writes the name relative to the origin as the master files usually do
+func relativeName(name string, origin string) string {
+	switch {
+	case origin == ".":
+		return name
+	case strings.EqualFold(name, origin):
+		return "@"
+	case dns.IsSubDomain(origin, name):
+		return name[:len(name)-len(origin)-1]
+	default:
+		return name
+	}
+}
+
+func rdataOf(rr dns.RR) string {
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
+
+func NewExportHandler(injector *do.Injector) (domain.ExportHandler, error) {
+	return &exportHandler{do.MustInvoke[domain.ExportUseCase](injector)}, nil
+}
@@ -0,0 +1,212 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cewuandy/go-restful-dns/internal/controller/http/middleware"
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+	"github.com/cewuandy/go-restful-dns/pkg/gin/routes"
+)
+
+type exportHandlerTestSuite struct {
+	suite.Suite
+
+	exportUseCase *mocks.ExportUseCase
+
+	r *gin.Engine
+}
+
+func TestExportHandler(t *testing.T) {
+	suite.Run(t, &exportHandlerTestSuite{})
+}
+
+func (t *exportHandlerTestSuite) SetupSuite() {
+	injector := do.New()
+	t.exportUseCase = &mocks.ExportUseCase{}
+	do.ProvideValue[domain.ExportUseCase](injector, t.exportUseCase)
+	do.Provide[domain.ExportHandler](injector, NewExportHandler)
+	do.Provide[domain.ErrorHandler](injector, middleware.NewErrorHandler)
+
+	t.r = gin.New()
+	t.r.Use(do.MustInvoke[domain.ErrorHandler](injector).HandleError)
+
+	routes.RegisterExportRoutes(t.r, do.MustInvoke[domain.ExportHandler](injector))
+}
+
+func (t *exportHandlerTestSuite) SetupTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyFilter  = mock.AnythingOfType("domain.ExportFilter")
+		zone       = &domain.Zone{
+			Name:        "test.com.",
+			Nameservers: []string{"ns1.test.com."},
+			Mbox:        "admin.test.com.",
+			Serial:      2024010100,
+			Refresh:     7200,
+			Retry:       3600,
+			Expire:      1209600,
+			Minttl:      300,
+			Ttl:         3600,
+		}
+	)
+
+	www, _ := dns.NewRR("www.test.com.\t3600\tIN\tTXT\t\"a,b\"")
+	other, _ := dns.NewRR("other.com.\t60\tIN\tA\t9.9.9.9")
+
+	t.exportUseCase.ExpectedCalls = nil
+	t.mockExport(
+		anyContext, anyFilter, nil,
+		&domain.ExportSection{
+			Origin: "test.com.", Ttl: 3600, RRs: append([]dns.RR{zone.SOA()}, append(zone.NS(), www)...),
+		},
+		&domain.ExportSection{Origin: ".", RRs: []dns.RR{other}},
+	)
+}
+
+// mockExport writes the sections, then returns the error
+func (t *exportHandlerTestSuite) mockExport(ctx interface{}, filter interface{}, err error,
+	sections ...*domain.ExportSection) {
+	t.exportUseCase.
+		On("Export", ctx, filter, mock.Anything).
+		Return(
+			func(_ context.Context, _ domain.ExportFilter, write func(section *domain.ExportSection) error) error {
+				for _, section := range sections {
+					if err := write(section); err != nil {
+						return err
+					}
+				}
+				return err
+			},
+		)
+}
+
+func (t *exportHandlerTestSuite) TestExportAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	get := func(query string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/api/v1/export"+query, nil)
+		t.Nil(err)
+		t.r.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run(
+		"success_bind", func() {
+			t.exportUseCase.Calls = nil
+			recorder := get("?zone=test.com&name=www.test.com")
+			t.Equal(http.StatusOK, recorder.Code)
+			t.Equal("text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+			t.Equal(
+				"$ORIGIN test.com.\n"+
+					"$TTL 3600\n"+
+					"@\t3600\tIN\tSOA\tns1.test.com. admin.test.com. 2024010100 7200 3600 1209600 300\n"+
+					"@\t3600\tIN\tNS\tns1.test.com.\n"+
+					"www\t3600\tIN\tTXT\t\"a,b\"\n"+
+					"\n"+
+					"$ORIGIN .\n"+
+					"other.com.\t60\tIN\tA\t9.9.9.9\n",
+				recorder.Body.String(),
+			)
+			t.exportUseCase.AssertCalled(
+				t.T(), "Export", anyContext, domain.ExportFilter{Zone: "test.com", Name: "www.test.com"}, mock.Anything,
+			)
+		},
+	)
+
+	t.Run(
+		"success_json", func() {
+			recorder := get("?format=json")
+			t.Equal(http.StatusOK, recorder.Code)
+			t.Equal("application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+			var output []domain.GenericRecord
+			t.Nil(json.Unmarshal(recorder.Body.Bytes(), &output))
+			t.Len(output, 4)
+			t.Equal(domain.RRType("SOA"), output[0].Hdr.Rrtype)
+			t.Equal("9.9.9.9", output[3].Rdata)
+		},
+	)
+
+	t.Run(
+		"success_csv", func() {
+			recorder := get("?format=csv")
+			t.Equal(http.StatusOK, recorder.Code)
+			t.Equal("text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+			t.Contains(recorder.Body.String(), "name,ttl,class,type,rdata\n")
+			t.Contains(recorder.Body.String(), "www.test.com.,3600,IN,TXT,\"\"\"a,b\"\"\"\n")
+			t.Contains(recorder.Body.String(), "other.com.,60,IN,A,9.9.9.9\n")
+		},
+	)
+
+	t.Run(
+		"success_empty_json", func() {
+			t.exportUseCase.ExpectedCalls = nil
+			t.mockExport(anyContext, mock.AnythingOfType("domain.ExportFilter"), nil)
+			defer t.SetupTest()
+
+			recorder := get("?format=json")
+			t.Equal(http.StatusOK, recorder.Code)
+			t.Equal("application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+			t.Equal("[]\n", recorder.Body.String())
+
+			recorder = get("?format=csv")
+			t.Equal(http.StatusOK, recorder.Code)
+			t.Equal("name,ttl,class,type,rdata\n", recorder.Body.String())
+		},
+	)
+
+	t.Run(
+		"format_error", func() {
+			recorder := get("?format=xml")
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "the export format should be bind, json or csv")
+		},
+	)
+
+	t.Run(
+		"Export_error", func() {
+			t.exportUseCase.ExpectedCalls = nil
+			t.mockExport(
+				anyContext, mock.AnythingOfType("domain.ExportFilter"),
+				&domain.Error{Message: "record not found", StatusCode: http.StatusNotFound},
+			)
+			defer t.SetupTest()
+
+			recorder := get("?zone=none.com")
+			t.Equal(http.StatusNotFound, recorder.Code)
+			t.Contains(recorder.Body.String(), "record not found")
+		},
+	)
+
+	t.Run(
+		"Export_error_after_section", func() {
+			other, _ := dns.NewRR("other.com.\t60\tIN\tA\t9.9.9.9")
+			t.exportUseCase.ExpectedCalls = nil
+			t.mockExport(
+				anyContext, mock.AnythingOfType("domain.ExportFilter"),
+				&domain.Error{Message: "DB error", StatusCode: http.StatusBadRequest},
+				&domain.ExportSection{Origin: ".", RRs: []dns.RR{other}},
+			)
+			defer t.SetupTest()
+
+			// the sent section is kept and the output is cut short
+			recorder := get("?format=json")
+			t.Equal(http.StatusOK, recorder.Code)
+			t.True(strings.HasPrefix(recorder.Body.String(), "[\n{"))
+			t.Contains(recorder.Body.String(), "\"rdata\":\"9.9.9.9\"")
+			t.False(strings.HasSuffix(recorder.Body.String(), "]\n"))
+		},
+	)
+}
@@ -0,0 +1,145 @@
+package v1
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+
+	"github.com/pkg/errors"
+)
+
+type forwardingRuleHandler struct {
+	forwardingRuleUseCase domain.ForwardingRuleUseCase
+}
+
+// CreateForwardingRuleAPI ...
+// @title CreateForwardingRuleAPI
+// @description Create a forwarding rule, which forwards the queries of the names under its suffix to its upstream
+// @description forwarders, e.g. corp.example. to the AD DNS servers. The rule of the longest matching suffix wins,
+// @description and the strategy is sequential unless given.
+// @tags Forwarding Rule
+// @accept json
+// @param body body domain.ForwardingRule true "The example of forwarding rule request body"
+// @success 201 {object} domain.ForwardingRule
+// @failure 400 {object} domain.Error
+// @router /forwarding-rules [POST]
+func (f *forwardingRuleHandler) CreateForwardingRuleAPI(ctx *gin.Context) {
+	var rule domain.ForwardingRule
+
+	err := ctx.ShouldBindJSON(&rule)
+	if err != nil {
+		err = &domain.Error{
+			Message:    fmt.Sprintf("Bind JSON error: %s", err.Error()),
+			Err:        errors.New(err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+		_ = ctx.Error(err)
+		return
+	}
+
+	err = f.forwardingRuleUseCase.CreateRule(ctx, &rule)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, rule)
+}
+
+// GetForwardingRuleAPI ...
+// @title GetForwardingRuleAPI
+// @description Get forwarding rule by suffix
+// @tags Forwarding Rule
+// @accept json
+// @param suffix path string true "Domain Suffix"
+// @success 200 {object} domain.ForwardingRule
+// @failure 404 {object} domain.Error
+// @router /forwarding-rules/{suffix} [GET]
+func (f *forwardingRuleHandler) GetForwardingRuleAPI(ctx *gin.Context) {
+	rule, err := f.forwardingRuleUseCase.GetRule(ctx, ctx.Param("suffix"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rule)
+}
+
+// ListForwardingRulesAPI ...
+// @title ListForwardingRulesAPI
+// @description List all forwarding rules
+// @tags Forwarding Rule
+// @accept json
+// @success 200 {object} []domain.ForwardingRule
+// @failure 400 {object} domain.Error
+// @router /forwarding-rules [GET]
+func (f *forwardingRuleHandler) ListForwardingRulesAPI(ctx *gin.Context) {
+	rules, err := f.forwardingRuleUseCase.ListRules(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rules)
+}
+
+// UpdateForwardingRuleAPI ...
+// @title UpdateForwardingRuleAPI
+// @description Update the upstream forwarders and the strategy of an existed forwarding rule
+// @tags Forwarding Rule
+// @accept json
+// @param suffix path string true "Domain Suffix"
+// @param body body domain.ForwardingRule true "The example of forwarding rule request body"
+// @success 200 {object} domain.ForwardingRule
+// @failure 400 {object} domain.Error
+// @failure 404 {object} domain.Error
+// @router /forwarding-rules/{suffix} [PUT]
+func (f *forwardingRuleHandler) UpdateForwardingRuleAPI(ctx *gin.Context) {
+	var rule domain.ForwardingRule
+
+	err := ctx.ShouldBindJSON(&rule)
+	if err != nil {
+		err = &domain.Error{
+			Message:    fmt.Sprintf("Bind JSON error: %s", err.Error()),
+			Err:        errors.New(err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+		_ = ctx.Error(err)
+		return
+	}
+	rule.Suffix = ctx.Param("suffix")
+
+	err = f.forwardingRuleUseCase.UpdateRule(ctx, &rule)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rule)
+}
+
+// DeleteForwardingRuleAPI ...
+// @title DeleteForwardingRuleAPI
+// @description Delete forwarding rule by suffix, its names are forwarded by the next longest suffix
+// @tags Forwarding Rule
+// @accept json
+// @param suffix path string true "Domain Suffix"
+// @success 204
+// @failure 404 {object} domain.Error
+// @router /forwarding-rules/{suffix} [DELETE]
+func (f *forwardingRuleHandler) DeleteForwardingRuleAPI(ctx *gin.Context) {
+	err := f.forwardingRuleUseCase.DeleteRule(ctx, ctx.Param("suffix"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func NewForwardingRuleHandler(injector *do.Injector) (domain.ForwardingRuleHandler, error) {
+	return &forwardingRuleHandler{do.MustInvoke[domain.ForwardingRuleUseCase](injector)}, nil
+}
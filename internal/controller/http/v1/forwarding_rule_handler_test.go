@@ -0,0 +1,236 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cewuandy/go-restful-dns/internal/controller/http/middleware"
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+	"github.com/cewuandy/go-restful-dns/pkg/gin/routes"
+)
+
+type forwardingRuleHandlerTestSuite struct {
+	suite.Suite
+
+	forwardingRuleUseCase *mocks.ForwardingRuleUseCase
+
+	r *gin.Engine
+}
+
+func TestForwardingRuleHandler(t *testing.T) {
+	suite.Run(t, &forwardingRuleHandlerTestSuite{})
+}
+
+func (t *forwardingRuleHandlerTestSuite) SetupSuite() {
+	injector := do.New()
+	t.forwardingRuleUseCase = &mocks.ForwardingRuleUseCase{}
+	do.ProvideValue[domain.ForwardingRuleUseCase](injector, t.forwardingRuleUseCase)
+	do.Provide[domain.ForwardingRuleHandler](injector, NewForwardingRuleHandler)
+	do.Provide[domain.ErrorHandler](injector, middleware.NewErrorHandler)
+
+	t.r = gin.New()
+	t.r.Use(do.MustInvoke[domain.ErrorHandler](injector).HandleError)
+
+	routes.RegisterForwardingRuleRoutes(t.r, do.MustInvoke[domain.ForwardingRuleHandler](injector))
+}
+
+func (t *forwardingRuleHandlerTestSuite) SetupTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		rule       = &domain.ForwardingRule{
+			Suffix:    "corp.example.",
+			Upstreams: []string{"10.0.0.1:53", "10.0.0.2:53"},
+			Strategy:  "sequential",
+		}
+	)
+
+	t.forwardingRuleUseCase.ExpectedCalls = nil
+	t.forwardingRuleUseCase.Calls = nil
+	t.forwardingRuleUseCase.
+		On("CreateRule", anyContext, mock.AnythingOfType("*domain.ForwardingRule")).
+		Return(nil)
+	t.forwardingRuleUseCase.
+		On("GetRule", anyContext, anyString).
+		Return(rule, nil)
+	t.forwardingRuleUseCase.
+		On("ListRules", anyContext).
+		Return([]*domain.ForwardingRule{rule}, nil)
+	t.forwardingRuleUseCase.
+		On("UpdateRule", anyContext, mock.AnythingOfType("*domain.ForwardingRule")).
+		Return(nil)
+	t.forwardingRuleUseCase.
+		On("DeleteRule", anyContext, anyString).
+		Return(nil)
+}
+
+func (t *forwardingRuleHandlerTestSuite) serve(method, path string, body io.Reader) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(method, "/api/v1/forwarding-rules"+path, body)
+	t.Nil(err)
+	t.r.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func (t *forwardingRuleHandlerTestSuite) TestCreateForwardingRuleAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			body, _ := json.Marshal(
+				domain.ForwardingRule{Suffix: "svc.cluster.local.", Upstreams: []string{"10.96.0.10:53"}},
+			)
+			recorder := t.serve(http.MethodPost, "", bytes.NewReader(body))
+			t.Equal(http.StatusCreated, recorder.Code)
+			t.forwardingRuleUseCase.AssertCalled(
+				t.T(), "CreateRule", anyContext,
+				&domain.ForwardingRule{Suffix: "svc.cluster.local.", Upstreams: []string{"10.96.0.10:53"}},
+			)
+		},
+	)
+
+	t.Run(
+		"bind_error", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodPost, "", bytes.NewReader([]byte(`{"suffix":"corp.example."}`)))
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.forwardingRuleUseCase.AssertNotCalled(t.T(), "CreateRule", anyContext, mock.Anything)
+		},
+	)
+
+	t.Run(
+		"CreateRule_error", func() {
+			t.SetupTest()
+			t.forwardingRuleUseCase.ExpectedCalls = nil
+			t.forwardingRuleUseCase.
+				On("CreateRule", anyContext, mock.AnythingOfType("*domain.ForwardingRule")).
+				Return(&domain.Error{Message: "the forwarding rule is already existed.", StatusCode: http.StatusBadRequest})
+
+			body, _ := json.Marshal(domain.ForwardingRule{Suffix: "corp.example.", Upstreams: []string{"10.0.0.1"}})
+			recorder := t.serve(http.MethodPost, "", bytes.NewReader(body))
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "already existed")
+		},
+	)
+}
+
+func (t *forwardingRuleHandlerTestSuite) TestGetForwardingRuleAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodGet, "/corp.example.", nil)
+			t.Equal(http.StatusOK, recorder.Code)
+
+			var rule domain.ForwardingRule
+			t.Nil(json.Unmarshal(recorder.Body.Bytes(), &rule))
+			t.Equal("corp.example.", rule.Suffix)
+			t.Len(rule.Upstreams, 2)
+			t.forwardingRuleUseCase.AssertCalled(t.T(), "GetRule", anyContext, "corp.example.")
+		},
+	)
+
+	t.Run(
+		"GetRule_error", func() {
+			t.SetupTest()
+			t.forwardingRuleUseCase.ExpectedCalls = nil
+			t.forwardingRuleUseCase.
+				On("GetRule", anyContext, mock.AnythingOfType("string")).
+				Return(nil, &domain.Error{Message: "forwarding rule not found", StatusCode: http.StatusNotFound})
+
+			recorder := t.serve(http.MethodGet, "/other.", nil)
+			t.Equal(http.StatusNotFound, recorder.Code)
+		},
+	)
+}
+
+func (t *forwardingRuleHandlerTestSuite) TestListForwardingRulesAPI() {
+	t.SetupTest()
+	recorder := t.serve(http.MethodGet, "", nil)
+	t.Equal(http.StatusOK, recorder.Code)
+
+	var rules []*domain.ForwardingRule
+	t.Nil(json.Unmarshal(recorder.Body.Bytes(), &rules))
+	t.Len(rules, 1)
+}
+
+func (t *forwardingRuleHandlerTestSuite) TestUpdateForwardingRuleAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			body, _ := json.Marshal(
+				domain.ForwardingRule{Suffix: "other.", Upstreams: []string{"10.0.0.3:53"}, Strategy: "fastest"},
+			)
+			recorder := t.serve(http.MethodPut, "/corp.example.", bytes.NewReader(body))
+			t.Equal(http.StatusOK, recorder.Code)
+			// the suffix comes from the path
+			t.forwardingRuleUseCase.AssertCalled(
+				t.T(), "UpdateRule", anyContext,
+				&domain.ForwardingRule{Suffix: "corp.example.", Upstreams: []string{"10.0.0.3:53"}, Strategy: "fastest"},
+			)
+		},
+	)
+
+	t.Run(
+		"bind_error", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodPut, "/corp.example.", bytes.NewReader([]byte(`{"upstreams":"10.0.0.3"}`)))
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.forwardingRuleUseCase.AssertNotCalled(t.T(), "UpdateRule", anyContext, mock.Anything)
+		},
+	)
+
+	t.Run(
+		"UpdateRule_error", func() {
+			t.SetupTest()
+			t.forwardingRuleUseCase.ExpectedCalls = nil
+			t.forwardingRuleUseCase.
+				On("UpdateRule", anyContext, mock.AnythingOfType("*domain.ForwardingRule")).
+				Return(&domain.Error{Message: "forwarding rule not found", StatusCode: http.StatusNotFound})
+
+			body, _ := json.Marshal(domain.ForwardingRule{Suffix: "other.", Upstreams: []string{"10.0.0.3"}})
+			recorder := t.serve(http.MethodPut, "/other.", bytes.NewReader(body))
+			t.Equal(http.StatusNotFound, recorder.Code)
+		},
+	)
+}
+
+func (t *forwardingRuleHandlerTestSuite) TestDeleteForwardingRuleAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodDelete, "/corp.example.", nil)
+			t.Equal(http.StatusNoContent, recorder.Code)
+			t.forwardingRuleUseCase.AssertCalled(t.T(), "DeleteRule", anyContext, "corp.example.")
+		},
+	)
+
+	t.Run(
+		"DeleteRule_error", func() {
+			t.SetupTest()
+			t.forwardingRuleUseCase.ExpectedCalls = nil
+			t.forwardingRuleUseCase.
+				On("DeleteRule", anyContext, mock.AnythingOfType("string")).
+				Return(&domain.Error{Message: "forwarding rule not found", StatusCode: http.StatusNotFound})
+
+			recorder := t.serve(http.MethodDelete, "/other.", nil)
+			t.Equal(http.StatusNotFound, recorder.Code)
+		},
+	)
+}
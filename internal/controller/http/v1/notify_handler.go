@@ -0,0 +1,63 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+)
+
+type notifyHandler struct {
+	notifyUseCase domain.NotifyUseCase
+}
+
+// NotifyZoneAPI ...
+// @title NotifyZoneAPI
+// @description Send NOTIFY of the zone to its targets at once, the delivery is reported by the notify
+// @description status API. The changes of the records notify the targets as well.
+// @tags Zone
+// @accept json
+// @param zone path string true "Zone Name"
+// @success 202 {object} []domain.NotifyStatus
+// @failure 404 {object} domain.Error
+// @router /zones/{zone}/notify [POST]
+func (n *notifyHandler) NotifyZoneAPI(ctx *gin.Context) {
+	err := n.notifyUseCase.Notify(ctx, ctx.Param("zone"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	statuses, err := n.notifyUseCase.ListStatus(ctx, ctx.Param("zone"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, statuses)
+}
+
+// ListNotifyStatusAPI ...
+// @title ListNotifyStatusAPI
+// @description List the delivery of the latest NOTIFY of the zone to each of its targets, a pending
+// @description NOTIFY is retried with backoff until it's delivered or failed
+// @tags Zone
+// @accept json
+// @param zone path string true "Zone Name"
+// @success 200 {object} []domain.NotifyStatus
+// @failure 404 {object} domain.Error
+// @router /zones/{zone}/notify [GET]
+func (n *notifyHandler) ListNotifyStatusAPI(ctx *gin.Context) {
+	statuses, err := n.notifyUseCase.ListStatus(ctx, ctx.Param("zone"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, statuses)
+}
+
+func NewNotifyHandler(injector *do.Injector) (domain.NotifyHandler, error) {
+	return &notifyHandler{do.MustInvoke[domain.NotifyUseCase](injector)}, nil
+}
@@ -0,0 +1,143 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cewuandy/go-restful-dns/internal/controller/http/middleware"
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+	"github.com/cewuandy/go-restful-dns/pkg/gin/routes"
+)
+
+type notifyHandlerTestSuite struct {
+	suite.Suite
+
+	notifyUseCase *mocks.NotifyUseCase
+
+	r *gin.Engine
+}
+
+func TestNotifyHandler(t *testing.T) {
+	suite.Run(t, &notifyHandlerTestSuite{})
+}
+
+func (t *notifyHandlerTestSuite) SetupSuite() {
+	injector := do.New()
+	t.notifyUseCase = &mocks.NotifyUseCase{}
+	do.ProvideValue[domain.NotifyUseCase](injector, t.notifyUseCase)
+	do.Provide[domain.NotifyHandler](injector, NewNotifyHandler)
+	do.Provide[domain.ErrorHandler](injector, middleware.NewErrorHandler)
+
+	t.r = gin.New()
+	t.r.Use(do.MustInvoke[domain.ErrorHandler](injector).HandleError)
+
+	routes.RegisterNotifyRoutes(t.r, do.MustInvoke[domain.NotifyHandler](injector))
+}
+
+func (t *notifyHandlerTestSuite) SetupTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+	)
+
+	t.notifyUseCase.ExpectedCalls = nil
+	t.notifyUseCase.Calls = nil
+	t.notifyUseCase.
+		On("Notify", anyContext, anyString).
+		Return(nil)
+	t.notifyUseCase.
+		On("ListStatus", anyContext, anyString).
+		Return(
+			[]*domain.NotifyStatus{
+				{
+					Target:   "192.0.2.2:53",
+					Serial:   2024010101,
+					State:    domain.NotifyPending,
+					Attempts: 1,
+					Updated:  time.Now(),
+				},
+			}, nil,
+		)
+}
+
+func (t *notifyHandlerTestSuite) serve(method string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(method, "/api/v1/zones/test.com/notify", nil)
+	t.Nil(err)
+	t.r.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func (t *notifyHandlerTestSuite) TestNotifyZoneAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodPost)
+			t.Equal(http.StatusAccepted, recorder.Code)
+
+			var statuses []*domain.NotifyStatus
+			t.Nil(json.Unmarshal(recorder.Body.Bytes(), &statuses))
+			t.Len(statuses, 1)
+			t.Equal("192.0.2.2:53", statuses[0].Target)
+			t.Equal(domain.NotifyPending, statuses[0].State)
+			t.notifyUseCase.AssertCalled(t.T(), "Notify", anyContext, "test.com")
+		},
+	)
+
+	t.Run(
+		"Notify_error", func() {
+			t.SetupTest()
+			t.notifyUseCase.ExpectedCalls = nil
+			t.notifyUseCase.
+				On("Notify", anyContext, mock.AnythingOfType("string")).
+				Return(&domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+
+			recorder := t.serve(http.MethodPost)
+			t.Equal(http.StatusNotFound, recorder.Code)
+			t.Contains(recorder.Body.String(), "record not found")
+		},
+	)
+}
+
+func (t *notifyHandlerTestSuite) TestListNotifyStatusAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodGet)
+			t.Equal(http.StatusOK, recorder.Code)
+
+			var statuses []*domain.NotifyStatus
+			t.Nil(json.Unmarshal(recorder.Body.Bytes(), &statuses))
+			t.Len(statuses, 1)
+			t.Equal(uint32(2024010101), statuses[0].Serial)
+			t.notifyUseCase.AssertNotCalled(t.T(), "Notify", anyContext, mock.AnythingOfType("string"))
+		},
+	)
+
+	t.Run(
+		"ListStatus_error", func() {
+			t.SetupTest()
+			t.notifyUseCase.ExpectedCalls = nil
+			t.notifyUseCase.
+				On("ListStatus", anyContext, mock.AnythingOfType("string")).
+				Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+
+			recorder := t.serve(http.MethodGet)
+			t.Equal(http.StatusNotFound, recorder.Code)
+			t.Contains(recorder.Body.String(), "record not found")
+		},
+	)
+}
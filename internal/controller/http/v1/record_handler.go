@@ -6,7 +6,7 @@ import (
 	"github.com/miekg/dns"
 	"github.com/samber/do"
 	"net/http"
-	"reflect"
+	"strings"
 
 	"github.com/cewuandy/go-restful-dns/internal/domain"
 
@@ -15,68 +15,59 @@ import (
 
 type recordHandler struct {
 	recordUseCase domain.RecordUseCase
+
+	createPtr bool
 }
 
 // CreateRecordAPI ...
 // @title CreateRecordAPI
-// @description Create a new dns record
+// @description Add a new dns record of any type to its RRset, the rdata is given either as the fields
+// @description of the record, e.g. "preference" and "mx" of MX, or as "rdata" in presentation format
 // @tags Record
 // @accept json
+// @param recordType path string true "Record Type, e.g. a, mx, txt"
+// @param createPtr query bool false "Maintain the PTR records of A and AAAA records"
 // @param body body domain.A true "The example of A record request body"
 // @success 201 {object} domain.A
 // @failure 400 {object} domain.Error
 // @router /record/{recordType} [POST]
 func (r *recordHandler) CreateRecordAPI(ctx *gin.Context) {
-	recordType := ctx.Param("recordType")
-	v, ok := domain.RecordTypeMap[recordType]
-	if !ok {
-		err := domain.Error{
-			Message:    "This type doesn't support currently",
-			StatusCode: http.StatusBadRequest,
-		}
+	rr, err := r.bindRecord(ctx)
+	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
 
-	record := reflect.New(v).Interface()
-
-	err := ctx.ShouldBindJSON(&record)
+	opts, err := r.bindOptions(ctx)
 	if err != nil {
-		err = &domain.Error{
-			Message:    fmt.Sprintf("Bind JSON error: %s", err.Error()),
-			Err:        errors.New(err.Error()),
-			StatusCode: http.StatusBadRequest,
-		}
 		_ = ctx.Error(err)
 		return
 	}
 
-	output := reflect.ValueOf(record).MethodByName("String").Call(nil)[0].String()
-	rr, _ := dns.NewRR(output)
-	err = r.recordUseCase.CreateRecord(ctx, rr)
+	err = r.recordUseCase.CreateRecord(ctx, rr, opts)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, rr)
+	ctx.JSON(http.StatusCreated, domain.NewGenericRecord(rr))
 }
 
 // GetRecordAPI ...
 // @title GetRecordAPI
-// @description Get dns record by name, qtype, qclass
+// @description Get all records of the RRset by name, qtype, qclass
 // @tags Record
 // @accept json
 // @param name query string true "Domain Name"
 // @param qtype query string true "Record Type"
 // @param qclass query string true "Record Class"
-// @success 200 {object} domain.A
+// @success 200 {object} []domain.A
 // @failure 400 {object} domain.Error
 // @router /record [GET]
 func (r *recordHandler) GetRecordAPI(ctx *gin.Context) {
 	var (
 		question domain.Question
-		rr       dns.RR
+		rrs      []dns.RR
 		err      error
 	)
 
@@ -90,13 +81,13 @@ func (r *recordHandler) GetRecordAPI(ctx *gin.Context) {
 		return
 	}
 
-	rr, err = r.recordUseCase.GetRecord(ctx, question)
+	rrs, err = r.recordUseCase.GetRecord(ctx, question)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, rr)
+	ctx.JSON(http.StatusOK, domain.NewGenericRecords(rrs))
 }
 
 // ListRecordsAPI ...
@@ -119,32 +110,63 @@ func (r *recordHandler) ListRecordsAPI(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, rrs)
+	ctx.JSON(http.StatusOK, domain.NewGenericRecords(rrs))
 }
 
 // UpdateRecordAPI ...
 // @title UpdateRecordAPI
-// @description Update an existed dns record
+// @description Replace an existed RRset of any type with the single record
 // @tags Record
 // @accept json
+// @param recordType path string true "Record Type, e.g. a, mx, txt"
+// @param createPtr query bool false "Maintain the PTR records of A and AAAA records"
 // @param body body domain.A true "The example of A record request body"
 // @success 200 {object} domain.A
 // @failure 400 {object} domain.Error
 // @router /record/{recordType} [PUT]
 func (r *recordHandler) UpdateRecordAPI(ctx *gin.Context) {
-	recordType := ctx.Param("recordType")
-	v, ok := domain.RecordTypeMap[recordType]
-	if !ok {
-		err := domain.Error{
-			Message:    "This type doesn't support currently",
-			StatusCode: http.StatusBadRequest,
-		}
+	rr, err := r.bindRecord(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	opts, err := r.bindOptions(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	err = r.recordUseCase.UpdateRecord(ctx, rr, opts)
+	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
 
-	record := reflect.New(v).Interface()
-	err := ctx.ShouldBindJSON(&record)
+	ctx.JSON(http.StatusOK, domain.NewGenericRecord(rr))
+}
+
+// ReplaceRRsetAPI ...
+// @title ReplaceRRsetAPI
+// @description Replace the whole RRset with the records, which share the same name, type and class
+// @tags Record
+// @accept json
+// @param recordType path string true "Record Type, e.g. a, mx, txt"
+// @param createPtr query bool false "Maintain the PTR records of A and AAAA records"
+// @param body body []domain.A true "The example of A RRset request body"
+// @success 200 {object} []domain.A
+// @failure 400 {object} domain.Error
+// @router /rrset/{recordType} [PUT]
+func (r *recordHandler) ReplaceRRsetAPI(ctx *gin.Context) {
+	var records []domain.GenericRecord
+
+	rrType, err := r.bindRecordType(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	err = ctx.ShouldBindJSON(&records)
 	if err != nil {
 		err = &domain.Error{
 			Message:    fmt.Sprintf("Bind JSON error: %s", err.Error()),
@@ -154,22 +176,73 @@ func (r *recordHandler) UpdateRecordAPI(ctx *gin.Context) {
 		_ = ctx.Error(err)
 		return
 	}
-	output := reflect.ValueOf(record).MethodByName("String").Call(nil)[0].String()
-	rr, _ := dns.NewRR(output)
-	err = r.recordUseCase.UpdateRecord(ctx, rr)
+
+	rrs := make([]dns.RR, 0, len(records))
+	for i := range records {
+		var rr dns.RR
+		rr, err = r.toRR(ctx, &records[i], rrType)
+		if err != nil {
+			_ = ctx.Error(err)
+			return
+		}
+		rrs = append(rrs, rr)
+	}
+
+	opts, err := r.bindOptions(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	err = r.recordUseCase.ReplaceRRset(ctx, rrs, opts)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, domain.NewGenericRecords(rrs))
+}
+
+// RemoveRecordAPI ...
+// @title RemoveRecordAPI
+// @description Remove a single record from its RRset
+// @tags Record
+// @accept json
+// @param recordType path string true "Record Type, e.g. a, mx, txt"
+// @param createPtr query bool false "Maintain the PTR records of A and AAAA records"
+// @param body body domain.A true "The example of A record request body"
+// @success 204
+// @failure 400 {object} domain.Error
+// @failure 404 {object} domain.Error
+// @router /record/{recordType} [DELETE]
+func (r *recordHandler) RemoveRecordAPI(ctx *gin.Context) {
+	rr, err := r.bindRecord(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	opts, err := r.bindOptions(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	err = r.recordUseCase.RemoveRecord(ctx, rr, opts)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, rr)
+	ctx.JSON(http.StatusNoContent, nil)
 }
 
 // DeleteRecordAPI ...
 // @title DeleteRecordAPI
-// @description Delete dns record by name, qtype, qclass
+// @description Delete the whole RRset by name, qtype, qclass
 // @tags Record
 // @accept json
+// @param createPtr query bool false "Maintain the PTR records of A and AAAA records"
 // @param body body dns.Question true "The example of Question request body"
 // @success 200 {object} domain.A
 // @failure 400 {object} domain.Error
@@ -187,7 +260,14 @@ func (r *recordHandler) DeleteRecordAPI(ctx *gin.Context) {
 		_ = ctx.Error(err)
 		return
 	}
-	err = r.recordUseCase.DeleteRecord(ctx, question)
+
+	opts, err := r.bindOptions(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	err = r.recordUseCase.DeleteRecord(ctx, question, opts)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
@@ -196,6 +276,71 @@ func (r *recordHandler) DeleteRecordAPI(ctx *gin.Context) {
 	ctx.JSON(http.StatusNoContent, nil)
 }
 
+// bindRecord decodes the request body of any record type given by the recordType path
+func (r *recordHandler) bindRecord(ctx *gin.Context) (dns.RR, error) {
+	var record domain.GenericRecord
+
+	rrType, err := r.bindRecordType(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ctx.ShouldBindJSON(&record)
+	if err != nil {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("Bind JSON error: %s", err.Error()),
+			Err:        errors.New(err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	return r.toRR(ctx, &record, rrType)
+}
+
+func (r *recordHandler) bindRecordType(ctx *gin.Context) (uint16, error) {
+	rrType, ok := domain.ParseRRType(ctx.Param("recordType"))
+	if !ok {
+		return 0, domain.Error{
+			Message:    "This type doesn't support currently",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	return rrType, nil
+}
+
+func (r *recordHandler) toRR(ctx *gin.Context, record *domain.GenericRecord, rrType uint16) (dns.RR, error) {
+	recordType := ctx.Param("recordType")
+	if record.Hdr.Rrtype == "" {
+		record.Hdr.Rrtype = domain.RRType(strings.ToUpper(recordType))
+	}
+	if t, _ := domain.ParseRRType(string(record.Hdr.Rrtype)); t != rrType {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("hdr.rrtype %s doesn't match %s", record.Hdr.Rrtype, recordType),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	return record.ToRR()
+}
+
+// bindOptions reads the record options from the query, the server defaults are kept for the
+// missing ones
+func (r *recordHandler) bindOptions(ctx *gin.Context) (domain.RecordOptions, error) {
+	opts := domain.RecordOptions{CreatePtr: r.createPtr}
+	err := ctx.ShouldBindQuery(&opts)
+	if err != nil {
+		return opts, &domain.Error{
+			Message:    fmt.Sprintf("Bind query error: %s", err.Error()),
+			Err:        errors.New(err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	return opts, nil
+}
+
 func NewRecordHandler(injector *do.Injector) (domain.RecordHandler, error) {
-	return &recordHandler{do.MustInvoke[domain.RecordUseCase](injector)}, nil
+	return &recordHandler{
+		do.MustInvoke[domain.RecordUseCase](injector),
+		do.MustInvoke[*domain.Options](injector).CreatePtr,
+	}, nil
 }
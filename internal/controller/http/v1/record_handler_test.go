@@ -20,6 +20,8 @@ import (
 	"github.com/cewuandy/go-restful-dns/pkg/gin/routes"
 )
 
+var anyOptions = mock.AnythingOfType("domain.RecordOptions")
+
 type recordHandlerTestSuite struct {
 	suite.Suite
 
@@ -40,6 +42,7 @@ func (t *recordHandlerTestSuite) SetupSuite() {
 	injector := do.New()
 	t.recordUsecase = &mocks.RecordUseCase{}
 	do.ProvideValue[domain.RecordUseCase](injector, t.recordUsecase)
+	do.ProvideValue(injector, &domain.Options{})
 	do.Provide[domain.RecordHandler](injector, NewRecordHandler)
 	do.Provide[domain.ErrorHandler](injector, middleware.NewErrorHandler)
 
@@ -77,19 +80,25 @@ func (t *recordHandlerTestSuite) SetupTest() {
 	rr, _ := dns.NewRR(a.String())
 
 	t.recordUsecase.
-		On("CreateRecord", anyContext, anyRR).
+		On("CreateRecord", anyContext, anyRR, anyOptions).
 		Return(nil)
 	t.recordUsecase.
 		On("GetRecord", anyContext, anyQuestion).
-		Return(rr, nil)
+		Return([]dns.RR{rr}, nil)
 	t.recordUsecase.
 		On("ListRecords", anyContext).
 		Return([]dns.RR{rr}, nil)
 	t.recordUsecase.
-		On("UpdateRecord", anyContext, anyRR).
+		On("UpdateRecord", anyContext, anyRR, anyOptions).
+		Return(nil)
+	t.recordUsecase.
+		On("ReplaceRRset", anyContext, mock.AnythingOfType("[]dns.RR"), anyOptions).
+		Return(nil)
+	t.recordUsecase.
+		On("RemoveRecord", anyContext, anyRR, anyOptions).
 		Return(nil)
 	t.recordUsecase.
-		On("DeleteRecord", anyContext, anyQuestion).
+		On("DeleteRecord", anyContext, anyQuestion, anyOptions).
 		Return(nil)
 }
 
@@ -120,6 +129,41 @@ func (t *recordHandlerTestSuite) TestCreateRecordAPI() {
 		},
 	)
 
+	t.Run(
+		"success_create_ptr", func() {
+			t.recordUsecase.Calls = nil
+			recorder := httptest.NewRecorder()
+			raw, _ := json.Marshal(t.exampleA)
+			request, err := http.NewRequest(
+				http.MethodPost, "/api/v1/record/a?createPtr=true", bytes.NewBuffer(raw),
+			)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusCreated, recorder.Code)
+			t.recordUsecase.AssertCalled(
+				t.T(), "CreateRecord", anyContext, anyRR, domain.RecordOptions{CreatePtr: true},
+			)
+		},
+	)
+
+	t.Run(
+		"bind_query_error", func() {
+			recorder := httptest.NewRecorder()
+			raw, _ := json.Marshal(t.exampleA)
+			request, err := http.NewRequest(
+				http.MethodPost, "/api/v1/record/a?createPtr=yes", bytes.NewBuffer(raw),
+			)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "Bind query error:")
+		},
+	)
+
 	t.Run(
 		"support_error", func() {
 			recorder := httptest.NewRecorder()
@@ -155,7 +199,7 @@ func (t *recordHandlerTestSuite) TestCreateRecordAPI() {
 		"CreateRecord_error", func() {
 			t.SetupErrorTest()
 			t.recordUsecase.
-				On("CreateRecord", anyContext, anyRR).
+				On("CreateRecord", anyContext, anyRR, anyOptions).
 				Return(&domain.Error{Message: "test-error", StatusCode: http.StatusBadRequest})
 			recorder := httptest.NewRecorder()
 			raw, _ := json.Marshal(t.exampleA)
@@ -172,6 +216,110 @@ func (t *recordHandlerTestSuite) TestCreateRecordAPI() {
 	)
 }
 
+func (t *recordHandlerTestSuite) TestCreateGenericRecordAPI() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		created    dns.RR
+	)
+
+	t.SetupErrorTest()
+	t.recordUsecase.
+		On("CreateRecord", anyContext, mock.MatchedBy(func(rr dns.RR) bool { created = rr; return true }), anyOptions).
+		Return(nil)
+	defer t.SetupTest()
+
+	post := func(recordType string, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(
+			http.MethodPost, "/api/v1/record/"+recordType, bytes.NewBufferString(body),
+		)
+		t.Nil(err)
+		t.r.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run(
+		"success_mx_fields", func() {
+			recorder := post(
+				"mx", `{"hdr":{"name":"test.com","rrtype":"MX","class":"INET","ttl":300},`+
+					`"preference":10,"mx":"mail.test.com"}`,
+			)
+			t.Equal(http.StatusCreated, recorder.Code)
+			t.Equal("test.com.\t300\tIN\tMX\t10 mail.test.com.", created.String())
+
+			var output map[string]interface{}
+			t.Nil(json.Unmarshal(recorder.Body.Bytes(), &output))
+			t.Equal("10 mail.test.com.", output["rdata"])
+			t.Equal("mail.test.com.", output["mx"])
+			t.Equal("MX", output["hdr"].(map[string]interface{})["rrtype"])
+		},
+	)
+
+	t.Run(
+		"success_txt_fields", func() {
+			recorder := post(
+				"txt", `{"hdr":{"name":"test.com.","rrtype":"TXT","class":"INET","ttl":300},`+
+					`"txt":["v=spf1 -all"]}`,
+			)
+			t.Equal(http.StatusCreated, recorder.Code)
+			t.Equal("test.com.\t300\tIN\tTXT\t\"v=spf1 -all\"", created.String())
+		},
+	)
+
+	t.Run(
+		"success_https_rdata", func() {
+			recorder := post(
+				"https", `{"hdr":{"name":"test.com.","ttl":300},"rdata":"1 . alpn=h2 ipv4hint=1.2.3.4"}`,
+			)
+			t.Equal(http.StatusCreated, recorder.Code)
+			t.Equal(dns.TypeHTTPS, created.Header().Rrtype)
+			t.Contains(created.String(), "alpn=\"h2\"")
+		},
+	)
+
+	t.Run(
+		"success_rfc3597_rdata", func() {
+			recorder := post(
+				"type65280", `{"hdr":{"name":"test.com.","rrtype":"TYPE65280","ttl":300},"rdata":"\\# 2 abcd"}`,
+			)
+			t.Equal(http.StatusCreated, recorder.Code)
+			t.Equal(uint16(65280), created.Header().Rrtype)
+		},
+	)
+
+	t.Run(
+		"rrtype_mismatch_error", func() {
+			recorder := post("mx", `{"hdr":{"name":"test.com.","rrtype":"A","ttl":300},"a":"1.1.1.1"}`)
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "doesn't match")
+		},
+	)
+
+	t.Run(
+		"invalid_fields_error", func() {
+			recorder := post("mx", `{"hdr":{"name":"test.com.","ttl":300},"preference":"high"}`)
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "use rdata instead")
+		},
+	)
+
+	t.Run(
+		"invalid_rdata_error", func() {
+			recorder := post("srv", `{"hdr":{"name":"_sip._tcp.test.com.","ttl":300},"rdata":"10 60"}`)
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "invalid rdata")
+		},
+	)
+
+	t.Run(
+		"meta_type_error", func() {
+			recorder := post("axfr", `{"hdr":{"name":"test.com.","ttl":300},"rdata":"x"}`)
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "This type doesn't support currently")
+		},
+	)
+}
+
 func (t *recordHandlerTestSuite) TestGetRecordAPI() {
 	var (
 		anyContext  = mock.MatchedBy(func(ctx context.Context) bool { return true })
@@ -189,6 +337,10 @@ func (t *recordHandlerTestSuite) TestGetRecordAPI() {
 
 			t.Equal(http.StatusOK, recorder.Code)
 			t.Contains(recorder.Body.String(), "test.com.")
+
+			var output []map[string]interface{}
+			t.Nil(json.Unmarshal(recorder.Body.Bytes(), &output))
+			t.Len(output, 1)
 		},
 	)
 
@@ -319,7 +471,7 @@ func (t *recordHandlerTestSuite) TestUpdateRecordAPI() {
 		"UpdateRecord_error", func() {
 			t.SetupErrorTest()
 			t.recordUsecase.
-				On("UpdateRecord", anyContext, anyRR).
+				On("UpdateRecord", anyContext, anyRR, anyOptions).
 				Return(&domain.Error{Message: "test-error", StatusCode: http.StatusBadRequest})
 			recorder := httptest.NewRecorder()
 			raw, _ := json.Marshal(t.exampleA)
@@ -336,6 +488,123 @@ func (t *recordHandlerTestSuite) TestUpdateRecordAPI() {
 	)
 }
 
+func (t *recordHandlerTestSuite) TestReplaceRRsetAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	put := func(recordType string, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(
+			http.MethodPut, "/api/v1/rrset/"+recordType, bytes.NewBufferString(body),
+		)
+		t.Nil(err)
+		t.r.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run(
+		"success", func() {
+			t.recordUsecase.Calls = nil
+			recorder := put(
+				"a", `[{"hdr":{"name":"test.com.","ttl":300},"a":"1.1.1.1"},`+
+					`{"hdr":{"name":"test.com.","ttl":300},"rdata":"2.2.2.2"}]`,
+			)
+			t.Equal(http.StatusOK, recorder.Code)
+			t.recordUsecase.AssertCalled(
+				t.T(), "ReplaceRRset", anyContext,
+				mock.MatchedBy(func(rrs []dns.RR) bool { return len(rrs) == 2 }), anyOptions,
+			)
+
+			var output []map[string]interface{}
+			t.Nil(json.Unmarshal(recorder.Body.Bytes(), &output))
+			t.Len(output, 2)
+		},
+	)
+
+	t.Run(
+		"bind_json_error", func() {
+			recorder := put("a", `{"hdr":{"name":"test.com.","ttl":300},"a":"1.1.1.1"}`)
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "Bind JSON error:")
+		},
+	)
+
+	t.Run(
+		"record_type_error", func() {
+			recorder := put("any", `[]`)
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "This type doesn't support currently")
+		},
+	)
+
+	t.Run(
+		"rrtype_mismatch_error", func() {
+			recorder := put("a", `[{"hdr":{"name":"test.com.","rrtype":"MX","ttl":300},"rdata":"10 mx.test.com."}]`)
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "doesn't match")
+		},
+	)
+
+	t.Run(
+		"ReplaceRRset_error", func() {
+			t.SetupErrorTest()
+			t.recordUsecase.
+				On("ReplaceRRset", anyContext, mock.AnythingOfType("[]dns.RR"), anyOptions).
+				Return(&domain.Error{Message: "test-error", StatusCode: http.StatusBadRequest})
+			defer t.SetupTest()
+
+			recorder := put("a", `[{"hdr":{"name":"test.com.","ttl":300},"a":"1.1.1.1"}]`)
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "test-error")
+		},
+	)
+}
+
+func (t *recordHandlerTestSuite) TestRemoveRecordAPI() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyRR      = mock.MatchedBy(func(rr dns.RR) bool { return true })
+	)
+
+	remove := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(
+			http.MethodDelete, "/api/v1/record/a", bytes.NewBufferString(body),
+		)
+		t.Nil(err)
+		t.r.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run(
+		"success", func() {
+			recorder := remove(`{"hdr":{"name":"test.com.","ttl":300},"a":"1.1.1.1"}`)
+			t.Equal(http.StatusNoContent, recorder.Code)
+		},
+	)
+
+	t.Run(
+		"bind_json_error", func() {
+			recorder := remove(`[]`)
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "Bind JSON error:")
+		},
+	)
+
+	t.Run(
+		"RemoveRecord_error", func() {
+			t.SetupErrorTest()
+			t.recordUsecase.
+				On("RemoveRecord", anyContext, anyRR, anyOptions).
+				Return(&domain.Error{Message: "test-error", StatusCode: http.StatusNotFound})
+			defer t.SetupTest()
+
+			recorder := remove(`{"hdr":{"name":"test.com.","ttl":300},"a":"1.1.1.1"}`)
+			t.Equal(http.StatusNotFound, recorder.Code)
+			t.Contains(recorder.Body.String(), "test-error")
+		},
+	)
+}
+
 func (t *recordHandlerTestSuite) TestDeleteRecordAPI() {
 	var (
 		anyContext  = mock.MatchedBy(func(ctx context.Context) bool { return true })
@@ -384,7 +653,7 @@ func (t *recordHandlerTestSuite) TestDeleteRecordAPI() {
 		"DeleteRecord_error", func() {
 			t.SetupErrorTest()
 			t.recordUsecase.
-				On("DeleteRecord", anyContext, anyQuestion).
+				On("DeleteRecord", anyContext, anyQuestion, anyOptions).
 				Return(&domain.Error{Message: "test-error", StatusCode: http.StatusBadRequest})
 			recorder := httptest.NewRecorder()
 			raw, _ := json.Marshal(q)
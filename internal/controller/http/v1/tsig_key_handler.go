@@ -0,0 +1,145 @@
+package v1
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+
+	"github.com/pkg/errors"
+)
+
+type tsigKeyHandler struct {
+	tsigKeyUseCase domain.TsigKeyUseCase
+}
+
+// CreateTsigKeyAPI ...
+// @title CreateTsigKeyAPI
+// @description Create a TSIG key, which signs the dynamic updates (RFC 2136) of the zones it's given, or of all
+// @description zones when none is given. The algorithm is hmac-sha256 and the secret is generated unless given.
+// @tags TSIG Key
+// @accept json
+// @param body body domain.TsigKey true "The example of TSIG key request body"
+// @success 201 {object} domain.TsigKey
+// @failure 400 {object} domain.Error
+// @router /tsig-keys [POST]
+func (t *tsigKeyHandler) CreateTsigKeyAPI(ctx *gin.Context) {
+	var key domain.TsigKey
+
+	err := ctx.ShouldBindJSON(&key)
+	if err != nil {
+		err = &domain.Error{
+			Message:    fmt.Sprintf("Bind JSON error: %s", err.Error()),
+			Err:        errors.New(err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+		_ = ctx.Error(err)
+		return
+	}
+
+	err = t.tsigKeyUseCase.CreateKey(ctx, &key)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, key)
+}
+
+// GetTsigKeyAPI ...
+// @title GetTsigKeyAPI
+// @description Get TSIG key by name
+// @tags TSIG Key
+// @accept json
+// @param name path string true "Key Name"
+// @success 200 {object} domain.TsigKey
+// @failure 404 {object} domain.Error
+// @router /tsig-keys/{name} [GET]
+func (t *tsigKeyHandler) GetTsigKeyAPI(ctx *gin.Context) {
+	key, err := t.tsigKeyUseCase.GetKey(ctx, ctx.Param("name"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
+
+// ListTsigKeysAPI ...
+// @title ListTsigKeysAPI
+// @description List all TSIG keys
+// @tags TSIG Key
+// @accept json
+// @success 200 {object} []domain.TsigKey
+// @failure 400 {object} domain.Error
+// @router /tsig-keys [GET]
+func (t *tsigKeyHandler) ListTsigKeysAPI(ctx *gin.Context) {
+	keys, err := t.tsigKeyUseCase.ListKeys(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, keys)
+}
+
+// UpdateTsigKeyAPI ...
+// @title UpdateTsigKeyAPI
+// @description Update the algorithm, secret and zones of an existed TSIG key, the secret is generated again
+// @description unless given, which rotates the key
+// @tags TSIG Key
+// @accept json
+// @param name path string true "Key Name"
+// @param body body domain.TsigKey true "The example of TSIG key request body"
+// @success 200 {object} domain.TsigKey
+// @failure 400 {object} domain.Error
+// @failure 404 {object} domain.Error
+// @router /tsig-keys/{name} [PUT]
+func (t *tsigKeyHandler) UpdateTsigKeyAPI(ctx *gin.Context) {
+	var key domain.TsigKey
+
+	err := ctx.ShouldBindJSON(&key)
+	if err != nil {
+		err = &domain.Error{
+			Message:    fmt.Sprintf("Bind JSON error: %s", err.Error()),
+			Err:        errors.New(err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+		_ = ctx.Error(err)
+		return
+	}
+	key.Name = ctx.Param("name")
+
+	err = t.tsigKeyUseCase.UpdateKey(ctx, &key)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
+
+// DeleteTsigKeyAPI ...
+// @title DeleteTsigKeyAPI
+// @description Delete TSIG key by name, the messages signed by it are no longer accepted
+// @tags TSIG Key
+// @accept json
+// @param name path string true "Key Name"
+// @success 204
+// @failure 404 {object} domain.Error
+// @router /tsig-keys/{name} [DELETE]
+func (t *tsigKeyHandler) DeleteTsigKeyAPI(ctx *gin.Context) {
+	err := t.tsigKeyUseCase.DeleteKey(ctx, ctx.Param("name"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func NewTsigKeyHandler(injector *do.Injector) (domain.TsigKeyHandler, error) {
+	return &tsigKeyHandler{do.MustInvoke[domain.TsigKeyUseCase](injector)}, nil
+}
@@ -0,0 +1,225 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cewuandy/go-restful-dns/internal/controller/http/middleware"
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+	"github.com/cewuandy/go-restful-dns/pkg/gin/routes"
+)
+
+type tsigKeyHandlerTestSuite struct {
+	suite.Suite
+
+	tsigKeyUseCase *mocks.TsigKeyUseCase
+
+	r *gin.Engine
+}
+
+func TestTsigKeyHandler(t *testing.T) {
+	suite.Run(t, &tsigKeyHandlerTestSuite{})
+}
+
+func (t *tsigKeyHandlerTestSuite) SetupSuite() {
+	injector := do.New()
+	t.tsigKeyUseCase = &mocks.TsigKeyUseCase{}
+	do.ProvideValue[domain.TsigKeyUseCase](injector, t.tsigKeyUseCase)
+	do.Provide[domain.TsigKeyHandler](injector, NewTsigKeyHandler)
+	do.Provide[domain.ErrorHandler](injector, middleware.NewErrorHandler)
+
+	t.r = gin.New()
+	t.r.Use(do.MustInvoke[domain.ErrorHandler](injector).HandleError)
+
+	routes.RegisterTsigKeyRoutes(t.r, do.MustInvoke[domain.TsigKeyHandler](injector))
+}
+
+func (t *tsigKeyHandlerTestSuite) SetupTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		key        = &domain.TsigKey{Name: "update.", Algorithm: "hmac-sha256.", Secret: "c2VjcmV0"}
+	)
+
+	t.tsigKeyUseCase.ExpectedCalls = nil
+	t.tsigKeyUseCase.Calls = nil
+	t.tsigKeyUseCase.
+		On("CreateKey", anyContext, mock.AnythingOfType("*domain.TsigKey")).
+		Return(nil)
+	t.tsigKeyUseCase.
+		On("GetKey", anyContext, anyString).
+		Return(key, nil)
+	t.tsigKeyUseCase.
+		On("ListKeys", anyContext).
+		Return([]*domain.TsigKey{key}, nil)
+	t.tsigKeyUseCase.
+		On("UpdateKey", anyContext, mock.AnythingOfType("*domain.TsigKey")).
+		Return(nil)
+	t.tsigKeyUseCase.
+		On("DeleteKey", anyContext, anyString).
+		Return(nil)
+}
+
+func (t *tsigKeyHandlerTestSuite) serve(method, path string, body io.Reader) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(method, "/api/v1/tsig-keys"+path, body)
+	t.Nil(err)
+	t.r.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func (t *tsigKeyHandlerTestSuite) TestCreateTsigKeyAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			body, _ := json.Marshal(domain.TsigKey{Name: "update.", Zones: []string{"test.com."}})
+			recorder := t.serve(http.MethodPost, "", bytes.NewReader(body))
+			t.Equal(http.StatusCreated, recorder.Code)
+			t.tsigKeyUseCase.AssertCalled(
+				t.T(), "CreateKey", anyContext, &domain.TsigKey{Name: "update.", Zones: []string{"test.com."}},
+			)
+		},
+	)
+
+	t.Run(
+		"bind_error", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodPost, "", bytes.NewReader([]byte(`{"algorithm":"hmac-sha256."}`)))
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.tsigKeyUseCase.AssertNotCalled(t.T(), "CreateKey", anyContext, mock.Anything)
+		},
+	)
+
+	t.Run(
+		"CreateKey_error", func() {
+			t.SetupTest()
+			t.tsigKeyUseCase.ExpectedCalls = nil
+			t.tsigKeyUseCase.
+				On("CreateKey", anyContext, mock.AnythingOfType("*domain.TsigKey")).
+				Return(&domain.Error{Message: "the TSIG key is already existed.", StatusCode: http.StatusBadRequest})
+
+			body, _ := json.Marshal(domain.TsigKey{Name: "update."})
+			recorder := t.serve(http.MethodPost, "", bytes.NewReader(body))
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "already existed")
+		},
+	)
+}
+
+func (t *tsigKeyHandlerTestSuite) TestGetTsigKeyAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodGet, "/update.", nil)
+			t.Equal(http.StatusOK, recorder.Code)
+
+			var key domain.TsigKey
+			t.Nil(json.Unmarshal(recorder.Body.Bytes(), &key))
+			t.Equal("update.", key.Name)
+			t.tsigKeyUseCase.AssertCalled(t.T(), "GetKey", anyContext, "update.")
+		},
+	)
+
+	t.Run(
+		"GetKey_error", func() {
+			t.SetupTest()
+			t.tsigKeyUseCase.ExpectedCalls = nil
+			t.tsigKeyUseCase.
+				On("GetKey", anyContext, mock.AnythingOfType("string")).
+				Return(nil, &domain.Error{Message: "TSIG key not found", StatusCode: http.StatusNotFound})
+
+			recorder := t.serve(http.MethodGet, "/other.", nil)
+			t.Equal(http.StatusNotFound, recorder.Code)
+		},
+	)
+}
+
+func (t *tsigKeyHandlerTestSuite) TestListTsigKeysAPI() {
+	t.SetupTest()
+	recorder := t.serve(http.MethodGet, "", nil)
+	t.Equal(http.StatusOK, recorder.Code)
+
+	var keys []*domain.TsigKey
+	t.Nil(json.Unmarshal(recorder.Body.Bytes(), &keys))
+	t.Len(keys, 1)
+}
+
+func (t *tsigKeyHandlerTestSuite) TestUpdateTsigKeyAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			body, _ := json.Marshal(domain.TsigKey{Name: "other.", Secret: "b3RoZXI="})
+			recorder := t.serve(http.MethodPut, "/update.", bytes.NewReader(body))
+			t.Equal(http.StatusOK, recorder.Code)
+			// the name comes from the path
+			t.tsigKeyUseCase.AssertCalled(
+				t.T(), "UpdateKey", anyContext, &domain.TsigKey{Name: "update.", Secret: "b3RoZXI="},
+			)
+		},
+	)
+
+	t.Run(
+		"bind_error", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodPut, "/update.", bytes.NewReader([]byte(`{"zones":"test.com."}`)))
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.tsigKeyUseCase.AssertNotCalled(t.T(), "UpdateKey", anyContext, mock.Anything)
+		},
+	)
+
+	t.Run(
+		"UpdateKey_error", func() {
+			t.SetupTest()
+			t.tsigKeyUseCase.ExpectedCalls = nil
+			t.tsigKeyUseCase.
+				On("UpdateKey", anyContext, mock.AnythingOfType("*domain.TsigKey")).
+				Return(&domain.Error{Message: "TSIG key not found", StatusCode: http.StatusNotFound})
+
+			body, _ := json.Marshal(domain.TsigKey{Name: "other."})
+			recorder := t.serve(http.MethodPut, "/other.", bytes.NewReader(body))
+			t.Equal(http.StatusNotFound, recorder.Code)
+		},
+	)
+}
+
+func (t *tsigKeyHandlerTestSuite) TestDeleteTsigKeyAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			recorder := t.serve(http.MethodDelete, "/update.", nil)
+			t.Equal(http.StatusNoContent, recorder.Code)
+			t.tsigKeyUseCase.AssertCalled(t.T(), "DeleteKey", anyContext, "update.")
+		},
+	)
+
+	t.Run(
+		"DeleteKey_error", func() {
+			t.SetupTest()
+			t.tsigKeyUseCase.ExpectedCalls = nil
+			t.tsigKeyUseCase.
+				On("DeleteKey", anyContext, mock.AnythingOfType("string")).
+				Return(&domain.Error{Message: "TSIG key not found", StatusCode: http.StatusNotFound})
+
+			recorder := t.serve(http.MethodDelete, "/other.", nil)
+			t.Equal(http.StatusNotFound, recorder.Code)
+		},
+	)
+}
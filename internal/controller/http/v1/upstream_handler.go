@@ -0,0 +1,38 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+)
+
+type upstreamHandler struct {
+	upstreamUseCase domain.UpstreamUseCase
+}
+
+// ListUpstreamsAPI ...
+// @title ListUpstreamsAPI
+// @description List the health of the upstream forwarders, with their smoothed RTTs and failures. An upstream
+// @description failing in a row is ejected for a while, and is tried last until a query or a health probe succeeds.
+// @description The default upstream forwarders come first without a suffix, followed by the ones of the forwarding
+// @description rules by their suffixes.
+// @tags Upstream
+// @accept json
+// @success 200 {object} []domain.UpstreamGroup
+// @failure 500 {object} domain.Error
+// @router /upstreams [GET]
+func (u *upstreamHandler) ListUpstreamsAPI(ctx *gin.Context) {
+	upstreams, err := u.upstreamUseCase.ListUpstreams(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, upstreams)
+}
+
+func NewUpstreamHandler(injector *do.Injector) (domain.UpstreamHandler, error) {
+	return &upstreamHandler{do.MustInvoke[domain.UpstreamUseCase](injector)}, nil
+}
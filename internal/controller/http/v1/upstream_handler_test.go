@@ -0,0 +1,118 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cewuandy/go-restful-dns/internal/controller/http/middleware"
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+	"github.com/cewuandy/go-restful-dns/pkg/gin/routes"
+)
+
+type upstreamHandlerTestSuite struct {
+	suite.Suite
+
+	upstreamUseCase *mocks.UpstreamUseCase
+
+	r *gin.Engine
+}
+
+func TestUpstreamHandler(t *testing.T) {
+	suite.Run(t, &upstreamHandlerTestSuite{})
+}
+
+func (t *upstreamHandlerTestSuite) SetupSuite() {
+	injector := do.New()
+	t.upstreamUseCase = &mocks.UpstreamUseCase{}
+	do.ProvideValue[domain.UpstreamUseCase](injector, t.upstreamUseCase)
+	do.Provide[domain.UpstreamHandler](injector, NewUpstreamHandler)
+	do.Provide[domain.ErrorHandler](injector, middleware.NewErrorHandler)
+
+	t.r = gin.New()
+	t.r.Use(do.MustInvoke[domain.ErrorHandler](injector).HandleError)
+
+	routes.RegisterUpstreamRoutes(t.r, do.MustInvoke[domain.UpstreamHandler](injector))
+}
+
+func (t *upstreamHandlerTestSuite) SetupTest() {
+	var (
+		anyContext   = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		ejectedUntil = time.Now().Add(time.Minute)
+	)
+
+	t.upstreamUseCase.ExpectedCalls = nil
+	t.upstreamUseCase.Calls = nil
+	t.upstreamUseCase.
+		On("ListUpstreams", anyContext).
+		Return(
+			[]*domain.UpstreamGroup{
+				{
+					Upstreams: []*domain.Upstream{
+						{Upstream: "1.1.1.1:53", Healthy: true, RttMs: 12.5, Exchanges: 10},
+						{
+							Upstream: "tls://8.8.8.8:853#dns.google", Exchanges: 3, Failures: 3, ConsecutiveFailures: 3,
+							EjectedUntil: &ejectedUntil, LastError: "i/o timeout",
+						},
+					},
+				},
+				{
+					Suffix:    "corp.example.",
+					Upstreams: []*domain.Upstream{{Upstream: "10.0.0.53:53", Healthy: true, Exchanges: 1}},
+				},
+			}, nil,
+		)
+}
+
+func (t *upstreamHandlerTestSuite) TestListUpstreamsAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/upstreams", nil)
+			t.Nil(err)
+			t.r.ServeHTTP(recorder, request)
+			t.Equal(http.StatusOK, recorder.Code)
+
+			var groups []*domain.UpstreamGroup
+			t.Nil(json.Unmarshal(recorder.Body.Bytes(), &groups))
+			t.Len(groups, 2)
+			t.Empty(groups[0].Suffix)
+			t.Equal("corp.example.", groups[1].Suffix)
+			t.Equal("10.0.0.53:53", groups[1].Upstreams[0].Upstream)
+			upstreams := groups[0].Upstreams
+			t.Len(upstreams, 2)
+			t.True(upstreams[0].Healthy)
+			t.Nil(upstreams[0].EjectedUntil)
+			t.False(upstreams[1].Healthy)
+			t.NotNil(upstreams[1].EjectedUntil)
+			t.Equal("i/o timeout", upstreams[1].LastError)
+		},
+	)
+
+	t.Run(
+		"ListUpstreams_error", func() {
+			t.SetupTest()
+			t.upstreamUseCase.ExpectedCalls = nil
+			t.upstreamUseCase.
+				On("ListUpstreams", anyContext).
+				Return(nil, &domain.Error{Message: "internal error", StatusCode: http.StatusInternalServerError})
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/upstreams", nil)
+			t.Nil(err)
+			t.r.ServeHTTP(recorder, request)
+			t.Equal(http.StatusInternalServerError, recorder.Code)
+		},
+	)
+}
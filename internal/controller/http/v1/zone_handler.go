@@ -0,0 +1,181 @@
+package v1
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+
+	"github.com/pkg/errors"
+)
+
+type zoneHandler struct {
+	zoneUseCase domain.ZoneUseCase
+}
+
+// CreateZoneAPI ...
+// @title CreateZoneAPI
+// @description Create a new authoritative zone, the serial is assigned by the server. A secondary zone only needs
+// @description the address of its primary, its records are transferred from the primary and are read-only.
+// @tags Zone
+// @accept json
+// @param body body domain.Zone true "The example of zone request body"
+// @success 201 {object} domain.Zone
+// @failure 400 {object} domain.Error
+// @router /zones [POST]
+func (z *zoneHandler) CreateZoneAPI(ctx *gin.Context) {
+	var zone domain.Zone
+
+	err := ctx.ShouldBindJSON(&zone)
+	if err != nil {
+		err = &domain.Error{
+			Message:    fmt.Sprintf("Bind JSON error: %s", err.Error()),
+			Err:        errors.New(err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+		_ = ctx.Error(err)
+		return
+	}
+
+	err = z.zoneUseCase.CreateZone(ctx, &zone)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, zone)
+}
+
+// GetZoneAPI ...
+// @title GetZoneAPI
+// @description Get zone by name
+// @tags Zone
+// @accept json
+// @param zone path string true "Zone Name"
+// @success 200 {object} domain.Zone
+// @failure 404 {object} domain.Error
+// @router /zones/{zone} [GET]
+func (z *zoneHandler) GetZoneAPI(ctx *gin.Context) {
+	zone, err := z.zoneUseCase.GetZone(ctx, ctx.Param("zone"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, zone)
+}
+
+// ListZonesAPI ...
+// @title ListZonesAPI
+// @description List all zones
+// @tags Zone
+// @accept json
+// @success 200 {object} []domain.Zone
+// @failure 400 {object} domain.Error
+// @router /zones [GET]
+func (z *zoneHandler) ListZonesAPI(ctx *gin.Context) {
+	zones, err := z.zoneUseCase.ListZones(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, zones)
+}
+
+// UpdateZoneAPI ...
+// @title UpdateZoneAPI
+// @description Update the SOA fields and NS set of an existed zone, the serial is increased
+// @tags Zone
+// @accept json
+// @param zone path string true "Zone Name"
+// @param body body domain.Zone true "The example of zone request body"
+// @success 200 {object} domain.Zone
+// @failure 400 {object} domain.Error
+// @failure 404 {object} domain.Error
+// @router /zones/{zone} [PUT]
+func (z *zoneHandler) UpdateZoneAPI(ctx *gin.Context) {
+	var zone domain.Zone
+
+	err := ctx.ShouldBindJSON(&zone)
+	if err != nil {
+		err = &domain.Error{
+			Message:    fmt.Sprintf("Bind JSON error: %s", err.Error()),
+			Err:        errors.New(err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+		_ = ctx.Error(err)
+		return
+	}
+	zone.Name = ctx.Param("zone")
+
+	err = z.zoneUseCase.UpdateZone(ctx, &zone)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, zone)
+}
+
+// DeleteZoneAPI ...
+// @title DeleteZoneAPI
+// @description Delete zone by name, the records inside the zone are kept
+// @tags Zone
+// @accept json
+// @param zone path string true "Zone Name"
+// @success 204
+// @failure 404 {object} domain.Error
+// @router /zones/{zone} [DELETE]
+func (z *zoneHandler) DeleteZoneAPI(ctx *gin.Context) {
+	err := z.zoneUseCase.DeleteZone(ctx, ctx.Param("zone"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// ImportZoneAPI ...
+// @title ImportZoneAPI
+// @description Import a master file (RFC 1035 5) into the zone, the zone is created by the SOA record
+// @description of the file when it isn't existed. The merge mode replaces the RRsets given by the file,
+// @description the replace mode replaces all records of the zone. Nothing is written when any RR is
+// @description invalid, the errors are reported per line in details.
+// @tags Zone
+// @accept plain
+// @param zone path string true "Zone Name"
+// @param mode query string false "Import Mode, merge or replace" default(merge)
+// @param body body string true "The zone file"
+// @success 200 {object} domain.ZoneImport
+// @failure 400 {object} domain.Error
+// @router /zones/{zone}/import [POST]
+func (z *zoneHandler) ImportZoneAPI(ctx *gin.Context) {
+	var opts domain.ZoneImportOptions
+
+	err := ctx.ShouldBindQuery(&opts)
+	if err != nil {
+		err = &domain.Error{
+			Message:    fmt.Sprintf("Bind query error: %s", err.Error()),
+			Err:        errors.New(err.Error()),
+			StatusCode: http.StatusBadRequest,
+		}
+		_ = ctx.Error(err)
+		return
+	}
+
+	result, err := z.zoneUseCase.ImportZone(ctx, ctx.Param("zone"), ctx.Request.Body, opts.Mode)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func NewZoneHandler(injector *do.Injector) (domain.ZoneHandler, error) {
+	return &zoneHandler{do.MustInvoke[domain.ZoneUseCase](injector)}, nil
+}
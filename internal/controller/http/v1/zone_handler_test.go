@@ -0,0 +1,306 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cewuandy/go-restful-dns/internal/controller/http/middleware"
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+	"github.com/cewuandy/go-restful-dns/pkg/gin/routes"
+)
+
+type zoneHandlerTestSuite struct {
+	suite.Suite
+
+	handler domain.ZoneHandler
+
+	zoneUseCase *mocks.ZoneUseCase
+
+	r *gin.Engine
+
+	exampleZone domain.Zone
+}
+
+func TestZoneHandler(t *testing.T) {
+	suite.Run(t, &zoneHandlerTestSuite{})
+}
+
+func (t *zoneHandlerTestSuite) SetupSuite() {
+	injector := do.New()
+	t.zoneUseCase = &mocks.ZoneUseCase{}
+	do.ProvideValue[domain.ZoneUseCase](injector, t.zoneUseCase)
+	do.Provide[domain.ZoneHandler](injector, NewZoneHandler)
+	do.Provide[domain.ErrorHandler](injector, middleware.NewErrorHandler)
+
+	t.r = gin.New()
+	t.r.Use(do.MustInvoke[domain.ErrorHandler](injector).HandleError)
+
+	routes.RegisterZoneRoutes(t.r, do.MustInvoke[domain.ZoneHandler](injector))
+
+	t.exampleZone = domain.Zone{
+		Name:        "test.com.",
+		Nameservers: []string{"ns1.test.com."},
+		Mbox:        "admin.test.com.",
+	}
+}
+
+func (t *zoneHandlerTestSuite) SetupTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyZone    = mock.AnythingOfType("*domain.Zone")
+		anyString  = mock.AnythingOfType("string")
+	)
+
+	t.zoneUseCase.ExpectedCalls = nil
+	t.zoneUseCase.
+		On("CreateZone", anyContext, anyZone).
+		Return(nil)
+	t.zoneUseCase.
+		On("GetZone", anyContext, anyString).
+		Return(&t.exampleZone, nil)
+	t.zoneUseCase.
+		On("ListZones", anyContext).
+		Return([]*domain.Zone{&t.exampleZone}, nil)
+	t.zoneUseCase.
+		On("UpdateZone", anyContext, anyZone).
+		Return(nil)
+	t.zoneUseCase.
+		On("DeleteZone", anyContext, anyString).
+		Return(nil)
+	t.zoneUseCase.
+		On("ImportZone", anyContext, anyString, mock.Anything, mock.AnythingOfType("domain.ImportMode")).
+		Return(&domain.ZoneImport{Zone: "test.com.", Mode: domain.ImportReplace, RRsets: 1, Records: 1}, nil)
+}
+
+func (t *zoneHandlerTestSuite) TestCreateZoneAPI() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyZone    = mock.AnythingOfType("*domain.Zone")
+	)
+
+	t.Run(
+		"success", func() {
+			recorder := httptest.NewRecorder()
+			raw, _ := json.Marshal(t.exampleZone)
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/zones", bytes.NewBuffer(raw))
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusCreated, recorder.Code)
+			t.Contains(recorder.Body.String(), "test.com.")
+		},
+	)
+
+	t.Run(
+		"success_secondary", func() {
+			recorder := httptest.NewRecorder()
+			raw := []byte(`{"name":"legacy.com.","type":"secondary","primary":"192.0.2.1"}`)
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/zones", bytes.NewBuffer(raw))
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusCreated, recorder.Code)
+			t.Contains(recorder.Body.String(), "192.0.2.1")
+		},
+	)
+
+	t.Run(
+		"secondary_without_primary_error", func() {
+			recorder := httptest.NewRecorder()
+			raw := []byte(`{"name":"legacy.com.","type":"secondary"}`)
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/zones", bytes.NewBuffer(raw))
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "Primary")
+		},
+	)
+
+	t.Run(
+		"bind_json_error", func() {
+			recorder := httptest.NewRecorder()
+			raw, _ := json.Marshal(domain.Zone{Name: "test.com."})
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/zones", bytes.NewBuffer(raw))
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "Bind JSON error:")
+		},
+	)
+
+	t.Run(
+		"CreateZone_error", func() {
+			t.zoneUseCase.ExpectedCalls = nil
+			t.zoneUseCase.
+				On("CreateZone", anyContext, anyZone).
+				Return(&domain.Error{Message: "test-error", StatusCode: http.StatusBadRequest})
+			recorder := httptest.NewRecorder()
+			raw, _ := json.Marshal(t.exampleZone)
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/zones", bytes.NewBuffer(raw))
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), "test-error")
+		},
+	)
+}
+
+func (t *zoneHandlerTestSuite) TestGetZoneAPI() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+	)
+
+	t.Run(
+		"success", func() {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/zones/test.com.", nil)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusOK, recorder.Code)
+			t.Contains(recorder.Body.String(), "ns1.test.com.")
+		},
+	)
+
+	t.Run(
+		"GetZone_error", func() {
+			t.zoneUseCase.ExpectedCalls = nil
+			t.zoneUseCase.
+				On("GetZone", anyContext, anyString).
+				Return(nil, &domain.Error{Message: "test-error", StatusCode: http.StatusNotFound})
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/zones/test.com.", nil)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusNotFound, recorder.Code)
+		},
+	)
+}
+
+func (t *zoneHandlerTestSuite) TestListZonesAPI() {
+	t.Run(
+		"success", func() {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/zones", nil)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusOK, recorder.Code)
+			t.Contains(recorder.Body.String(), "test.com.")
+		},
+	)
+}
+
+func (t *zoneHandlerTestSuite) TestUpdateZoneAPI() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			recorder := httptest.NewRecorder()
+			raw, _ := json.Marshal(t.exampleZone)
+			request, err := http.NewRequest(
+				http.MethodPut, "/api/v1/zones/test.com.", bytes.NewBuffer(raw),
+			)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusOK, recorder.Code)
+			t.zoneUseCase.AssertCalled(
+				t.T(), "UpdateZone", anyContext,
+				mock.MatchedBy(func(zone *domain.Zone) bool { return zone.Name == "test.com." }),
+			)
+		},
+	)
+}
+
+func (t *zoneHandlerTestSuite) TestDeleteZoneAPI() {
+	t.Run(
+		"success", func() {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodDelete, "/api/v1/zones/test.com.", nil)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusNoContent, recorder.Code)
+		},
+	)
+}
+
+func (t *zoneHandlerTestSuite) TestImportZoneAPI() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+	)
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			t.zoneUseCase.Calls = nil
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(
+				http.MethodPost, "/api/v1/zones/test.com/import?mode=replace",
+				strings.NewReader("www IN A 1.1.1.1"),
+			)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusOK, recorder.Code)
+			t.Contains(recorder.Body.String(), `"records":1`)
+			t.zoneUseCase.AssertCalled(
+				t.T(), "ImportZone", anyContext, "test.com", mock.Anything, domain.ImportReplace,
+			)
+		},
+	)
+
+	t.Run(
+		"ImportZone_error", func() {
+			t.SetupTest()
+			t.zoneUseCase.ExpectedCalls = nil
+			t.zoneUseCase.
+				On("ImportZone", anyContext, anyString, mock.Anything, mock.AnythingOfType("domain.ImportMode")).
+				Return(
+					nil, &domain.Error{
+						Message:    "the zone file of test.com. has 1 errors",
+						StatusCode: http.StatusBadRequest,
+						Details:    []string{"line 1: bad A A: \"1.1.1\""},
+					},
+				)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(
+				http.MethodPost, "/api/v1/zones/test.com/import", strings.NewReader("www IN A 1.1.1"),
+			)
+			t.Nil(err)
+
+			t.r.ServeHTTP(recorder, request)
+
+			t.Equal(http.StatusBadRequest, recorder.Code)
+			t.Contains(recorder.Body.String(), `"details":["line 1: bad A A: \"1.1.1\""]`)
+		},
+	)
+}
@@ -16,6 +16,10 @@ func (i *initHandler) Initialize(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	err = i.initUseCase.RecoverZones(ctx)
+	if err != nil {
+		return err
+	}
 	return i.initUseCase.RecoverRecords(ctx)
 }
 
@@ -37,6 +37,9 @@ func (t *initHandlerTestSuite) SetupTest() {
 	t.initUseCase.
 		On("ClearRedisData", anyContext).
 		Return(nil)
+	t.initUseCase.
+		On("RecoverZones", anyContext).
+		Return(nil)
 	t.initUseCase.
 		On("RecoverRecords", anyContext).
 		Return(nil)
@@ -64,4 +67,19 @@ func (t *initHandlerTestSuite) TestInitialize() {
 			t.Equal("test-error", err.Error())
 		},
 	)
+
+	t.Run(
+		"RecoverZones_error", func() {
+			t.initUseCase.ExpectedCalls = nil
+			t.initUseCase.
+				On("ClearRedisData", anyContext).
+				Return(nil)
+			t.initUseCase.
+				On("RecoverZones", anyContext).
+				Return(fmt.Errorf("test-error"))
+			err := t.handler.Initialize(context.Background())
+			t.NotNil(err)
+			t.Equal("test-error", err.Error())
+		},
+	)
 }
@@ -2,11 +2,54 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"github.com/miekg/dns"
+	"net"
 )
 
+// ErrRefused is wrapped by errors which should be answered with REFUSED
+var ErrRefused = errors.New("query refused by policy")
+
+// the errors which are explained to the clients by extended DNS errors (RFC 8914)
+var (
+	ErrDnssecBogus = errors.New("DNSSEC bogus")
+	ErrNoUpstream  = errors.New("no reachable upstream forwarder")
+)
+
+// RcodeField is the cache field which keeps a non NOERROR rcode, e.g. NXDOMAIN
+const RcodeField = "Rcode"
+
+// AuthenticatedField is the cache field which marks an answer validated by DNSSEC
+const AuthenticatedField = "AD"
+
+type clientAddrKey struct{}
+
+// WithClientAddr carries the address of the client which asks the question, whose subnet may be
+// forwarded to the upstream forwarders
+func WithClientAddr(ctx context.Context, addr net.Addr) context.Context {
+	return context.WithValue(ctx, clientAddrKey{}, addr)
+}
+
+// ClientAddr returns the address of the client which asks the question, or nil when it isn't known
+func ClientAddr(ctx context.Context) net.Addr {
+	addr, _ := ctx.Value(clientAddrKey{}).(net.Addr)
+	return addr
+}
+
 type DNSUseCase interface {
+	// QueryAuthoritative answers names inside a locally hosted zone, it returns a nil
+	// response when no zone contains the question
+	QueryAuthoritative(ctx context.Context, req *dns.Msg) (resp *dns.Msg, err error)
+
 	QueryRedisCache(ctx context.Context, req *dns.Msg) (resp *dns.Msg, err error)
 
 	QueryUpstream(ctx context.Context, req *dns.Msg) (resp *dns.Msg, err error)
 }
+
+// ResolveUseCase resolves the names iteratively from the root servers, following the referrals and
+// the CNAMEs like a recursive resolver (RFC 1034 5.3.3)
+type ResolveUseCase interface {
+	// Resolve returns the answer, NXDOMAIN or NODATA of the question, the signatures are asked for when
+	// the request sets the DO bit
+	Resolve(ctx context.Context, req *dns.Msg) (*dns.Msg, error)
+}
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/miekg/dns"
+	"time"
+)
+
+type DnssecKeyType string
+
+const (
+	// DnssecKSK signs the DNSKEY RRset, its DS is published by the parent zone
+	DnssecKSK DnssecKeyType = "ksk"
+	// DnssecZSK signs the other RRsets of the zone
+	DnssecZSK DnssecKeyType = "zsk"
+)
+
+type DnssecKeyState string
+
+const (
+	// DnssecKeyPublished is in the DNSKEY RRset ahead of signing, so that resolvers have it cached
+	DnssecKeyPublished DnssecKeyState = "published"
+	// DnssecKeyActive signs the zone
+	DnssecKeyActive DnssecKeyState = "active"
+	// DnssecKeyRetired is still in the DNSKEY RRset until the signatures made by it expire from caches
+	DnssecKeyRetired DnssecKeyState = "retired"
+	// DnssecKeyRemoved is no longer published
+	DnssecKeyRemoved DnssecKeyState = "removed"
+)
+
+// DnssecKey is a signing key of a zone, it's published when it's created and its timestamps
+// schedule the rollover
+type DnssecKey struct {
+	ID         uint           `json:"id"`
+	Zone       string         `json:"zone"`
+	Type       DnssecKeyType  `json:"type"`
+	KeyTag     uint16         `json:"keyTag"`
+	DNSKEY     string         `json:"dnskey"` // presentation format
+	PrivateKey string         `json:"-"`      // private key format of BIND
+	State      DnssecKeyState `json:"state"`
+	Publish    time.Time      `json:"publish"`
+	Activate   time.Time      `json:"activate"`
+	Retire     *time.Time     `json:"retire,omitempty"`
+	Remove     *time.Time     `json:"remove,omitempty"`
+}
+
+// StateAt returns the state of the key by its timestamps
+func (k *DnssecKey) StateAt(now time.Time) DnssecKeyState {
+	switch {
+	case k.Remove != nil && !now.Before(*k.Remove):
+		return DnssecKeyRemoved
+	case k.Retire != nil && !now.Before(*k.Retire):
+		return DnssecKeyRetired
+	case !now.Before(k.Activate):
+		return DnssecKeyActive
+	}
+	return DnssecKeyPublished
+}
+
+// Dnssec is how a zone is signed, a zone without it is unsigned
+type Dnssec struct {
+	Zone      string `json:"zone"`
+	Algorithm string `json:"algorithm"` // ECDSAP256SHA256 when not given, or ED25519
+
+	// Nsec3 proves the denial by NSEC3 (RFC 5155) instead of NSEC, both are minimally covering
+	// records (RFC 4470), so that the zone cannot be walked
+	Nsec3      bool   `json:"nsec3"`
+	Iterations uint16 `json:"iterations"` // of NSEC3, 0 is recommended (RFC 9276)
+	Salt       string `json:"salt"`       // of NSEC3 in hex, none is recommended (RFC 9276)
+
+	Keys []*DnssecKey `json:"keys,omitempty"`
+	// DS are the records of the published KSKs, which are given to the parent zone
+	DS []string `json:"ds,omitempty"`
+}
+
+// DnssecRollover schedules a new key to replace the active keys of the type
+type DnssecRollover struct {
+	Type DnssecKeyType `json:"type" binding:"required,oneof=ksk zsk"`
+	// At is when the new key starts signing and the old keys retire, the DNSKEY TTL from now when
+	// not given. The DS of a new KSK should be at the parent by then.
+	At *time.Time `json:"at"`
+}
+
+type DnssecHandler interface {
+	EnableDnssecAPI(ctx *gin.Context)
+
+	GetDnssecAPI(ctx *gin.Context)
+
+	DisableDnssecAPI(ctx *gin.Context)
+
+	ListDSAPI(ctx *gin.Context)
+
+	RolloverAPI(ctx *gin.Context)
+}
+
+type DnssecUseCase interface {
+	// EnableDnssec generates a KSK and a ZSK of the zone, which sign the zone at once
+	EnableDnssec(ctx context.Context, dnssec *Dnssec) error
+
+	GetDnssec(ctx context.Context, zone string) (*Dnssec, error)
+
+	// DisableDnssec deletes the keys of the zone, the DS should be removed from the parent first
+	DisableDnssec(ctx context.Context, zone string) error
+
+	ListDS(ctx context.Context, zone string) ([]string, error)
+
+	Rollover(ctx context.Context, zone string, rollover *DnssecRollover) (*Dnssec, error)
+
+	// Sign answers DNSKEY and NSEC3PARAM of a signed zone, and when the client sets the DO bit
+	// (RFC 3225) it signs the RRsets of the authoritative response and proves the denial by NSEC
+	// or NSEC3
+	Sign(ctx context.Context, req *dns.Msg, resp *dns.Msg) (*dns.Msg, error)
+}
+
+// ValidateUseCase validates the answers of the upstream forwarders up to the trust anchors (RFC 4035 5)
+type ValidateUseCase interface {
+	// Validate returns whether the response is secure, it's insecure when its records are proven to be
+	// unsigned, and bogus data is an error
+	Validate(ctx context.Context, resp *dns.Msg) (secure bool, err error)
+}
+
+type DnssecRepo interface {
+	// Create saves the policy of the zone, the keys are created by CreateKey
+	Create(ctx context.Context, dnssec *Dnssec) error
+
+	// Get returns the policy of the zone with its keys from the oldest
+	Get(ctx context.Context, zone string) (*Dnssec, error)
+
+	// Delete deletes the policy and the keys of the zone
+	Delete(ctx context.Context, zone string) error
+
+	CreateKey(ctx context.Context, key *DnssecKey) error
+
+	UpdateKey(ctx context.Context, key *DnssecKey) error
+}
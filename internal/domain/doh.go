@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/miekg/dns"
+)
+
+const (
+	DNSMessageContentType = "application/dns-message"
+	DNSJSONContentType    = "application/dns-json"
+)
+
+type DoHHandler interface {
+	QueryGetAPI(ctx *gin.Context)
+
+	QueryPostAPI(ctx *gin.Context)
+}
+
+// SingleMsgWriter is a dns.ResponseWriter which answers by a single message, e.g. the HTTP response of
+// DoH, so the zone transfers which take many messages aren't served through it
+type SingleMsgWriter interface {
+	dns.ResponseWriter
+
+	SingleMsg()
+}
+
+type DoHQuestion struct {
+	Name string `json:"name"`
+	Type uint16 `json:"type"`
+}
+
+type DoHAnswer struct {
+	Name string `json:"name"`
+	Type uint16 `json:"type"`
+	TTL  uint32 `json:"TTL"`
+	Data string `json:"data"`
+}
+
+// DoHJSONResponse is the application/dns-json representation of a dns reply
+type DoHJSONResponse struct {
+	Status     int           `json:"Status"`
+	TC         bool          `json:"TC"`
+	RD         bool          `json:"RD"`
+	RA         bool          `json:"RA"`
+	AD         bool          `json:"AD"`
+	CD         bool          `json:"CD"`
+	Question   []DoHQuestion `json:"Question"`
+	Answer     []DoHAnswer   `json:"Answer,omitempty"`
+	Authority  []DoHAnswer   `json:"Authority,omitempty"`
+	Additional []DoHAnswer   `json:"Additional,omitempty"`
+}
@@ -9,6 +9,9 @@ type Error struct {
 	Message    string `json:"message"`
 	StatusCode int    `json:"statusCode"`
 	Err        error  `json:"-"`
+
+	// Details are the individual problems, e.g. the per-line errors of a zone file
+	Details []string `json:"details,omitempty"`
 }
 
 func (e Error) Unwrap() error {
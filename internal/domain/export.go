@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/miekg/dns"
+)
+
+type ExportFormat string
+
+const (
+	ExportBind ExportFormat = "bind"
+	ExportJSON ExportFormat = "json"
+	ExportCSV  ExportFormat = "csv"
+)
+
+// ExportFilter narrows the export down to a zone or an owner name, both are optional
+type ExportFilter struct {
+	Zone string `form:"zone"`
+	Name string `form:"name"`
+}
+
+type ExportOptions struct {
+	ExportFilter
+
+	Format ExportFormat `form:"format"`
+}
+
+// ExportSection is a group of records sharing the same origin, the records of a zone start
+// with its SOA and NS records, Ttl is 0 for the records outside any zone
+type ExportSection struct {
+	Origin string
+	Ttl    uint32
+	RRs    []dns.RR
+}
+
+type ExportHandler interface {
+	ExportAPI(ctx *gin.Context)
+}
+
+type ExportUseCase interface {
+	// Export reads the records zone by zone and calls the write with each section in the canonical
+	// order (RFC 4034 6), nothing is written when the error is returned before the first section
+	Export(ctx context.Context, filter ExportFilter, write func(section *ExportSection) error) error
+}
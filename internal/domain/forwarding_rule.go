@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/miekg/dns"
+)
+
+// ForwardingRule forwards the queries of the names under its suffix to its own upstream forwarders,
+// the rule of the longest matching suffix wins, and the queries matching no rule go to the default
+// upstream forwarders
+type ForwardingRule struct {
+	Suffix    string   `json:"suffix" binding:"required"`
+	Upstreams []string `json:"upstreams" binding:"required"`
+	Strategy  string   `json:"strategy"` // sequential when not given
+}
+
+type ForwardingRuleHandler interface {
+	CreateForwardingRuleAPI(ctx *gin.Context)
+
+	GetForwardingRuleAPI(ctx *gin.Context)
+
+	ListForwardingRulesAPI(ctx *gin.Context)
+
+	UpdateForwardingRuleAPI(ctx *gin.Context)
+
+	DeleteForwardingRuleAPI(ctx *gin.Context)
+}
+
+type ForwardingRuleUseCase interface {
+	// Forward asks the upstream forwarders of the rule matching the question until an answer is
+	// accepted
+	Forward(ctx context.Context, req *dns.Msg, accept func(*dns.Msg) bool) (*dns.Msg, error)
+
+	// MatchSuffix returns the suffix of the rule matching the name, or empty when no rule matches
+	MatchSuffix(ctx context.Context, name string) (string, error)
+
+	CreateRule(ctx context.Context, rule *ForwardingRule) error
+
+	GetRule(ctx context.Context, suffix string) (*ForwardingRule, error)
+
+	ListRules(ctx context.Context) ([]*ForwardingRule, error)
+
+	// UpdateRule replaces the upstream forwarders and the strategy of the rule
+	UpdateRule(ctx context.Context, rule *ForwardingRule) error
+
+	DeleteRule(ctx context.Context, suffix string) error
+
+	// ProbeUpstreams probes the health of the upstream forwarders of every rule once
+	ProbeUpstreams(ctx context.Context) error
+
+	// ListUpstreams returns the health of the upstream forwarders of the rules sorted by their suffixes
+	ListUpstreams(ctx context.Context) ([]*UpstreamGroup, error)
+}
+
+type ForwardingRuleRepo interface {
+	Create(ctx context.Context, rule *ForwardingRule) error
+
+	Get(ctx context.Context, suffix string) (*ForwardingRule, error)
+
+	List(ctx context.Context) ([]*ForwardingRule, error)
+
+	Update(ctx context.Context, rule *ForwardingRule) error
+
+	Delete(ctx context.Context, suffix string) error
+}
@@ -0,0 +1,249 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/iancoleman/strcase"
+	"github.com/miekg/dns"
+	"net/http"
+	"strings"
+)
+
+const (
+	hdrKey   = "hdr"
+	rdataKey = "rdata"
+)
+
+// UnsupportedRecordTypes are meta and query types which cannot be stored as records
+var UnsupportedRecordTypes = map[RRType]bool{
+	TypeNone:     true,
+	TypeOPT:      true,
+	TypeTKEY:     true,
+	TypeTSIG:     true,
+	TypeIXFR:     true,
+	TypeAXFR:     true,
+	TypeMAILB:    true,
+	TypeMAILA:    true,
+	TypeANY:      true,
+	TypeReserved: true,
+}
+
+// GenericRecord is the JSON codec of every resource record type. The rdata fields of the
+// miekg/dns struct sit next to "hdr" with lower camel case keys, e.g. "preference" and "mx"
+// of a MX record, and "rdata" carries the presentation format as a fallback, which also
+// accepts the RFC 3597 "\# <length> <hex>" syntax.
+type GenericRecord struct {
+	Hdr    GenericHeader              `swaggerignore:"true"`
+	Rdata  string                     `swaggerignore:"true"`
+	Fields map[string]json.RawMessage `swaggerignore:"true"`
+}
+
+// GenericHeader is the header of GenericRecord, the rrtype may be omitted when it is given
+// by the path and the class defaults to INET
+type GenericHeader struct {
+	Name   string `json:"name"`
+	Rrtype RRType `json:"rrtype"`
+	Class  Class  `json:"class"`
+	Ttl    uint32 `json:"ttl"`
+}
+
+// UnmarshalJSON only reads the lower case keys, the upper case ones are the embedded
+// dns.RR_Header of RR_Header which is still sent by the typed request bodies
+func (h *GenericHeader) UnmarshalJSON(raw []byte) error {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(raw, &fields)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range map[string]interface{}{
+		"name":   &h.Name,
+		"rrtype": &h.Rrtype,
+		"class":  &h.Class,
+		"ttl":    &h.Ttl,
+	} {
+		if field, ok := fields[k]; ok {
+			err = json.Unmarshal(field, v)
+			if err != nil {
+				return fmt.Errorf("hdr.%s: %w", k, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (g GenericRecord) MarshalJSON() ([]byte, error) {
+	output := map[string]interface{}{
+		hdrKey: g.Hdr,
+	}
+	if g.Rdata != "" {
+		output[rdataKey] = g.Rdata
+	}
+	for k, v := range g.Fields {
+		output[k] = v
+	}
+	return json.Marshal(output)
+}
+
+func (g *GenericRecord) UnmarshalJSON(raw []byte) error {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(raw, &fields)
+	if err != nil {
+		return err
+	}
+
+	if hdr, ok := fields[hdrKey]; ok {
+		err = json.Unmarshal(hdr, &g.Hdr)
+		if err != nil {
+			return err
+		}
+	}
+	// the header is never decoded from the fields, whatever case it is given in
+	for k := range fields {
+		if strings.EqualFold(k, hdrKey) {
+			delete(fields, k)
+		}
+	}
+	if rdata, ok := fields[rdataKey]; ok {
+		err = json.Unmarshal(rdata, &g.Rdata)
+		if err != nil {
+			return err
+		}
+		delete(fields, rdataKey)
+	}
+	g.Fields = fields
+	return nil
+}
+
+// ToRR builds the dns.RR from the rdata fields, or from the presentation format when
+// "rdata" is given
+func (g *GenericRecord) ToRR() (dns.RR, error) {
+	if g.Hdr.Name == "" || g.Hdr.Rrtype == "" {
+		return nil, g.error("hdr.name and hdr.rrtype are required")
+	}
+	rrType, ok := ParseRRType(string(g.Hdr.Rrtype))
+	if !ok {
+		return nil, g.error(fmt.Sprintf("record type %s is not supported", g.Hdr.Rrtype))
+	}
+	class := uint16(dns.ClassINET)
+	if g.Hdr.Class != "" {
+		class, ok = ClassMap[g.Hdr.Class]
+		if !ok {
+			return nil, g.error(fmt.Sprintf("record class %s is not supported", g.Hdr.Class))
+		}
+	}
+	header := fmt.Sprintf(
+		"%s\t%d\t%s\t%s\t", dns.Fqdn(g.Hdr.Name), g.Hdr.Ttl, dns.Class(class), dns.Type(rrType),
+	)
+
+	if g.Rdata != "" {
+		rr, err := dns.NewRR(header + g.Rdata)
+		if err != nil || rr == nil {
+			return nil, g.error(fmt.Sprintf("invalid rdata %q: %v", g.Rdata, err))
+		}
+		return rr, nil
+	}
+
+	if len(g.Fields) == 0 {
+		return nil, g.error("either rdata or the fields of the record are required")
+	}
+	newRR, ok := dns.TypeToRR[rrType]
+	if !ok {
+		return nil, g.error(fmt.Sprintf("record type %s only supports rdata", g.Hdr.Rrtype))
+	}
+	rr := newRR()
+	raw, _ := json.Marshal(g.Fields)
+	err := json.Unmarshal(raw, rr)
+	if err != nil {
+		return nil, g.error(
+			fmt.Sprintf("invalid fields of %s record, use rdata instead: %s", g.Hdr.Rrtype, err.Error()),
+		)
+	}
+
+	// round trip the presentation format to validate and normalize the fields
+	rdata := strings.TrimPrefix(rr.String(), rr.Header().String())
+	parsed, err := dns.NewRR(header + rdata)
+	if err != nil || parsed == nil {
+		return nil, g.error(fmt.Sprintf("invalid fields of %s record: %v", g.Hdr.Rrtype, err))
+	}
+	return parsed, nil
+}
+
+func (g *GenericRecord) error(message string) error {
+	return &Error{
+		Message:    message,
+		StatusCode: http.StatusBadRequest,
+	}
+}
+
+// NewGenericRecord encodes the dns.RR with both its fields and its presentation format
+func NewGenericRecord(rr dns.RR) *GenericRecord {
+	header := rr.Header()
+	g := &GenericRecord{
+		Hdr: GenericHeader{
+			Name:   header.Name,
+			Rrtype: RRTypeOf(header.Rrtype),
+			Class:  ClassOf(header.Class),
+			Ttl:    header.Ttl,
+		},
+		Rdata:  strings.TrimPrefix(rr.String(), header.String()),
+		Fields: map[string]json.RawMessage{},
+	}
+
+	var fields map[string]json.RawMessage
+	raw, err := json.Marshal(rr)
+	if err == nil && json.Unmarshal(raw, &fields) == nil {
+		for k, v := range fields {
+			if k == "Hdr" {
+				continue
+			}
+			g.Fields[strcase.ToLowerCamel(k)] = v
+		}
+	}
+	return g
+}
+
+func NewGenericRecords(rrs []dns.RR) []*GenericRecord {
+	records := make([]*GenericRecord, 0, len(rrs))
+	for _, rr := range rrs {
+		records = append(records, NewGenericRecord(rr))
+	}
+	return records
+}
+
+// ParseRRType accepts the mnemonics of RRTypeMap case-insensitively and the RFC 3597
+// TYPEnnn form, meta types are rejected
+func ParseRRType(s string) (uint16, bool) {
+	s = strings.ToUpper(s)
+	for k, v := range RRTypeMap {
+		if strings.ToUpper(string(k)) == s {
+			return v, !UnsupportedRecordTypes[k]
+		}
+	}
+
+	var t uint16
+	_, err := fmt.Sscanf(s, "TYPE%d", &t)
+	if err != nil || t == 0 {
+		return 0, false
+	}
+	return t, !UnsupportedRecordTypes[RRTypeOf(t)]
+}
+
+// RRTypeOf returns the mnemonic of the type, or the RFC 3597 TYPEnnn form when unknown
+func RRTypeOf(t uint16) RRType {
+	for k, v := range RRTypeMap {
+		if v == t && k != TypeNone {
+			return k
+		}
+	}
+	return RRType(dns.Type(t).String())
+}
+
+func ClassOf(c uint16) Class {
+	for k, v := range ClassMap {
+		if v == c {
+			return k
+		}
+	}
+	return Class(dns.Class(c).String())
+}
@@ -10,4 +10,6 @@ type InitUseCase interface {
 	ClearRedisData(ctx context.Context) error
 
 	RecoverRecords(ctx context.Context) error
+
+	RecoverZones(ctx context.Context) error
 }
@@ -40,3 +40,17 @@ func (_m *InitUseCase) RecoverRecords(ctx context.Context) error {
 
 	return r0
 }
+
+// RecoverZones provides a mock function with given fields: ctx
+func (_m *InitUseCase) RecoverZones(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
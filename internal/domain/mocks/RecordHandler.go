@@ -4,6 +4,7 @@ package mocks
 
 import (
 	gin "github.com/gin-gonic/gin"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -32,6 +33,16 @@ func (_m *RecordHandler) ListRecordsAPI(ctx *gin.Context) {
 	_m.Called(ctx)
 }
 
+// RemoveRecordAPI provides a mock function with given fields: ctx
+func (_m *RecordHandler) RemoveRecordAPI(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
+// ReplaceRRsetAPI provides a mock function with given fields: ctx
+func (_m *RecordHandler) ReplaceRRsetAPI(ctx *gin.Context) {
+	_m.Called(ctx)
+}
+
 // UpdateRecordAPI provides a mock function with given fields: ctx
 func (_m *RecordHandler) UpdateRecordAPI(ctx *gin.Context) {
 	_m.Called(ctx)
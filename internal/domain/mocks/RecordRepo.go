@@ -6,6 +6,7 @@ import (
 	context "context"
 
 	domain "github.com/cewuandy/go-restful-dns/internal/domain"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -42,16 +43,37 @@ func (_m *RecordRepo) Delete(ctx context.Context, name string, rrType uint16, cl
 	return r0
 }
 
-// Get provides a mock function with given fields: ctx, name, rrType, class
-func (_m *RecordRepo) Get(ctx context.Context, name string, rrType uint16, class uint16) (*domain.Record, error) {
+// ExistName provides a mock function with given fields: ctx, name
+func (_m *RecordRepo) ExistName(ctx context.Context, name string) (bool, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRRset provides a mock function with given fields: ctx, name, rrType, class
+func (_m *RecordRepo) GetRRset(ctx context.Context, name string, rrType uint16, class uint16) ([]*domain.Record, error) {
 	ret := _m.Called(ctx, name, rrType, class)
 
-	var r0 *domain.Record
-	if rf, ok := ret.Get(0).(func(context.Context, string, uint16, uint16) *domain.Record); ok {
+	var r0 []*domain.Record
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint16, uint16) []*domain.Record); ok {
 		r0 = rf(ctx, name, rrType, class)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*domain.Record)
+			r0 = ret.Get(0).([]*domain.Record)
 		}
 	}
 
@@ -88,13 +110,64 @@ func (_m *RecordRepo) List(ctx context.Context) ([]*domain.Record, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: ctx, record
-func (_m *RecordRepo) Update(ctx context.Context, record *domain.Record) error {
-	ret := _m.Called(ctx, record)
+// ListByName provides a mock function with given fields: ctx, name, class
+func (_m *RecordRepo) ListByName(ctx context.Context, name string, class uint16) ([]*domain.Record, error) {
+	ret := _m.Called(ctx, name, class)
+
+	var r0 []*domain.Record
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint16) []*domain.Record); ok {
+		r0 = rf(ctx, name, class)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Record)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint16) error); ok {
+		r1 = rf(ctx, name, class)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListInBatches provides a mock function with given fields: ctx, zone, batchSize, fn
+func (_m *RecordRepo) ListInBatches(ctx context.Context, zone string, batchSize int, fn func(records []*domain.Record) error) error {
+	ret := _m.Called(ctx, zone, batchSize, fn)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *domain.Record) error); ok {
-		r0 = rf(ctx, record)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, func(records []*domain.Record) error) error); ok {
+		r0 = rf(ctx, zone, batchSize, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceRRset provides a mock function with given fields: ctx, name, rrType, class, records
+func (_m *RecordRepo) ReplaceRRset(ctx context.Context, name string, rrType uint16, class uint16, records []*domain.Record) error {
+	ret := _m.Called(ctx, name, rrType, class, records)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint16, uint16, []*domain.Record) error); ok {
+		r0 = rf(ctx, name, rrType, class, records)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceRRsets provides a mock function with given fields: ctx, deleted, records
+func (_m *RecordRepo) ReplaceRRsets(ctx context.Context, deleted []*domain.Record, records []*domain.Record) error {
+	ret := _m.Called(ctx, deleted, records)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*domain.Record, []*domain.Record) error); ok {
+		r0 = rf(ctx, deleted, records)
 	} else {
 		r0 = ret.Error(0)
 	}
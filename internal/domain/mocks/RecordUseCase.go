@@ -17,13 +17,13 @@ type RecordUseCase struct {
 	mock.Mock
 }
 
-// CreateRecord provides a mock function with given fields: ctx, rr
-func (_m *RecordUseCase) CreateRecord(ctx context.Context, rr dns.RR) error {
-	ret := _m.Called(ctx, rr)
+// CreateRecord provides a mock function with given fields: ctx, rr, opts
+func (_m *RecordUseCase) CreateRecord(ctx context.Context, rr dns.RR, opts domain.RecordOptions) error {
+	ret := _m.Called(ctx, rr, opts)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, dns.RR) error); ok {
-		r0 = rf(ctx, rr)
+	if rf, ok := ret.Get(0).(func(context.Context, dns.RR, domain.RecordOptions) error); ok {
+		r0 = rf(ctx, rr, opts)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -31,13 +31,13 @@ func (_m *RecordUseCase) CreateRecord(ctx context.Context, rr dns.RR) error {
 	return r0
 }
 
-// DeleteRecord provides a mock function with given fields: ctx, question
-func (_m *RecordUseCase) DeleteRecord(ctx context.Context, question domain.Question) error {
-	ret := _m.Called(ctx, question)
+// DeleteRecord provides a mock function with given fields: ctx, question, opts
+func (_m *RecordUseCase) DeleteRecord(ctx context.Context, question domain.Question, opts domain.RecordOptions) error {
+	ret := _m.Called(ctx, question, opts)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, domain.Question) error); ok {
-		r0 = rf(ctx, question)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Question, domain.RecordOptions) error); ok {
+		r0 = rf(ctx, question, opts)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -46,15 +46,15 @@ func (_m *RecordUseCase) DeleteRecord(ctx context.Context, question domain.Quest
 }
 
 // GetRecord provides a mock function with given fields: ctx, question
-func (_m *RecordUseCase) GetRecord(ctx context.Context, question domain.Question) (dns.RR, error) {
+func (_m *RecordUseCase) GetRecord(ctx context.Context, question domain.Question) ([]dns.RR, error) {
 	ret := _m.Called(ctx, question)
 
-	var r0 dns.RR
-	if rf, ok := ret.Get(0).(func(context.Context, domain.Question) dns.RR); ok {
+	var r0 []dns.RR
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Question) []dns.RR); ok {
 		r0 = rf(ctx, question)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(dns.RR)
+			r0 = ret.Get(0).([]dns.RR)
 		}
 	}
 
@@ -91,13 +91,55 @@ func (_m *RecordUseCase) ListRecords(ctx context.Context) ([]dns.RR, error) {
 	return r0, r1
 }
 
-// UpdateRecord provides a mock function with given fields: ctx, rr
-func (_m *RecordUseCase) UpdateRecord(ctx context.Context, rr dns.RR) error {
-	ret := _m.Called(ctx, rr)
+// RemoveRecord provides a mock function with given fields: ctx, rr, opts
+func (_m *RecordUseCase) RemoveRecord(ctx context.Context, rr dns.RR, opts domain.RecordOptions) error {
+	ret := _m.Called(ctx, rr, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, dns.RR, domain.RecordOptions) error); ok {
+		r0 = rf(ctx, rr, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceRRset provides a mock function with given fields: ctx, rrs, opts
+func (_m *RecordUseCase) ReplaceRRset(ctx context.Context, rrs []dns.RR, opts domain.RecordOptions) error {
+	ret := _m.Called(ctx, rrs, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []dns.RR, domain.RecordOptions) error); ok {
+		r0 = rf(ctx, rrs, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceRRsets provides a mock function with given fields: ctx, rrsets, opts
+func (_m *RecordUseCase) ReplaceRRsets(ctx context.Context, rrsets []*domain.RRset, opts domain.RecordOptions) error {
+	ret := _m.Called(ctx, rrsets, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*domain.RRset, domain.RecordOptions) error); ok {
+		r0 = rf(ctx, rrsets, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateRecord provides a mock function with given fields: ctx, rr, opts
+func (_m *RecordUseCase) UpdateRecord(ctx context.Context, rr dns.RR, opts domain.RecordOptions) error {
+	ret := _m.Called(ctx, rr, opts)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, dns.RR) error); ok {
-		r0 = rf(ctx, rr)
+	if rf, ok := ret.Get(0).(func(context.Context, dns.RR, domain.RecordOptions) error); ok {
+		r0 = rf(ctx, rr, opts)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+type NotifyState string
+
+const (
+	// NotifyPending is being sent or waits for a retry
+	NotifyPending NotifyState = "pending"
+	// NotifyDelivered is acknowledged by the target
+	NotifyDelivered NotifyState = "delivered"
+	// NotifyFailed is given up after the retries
+	NotifyFailed NotifyState = "failed"
+)
+
+// NotifyStatus is the delivery of the latest NOTIFY of a zone to one of its targets
+type NotifyStatus struct {
+	Target    string      `json:"target"`
+	Serial    uint32      `json:"serial"`
+	State     NotifyState `json:"state"`
+	Attempts  int         `json:"attempts"`
+	LastError string      `json:"lastError,omitempty"`
+	Updated   time.Time   `json:"updated"`
+}
+
+type NotifyHandler interface {
+	NotifyZoneAPI(ctx *gin.Context)
+
+	ListNotifyStatusAPI(ctx *gin.Context)
+}
+
+type NotifyUseCase interface {
+	// Notify sends NOTIFY of the zone to its targets in the background (RFC 1996), a target which
+	// doesn't acknowledge is retried with backoff until a newer NOTIFY supersedes it
+	Notify(ctx context.Context, name string) error
+
+	// ListStatus returns the delivery of the latest NOTIFY to the targets of the zone
+	ListStatus(ctx context.Context, name string) ([]*NotifyStatus, error)
+}
@@ -5,10 +5,34 @@ type Options struct {
 	HttpPort           uint   `default:"8081" usage:"[Server Mode] Restful API port"`
 	DnsAddr            string `default:"0.0.0.0" usage:"DNS address"`
 	DnsPort            uint   `default:"53" usage:"DNS port"`
-	UpstreamForwarders string `default:"1.1.1.1:53" usage:"DNS upstream forwarders, e.g. 1.1.1.1:53,8.8.8.8:53"`
+	DotPort            uint   `default:"853" usage:"DNS-over-TLS port, served when tls-cert-file and tls-key-file are set"`
+	TlsCertFile        string `default:"" usage:"TLS certificate file, reloaded when rotated"`
+	TlsKeyFile         string `default:"" usage:"TLS private key file, reloaded when rotated"`
+	CreatePtr          bool   `default:"false" usage:"Maintain the PTR records of A and AAAA records unless createPtr is given"`
+	EdnsUdpSize        uint   `default:"1232" usage:"EDNS0 UDP buffer size advertised to the clients and the upstream forwarders"`
+	CookieSecret       string `default:"" usage:"Hex secret of at least 16 bytes signing the DNS server cookies, shared by the servers of an anycast address, random when not given"`
+	TransferAllow      string `default:"" usage:"Clients allowed to transfer zones by AXFR and IXFR, e.g. 192.0.2.1,10.0.0.0/8"`
+	TrustedProxies     string `default:"" usage:"Proxies whose X-Forwarded-For and X-Real-IP headers give the addresses of the HTTP clients, e.g. 10.0.0.0/8, none when not given"`
+	UpstreamForwarders string `default:"1.1.1.1:53" usage:"DNS upstream forwarders, e.g. 1.1.1.1:53,tcp://8.8.8.8:53,tls://1.1.1.1:853#cloudflare-dns.com,https://dns.google/dns-query"`
+	UpstreamCaFile     string `default:"" usage:"CA file verifying the certificates of the DoT and DoH forwarders, the system roots when not given"`
+	UpstreamInsecure   bool   `default:"false" usage:"Skip verifying the certificates of the DoT and DoH forwarders"`
+	UpstreamStrategy   string `default:"sequential" usage:"Order of trying the upstream forwarders: sequential, round-robin, fastest by RTT, or parallel racing the fastest ones"`
+	UpstreamParallel   uint   `default:"2" usage:"Number of the fastest upstream forwarders raced at once by the parallel strategy"`
+	UpstreamMaxFails   uint   `default:"3" usage:"Failures in a row ejecting an upstream forwarder, which is tried last until it's back"`
+	UpstreamEjectTime  uint   `default:"30" usage:"Seconds an upstream forwarder stays ejected unless a health probe succeeds"`
+	UpstreamProbeTime  uint   `default:"10" usage:"Seconds between the health probes of the upstream forwarders, 0 disables the probes"`
+	EcsForwarding      bool   `default:"false" usage:"Forward the subnets of the clients by EDNS Client Subnet, the answers are cached by their scopes"`
+	EcsPrefixes        string `default:"24,56" usage:"Longest IPv4 and IPv6 prefixes of the client subnets which are forwarded"`
+	Recursion          bool   `default:"false" usage:"Resolve the names matching no forwarding rule iteratively from the root hints instead of the upstream forwarders"`
+	RootHintsFile      string `default:"" usage:"Master file of the NS records of the root and the addresses of their servers, e.g. an internal root, the IANA root servers when not given"`
+	DnssecValidation   bool   `default:"false" usage:"Validate the DNSSEC of the upstream answers, bogus answers are SERVFAIL"`
+	TrustAnchorFile    string `default:"" usage:"Master file of the DS or DNSKEY records of the trust anchors, the root KSKs when not given"`
 	RedisAddr          string `default:"" usage:"Redis address"`
 	RedisPassword      string `default:"" usage:"Redis password"`
 	RedisMasterName    string `default:"" usage:"Redis master"`
 	RedisSentinelHost  string `default:"" usage:"Sentinel host"`
 	RedisSentinelPort  uint   `default:"" usage:"Sentinel port"`
+
+	// Args are the arguments after the options, which run a command instead of the servers
+	Args []string
 }
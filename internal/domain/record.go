@@ -4,16 +4,41 @@ import (
 	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/miekg/dns"
-	"reflect"
+	"strings"
 )
 
+// Record is a single member of a RRset, which is keyed by its rdata
 type Record struct {
 	Name   string `json:"name"`
 	RrType uint16 `json:"rrType"`
 	Class  uint16 `json:"class"`
+	Rdata  string `json:"rdata"`
 	Record string `json:"record"`
 }
 
+func NewRecord(rr dns.RR) *Record {
+	header := rr.Header()
+	return &Record{
+		Name:   header.Name,
+		RrType: header.Rrtype,
+		Class:  header.Class,
+		Rdata:  strings.TrimPrefix(rr.String(), header.String()),
+		Record: rr.String(),
+	}
+}
+
+// RecordOptions are the per request options of the record API
+type RecordOptions struct {
+	// CreatePtr maintains the PTR records of the addresses of A and AAAA records
+	CreatePtr bool `form:"createPtr"`
+}
+
+// RRset is the new content of the RRset of the question, no records deletes the RRset
+type RRset struct {
+	Question dns.Question
+	Records  []dns.RR
+}
+
 type ResponseType string
 
 const (
@@ -33,34 +58,61 @@ type RecordHandler interface {
 
 	UpdateRecordAPI(ctx *gin.Context)
 
+	ReplaceRRsetAPI(ctx *gin.Context)
+
+	RemoveRecordAPI(ctx *gin.Context)
+
 	DeleteRecordAPI(ctx *gin.Context)
 }
 
 type RecordUseCase interface {
-	CreateRecord(ctx context.Context, rr dns.RR) error
+	// CreateRecord adds the record to its RRset
+	CreateRecord(ctx context.Context, rr dns.RR, opts RecordOptions) error
 
-	GetRecord(ctx context.Context, question Question) (dns.RR, error)
+	// GetRecord returns all members of the RRset
+	GetRecord(ctx context.Context, question Question) ([]dns.RR, error)
 
 	ListRecords(ctx context.Context) ([]dns.RR, error)
 
-	UpdateRecord(ctx context.Context, rr dns.RR) error
+	// UpdateRecord replaces an existed RRset with the single record
+	UpdateRecord(ctx context.Context, rr dns.RR, opts RecordOptions) error
+
+	// ReplaceRRset replaces the whole RRset, all records must have the same name, type and class
+	ReplaceRRset(ctx context.Context, rrs []dns.RR, opts RecordOptions) error
 
-	DeleteRecord(ctx context.Context, question Question) error
+	// RemoveRecord removes a single member from its RRset
+	RemoveRecord(ctx context.Context, rr dns.RR, opts RecordOptions) error
+
+	// DeleteRecord deletes the whole RRset
+	DeleteRecord(ctx context.Context, question Question, opts RecordOptions) error
+
+	// ReplaceRRsets replaces the RRsets in one transaction, the serial of every changed zone is
+	// bumped once
+	ReplaceRRsets(ctx context.Context, rrsets []*RRset, opts RecordOptions) error
 }
 
 type RecordRepo interface {
 	Create(ctx context.Context, record *Record) error
 
-	Get(ctx context.Context, name string, rrType uint16, class uint16) (*Record, error)
+	GetRRset(ctx context.Context, name string, rrType uint16, class uint16) ([]*Record, error)
 
 	List(ctx context.Context) ([]*Record, error)
 
-	Update(ctx context.Context, record *Record) error
+	// ListInBatches calls the fn with the records owned by the zone or its sub-domains batch by batch, an
+	// empty zone reads all records
+	ListInBatches(ctx context.Context, zone string, batchSize int, fn func(records []*Record) error) error
 
-	Delete(ctx context.Context, name string, rrType uint16, class uint16) error
-}
+	// ListByName returns the records of every type owned by the name
+	ListByName(ctx context.Context, name string, class uint16) ([]*Record, error)
 
-var RecordTypeMap = map[string]reflect.Type{
-	"a":    reflect.TypeOf(A{}),
-	"aaaa": reflect.TypeOf(AAAA{}),
+	// ExistName reports whether the name owns records or is an ancestor of a name which does
+	ExistName(ctx context.Context, name string) (bool, error)
+
+	ReplaceRRset(ctx context.Context, name string, rrType uint16, class uint16, records []*Record) error
+
+	// ReplaceRRsets deletes the RRsets which the deleted records belong to and creates the
+	// records in one transaction
+	ReplaceRRsets(ctx context.Context, deleted []*Record, records []*Record) error
+
+	Delete(ctx context.Context, name string, rrType uint16, class uint16) error
 }
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"context"
+	"net"
+)
+
+type SecondaryUseCase interface {
+	// Run refreshes the secondary zones on the timers of their SOA records until the context is done
+	Run(ctx context.Context) error
+
+	// Refresh checks the serial of the primary and transfers the zone by IXFR or AXFR when it's newer
+	Refresh(ctx context.Context, name string) error
+
+	// Notify schedules an immediate refresh of the zone, the NOTIFY should come from the primary
+	// of the zone (RFC 1996 3.10)
+	Notify(ctx context.Context, name string, addr net.Addr) error
+}
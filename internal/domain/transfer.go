@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"github.com/miekg/dns"
+)
+
+// ErrNotAuth is wrapped by errors which should be answered with NOTAUTH, e.g. a zone transfer
+// of a zone which isn't hosted here
+var ErrNotAuth = errors.New("not authoritative for the zone")
+
+// Journal is the difference of a zone between two serials, the records are in presentation format
+type Journal struct {
+	Zone    string   `json:"zone"`
+	From    uint32   `json:"from"`
+	To      uint32   `json:"to"`
+	Deleted []string `json:"deleted"`
+	Added   []string `json:"added"`
+}
+
+type TransferUseCase interface {
+	// AXFR returns the whole zone between two SOA records (RFC 5936)
+	AXFR(ctx context.Context, zone string) ([]dns.RR, error)
+
+	// IXFR returns the differences since the serial (RFC 1995), the whole zone is returned when
+	// the journal doesn't cover the serial
+	IXFR(ctx context.Context, zone string, serial uint32) ([]dns.RR, error)
+}
+
+type JournalRepo interface {
+	Create(ctx context.Context, journal *Journal) error
+
+	// List returns the journals of the zone from the oldest
+	List(ctx context.Context, zone string) ([]*Journal, error)
+
+	// Trim keeps the latest size journals of the zone
+	Trim(ctx context.Context, zone string, size int) error
+
+	Delete(ctx context.Context, zone string) error
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/miekg/dns"
+)
+
+// TsigKey is a shared secret which signs messages by TSIG (RFC 8945)
+type TsigKey struct {
+	Name      string `json:"name" binding:"required"`
+	Algorithm string `json:"algorithm"` // hmac-sha256 when not given
+	Secret    string `json:"secret"`    // base64, generated when not given
+
+	// Zones are the zones which the key may update, all zones when empty
+	Zones []string `json:"zones,omitempty"`
+}
+
+// Allows reports whether the key may change the zone
+func (k *TsigKey) Allows(zone string) bool {
+	if len(k.Zones) == 0 {
+		return true
+	}
+	for _, name := range k.Zones {
+		if dns.CanonicalName(name) == dns.CanonicalName(zone) {
+			return true
+		}
+	}
+	return false
+}
+
+type TsigKeyHandler interface {
+	CreateTsigKeyAPI(ctx *gin.Context)
+
+	GetTsigKeyAPI(ctx *gin.Context)
+
+	ListTsigKeysAPI(ctx *gin.Context)
+
+	UpdateTsigKeyAPI(ctx *gin.Context)
+
+	DeleteTsigKeyAPI(ctx *gin.Context)
+}
+
+type TsigKeyUseCase interface {
+	// Generate returns the MAC of the message by the stored key of the TSIG record, which makes
+	// the use case a dns.TsigProvider of the DNS servers
+	Generate(msg []byte, t *dns.TSIG) ([]byte, error)
+
+	// Verify checks the MAC of the TSIG record by the stored key
+	Verify(msg []byte, t *dns.TSIG) error
+
+	CreateKey(ctx context.Context, key *TsigKey) error
+
+	GetKey(ctx context.Context, name string) (*TsigKey, error)
+
+	ListKeys(ctx context.Context) ([]*TsigKey, error)
+
+	// UpdateKey replaces the algorithm, secret and zones of the key, the secret is generated again
+	// unless given
+	UpdateKey(ctx context.Context, key *TsigKey) error
+
+	DeleteKey(ctx context.Context, name string) error
+}
+
+type TsigKeyRepo interface {
+	Create(ctx context.Context, key *TsigKey) error
+
+	Get(ctx context.Context, name string) (*TsigKey, error)
+
+	List(ctx context.Context) ([]*TsigKey, error)
+
+	Update(ctx context.Context, key *TsigKey) error
+
+	Delete(ctx context.Context, name string) error
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"github.com/miekg/dns"
+)
+
+// The errors of a dynamic update are answered with the rcodes of RFC 2136 2.2
+var (
+	ErrFormat   = errors.New("malformed update")
+	ErrNotZone  = errors.New("name outside the zone")
+	ErrNXDomain = errors.New("name isn't in use")
+	ErrYXDomain = errors.New("name is in use")
+	ErrNXRRSet  = errors.New("RRset isn't existed")
+	ErrYXRRSet  = errors.New("RRset is existed")
+)
+
+type UpdateUseCase interface {
+	// Update applies the dynamic update (RFC 2136) which is signed by the TSIG key. The
+	// prerequisites and the whole update are checked before any change is written, then the
+	// changed RRsets are written through the record use case.
+	Update(ctx context.Context, key string, req *dns.Msg) error
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// Upstream is the health of an upstream forwarder
+type Upstream struct {
+	Upstream string `json:"upstream"`
+	// Healthy is false while the upstream is ejected after failing in a row
+	Healthy             bool       `json:"healthy"`
+	RttMs               float64    `json:"rttMs"` // smoothed RTT, 0 before any answer
+	Exchanges           uint64     `json:"exchanges"`
+	Failures            uint64     `json:"failures"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	EjectedUntil        *time.Time `json:"ejectedUntil,omitempty"`
+	LastProbe           *time.Time `json:"lastProbe,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+}
+
+// UpstreamGroup is the health of the upstream forwarders of the forwarding rule of the suffix, the
+// default upstream forwarders have no suffix
+type UpstreamGroup struct {
+	Suffix    string      `json:"suffix,omitempty"`
+	Upstreams []*Upstream `json:"upstreams"`
+}
+
+type UpstreamHandler interface {
+	ListUpstreamsAPI(ctx *gin.Context)
+}
+
+type UpstreamUseCase interface {
+	// Run probes the health of the default upstream forwarders and the ones of the forwarding rules
+	// periodically until the context is done
+	Run(ctx context.Context) error
+
+	// ListUpstreams returns the default upstream forwarders first, then the ones of the forwarding rules
+	// by their suffixes
+	ListUpstreams(ctx context.Context) ([]*UpstreamGroup, error)
+}
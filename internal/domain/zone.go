@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/miekg/dns"
+	"io"
+	"time"
+)
+
+const (
+	DefaultZoneRefresh uint32 = 7200
+	DefaultZoneRetry   uint32 = 3600
+	DefaultZoneExpire  uint32 = 1209600
+	DefaultZoneMinttl  uint32 = 300
+	DefaultZoneTtl     uint32 = 3600
+)
+
+type ZoneType string
+
+const (
+	// ZonePrimary is mastered here, its records are managed by the API
+	ZonePrimary ZoneType = "primary"
+	// ZoneSecondary is transferred from its primary server, its records are read-only
+	ZoneSecondary ZoneType = "secondary"
+)
+
+type Zone struct {
+	Name        string   `json:"name" binding:"required"`
+	Type        ZoneType `json:"type" binding:"omitempty,oneof=primary secondary"`
+	Nameservers []string `json:"nameservers" binding:"required_unless=Type secondary,omitempty,min=1"`
+	Mbox        string   `json:"mbox" binding:"required_unless=Type secondary"`
+	Serial      uint32   `json:"serial"`
+	Refresh     uint32   `json:"refresh"`
+	Retry       uint32   `json:"retry"`
+	Expire      uint32   `json:"expire"`
+	Minttl      uint32   `json:"minttl"`
+	Ttl         uint32   `json:"ttl"` // default TTL of the records in this zone
+
+	// Notify are the addresses of the secondaries which are notified of the changes of the zone
+	Notify []string `json:"notify,omitempty"`
+	// Primary is the address of the server which a secondary zone is transferred from
+	Primary string `json:"primary,omitempty" binding:"required_if=Type secondary"`
+	// TsigKey is the name of the TSIG key which signs the queries and transfers of a secondary zone
+	// to its primary, they are unsigned when it's empty
+	TsigKey string `json:"tsigKey,omitempty"`
+	// Refreshed is when a secondary zone was last found up to date with its primary
+	Refreshed *time.Time `json:"refreshed,omitempty"`
+}
+
+// SetDefaults fills the timers which are not given with the common values
+func (z *Zone) SetDefaults() {
+	if z.Type == "" {
+		z.Type = ZonePrimary
+	}
+	if z.Refresh == 0 {
+		z.Refresh = DefaultZoneRefresh
+	}
+	if z.Retry == 0 {
+		z.Retry = DefaultZoneRetry
+	}
+	if z.Expire == 0 {
+		z.Expire = DefaultZoneExpire
+	}
+	if z.Minttl == 0 {
+		z.Minttl = DefaultZoneMinttl
+	}
+	if z.Ttl == 0 {
+		z.Ttl = DefaultZoneTtl
+	}
+}
+
+func (z *Zone) IsSecondary() bool {
+	return z.Type == ZoneSecondary
+}
+
+// Expired reports whether a secondary zone hasn't reached its primary for the expire time of
+// its SOA record, such a zone is no longer answered (RFC 1035 3.3.13)
+func (z *Zone) Expired(now time.Time) bool {
+	if !z.IsSecondary() {
+		return false
+	}
+	return z.Refreshed == nil || now.Sub(*z.Refreshed) > time.Duration(z.Expire)*time.Second
+}
+
+func (z *Zone) SOA() *dns.SOA {
+	var ns string
+	if len(z.Nameservers) > 0 {
+		ns = dns.Fqdn(z.Nameservers[0])
+	}
+	return &dns.SOA{
+		Hdr: dns.RR_Header{
+			Name:   z.Name,
+			Rrtype: dns.TypeSOA,
+			Class:  dns.ClassINET,
+			Ttl:    z.Ttl,
+		},
+		Ns:      ns,
+		Mbox:    dns.Fqdn(z.Mbox),
+		Serial:  z.Serial,
+		Refresh: z.Refresh,
+		Retry:   z.Retry,
+		Expire:  z.Expire,
+		Minttl:  z.Minttl,
+	}
+}
+
+func (z *Zone) NS() []dns.RR {
+	var rrs []dns.RR
+	for _, ns := range z.Nameservers {
+		rrs = append(
+			rrs, &dns.NS{
+				Hdr: dns.RR_Header{
+					Name:   z.Name,
+					Rrtype: dns.TypeNS,
+					Class:  dns.ClassINET,
+					Ttl:    z.Ttl,
+				},
+				Ns: dns.Fqdn(ns),
+			},
+		)
+	}
+	return rrs
+}
+
+type ImportMode string
+
+const (
+	// ImportMerge replaces the RRsets given by the zone file and keeps the other records
+	ImportMerge ImportMode = "merge"
+	// ImportReplace replaces all records of the zone with the zone file
+	ImportReplace ImportMode = "replace"
+)
+
+type ZoneImportOptions struct {
+	Mode ImportMode `form:"mode"`
+}
+
+// ZoneImport is the summary of an imported zone file
+type ZoneImport struct {
+	Zone    string     `json:"zone"`
+	Mode    ImportMode `json:"mode"`
+	RRsets  int        `json:"rrsets"`
+	Records int        `json:"records"`
+	Deleted int        `json:"deleted"` // the RRsets which are removed since the file doesn't have them
+}
+
+type ZoneHandler interface {
+	CreateZoneAPI(ctx *gin.Context)
+
+	GetZoneAPI(ctx *gin.Context)
+
+	ListZonesAPI(ctx *gin.Context)
+
+	UpdateZoneAPI(ctx *gin.Context)
+
+	DeleteZoneAPI(ctx *gin.Context)
+
+	ImportZoneAPI(ctx *gin.Context)
+}
+
+type ZoneUseCase interface {
+	CreateZone(ctx context.Context, zone *Zone) error
+
+	GetZone(ctx context.Context, name string) (*Zone, error)
+
+	ListZones(ctx context.Context) ([]*Zone, error)
+
+	UpdateZone(ctx context.Context, zone *Zone) error
+
+	DeleteZone(ctx context.Context, name string) error
+
+	// ImportZone loads a master file (RFC 1035 5) into the zone, the zone is created by the SOA
+	// record of the file when it isn't existed. Nothing is written unless every RR is valid.
+	ImportZone(ctx context.Context, name string, file io.Reader, mode ImportMode) (*ZoneImport, error)
+}
+
+type ZoneRepo interface {
+	Create(ctx context.Context, zone *Zone) error
+
+	Get(ctx context.Context, name string) (*Zone, error)
+
+	// GetClosest returns the most specific zone which contains the name
+	GetClosest(ctx context.Context, name string) (*Zone, error)
+
+	List(ctx context.Context) ([]*Zone, error)
+
+	Update(ctx context.Context, zone *Zone) error
+
+	// Import creates or updates the zone, and replaces its RRsets like RecordRepo.ReplaceRRsets in the
+	// same transaction
+	Import(ctx context.Context, zone *Zone, deleted []*Record, records []*Record) error
+
+	Delete(ctx context.Context, name string) error
+}
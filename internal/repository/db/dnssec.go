@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/repository/db/models"
+
+	"github.com/pkg/errors"
+	"github.com/samber/do"
+	"gorm.io/gorm"
+)
+
+type dnssecRepo struct {
+	db *gorm.DB
+}
+
+func (d *dnssecRepo) Create(ctx context.Context, dnssec *domain.Dnssec) error {
+	raw := &models.Dnssec{
+		Zone:       dnssec.Zone,
+		Algorithm:  dnssec.Algorithm,
+		Nsec3:      dnssec.Nsec3,
+		Iterations: dnssec.Iterations,
+		Salt:       dnssec.Salt,
+	}
+	err := d.db.WithContext(ctx).Create(raw).Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (d *dnssecRepo) Get(ctx context.Context, zone string) (*domain.Dnssec, error) {
+	var (
+		raw  models.Dnssec
+		keys []models.DnssecKey
+		err  error
+	)
+
+	err = d.db.WithContext(ctx).
+		Where("zone=?", zone).
+		First(&raw).
+		Error
+	if err != nil {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	err = d.db.WithContext(ctx).
+		Where("zone=?", zone).
+		Order("id").
+		Find(&keys).
+		Error
+	if err != nil {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	dnssec := &domain.Dnssec{
+		Zone:       raw.Zone,
+		Algorithm:  raw.Algorithm,
+		Nsec3:      raw.Nsec3,
+		Iterations: raw.Iterations,
+		Salt:       raw.Salt,
+	}
+	for i := range keys {
+		dnssec.Keys = append(dnssec.Keys, d.toDomainKey(&keys[i]))
+	}
+
+	return dnssec, nil
+}
+
+func (d *dnssecRepo) Delete(ctx context.Context, zone string) error {
+	return d.db.WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			for _, model := range []interface{}{&models.DnssecKey{}, &models.Dnssec{}} {
+				err := tx.Unscoped().Where("zone=?", zone).Delete(model).Error
+				if err != nil {
+					return &domain.Error{
+						Message:    fmt.Sprintf("DB error: %s", err.Error()),
+						StatusCode: http.StatusBadRequest,
+						Err:        errors.New(err.Error()),
+					}
+				}
+			}
+			return nil
+		},
+	)
+}
+
+func (d *dnssecRepo) CreateKey(ctx context.Context, key *domain.DnssecKey) error {
+	raw := d.toModelKey(key)
+	err := d.db.WithContext(ctx).Create(raw).Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+	key.ID = raw.ID
+
+	return nil
+}
+
+func (d *dnssecRepo) UpdateKey(ctx context.Context, key *domain.DnssecKey) error {
+	var (
+		raw models.DnssecKey
+		err error
+	)
+
+	err = d.db.WithContext(ctx).
+		Where("id=?", key.ID).
+		First(&raw).
+		Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	updated := d.toModelKey(key)
+	updated.Model = raw.Model
+	err = d.db.WithContext(ctx).Save(updated).Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (d *dnssecRepo) toModelKey(key *domain.DnssecKey) *models.DnssecKey {
+	return &models.DnssecKey{
+		Zone:       key.Zone,
+		Type:       string(key.Type),
+		KeyTag:     key.KeyTag,
+		DNSKEY:     key.DNSKEY,
+		PrivateKey: key.PrivateKey,
+		Publish:    key.Publish,
+		Activate:   key.Activate,
+		Retire:     key.Retire,
+		Remove:     key.Remove,
+	}
+}
+
+func (d *dnssecRepo) toDomainKey(raw *models.DnssecKey) *domain.DnssecKey {
+	return &domain.DnssecKey{
+		ID:         raw.ID,
+		Zone:       raw.Zone,
+		Type:       domain.DnssecKeyType(raw.Type),
+		KeyTag:     raw.KeyTag,
+		DNSKEY:     raw.DNSKEY,
+		PrivateKey: raw.PrivateKey,
+		Publish:    raw.Publish,
+		Activate:   raw.Activate,
+		Retire:     raw.Retire,
+		Remove:     raw.Remove,
+	}
+}
+
+func NewDnssecRepo(injector *do.Injector) (domain.DnssecRepo, error) {
+	return &dnssecRepo{do.MustInvoke[*gorm.DB](injector)}, nil
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	pkgGorm "github.com/cewuandy/go-restful-dns/pkg/gorm"
+)
+
+type dnssecRepoTestSuite struct {
+	suite.Suite
+
+	repo domain.DnssecRepo
+
+	activate time.Time
+}
+
+func TestDnssecRepo(t *testing.T) {
+	suite.Run(t, &dnssecRepoTestSuite{})
+}
+
+func (t *dnssecRepoTestSuite) SetupSuite() {
+	injector := do.New()
+	db, err := gorm.Open(
+		sqlite.Open("dns.db"), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		},
+	)
+	t.Nil(err)
+
+	do.ProvideValue[*gorm.DB](injector, db)
+	err = pkgGorm.AutoMigrate(db)
+	t.Nil(err)
+
+	t.repo, _ = NewDnssecRepo(injector)
+	t.activate = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_ = t.repo.Create(
+		context.Background(), &domain.Dnssec{
+			Zone:       "test.com.",
+			Algorithm:  "ECDSAP256SHA256",
+			Nsec3:      true,
+			Iterations: 0,
+			Salt:       "aabb",
+		},
+	)
+	for _, keyType := range []domain.DnssecKeyType{domain.DnssecKSK, domain.DnssecZSK} {
+		_ = t.repo.CreateKey(
+			context.Background(), &domain.DnssecKey{
+				Zone:       "test.com.",
+				Type:       keyType,
+				KeyTag:     12345,
+				DNSKEY:     "test.com.\t3600\tIN\tDNSKEY\t257 3 13 AAAA",
+				PrivateKey: "Private-key-format: v1.3",
+				Publish:    t.activate,
+				Activate:   t.activate,
+			},
+		)
+	}
+}
+
+func (t *dnssecRepoTestSuite) TearDownSuite() {
+	_ = os.Remove("dns.db")
+}
+
+func (t *dnssecRepoTestSuite) TestCreate() {
+	t.Run(
+		"duplicated_error", func() {
+			err := t.repo.Create(context.Background(), &domain.Dnssec{Zone: "test.com."})
+			t.NotNil(err)
+			t.Contains(err.Error(), "DB error")
+		},
+	)
+}
+
+func (t *dnssecRepoTestSuite) TestGet() {
+	t.Run(
+		"success", func() {
+			dnssec, err := t.repo.Get(context.Background(), "test.com.")
+			t.Nil(err)
+			t.True(dnssec.Nsec3)
+			t.Equal("aabb", dnssec.Salt)
+			t.Len(dnssec.Keys, 2)
+			t.Equal(domain.DnssecKSK, dnssec.Keys[0].Type)
+			t.Equal(domain.DnssecZSK, dnssec.Keys[1].Type)
+			t.Equal("Private-key-format: v1.3", dnssec.Keys[0].PrivateKey)
+			t.True(t.activate.Equal(dnssec.Keys[0].Activate))
+			t.Nil(dnssec.Keys[0].Retire)
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			dnssec, err := t.repo.Get(context.Background(), "other.com.")
+			t.Nil(dnssec)
+			t.Contains(err.Error(), "record not found")
+		},
+	)
+}
+
+func (t *dnssecRepoTestSuite) TestUpdateKey() {
+	t.Run(
+		"success", func() {
+			key := &domain.DnssecKey{
+				Zone:     "update.com.",
+				Type:     domain.DnssecZSK,
+				Publish:  t.activate,
+				Activate: t.activate,
+			}
+			err := t.repo.CreateKey(context.Background(), key)
+			t.Nil(err)
+			t.NotZero(key.ID)
+			defer func() { _ = t.repo.Delete(context.Background(), "update.com.") }()
+
+			retire := t.activate.Add(time.Hour)
+			remove := retire.Add(time.Hour)
+			key.Retire, key.Remove = &retire, &remove
+			err = t.repo.UpdateKey(context.Background(), key)
+			t.Nil(err)
+
+			_ = t.repo.Create(context.Background(), &domain.Dnssec{Zone: "update.com."})
+			dnssec, err := t.repo.Get(context.Background(), "update.com.")
+			t.Nil(err)
+			t.True(retire.Equal(*dnssec.Keys[0].Retire))
+			t.True(remove.Equal(*dnssec.Keys[0].Remove))
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			err := t.repo.UpdateKey(context.Background(), &domain.DnssecKey{ID: 1000})
+			t.NotNil(err)
+		},
+	)
+}
+
+func (t *dnssecRepoTestSuite) TestDelete() {
+	err := t.repo.Create(context.Background(), &domain.Dnssec{Zone: "delete.com."})
+	t.Nil(err)
+	err = t.repo.CreateKey(context.Background(), &domain.DnssecKey{Zone: "delete.com.", Type: domain.DnssecKSK})
+	t.Nil(err)
+
+	err = t.repo.Delete(context.Background(), "delete.com.")
+	t.Nil(err)
+	_, err = t.repo.Get(context.Background(), "delete.com.")
+	t.NotNil(err)
+
+	// the keys are deleted with the policy
+	err = t.repo.Create(context.Background(), &domain.Dnssec{Zone: "delete.com."})
+	t.Nil(err)
+	dnssec, err := t.repo.Get(context.Background(), "delete.com.")
+	t.Nil(err)
+	t.Empty(dnssec.Keys)
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/repository/db/models"
+
+	"github.com/pkg/errors"
+	"github.com/samber/do"
+	"gorm.io/gorm"
+)
+
+type forwardingRuleRepo struct {
+	db *gorm.DB
+}
+
+func (f *forwardingRuleRepo) Create(ctx context.Context, rule *domain.ForwardingRule) error {
+	err := f.db.WithContext(ctx).Create(f.toModel(rule)).Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (f *forwardingRuleRepo) Get(ctx context.Context, suffix string) (*domain.ForwardingRule, error) {
+	var (
+		raw models.ForwardingRule
+		err error
+	)
+
+	err = f.db.WithContext(ctx).
+		Where("suffix=?", suffix).
+		First(&raw).
+		Error
+	if err != nil {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return f.toDomain(&raw), nil
+}
+
+func (f *forwardingRuleRepo) List(ctx context.Context) ([]*domain.ForwardingRule, error) {
+	var (
+		raws  []models.ForwardingRule
+		rules []*domain.ForwardingRule
+		err   error
+	)
+
+	err = f.db.WithContext(ctx).Order("suffix").Find(&raws).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range raws {
+		rules = append(rules, f.toDomain(&raws[i]))
+	}
+
+	return rules, nil
+}
+
+func (f *forwardingRuleRepo) Update(ctx context.Context, rule *domain.ForwardingRule) error {
+	var (
+		raw models.ForwardingRule
+		err error
+	)
+
+	err = f.db.WithContext(ctx).
+		Where("suffix=?", rule.Suffix).
+		First(&raw).
+		Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	updated := f.toModel(rule)
+	updated.Model = raw.Model
+	err = f.db.WithContext(ctx).Save(updated).Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (f *forwardingRuleRepo) Delete(ctx context.Context, suffix string) error {
+	var (
+		raw models.ForwardingRule
+		err error
+	)
+
+	err = f.db.WithContext(ctx).
+		Where("suffix=?", suffix).
+		First(&raw).
+		Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	err = f.db.WithContext(ctx).
+		Unscoped().
+		Delete(&raw).
+		Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (f *forwardingRuleRepo) toModel(rule *domain.ForwardingRule) *models.ForwardingRule {
+	return &models.ForwardingRule{
+		Suffix:    rule.Suffix,
+		Upstreams: strings.Join(rule.Upstreams, ","),
+		Strategy:  rule.Strategy,
+	}
+}
+
+func (f *forwardingRuleRepo) toDomain(raw *models.ForwardingRule) *domain.ForwardingRule {
+	return &domain.ForwardingRule{
+		Suffix:    raw.Suffix,
+		Upstreams: strings.Split(raw.Upstreams, ","),
+		Strategy:  raw.Strategy,
+	}
+}
+
+func NewForwardingRuleRepo(injector *do.Injector) (domain.ForwardingRuleRepo, error) {
+	return &forwardingRuleRepo{do.MustInvoke[*gorm.DB](injector)}, nil
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"os"
+	"testing"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	pkgGorm "github.com/cewuandy/go-restful-dns/pkg/gorm"
+)
+
+type forwardingRuleRepoTestSuite struct {
+	suite.Suite
+
+	repo domain.ForwardingRuleRepo
+}
+
+func TestForwardingRuleRepo(t *testing.T) {
+	suite.Run(t, &forwardingRuleRepoTestSuite{})
+}
+
+func (t *forwardingRuleRepoTestSuite) SetupSuite() {
+	injector := do.New()
+	db, err := gorm.Open(
+		sqlite.Open("dns.db"), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		},
+	)
+	t.Nil(err)
+
+	do.ProvideValue[*gorm.DB](injector, db)
+	err = pkgGorm.AutoMigrate(db)
+	t.Nil(err)
+
+	t.repo, _ = NewForwardingRuleRepo(injector)
+
+	_ = t.repo.Create(
+		context.Background(), &domain.ForwardingRule{
+			Suffix:    "corp.example.",
+			Upstreams: []string{"10.0.0.1:53", "tcp://10.0.0.2:53"},
+			Strategy:  "sequential",
+		},
+	)
+	_ = t.repo.Create(
+		context.Background(), &domain.ForwardingRule{
+			Suffix:    "svc.cluster.local.",
+			Upstreams: []string{"10.96.0.10:53"},
+			Strategy:  "round-robin",
+		},
+	)
+}
+
+func (t *forwardingRuleRepoTestSuite) TearDownSuite() {
+	_ = os.Remove("dns.db")
+}
+
+func (t *forwardingRuleRepoTestSuite) TestCreate() {
+	t.Run(
+		"duplicated_error", func() {
+			err := t.repo.Create(
+				context.Background(), &domain.ForwardingRule{Suffix: "corp.example.", Upstreams: []string{"10.0.0.3"}},
+			)
+			t.NotNil(err)
+			t.Contains(err.Error(), "DB error")
+		},
+	)
+}
+
+func (t *forwardingRuleRepoTestSuite) TestGet() {
+	t.Run(
+		"success", func() {
+			rule, err := t.repo.Get(context.Background(), "corp.example.")
+			t.Nil(err)
+			t.Equal(
+				&domain.ForwardingRule{
+					Suffix:    "corp.example.",
+					Upstreams: []string{"10.0.0.1:53", "tcp://10.0.0.2:53"},
+					Strategy:  "sequential",
+				}, rule,
+			)
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			rule, err := t.repo.Get(context.Background(), "other.")
+			t.Nil(rule)
+			t.Contains(err.Error(), "record not found")
+		},
+	)
+}
+
+func (t *forwardingRuleRepoTestSuite) TestList() {
+	t.Run(
+		"success", func() {
+			rules, err := t.repo.List(context.Background())
+			t.Nil(err)
+			t.Len(rules, 2)
+			t.Equal("corp.example.", rules[0].Suffix)
+		},
+	)
+}
+
+func (t *forwardingRuleRepoTestSuite) TestUpdate() {
+	t.Run(
+		"success", func() {
+			err := t.repo.Create(
+				context.Background(), &domain.ForwardingRule{
+					Suffix:    "update.example.",
+					Upstreams: []string{"10.0.0.1:53"},
+					Strategy:  "sequential",
+				},
+			)
+			t.Nil(err)
+			defer func() { _ = t.repo.Delete(context.Background(), "update.example.") }()
+
+			err = t.repo.Update(
+				context.Background(), &domain.ForwardingRule{
+					Suffix:    "update.example.",
+					Upstreams: []string{"tls://10.0.0.5:853", "10.0.0.6:53"},
+					Strategy:  "fastest",
+				},
+			)
+			t.Nil(err)
+
+			rule, _ := t.repo.Get(context.Background(), "update.example.")
+			t.Equal(
+				&domain.ForwardingRule{
+					Suffix:    "update.example.",
+					Upstreams: []string{"tls://10.0.0.5:853", "10.0.0.6:53"},
+					Strategy:  "fastest",
+				}, rule,
+			)
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			err := t.repo.Update(context.Background(), &domain.ForwardingRule{Suffix: "other."})
+			t.NotNil(err)
+		},
+	)
+}
+
+func (t *forwardingRuleRepoTestSuite) TestDelete() {
+	t.Run(
+		"success", func() {
+			err := t.repo.Create(
+				context.Background(), &domain.ForwardingRule{Suffix: "delete.", Upstreams: []string{"10.0.0.1"}},
+			)
+			t.Nil(err)
+			err = t.repo.Delete(context.Background(), "delete.")
+			t.Nil(err)
+
+			_, err = t.repo.Get(context.Background(), "delete.")
+			t.NotNil(err)
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			err := t.repo.Delete(context.Background(), "other.")
+			t.NotNil(err)
+			t.Contains(err.Error(), "record not found")
+		},
+	)
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/repository/db/models"
+
+	"github.com/pkg/errors"
+	"github.com/samber/do"
+	"gorm.io/gorm"
+)
+
+type journalRepo struct {
+	db *gorm.DB
+}
+
+func (j *journalRepo) Create(ctx context.Context, journal *domain.Journal) error {
+	raw := &models.Journal{
+		Zone:       journal.Zone,
+		FromSerial: journal.From,
+		ToSerial:   journal.To,
+		Deleted:    strings.Join(journal.Deleted, "\n"),
+		Added:      strings.Join(journal.Added, "\n"),
+	}
+	err := j.db.WithContext(ctx).Create(raw).Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (j *journalRepo) List(ctx context.Context, zone string) ([]*domain.Journal, error) {
+	var (
+		raws     []models.Journal
+		journals []*domain.Journal
+		err      error
+	)
+
+	err = j.db.WithContext(ctx).
+		Where("zone=?", zone).
+		Order("id").
+		Find(&raws).
+		Error
+	if err != nil {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	for _, raw := range raws {
+		journals = append(
+			journals, &domain.Journal{
+				Zone:    raw.Zone,
+				From:    raw.FromSerial,
+				To:      raw.ToSerial,
+				Deleted: splitLines(raw.Deleted),
+				Added:   splitLines(raw.Added),
+			},
+		)
+	}
+
+	return journals, nil
+}
+
+func (j *journalRepo) Trim(ctx context.Context, zone string, size int) error {
+	err := j.db.WithContext(ctx).
+		Unscoped().
+		Where(
+			"zone=? AND id NOT IN (?)", zone,
+			j.db.Model(&models.Journal{}).Select("id").Where("zone=?", zone).Order("id DESC").Limit(size),
+		).
+		Delete(&models.Journal{}).
+		Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (j *journalRepo) Delete(ctx context.Context, zone string) error {
+	err := j.db.WithContext(ctx).
+		Unscoped().
+		Where("zone=?", zone).
+		Delete(&models.Journal{}).
+		Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func NewJournalRepo(injector *do.Injector) (domain.JournalRepo, error) {
+	return &journalRepo{do.MustInvoke[*gorm.DB](injector)}, nil
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"os"
+	"testing"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	pkgGorm "github.com/cewuandy/go-restful-dns/pkg/gorm"
+)
+
+type journalRepoTestSuite struct {
+	suite.Suite
+
+	repo domain.JournalRepo
+}
+
+func TestJournalRepo(t *testing.T) {
+	suite.Run(t, &journalRepoTestSuite{})
+}
+
+func (t *journalRepoTestSuite) SetupSuite() {
+	injector := do.New()
+	db, err := gorm.Open(
+		sqlite.Open("dns.db"), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		},
+	)
+	t.Nil(err)
+
+	do.ProvideValue[*gorm.DB](injector, db)
+	err = pkgGorm.AutoMigrate(db)
+	t.Nil(err)
+
+	t.repo, _ = NewJournalRepo(injector)
+}
+
+func (t *journalRepoTestSuite) TearDownSuite() {
+	_ = os.Remove("dns.db")
+}
+
+func (t *journalRepoTestSuite) TestJournal() {
+	t.Run(
+		"success_create_list", func() {
+			for serial := uint32(1); serial <= 3; serial++ {
+				err := t.repo.Create(
+					context.Background(), &domain.Journal{
+						Zone:    "test.com.",
+						From:    serial,
+						To:      serial + 1,
+						Deleted: []string{"a.test.com.\t3600\tIN\tA\t1.1.1.1"},
+						Added: []string{
+							"a.test.com.\t3600\tIN\tA\t2.2.2.2",
+							"a.test.com.\t3600\tIN\tA\t3.3.3.3",
+						},
+					},
+				)
+				t.Nil(err)
+			}
+			t.Nil(t.repo.Create(context.Background(), &domain.Journal{Zone: "other.com.", From: 1, To: 2}))
+
+			journals, err := t.repo.List(context.Background(), "test.com.")
+			t.Nil(err)
+			t.Len(journals, 3)
+			t.Equal(uint32(1), journals[0].From)
+			t.Equal(uint32(2), journals[0].To)
+			t.Len(journals[0].Deleted, 1)
+			t.Len(journals[0].Added, 2)
+		},
+	)
+
+	t.Run(
+		"success_trim", func() {
+			err := t.repo.Trim(context.Background(), "test.com.", 2)
+			t.Nil(err)
+
+			journals, err := t.repo.List(context.Background(), "test.com.")
+			t.Nil(err)
+			t.Len(journals, 2)
+			t.Equal(uint32(2), journals[0].From)
+
+			journals, err = t.repo.List(context.Background(), "other.com.")
+			t.Nil(err)
+			t.Len(journals, 1)
+			t.Nil(journals[0].Deleted)
+		},
+	)
+
+	t.Run(
+		"success_delete", func() {
+			err := t.repo.Delete(context.Background(), "test.com.")
+			t.Nil(err)
+
+			journals, err := t.repo.List(context.Background(), "test.com.")
+			t.Nil(err)
+			t.Len(journals, 0)
+		},
+	)
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type Dnssec struct {
+	gorm.Model
+	Zone       string `gorm:"uniqueIndex"`
+	Algorithm  string
+	Nsec3      bool
+	Iterations uint16
+	Salt       string
+}
+
+type DnssecKey struct {
+	gorm.Model
+	Zone       string `gorm:"index"`
+	Type       string
+	KeyTag     uint16
+	DNSKEY     string
+	PrivateKey string
+	Publish    time.Time
+	Activate   time.Time
+	Retire     *time.Time
+	Remove     *time.Time
+}
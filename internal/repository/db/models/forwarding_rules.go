@@ -0,0 +1,10 @@
+package models
+
+import "gorm.io/gorm"
+
+type ForwardingRule struct {
+	gorm.Model
+	Suffix    string `gorm:"uniqueIndex"`
+	Upstreams string
+	Strategy  string
+}
@@ -0,0 +1,12 @@
+package models
+
+import "gorm.io/gorm"
+
+type Journal struct {
+	gorm.Model
+	Zone       string `gorm:"index"`
+	FromSerial uint32
+	ToSerial   uint32
+	Deleted    string // the records are separated by newlines
+	Added      string
+}
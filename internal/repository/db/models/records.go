@@ -4,8 +4,9 @@ import "gorm.io/gorm"
 
 type Record struct {
 	gorm.Model
-	Name   string
-	RrType uint16
-	Class  uint16
+	Name   string `gorm:"uniqueIndex:idx_records_rdata"`
+	RrType uint16 `gorm:"uniqueIndex:idx_records_rdata"`
+	Class  uint16 `gorm:"uniqueIndex:idx_records_rdata"`
+	Rdata  string `gorm:"uniqueIndex:idx_records_rdata"`
 	Record string
 }
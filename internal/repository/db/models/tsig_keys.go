@@ -0,0 +1,11 @@
+package models
+
+import "gorm.io/gorm"
+
+type TsigKey struct {
+	gorm.Model
+	Name      string `gorm:"uniqueIndex"`
+	Algorithm string
+	Secret    string
+	Zones     string
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type Zone struct {
+	gorm.Model
+	Name        string `gorm:"uniqueIndex"`
+	Nameservers string
+	Mbox        string
+	Serial      uint32
+	Refresh     uint32
+	Retry       uint32
+	Expire      uint32
+	Minttl      uint32
+	Ttl         uint32
+	Type        string
+	Primary     string
+	TsigKey     string
+	Notify      string
+	Refreshed   *time.Time
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/cewuandy/go-restful-dns/internal/domain"
 	"github.com/cewuandy/go-restful-dns/internal/repository/db/models"
@@ -37,18 +38,18 @@ func (r *recordRepo) Create(ctx context.Context, record *domain.Record) error {
 	return nil
 }
 
-func (r *recordRepo) Get(ctx context.Context, name string, rrType uint16,
-	class uint16) (*domain.Record, error) {
+func (r *recordRepo) GetRRset(ctx context.Context, name string, rrType uint16,
+	class uint16) ([]*domain.Record, error) {
 	var (
-		raw    models.Record
-		record domain.Record
-		err    error
+		raws    []models.Record
+		records []*domain.Record
+		err     error
 	)
 
 	err = r.db.WithContext(ctx).
-		Unscoped().
 		Where("name=? AND rr_type=? AND class=?", name, rrType, class).
-		First(&raw).
+		Order("id").
+		Find(&raws).
 		Error
 	if err != nil {
 		return nil, &domain.Error{
@@ -58,9 +59,13 @@ func (r *recordRepo) Get(ctx context.Context, name string, rrType uint16,
 		}
 	}
 
-	_ = utils.Convert(&raw, &record)
+	for _, raw := range raws {
+		record := domain.Record{}
+		_ = utils.Convert(&raw, &record)
+		records = append(records, &record)
+	}
 
-	return &record, nil
+	return records, nil
 }
 
 func (r *recordRepo) List(ctx context.Context) ([]*domain.Record, error) {
@@ -84,27 +89,140 @@ func (r *recordRepo) List(ctx context.Context) ([]*domain.Record, error) {
 	return records, nil
 }
 
-func (r *recordRepo) Update(ctx context.Context, record *domain.Record) error {
+func (r *recordRepo) ListInBatches(ctx context.Context, zone string, batchSize int,
+	fn func(records []*domain.Record) error) error {
 	var (
-		raw models.Record
-		err error
+		raws  []models.Record
+		fnErr error
+		err   error
 	)
 
-	err = r.db.
-		WithContext(ctx).
-		Where("name=? AND rr_type=? AND class=?", record.Name, record.RrType, record.Class).
-		First(&raw).
+	tx := r.db.WithContext(ctx)
+	if zone != "" {
+		// the names are matched case insensitively, and the wildcards of LIKE may match more names, so
+		// the caller checks the zones of the records
+		zone = strings.ToLower(zone)
+		tx = tx.Where("LOWER(name)=? OR LOWER(name) LIKE ?", zone, "%."+zone)
+	}
+	err = tx.FindInBatches(
+		&raws, batchSize, func(_ *gorm.DB, _ int) error {
+			records := make([]*domain.Record, 0, len(raws))
+			for _, raw := range raws {
+				record := domain.Record{}
+				_ = utils.Convert(&raw, &record)
+				records = append(records, &record)
+			}
+			fnErr = fn(records)
+			return fnErr
+		},
+	).Error
+	// the errors of the fn are returned as they are
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (r *recordRepo) ListByName(ctx context.Context, name string, class uint16) ([]*domain.Record, error) {
+	var (
+		raws    []models.Record
+		records []*domain.Record
+		err     error
+	)
+
+	err = r.db.WithContext(ctx).
+		Where("name=? AND class=?", name, class).
+		Order("id").
+		Find(&raws).
+		Error
+	if err != nil {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	for _, raw := range raws {
+		record := domain.Record{}
+		_ = utils.Convert(&raw, &record)
+		records = append(records, &record)
+	}
+
+	return records, nil
+}
+
+func (r *recordRepo) ExistName(ctx context.Context, name string) (bool, error) {
+	var (
+		count int64
+		err   error
+	)
+
+	suffix := "." + name
+	err = r.db.WithContext(ctx).
+		Model(&models.Record{}).
+		Where("name=? OR substr(name, -?)=?", name, len(suffix), suffix).
+		Count(&count).
 		Error
+	if err != nil {
+		return false, &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return count > 0, nil
+}
+
+func (r *recordRepo) ReplaceRRset(ctx context.Context, name string, rrType uint16, class uint16,
+	records []*domain.Record) error {
+	err := r.db.WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			err := tx.Unscoped().
+				Where("name=? AND rr_type=? AND class=?", name, rrType, class).
+				Delete(&models.Record{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			for _, record := range records {
+				var raw models.Record
+				_ = utils.Convert(&record, &raw)
+				err = tx.Create(&raw).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
 	if err != nil {
 		return &domain.Error{
 			Message:    fmt.Sprintf("DB error: %s", err.Error()),
-			StatusCode: http.StatusNotFound,
+			StatusCode: http.StatusBadRequest,
 			Err:        errors.New(err.Error()),
 		}
 	}
 
-	_ = utils.Convert(&record, &raw)
-	err = r.db.WithContext(ctx).Updates(&raw).Error
+	return nil
+}
+
+func (r *recordRepo) ReplaceRRsets(ctx context.Context, deleted []*domain.Record, records []*domain.Record) error {
+	err := r.db.WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			return replaceRRsets(tx, deleted, records)
+		},
+	)
 	if err != nil {
 		return &domain.Error{
 			Message:    fmt.Sprintf("DB error: %s", err.Error()),
@@ -154,3 +272,27 @@ func (r *recordRepo) Delete(ctx context.Context, name string, rrType uint16, cla
 func NewRecordsRepo(injector *do.Injector) (domain.RecordRepo, error) {
 	return &recordRepo{do.MustInvoke[*gorm.DB](injector)}, nil
 }
+
+// replaceRRsets deletes the RRsets which the deleted records belong to and creates the records in the
+// transaction
+func replaceRRsets(tx *gorm.DB, deleted []*domain.Record, records []*domain.Record) error {
+	for _, record := range deleted {
+		err := tx.Unscoped().
+			Where("name=? AND rr_type=? AND class=?", record.Name, record.RrType, record.Class).
+			Delete(&models.Record{}).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, record := range records {
+		var raw models.Record
+		_ = utils.Convert(&record, &raw)
+		err := tx.Create(&raw).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"github.com/samber/do"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/driver/sqlite"
@@ -44,6 +45,7 @@ func (t *recordRepoTestSuite) SetupSuite() {
 			Name:   "test.com.",
 			RrType: 1,
 			Class:  1,
+			Rdata:  "1.1.1.1",
 			Record: "test.com.\t1440\tIN\tA\t1.1.1.1",
 		},
 	)
@@ -65,22 +67,47 @@ func (t *recordRepoTestSuite) TestCreate() {
 					Name:   "test.com.",
 					RrType: 1,
 					Class:  1,
-					Record: "test.com.\t1440\tIN\tA\t1.1.1.1",
+					Rdata:  "3.3.3.3",
+					Record: "test.com.\t1440\tIN\tA\t3.3.3.3",
 				},
 			)
 			t.Nil(err)
 		},
 	)
+
+	t.Run(
+		"duplicated_error", func() {
+			err := t.repo.Create(
+				context.Background(), &domain.Record{
+					Name:   "test.com.",
+					RrType: 1,
+					Class:  1,
+					Rdata:  "1.1.1.1",
+					Record: "test.com.\t1440\tIN\tA\t1.1.1.1",
+				},
+			)
+			t.NotNil(err)
+		},
+	)
 }
 
-func (t *recordRepoTestSuite) TestGet() {
+func (t *recordRepoTestSuite) TestGetRRset() {
 	t.Run(
 		"success", func() {
-			record, err := t.repo.Get(context.Background(), "test.com.", 1, 1)
-			t.Equal("test.com.", record.Name)
-			t.Equal(uint16(1), record.RrType)
-			t.Equal(uint16(1), record.Class)
+			records, err := t.repo.GetRRset(context.Background(), "test.com.", 1, 1)
 			t.Nil(err)
+			t.NotEmpty(records)
+			t.Equal("test.com.", records[0].Name)
+			t.Equal(uint16(1), records[0].RrType)
+			t.Equal(uint16(1), records[0].Class)
+		},
+	)
+
+	t.Run(
+		"not_existed", func() {
+			records, err := t.repo.GetRRset(context.Background(), "www.test.com.", 1, 1)
+			t.Nil(err)
+			t.Empty(records)
 		},
 	)
 }
@@ -98,18 +125,204 @@ func (t *recordRepoTestSuite) TestList() {
 	)
 }
 
-func (t *recordRepoTestSuite) TestUpdate() {
+func (t *recordRepoTestSuite) TestListInBatches() {
+	var records []*domain.Record
+	for _, name := range []string{"batch.com.", "a.batch.com.", "B.Batch.com.", "notbatch.com."} {
+		record := &domain.Record{
+			Name:   name,
+			RrType: 1,
+			Class:  1,
+			Rdata:  "1.1.1.1",
+			Record: name + "\t1440\tIN\tA\t1.1.1.1",
+		}
+		t.Nil(t.repo.Create(context.Background(), record))
+		records = append(records, record)
+	}
+	defer func() {
+		_ = t.repo.ReplaceRRsets(context.Background(), records, nil)
+	}()
+
 	t.Run(
 		"success", func() {
-			err := t.repo.Update(
-				context.Background(), &domain.Record{
-					Name:   "test.com.",
+			var batches [][]string
+			err := t.repo.ListInBatches(
+				context.Background(), "BATCH.com.", 2, func(records []*domain.Record) error {
+					var names []string
+					for _, record := range records {
+						names = append(names, record.Name)
+					}
+					batches = append(batches, names)
+					return nil
+				},
+			)
+			t.Nil(err)
+			t.Equal([][]string{{"batch.com.", "a.batch.com."}, {"B.Batch.com."}}, batches)
+		},
+	)
+
+	t.Run(
+		"fn_error", func() {
+			var calls int
+			err := t.repo.ListInBatches(
+				context.Background(), "", 1, func(records []*domain.Record) error {
+					calls++
+					return errors.New("test-error")
+				},
+			)
+			t.NotNil(err)
+			t.Equal("test-error", err.Error())
+			t.Equal(1, calls)
+		},
+	)
+}
+
+func (t *recordRepoTestSuite) TestListByName() {
+	t.Run(
+		"success", func() {
+			records, err := t.repo.ListByName(context.Background(), "test.com.", 1)
+			t.Nil(err)
+			t.NotEmpty(records)
+			t.Equal("test.com.", records[0].Name)
+		},
+	)
+
+	t.Run(
+		"not_existed", func() {
+			records, err := t.repo.ListByName(context.Background(), "www.test.com.", 1)
+			t.Nil(err)
+			t.Empty(records)
+		},
+	)
+}
+
+func (t *recordRepoTestSuite) TestExistName() {
+	t.Run(
+		"success_owner", func() {
+			existed, err := t.repo.ExistName(context.Background(), "test.com.")
+			t.Nil(err)
+			t.True(existed)
+		},
+	)
+
+	t.Run(
+		"success_empty_non_terminal", func() {
+			existed, err := t.repo.ExistName(context.Background(), "com.")
+			t.Nil(err)
+			t.True(existed)
+		},
+	)
+
+	t.Run(
+		"not_existed", func() {
+			existed, err := t.repo.ExistName(context.Background(), "www.test.com.")
+			t.Nil(err)
+			t.False(existed)
+		},
+	)
+}
+
+func (t *recordRepoTestSuite) TestReplaceRRset() {
+	t.Run(
+		"success", func() {
+			records := []*domain.Record{
+				{
+					Name:   "rrset.test.com.",
 					RrType: 1,
 					Class:  1,
-					Record: "test.com.\t1440\tIN\tA\t2.2.2.2",
+					Rdata:  "1.1.1.1",
+					Record: "rrset.test.com.\t1440\tIN\tA\t1.1.1.1",
 				},
+				{
+					Name:   "rrset.test.com.",
+					RrType: 1,
+					Class:  1,
+					Rdata:  "2.2.2.2",
+					Record: "rrset.test.com.\t1440\tIN\tA\t2.2.2.2",
+				},
+			}
+			err := t.repo.ReplaceRRset(context.Background(), "rrset.test.com.", 1, 1, records)
+			t.Nil(err)
+
+			err = t.repo.ReplaceRRset(context.Background(), "rrset.test.com.", 1, 1, records[1:])
+			t.Nil(err)
+
+			rrset, err := t.repo.GetRRset(context.Background(), "rrset.test.com.", 1, 1)
+			t.Nil(err)
+			t.Len(rrset, 1)
+			t.Equal("2.2.2.2", rrset[0].Rdata)
+		},
+	)
+
+	t.Run(
+		"duplicated_error", func() {
+			record := &domain.Record{
+				Name:   "rrset.test.com.",
+				RrType: 1,
+				Class:  1,
+				Rdata:  "3.3.3.3",
+				Record: "rrset.test.com.\t1440\tIN\tA\t3.3.3.3",
+			}
+			err := t.repo.ReplaceRRset(
+				context.Background(), "rrset.test.com.", 1, 1, []*domain.Record{record, record},
+			)
+			t.NotNil(err)
+
+			// the RRset is kept when the transaction is rolled back
+			rrset, err := t.repo.GetRRset(context.Background(), "rrset.test.com.", 1, 1)
+			t.Nil(err)
+			t.Len(rrset, 1)
+		},
+	)
+}
+
+func (t *recordRepoTestSuite) TestReplaceRRsets() {
+	stale := &domain.Record{
+		Name:   "stale.import.com.",
+		RrType: 1,
+		Class:  1,
+		Rdata:  "1.1.1.1",
+		Record: "stale.import.com.\t1440\tIN\tA\t1.1.1.1",
+	}
+	record := &domain.Record{
+		Name:   "www.import.com.",
+		RrType: 1,
+		Class:  1,
+		Rdata:  "2.2.2.2",
+		Record: "www.import.com.\t1440\tIN\tA\t2.2.2.2",
+	}
+
+	t.Run(
+		"success", func() {
+			t.Nil(t.repo.Create(context.Background(), stale))
+
+			err := t.repo.ReplaceRRsets(
+				context.Background(), []*domain.Record{stale, record}, []*domain.Record{record},
 			)
 			t.Nil(err)
+
+			rrset, err := t.repo.GetRRset(context.Background(), "stale.import.com.", 1, 1)
+			t.Nil(err)
+			t.Len(rrset, 0)
+			rrset, err = t.repo.GetRRset(context.Background(), "www.import.com.", 1, 1)
+			t.Nil(err)
+			t.Len(rrset, 1)
+		},
+	)
+
+	t.Run(
+		"duplicated_error", func() {
+			err := t.repo.ReplaceRRsets(
+				context.Background(), []*domain.Record{record}, []*domain.Record{stale, record, record},
+			)
+			t.NotNil(err)
+
+			// nothing is changed when the transaction is rolled back
+			rrset, err := t.repo.GetRRset(context.Background(), "stale.import.com.", 1, 1)
+			t.Nil(err)
+			t.Len(rrset, 0)
+			rrset, err = t.repo.GetRRset(context.Background(), "www.import.com.", 1, 1)
+			t.Nil(err)
+			t.Len(rrset, 1)
 		},
 	)
 }
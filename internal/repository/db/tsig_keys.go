@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/repository/db/models"
+
+	"github.com/pkg/errors"
+	"github.com/samber/do"
+	"gorm.io/gorm"
+)
+
+type tsigKeyRepo struct {
+	db *gorm.DB
+}
+
+func (t *tsigKeyRepo) Create(ctx context.Context, key *domain.TsigKey) error {
+	err := t.db.WithContext(ctx).Create(t.toModel(key)).Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (t *tsigKeyRepo) Get(ctx context.Context, name string) (*domain.TsigKey, error) {
+	var (
+		raw models.TsigKey
+		err error
+	)
+
+	err = t.db.WithContext(ctx).
+		Where("name=?", name).
+		First(&raw).
+		Error
+	if err != nil {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return t.toDomain(&raw), nil
+}
+
+func (t *tsigKeyRepo) List(ctx context.Context) ([]*domain.TsigKey, error) {
+	var (
+		raws []models.TsigKey
+		keys []*domain.TsigKey
+		err  error
+	)
+
+	err = t.db.WithContext(ctx).Order("name").Find(&raws).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range raws {
+		keys = append(keys, t.toDomain(&raws[i]))
+	}
+
+	return keys, nil
+}
+
+func (t *tsigKeyRepo) Update(ctx context.Context, key *domain.TsigKey) error {
+	var (
+		raw models.TsigKey
+		err error
+	)
+
+	err = t.db.WithContext(ctx).
+		Where("name=?", key.Name).
+		First(&raw).
+		Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	updated := t.toModel(key)
+	updated.Model = raw.Model
+	err = t.db.WithContext(ctx).Save(updated).Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (t *tsigKeyRepo) Delete(ctx context.Context, name string) error {
+	var (
+		raw models.TsigKey
+		err error
+	)
+
+	err = t.db.WithContext(ctx).
+		Where("name=?", name).
+		First(&raw).
+		Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	err = t.db.WithContext(ctx).
+		Unscoped().
+		Delete(&raw).
+		Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (t *tsigKeyRepo) toModel(key *domain.TsigKey) *models.TsigKey {
+	return &models.TsigKey{
+		Name:      key.Name,
+		Algorithm: key.Algorithm,
+		Secret:    key.Secret,
+		Zones:     strings.Join(key.Zones, ","),
+	}
+}
+
+func (t *tsigKeyRepo) toDomain(raw *models.TsigKey) *domain.TsigKey {
+	key := &domain.TsigKey{
+		Name:      raw.Name,
+		Algorithm: raw.Algorithm,
+		Secret:    raw.Secret,
+	}
+	if raw.Zones != "" {
+		key.Zones = strings.Split(raw.Zones, ",")
+	}
+	return key
+}
+
+func NewTsigKeyRepo(injector *do.Injector) (domain.TsigKeyRepo, error) {
+	return &tsigKeyRepo{do.MustInvoke[*gorm.DB](injector)}, nil
+}
@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"os"
+	"testing"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	pkgGorm "github.com/cewuandy/go-restful-dns/pkg/gorm"
+)
+
+type tsigKeyRepoTestSuite struct {
+	suite.Suite
+
+	repo domain.TsigKeyRepo
+}
+
+func TestTsigKeyRepo(t *testing.T) {
+	suite.Run(t, &tsigKeyRepoTestSuite{})
+}
+
+func (t *tsigKeyRepoTestSuite) SetupSuite() {
+	injector := do.New()
+	db, err := gorm.Open(
+		sqlite.Open("dns.db"), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		},
+	)
+	t.Nil(err)
+
+	do.ProvideValue[*gorm.DB](injector, db)
+	err = pkgGorm.AutoMigrate(db)
+	t.Nil(err)
+
+	t.repo, _ = NewTsigKeyRepo(injector)
+
+	_ = t.repo.Create(
+		context.Background(), &domain.TsigKey{
+			Name:      "dhcp.",
+			Algorithm: dns.HmacSHA256,
+			Secret:    "c2VjcmV0",
+			Zones:     []string{"test.com.", "2.0.192.in-addr.arpa."},
+		},
+	)
+	_ = t.repo.Create(
+		context.Background(), &domain.TsigKey{
+			Name:      "certbot.",
+			Algorithm: dns.HmacSHA512,
+			Secret:    "b3RoZXI=",
+		},
+	)
+}
+
+func (t *tsigKeyRepoTestSuite) TearDownSuite() {
+	_ = os.Remove("dns.db")
+}
+
+func (t *tsigKeyRepoTestSuite) TestCreate() {
+	t.Run(
+		"duplicated_error", func() {
+			err := t.repo.Create(context.Background(), &domain.TsigKey{Name: "dhcp."})
+			t.NotNil(err)
+			t.Contains(err.Error(), "DB error")
+		},
+	)
+}
+
+func (t *tsigKeyRepoTestSuite) TestGet() {
+	t.Run(
+		"success", func() {
+			key, err := t.repo.Get(context.Background(), "dhcp.")
+			t.Nil(err)
+			t.Equal(
+				&domain.TsigKey{
+					Name:      "dhcp.",
+					Algorithm: dns.HmacSHA256,
+					Secret:    "c2VjcmV0",
+					Zones:     []string{"test.com.", "2.0.192.in-addr.arpa."},
+				}, key,
+			)
+		},
+	)
+
+	t.Run(
+		"success_all_zones", func() {
+			key, err := t.repo.Get(context.Background(), "certbot.")
+			t.Nil(err)
+			t.Nil(key.Zones)
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			key, err := t.repo.Get(context.Background(), "other.")
+			t.Nil(key)
+			t.Contains(err.Error(), "record not found")
+		},
+	)
+}
+
+func (t *tsigKeyRepoTestSuite) TestList() {
+	t.Run(
+		"success", func() {
+			keys, err := t.repo.List(context.Background())
+			t.Nil(err)
+			t.Len(keys, 2)
+			t.Equal("certbot.", keys[0].Name)
+		},
+	)
+}
+
+func (t *tsigKeyRepoTestSuite) TestUpdate() {
+	t.Run(
+		"success", func() {
+			err := t.repo.Create(
+				context.Background(), &domain.TsigKey{
+					Name:      "rotate.",
+					Algorithm: dns.HmacSHA256,
+					Secret:    "c2VjcmV0",
+					Zones:     []string{"test.com."},
+				},
+			)
+			t.Nil(err)
+			defer func() { _ = t.repo.Delete(context.Background(), "rotate.") }()
+
+			err = t.repo.Update(
+				context.Background(), &domain.TsigKey{
+					Name:      "rotate.",
+					Algorithm: dns.HmacSHA512,
+					Secret:    "b3RoZXI=",
+				},
+			)
+			t.Nil(err)
+
+			key, _ := t.repo.Get(context.Background(), "rotate.")
+			t.Equal(&domain.TsigKey{Name: "rotate.", Algorithm: dns.HmacSHA512, Secret: "b3RoZXI="}, key)
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			err := t.repo.Update(context.Background(), &domain.TsigKey{Name: "other."})
+			t.NotNil(err)
+		},
+	)
+}
+
+func (t *tsigKeyRepoTestSuite) TestDelete() {
+	t.Run(
+		"success", func() {
+			err := t.repo.Create(context.Background(), &domain.TsigKey{Name: "delete."})
+			t.Nil(err)
+			err = t.repo.Delete(context.Background(), "delete.")
+			t.Nil(err)
+
+			_, err = t.repo.Get(context.Background(), "delete.")
+			t.NotNil(err)
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			err := t.repo.Delete(context.Background(), "other.")
+			t.NotNil(err)
+			t.Contains(err.Error(), "record not found")
+		},
+	)
+}
@@ -0,0 +1,246 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/repository/db/models"
+
+	"github.com/pkg/errors"
+	"github.com/samber/do"
+	"gorm.io/gorm"
+)
+
+type zoneRepo struct {
+	db *gorm.DB
+}
+
+func (z *zoneRepo) Create(ctx context.Context, zone *domain.Zone) error {
+	raw := z.toModel(zone)
+	err := z.db.WithContext(ctx).Create(raw).Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (z *zoneRepo) Get(ctx context.Context, name string) (*domain.Zone, error) {
+	var (
+		raw models.Zone
+		err error
+	)
+
+	err = z.db.WithContext(ctx).
+		Where("name=?", name).
+		First(&raw).
+		Error
+	if err != nil {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return z.toDomain(&raw), nil
+}
+
+func (z *zoneRepo) GetClosest(ctx context.Context, name string) (*domain.Zone, error) {
+	var (
+		raw models.Zone
+		err error
+	)
+
+	err = z.db.WithContext(ctx).
+		Where("name=? OR name='.' OR substr(?, -length(name)-1)='.' || name", name, name).
+		Order("LENGTH(name) DESC").
+		First(&raw).
+		Error
+	if err != nil {
+		// only a missing zone means the name is outside any zone
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: statusCode,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return z.toDomain(&raw), nil
+}
+
+func (z *zoneRepo) List(ctx context.Context) ([]*domain.Zone, error) {
+	var (
+		raws  []models.Zone
+		zones []*domain.Zone
+		err   error
+	)
+
+	err = z.db.WithContext(ctx).Order("name").Find(&raws).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range raws {
+		zones = append(zones, z.toDomain(&raws[i]))
+	}
+
+	return zones, nil
+}
+
+func (z *zoneRepo) Update(ctx context.Context, zone *domain.Zone) error {
+	var (
+		raw models.Zone
+		err error
+	)
+
+	err = z.db.WithContext(ctx).
+		Where("name=?", zone.Name).
+		First(&raw).
+		Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	updated := z.toModel(zone)
+	updated.Model = raw.Model
+	err = z.db.WithContext(ctx).Save(updated).Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (z *zoneRepo) Import(ctx context.Context, zone *domain.Zone, deleted []*domain.Record,
+	records []*domain.Record) error {
+	err := z.db.WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			var raw models.Zone
+			err := tx.Where("name=?", zone.Name).Limit(1).Find(&raw).Error
+			if err != nil {
+				return err
+			}
+			updated := z.toModel(zone)
+			updated.Model = raw.Model
+			err = tx.Save(updated).Error
+			if err != nil {
+				return err
+			}
+
+			return replaceRRsets(tx, deleted, records)
+		},
+	)
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (z *zoneRepo) Delete(ctx context.Context, name string) error {
+	var (
+		raw models.Zone
+		err error
+	)
+
+	err = z.db.WithContext(ctx).
+		Where("name=?", name).
+		First(&raw).
+		Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	err = z.db.WithContext(ctx).
+		Unscoped().
+		Delete(&raw).
+		Error
+	if err != nil {
+		return &domain.Error{
+			Message:    fmt.Sprintf("DB error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New(err.Error()),
+		}
+	}
+
+	return nil
+}
+
+func (z *zoneRepo) toModel(zone *domain.Zone) *models.Zone {
+	return &models.Zone{
+		Name:        zone.Name,
+		Nameservers: strings.Join(zone.Nameservers, ","),
+		Mbox:        zone.Mbox,
+		Serial:      zone.Serial,
+		Refresh:     zone.Refresh,
+		Retry:       zone.Retry,
+		Expire:      zone.Expire,
+		Minttl:      zone.Minttl,
+		Ttl:         zone.Ttl,
+		Type:        string(zone.Type),
+		Primary:     zone.Primary,
+		TsigKey:     zone.TsigKey,
+		Notify:      strings.Join(zone.Notify, ","),
+		Refreshed:   zone.Refreshed,
+	}
+}
+
+func (z *zoneRepo) toDomain(raw *models.Zone) *domain.Zone {
+	zone := &domain.Zone{
+		Name:      raw.Name,
+		Mbox:      raw.Mbox,
+		Serial:    raw.Serial,
+		Refresh:   raw.Refresh,
+		Retry:     raw.Retry,
+		Expire:    raw.Expire,
+		Minttl:    raw.Minttl,
+		Ttl:       raw.Ttl,
+		Type:      domain.ZoneType(raw.Type),
+		Primary:   raw.Primary,
+		TsigKey:   raw.TsigKey,
+		Refreshed: raw.Refreshed,
+	}
+	// the zones created before the zone types are primary ones
+	if zone.Type == "" {
+		zone.Type = domain.ZonePrimary
+	}
+	if raw.Nameservers != "" {
+		zone.Nameservers = strings.Split(raw.Nameservers, ",")
+	}
+	if raw.Notify != "" {
+		zone.Notify = strings.Split(raw.Notify, ",")
+	}
+	return zone
+}
+
+func NewZoneRepo(injector *do.Injector) (domain.ZoneRepo, error) {
+	return &zoneRepo{do.MustInvoke[*gorm.DB](injector)}, nil
+}
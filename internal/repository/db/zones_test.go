@@ -0,0 +1,268 @@
+package db
+
+import (
+	"context"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	pkgGorm "github.com/cewuandy/go-restful-dns/pkg/gorm"
+)
+
+type zoneRepoTestSuite struct {
+	suite.Suite
+
+	repo       domain.ZoneRepo
+	recordRepo domain.RecordRepo
+}
+
+func TestZoneRepo(t *testing.T) {
+	suite.Run(t, &zoneRepoTestSuite{})
+}
+
+func (t *zoneRepoTestSuite) SetupSuite() {
+	injector := do.New()
+	db, err := gorm.Open(
+		sqlite.Open("dns.db"), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		},
+	)
+	t.Nil(err)
+
+	do.ProvideValue[*gorm.DB](injector, db)
+	err = pkgGorm.AutoMigrate(db)
+	t.Nil(err)
+
+	t.repo, _ = NewZoneRepo(injector)
+	t.recordRepo, _ = NewRecordsRepo(injector)
+
+	for _, name := range []string{"test.com.", "sub.test.com."} {
+		_ = t.repo.Create(
+			context.Background(), &domain.Zone{
+				Name:        name,
+				Nameservers: []string{"ns1.test.com.", "ns2.test.com."},
+				Mbox:        "admin.test.com.",
+				Serial:      1,
+				Ttl:         3600,
+			},
+		)
+	}
+}
+
+func (t *zoneRepoTestSuite) TearDownSuite() {
+	_ = os.Remove("dns.db")
+}
+
+func (t *zoneRepoTestSuite) TestCreate() {
+	t.Run(
+		"duplicated_error", func() {
+			err := t.repo.Create(context.Background(), &domain.Zone{Name: "test.com."})
+			t.NotNil(err)
+			t.Contains(err.Error(), "DB error")
+		},
+	)
+}
+
+func (t *zoneRepoTestSuite) TestGet() {
+	t.Run(
+		"success", func() {
+			zone, err := t.repo.Get(context.Background(), "test.com.")
+			t.Nil(err)
+			t.Equal("test.com.", zone.Name)
+			t.Equal([]string{"ns1.test.com.", "ns2.test.com."}, zone.Nameservers)
+			t.Equal(uint32(3600), zone.Ttl)
+			t.Equal(domain.ZonePrimary, zone.Type)
+		},
+	)
+
+	t.Run(
+		"success_secondary", func() {
+			refreshed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			err := t.repo.Create(
+				context.Background(), &domain.Zone{
+					Name:      "legacy.com.",
+					Type:      domain.ZoneSecondary,
+					Primary:   "192.0.2.1:53",
+					Refreshed: &refreshed,
+				},
+			)
+			t.Nil(err)
+
+			zone, err := t.repo.Get(context.Background(), "legacy.com.")
+			t.Nil(err)
+			t.Equal(domain.ZoneSecondary, zone.Type)
+			t.Equal("192.0.2.1:53", zone.Primary)
+			t.True(refreshed.Equal(*zone.Refreshed))
+			_ = t.repo.Delete(context.Background(), "legacy.com.")
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			zone, err := t.repo.Get(context.Background(), "other.com.")
+			t.Nil(zone)
+			t.Contains(err.Error(), "record not found")
+		},
+	)
+}
+
+func (t *zoneRepoTestSuite) TestGetClosest() {
+	t.Run(
+		"success_apex", func() {
+			zone, err := t.repo.GetClosest(context.Background(), "test.com.")
+			t.Nil(err)
+			t.Equal("test.com.", zone.Name)
+		},
+	)
+
+	t.Run(
+		"success_most_specific", func() {
+			zone, err := t.repo.GetClosest(context.Background(), "www.sub.test.com.")
+			t.Nil(err)
+			t.Equal("sub.test.com.", zone.Name)
+		},
+	)
+
+	t.Run(
+		"label_boundary", func() {
+			zone, err := t.repo.GetClosest(context.Background(), "mytest.com.")
+			t.Nil(zone)
+			t.NotNil(err)
+		},
+	)
+}
+
+func (t *zoneRepoTestSuite) TestList() {
+	t.Run(
+		"success", func() {
+			zones, err := t.repo.List(context.Background())
+			t.Nil(err)
+			t.Len(zones, 2)
+			t.Equal("sub.test.com.", zones[0].Name)
+		},
+	)
+}
+
+func (t *zoneRepoTestSuite) TestUpdate() {
+	t.Run(
+		"success", func() {
+			err := t.repo.Update(
+				context.Background(), &domain.Zone{
+					Name:        "test.com.",
+					Nameservers: []string{"ns3.test.com."},
+					Mbox:        "admin.test.com.",
+					Serial:      2,
+					Ttl:         3600,
+					Notify:      []string{"192.0.2.2:53", "[2001:db8::2]:53"},
+					TsigKey:     "transfer.",
+				},
+			)
+			t.Nil(err)
+
+			zone, _ := t.repo.Get(context.Background(), "test.com.")
+			t.Equal(uint32(2), zone.Serial)
+			t.Equal("transfer.", zone.TsigKey)
+			t.Equal([]string{"ns3.test.com."}, zone.Nameservers)
+			t.Equal([]string{"192.0.2.2:53", "[2001:db8::2]:53"}, zone.Notify)
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			err := t.repo.Update(context.Background(), &domain.Zone{Name: "other.com."})
+			t.NotNil(err)
+		},
+	)
+}
+
+func (t *zoneRepoTestSuite) TestImport() {
+	record := &domain.Record{
+		Name:   "www.import.test.",
+		RrType: 1,
+		Class:  1,
+		Rdata:  "1.1.1.1",
+		Record: "www.import.test.\t3600\tIN\tA\t1.1.1.1",
+	}
+	zone := &domain.Zone{
+		Name:        "import.test.",
+		Nameservers: []string{"ns1.import.test."},
+		Mbox:        "admin.import.test.",
+		Serial:      1,
+		Ttl:         3600,
+	}
+
+	t.Run(
+		"create_success", func() {
+			err := t.repo.Import(context.Background(), zone, []*domain.Record{record}, []*domain.Record{record})
+			t.Nil(err)
+
+			imported, err := t.repo.Get(context.Background(), "import.test.")
+			t.Nil(err)
+			t.Equal(uint32(1), imported.Serial)
+			rrset, err := t.recordRepo.GetRRset(context.Background(), "www.import.test.", 1, 1)
+			t.Nil(err)
+			t.Len(rrset, 1)
+		},
+	)
+
+	t.Run(
+		"update_success", func() {
+			zone.Serial = 2
+			err := t.repo.Import(context.Background(), zone, []*domain.Record{record}, []*domain.Record{record})
+			t.Nil(err)
+
+			imported, err := t.repo.Get(context.Background(), "import.test.")
+			t.Nil(err)
+			t.Equal(uint32(2), imported.Serial)
+			zones, err := t.repo.List(context.Background())
+			t.Nil(err)
+			t.Len(zones, 3)
+		},
+	)
+
+	t.Run(
+		"duplicated_error", func() {
+			zone.Serial = 3
+			err := t.repo.Import(
+				context.Background(), zone, []*domain.Record{}, []*domain.Record{record},
+			)
+			t.NotNil(err)
+			t.Contains(err.Error(), "DB error")
+
+			// neither the zone nor its records are changed when the transaction is rolled back
+			imported, err := t.repo.Get(context.Background(), "import.test.")
+			t.Nil(err)
+			t.Equal(uint32(2), imported.Serial)
+			rrset, err := t.recordRepo.GetRRset(context.Background(), "www.import.test.", 1, 1)
+			t.Nil(err)
+			t.Len(rrset, 1)
+		},
+	)
+
+	_ = t.repo.Delete(context.Background(), "import.test.")
+	_ = t.recordRepo.Delete(context.Background(), "www.import.test.", 1, 1)
+}
+
+func (t *zoneRepoTestSuite) TestDelete() {
+	t.Run(
+		"success", func() {
+			err := t.repo.Create(context.Background(), &domain.Zone{Name: "delete.test.com."})
+			t.Nil(err)
+			err = t.repo.Delete(context.Background(), "delete.test.com.")
+			t.Nil(err)
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			err := t.repo.Delete(context.Background(), "delete.test.com.")
+			t.NotNil(err)
+		},
+	)
+}
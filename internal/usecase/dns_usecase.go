@@ -3,8 +3,12 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"net"
 	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cewuandy/go-restful-dns/internal/domain"
@@ -13,19 +17,135 @@ import (
 	"github.com/samber/do"
 )
 
+// maxCNAMEChain bounds the length of a CNAME chain which is followed
+const maxCNAMEChain = 8
+
+// the address families of the client subnets (RFC 7871 6)
+const (
+	ecsIPv4 = 1
+	ecsIPv6 = 2
+)
+
 type dnsUseCase struct {
-	redisRepo domain.RedisRepo
-	upstreams []string
+	redisRepo  domain.RedisRepo
+	recordRepo domain.RecordRepo
+	zoneRepo   domain.ZoneRepo
+
+	// forwardingRuleUseCase picks the upstream forwarders by the suffixes of the questions
+	forwardingRuleUseCase domain.ForwardingRuleUseCase
+
+	// udpSize is the EDNS0 buffer size advertised to the upstream forwarders
+	udpSize uint16
+
+	// ecsPrefixes are the longest prefixes of the client subnets by their address families, it's nil
+	// when no client subnet is forwarded (RFC 7871)
+	ecsPrefixes map[uint16]uint8
+
+	// validateUseCase is nil when the upstream answers aren't validated
+	validateUseCase domain.ValidateUseCase
+}
+
+func (d *dnsUseCase) QueryAuthoritative(ctx context.Context, req *dns.Msg) (resp *dns.Msg, err error) {
+	resp, err = d.queryAuthoritative(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	return d.chaseCNAME(ctx, req, resp)
+}
+
+func (d *dnsUseCase) queryAuthoritative(ctx context.Context, req *dns.Msg) (resp *dns.Msg, err error) {
+	var (
+		soa    *dns.SOA
+		rrMap  map[string]string
+		exists bool
+	)
+
+	q := req.Question[0]
+	soa, err = d.findZoneSOA(ctx, q.Name)
+	if err != nil || soa == nil {
+		return nil, err
+	}
+
+	resp = d.initRespMsg(req, resp)
+	resp.Authoritative = true
+
+	rrMap, err = d.redisRepo.HGetAll(ctx, q.String())
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range rrMap {
+		if !strings.HasPrefix(k, string(domain.Answer)) {
+			continue
+		}
+		rr, _ := dns.NewRR(v)
+		if rr != nil {
+			resp.Answer = append(resp.Answer, rr)
+		}
+	}
+	if len(resp.Answer) > 0 {
+		return resp, nil
+	}
+
+	exists = q.Name == soa.Hdr.Name
+	if !exists {
+		exists, err = d.recordRepo.ExistName(ctx, q.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !exists {
+		var source string
+		source, err = d.wildcardSource(ctx, q.Name, soa.Hdr.Name)
+		if err != nil {
+			return nil, err
+		}
+		if source != "" {
+			resp.Answer, err = d.synthesize(ctx, source, q)
+			if err != nil {
+				return nil, err
+			}
+			if len(resp.Answer) > 0 {
+				return resp, nil
+			}
+			// the wildcard only owns other types, which is NODATA (RFC 4592 2.2.1)
+			exists = true
+		}
+	}
+	if !exists {
+		resp.Rcode = dns.RcodeNameError
+	}
+
+	// the TTL of a negative answer is bounded by the SOA minimum (RFC 2308)
+	soa.Hdr.Ttl = min(soa.Hdr.Ttl, soa.Minttl)
+	resp.Ns = append(resp.Ns, soa)
+	return resp, nil
 }
 
 func (d *dnsUseCase) QueryRedisCache(ctx context.Context, req *dns.Msg) (resp *dns.Msg, err error) {
+	var scope uint8
+
+	resp, scope, err = d.queryRedisCache(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err = d.chaseCNAME(ctx, req, resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Answer) > 0 || len(resp.Ns) > 0 {
+		d.echoSubnet(req, resp, scope)
+	}
+	return d.filterDnssec(req, resp), nil
+}
+
+func (d *dnsUseCase) queryRedisCache(ctx context.Context, req *dns.Msg) (resp *dns.Msg, scope uint8, err error) {
 	var rrMap map[string]string
 
 	resp = d.initRespMsg(req, resp)
 	q := req.Question[0]
-	rrMap, err = d.redisRepo.HGetAll(ctx, q.String())
+	rrMap, scope, err = d.getCache(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	for k, v := range rrMap {
@@ -36,6 +156,15 @@ func (d *dnsUseCase) QueryRedisCache(ctx context.Context, req *dns.Msg) (resp *d
 			extraPattern  = regexp.MustCompile(fmt.Sprintf("%s-*", domain.Extra))
 		)
 
+		if k == domain.RcodeField {
+			resp.Rcode, _ = strconv.Atoi(v)
+			continue
+		}
+		if k == domain.AuthenticatedField {
+			resp.AuthenticatedData = true
+			continue
+		}
+
 		rr, _ = dns.NewRR(v)
 		switch {
 		case answerPattern.Match([]byte(k)):
@@ -47,26 +176,423 @@ func (d *dnsUseCase) QueryRedisCache(ctx context.Context, req *dns.Msg) (resp *d
 		}
 	}
 
-	return resp, nil
+	if len(rrMap) == 0 {
+		resp.Answer, err = d.matchWildcard(ctx, q)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return resp, scope, nil
+}
+
+// getCache returns the cached answer of the question and its scope, the answers cached for the
+// subnet of the client go before the one cached for every subnet
+func (d *dnsUseCase) getCache(ctx context.Context, req *dns.Msg) (map[string]string, uint8, error) {
+	q := req.Question[0]
+	if subnet := d.clientSubnet(ctx, req); subnet != nil && subnet.SourceNetmask > 0 {
+		scopes, err := d.redisRepo.HGetAll(ctx, scopesKey(q))
+		if err != nil {
+			return nil, 0, err
+		}
+
+		var prefixes []int
+		for field := range scopes {
+			prefix, err := strconv.Atoi(field)
+			if err == nil && prefix <= int(subnet.SourceNetmask) {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(prefixes)))
+		for _, prefix := range prefixes {
+			rrMap, err := d.redisRepo.HGetAll(ctx, subnetKey(q, subnet, uint8(prefix)))
+			if err != nil {
+				return nil, 0, err
+			}
+			if len(rrMap) > 0 {
+				return rrMap, uint8(prefix), nil
+			}
+		}
+	}
+
+	rrMap, err := d.redisRepo.HGetAll(ctx, q.String())
+	return rrMap, 0, err
 }
 
 func (d *dnsUseCase) QueryUpstream(ctx context.Context, req *dns.Msg) (resp *dns.Msg, err error) {
+	q := req.Question[0]
+	if q.Qclass != dns.ClassINET {
+		return nil, domain.Error{
+			Message: fmt.Sprintf("refuse to forward %s query of class %s", q.Name, dns.Class(q.Qclass)),
+			Err:     domain.ErrRefused,
+		}
+	}
+
 	resp = d.initRespMsg(req, resp)
-	client := &dns.Client{Net: "udp", DialTimeout: time.Second}
+	subnet := d.clientSubnet(ctx, req)
+	forwarded := d.forwardMsg(req, subnet)
+	resp, err = d.forwardingRuleUseCase.Forward(
+		ctx, forwarded, func(resp *dns.Msg) bool {
+			// NXDOMAIN and NODATA are authoritative answers, every other rcode means the
+			// forwarder cannot answer and the next one should be tried
+			_, ok := d.subnetScope(subnet, resp)
+			return ok && (resp.Rcode == dns.RcodeSuccess || resp.Rcode == dns.RcodeNameError)
+		},
+	)
+	if err != nil {
+		return nil, domain.Error{
+			Message: fmt.Sprintf("cannot get %s from upstream forwarder", q.Name),
+			Err:     domain.ErrNoUpstream,
+		}
+	}
+	scope, _ := d.subnetScope(subnet, resp)
+	resp.Extra = removeOpt(resp.Extra)
 
-	for _, server := range d.upstreams {
-		resp, _, err = client.Exchange(req, server)
-		if err != nil || (resp.Answer == nil && resp.Ns == nil && resp.Extra == nil) {
+	if d.validateUseCase != nil {
+		var secure bool
+		secure, err = d.validateUseCase.Validate(ctx, resp)
+		// the bogus answers are never cached, and only returned when the client disables the checking
+		// (RFC 4035 3.2.2)
+		if err != nil && !req.CheckingDisabled {
+			return nil, err
+		}
+		resp.CheckingDisabled = req.CheckingDisabled
+		if err != nil {
+			return d.filterDnssec(req, resp), nil
+		}
+		resp.AuthenticatedData = secure
+	}
+
+	err = d.cacheAnswer(ctx, resp, subnet, scope)
+	d.echoSubnet(req, resp, scope)
+	return d.filterDnssec(req, resp), err
+}
+
+// forwardMsg copies the question for the upstream forwarders, the OPT and the TSIG of the client are
+// hop by hop (RFC 6891 6.1.1) so only its DO bit is kept, with the client subnet when it's given. The
+// signatures are always asked for when validating, and the bogus answers are returned to be validated
+// here.
+func (d *dnsUseCase) forwardMsg(req *dns.Msg, subnet *dns.EDNS0_SUBNET) *dns.Msg {
+	forwarded := req.Copy()
+	forwarded.Extra = nil
+
+	dnssecOK := false
+	if opt := req.IsEdns0(); opt != nil {
+		dnssecOK = opt.Do()
+	}
+	if d.validateUseCase != nil {
+		forwarded.CheckingDisabled = true
+		dnssecOK = true
+	}
+	forwarded.SetEdns0(d.udpSize, dnssecOK)
+	if subnet != nil {
+		forwarded.IsEdns0().Option = append(forwarded.IsEdns0().Option, subnet)
+	}
+	return forwarded
+}
+
+// clientSubnet returns the subnet of the client which is forwarded, which is the subnet in the question
+// shortened to the longest prefix (RFC 7871 7.1.1), or the subnet of the client address when it's
+// public. It's nil when no subnet is forwarded.
+func (d *dnsUseCase) clientSubnet(ctx context.Context, req *dns.Msg) *dns.EDNS0_SUBNET {
+	if d.ecsPrefixes == nil {
+		return nil
+	}
+	if subnet := findSubnet(req); subnet != nil {
+		prefix, ok := d.ecsPrefixes[subnet.Family]
+		if !ok {
+			return nil
+		}
+		return newSubnet(subnet.Family, subnet.Address, min(subnet.SourceNetmask, prefix))
+	}
+
+	addr := domain.ClientAddr(ctx)
+	if addr == nil {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return nil
+	}
+	if ip.To4() != nil {
+		return newSubnet(ecsIPv4, ip, d.ecsPrefixes[ecsIPv4])
+	}
+	return newSubnet(ecsIPv6, ip, d.ecsPrefixes[ecsIPv6])
+}
+
+// subnetScope returns the scope of the answer for the forwarded subnet, the answer without a subnet is
+// for every subnet and the one for another subnet is discarded (RFC 7871 7.3)
+func (d *dnsUseCase) subnetScope(subnet *dns.EDNS0_SUBNET, resp *dns.Msg) (uint8, bool) {
+	answered := findSubnet(resp)
+	if subnet == nil || answered == nil {
+		return 0, true
+	}
+	if answered.Family != subnet.Family || answered.SourceNetmask != subnet.SourceNetmask ||
+		!answered.Address.Equal(subnet.Address) {
+		return 0, false
+	}
+	return min(answered.SourceScope, subnet.SourceNetmask), true
+}
+
+// echoSubnet answers the subnet of the question with the scope of the answer (RFC 7871 7.2.1)
+func (d *dnsUseCase) echoSubnet(req *dns.Msg, resp *dns.Msg, scope uint8) {
+	subnet := findSubnet(req)
+	if d.ecsPrefixes == nil || subnet == nil {
+		return
+	}
+	resp.Extra = append(
+		resp.Extra, &dns.OPT{
+			Hdr: dns.RR_Header{Name: ".", Rrtype: dns.TypeOPT},
+			Option: []dns.EDNS0{
+				&dns.EDNS0_SUBNET{
+					Code:          dns.EDNS0SUBNET,
+					Family:        subnet.Family,
+					SourceNetmask: subnet.SourceNetmask,
+					SourceScope:   scope,
+					Address:       subnet.Address,
+				},
+			},
+		},
+	)
+}
+
+// chaseCNAME follows the CNAME chain starting at the question name, local CNAME records and
+// the records of their targets are appended to the answer, out of zone targets are resolved by
+// the upstream forwarders. Chains which are already complete in the answer, e.g. the ones cached
+// from upstream, are only walked through.
+func (d *dnsUseCase) chaseCNAME(ctx context.Context, req *dns.Msg, resp *dns.Msg) (*dns.Msg, error) {
+	q := req.Question[0]
+	if q.Qtype == dns.TypeCNAME || q.Qtype == dns.TypeANY {
+		return resp, nil
+	}
+
+	name := q.Name
+	visited := map[string]bool{strings.ToLower(name): true}
+	for {
+		found, target := d.scanAnswer(resp.Answer, name, q.Qtype)
+		if found {
+			return resp, nil
+		}
+
+		local := false
+		if target == "" {
+			cname, err := d.localCNAME(ctx, name, q.Qclass)
+			if err != nil {
+				return nil, err
+			}
+			if cname == nil {
+				return resp, nil
+			}
+			resp.Answer = append(resp.Answer, cname)
+			resp.Rcode = dns.RcodeSuccess
+			resp.Ns = nil
+			target = cname.Target
+			local = true
+		}
+
+		if visited[strings.ToLower(target)] || len(visited) > maxCNAMEChain {
+			return nil, domain.Error{Message: fmt.Sprintf("CNAME loop detected at %s", target)}
+		}
+		visited[strings.ToLower(target)] = true
+		name = target
+		if !local {
 			continue
 		}
 
-		if resp.Answer != nil || resp.Ns != nil || resp.Extra != nil {
-			err = d.cacheRecord(ctx, resp)
-			return resp, err
+		sub, err := d.lookup(ctx, name, q.Qtype, q.Qclass)
+		if err != nil {
+			return nil, err
+		}
+		resp.Answer = append(resp.Answer, sub.Answer...)
+		if len(sub.Answer) == 0 {
+			resp.Rcode = sub.Rcode
+			resp.Ns = sub.Ns
+		}
+	}
+}
+
+// scanAnswer reports whether the answer has records of the type owned by the name, or the
+// target of the CNAME owned by the name
+func (d *dnsUseCase) scanAnswer(answer []dns.RR, name string, qtype uint16) (bool, string) {
+	var target string
+	for _, rr := range answer {
+		if !strings.EqualFold(rr.Header().Name, name) {
+			continue
 		}
+		if rr.Header().Rrtype == qtype {
+			return true, ""
+		}
+		if cname, ok := rr.(*dns.CNAME); ok {
+			target = cname.Target
+		}
+	}
+	return false, target
+}
+
+func (d *dnsUseCase) localCNAME(ctx context.Context, name string, qclass uint16) (*dns.CNAME, error) {
+	q := dns.Question{Name: name, Qtype: dns.TypeCNAME, Qclass: qclass}
+	rrMap, err := d.redisRepo.HGetAll(ctx, q.String())
+	if err != nil {
+		return nil, err
+	}
+	rr, _ := dns.NewRR(rrMap[fmt.Sprintf("%s-%d", domain.Answer, 0)])
+	if rr == nil {
+		var rrs []dns.RR
+		rrs, err = d.matchWildcard(ctx, q)
+		if err != nil || len(rrs) == 0 {
+			return nil, err
+		}
+		rr = rrs[0]
+	}
+	cname, _ := rr.(*dns.CNAME)
+	return cname, nil
+}
+
+// matchWildcard looks for the closest wildcard owning records of the question type and
+// synthesizes them for the name, the wildcard doesn't apply when the name or any name
+// between it and the wildcard exists (RFC 4592 3.3.1)
+func (d *dnsUseCase) matchWildcard(ctx context.Context, q dns.Question) ([]dns.RR, error) {
+	labels := dns.Split(q.Name)
+	for i := 1; i <= len(labels); i++ {
+		source := "*."
+		if i < len(labels) {
+			source += q.Name[labels[i]:]
+		}
+
+		rrs, err := d.synthesize(ctx, source, q)
+		if err != nil {
+			return nil, err
+		}
+		if len(rrs) == 0 {
+			continue
+		}
+
+		exists, err := d.recordRepo.ExistName(ctx, q.Name[labels[i-1]:])
+		if err != nil || exists {
+			return nil, err
+		}
+		return rrs, nil
+	}
+	return nil, nil
+}
+
+// wildcardSource returns the wildcard at the closest encloser of the name inside the zone,
+// or an empty string when the closest encloser has no wildcard
+func (d *dnsUseCase) wildcardSource(ctx context.Context, name string, apex string) (string, error) {
+	var enclosers []string
+	for _, i := range dns.Split(name)[1:] {
+		enclosers = append(enclosers, name[i:])
+	}
+	enclosers = append(enclosers, ".")
+
+	for _, encloser := range enclosers {
+		exists := encloser == apex
+		if !exists {
+			var err error
+			exists, err = d.recordRepo.ExistName(ctx, encloser)
+			if err != nil {
+				return "", err
+			}
+		}
+		if !exists {
+			continue
+		}
+
+		source := "*." + encloser
+		if encloser == "." {
+			source = "*."
+		}
+		exists, err := d.recordRepo.ExistName(ctx, source)
+		if err != nil || !exists {
+			return "", err
+		}
+		return source, nil
+	}
+	return "", nil
+}
+
+// synthesize copies the records of the wildcard owner with the owner replaced by the name
+func (d *dnsUseCase) synthesize(ctx context.Context, source string, q dns.Question) ([]dns.RR, error) {
+	key := dns.Question{Name: source, Qtype: q.Qtype, Qclass: q.Qclass}
+	rrMap, err := d.redisRepo.HGetAll(ctx, key.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var rrs []dns.RR
+	for k, v := range rrMap {
+		if !strings.HasPrefix(k, string(domain.Answer)) {
+			continue
+		}
+		rr, _ := dns.NewRR(v)
+		if rr == nil {
+			continue
+		}
+		rr.Header().Name = q.Name
+		rrs = append(rrs, rr)
+	}
+	return rrs, nil
+}
+
+// lookup resolves a single hop of a CNAME chain without chasing it any further
+func (d *dnsUseCase) lookup(ctx context.Context, name string, qtype uint16, qclass uint16) (*dns.Msg, error) {
+	req := new(dns.Msg)
+	req.SetQuestion(name, qtype)
+	req.Question[0].Qclass = qclass
+
+	resp, err := d.queryAuthoritative(ctx, req)
+	if err != nil || resp != nil {
+		return resp, err
+	}
+
+	resp, _, err = d.queryRedisCache(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Answer) > 0 || len(resp.Ns) > 0 {
+		return resp, nil
 	}
 
-	return nil, domain.Error{Message: fmt.Sprintf("cannot get %s from upstream forwarder", req.Question[0].Name)}
+	return d.QueryUpstream(ctx, req)
+}
+
+// findZoneSOA returns the SOA of the closest locally hosted zone from the zone table, the SOA
+// answers of the upstreams are cached under the same Redis keys as the hosted ones
+func (d *dnsUseCase) findZoneSOA(ctx context.Context, name string) (*dns.SOA, error) {
+	zone, err := getClosestZone(ctx, d.zoneRepo, dns.CanonicalName(name))
+	if err != nil || zone == nil {
+		return nil, err
+	}
+	return zone.SOA(), nil
+}
+
+// filterDnssec removes the DNSSEC records which the client doesn't ask for by the DO bit
+// (RFC 4035 3.2.1), and the AD bit unless the client understands it (RFC 6840 5.8)
+func (d *dnsUseCase) filterDnssec(req *dns.Msg, resp *dns.Msg) *dns.Msg {
+	if opt := req.IsEdns0(); opt != nil && opt.Do() {
+		return resp
+	}
+	resp.AuthenticatedData = resp.AuthenticatedData && req.AuthenticatedData
+
+	q := req.Question[0]
+	filter := func(rrs []dns.RR) []dns.RR {
+		kept := rrs[:0]
+		for _, rr := range rrs {
+			rrtype := rr.Header().Rrtype
+			if rrtype != q.Qtype && (rrtype == dns.TypeRRSIG || rrtype == dns.TypeNSEC || rrtype == dns.TypeNSEC3) {
+				continue
+			}
+			kept = append(kept, rr)
+		}
+		return kept
+	}
+	resp.Answer = filter(resp.Answer)
+	resp.Ns = filter(resp.Ns)
+	return resp
 }
 
 func (d *dnsUseCase) initRespMsg(req *dns.Msg, resp *dns.Msg) *dns.Msg {
@@ -76,8 +602,29 @@ func (d *dnsUseCase) initRespMsg(req *dns.Msg, resp *dns.Msg) *dns.Msg {
 	return resp
 }
 
-func (d *dnsUseCase) cacheRecord(ctx context.Context, resp *dns.Msg) error {
-	q := domain.Question{Question: resp.Question[0]}
+// cacheAnswer caches the answer for every subnet, or for the subnet of its scope which is indexed with
+// the other scopes of the question
+func (d *dnsUseCase) cacheAnswer(ctx context.Context, resp *dns.Msg, subnet *dns.EDNS0_SUBNET, scope uint8) error {
+	q := resp.Question[0]
+	if subnet == nil || scope == 0 {
+		return d.cacheRecord(ctx, q.String(), resp)
+	}
+
+	err := d.cacheRecord(ctx, subnetKey(q, subnet, scope), resp)
+	if err != nil {
+		return err
+	}
+	var ttl uint32
+	for _, rrs := range [][]dns.RR{resp.Answer, resp.Ns, resp.Extra} {
+		for _, rr := range rrs {
+			ttl = max(ttl, rr.Header().Ttl)
+		}
+	}
+	return d.redisRepo.HSet(ctx, scopesKey(q), strconv.Itoa(int(scope)), "1", time.Duration(ttl)*time.Second)
+}
+
+func (d *dnsUseCase) cacheRecord(ctx context.Context, key string, resp *dns.Msg) error {
+	authenticatedTTL := time.Duration(-1)
 	for _, t := range domain.ResponseTypeMap {
 		value := reflect.ValueOf(*resp).FieldByName(string(t)).Interface()
 		rr := value.([]dns.RR)
@@ -88,19 +635,130 @@ func (d *dnsUseCase) cacheRecord(ctx context.Context, resp *dns.Msg) error {
 			}
 			field := fmt.Sprintf("%s-%d", t, i)
 			ttl := time.Duration(rl.Header().Ttl) * time.Second
-			err = d.redisRepo.HSet(ctx, q.String(), field, rl.String(), ttl)
+			err = d.redisRepo.HSet(ctx, key, field, rl.String(), ttl)
 			if err != nil {
 				return err
 			}
+			if authenticatedTTL < 0 || ttl < authenticatedTTL {
+				authenticatedTTL = ttl
+			}
+		}
+	}
+
+	// the validated answer keeps the AD bit until any of its records expires
+	if resp.AuthenticatedData && authenticatedTTL >= 0 {
+		err := d.redisRepo.HSet(ctx, key, domain.AuthenticatedField, "1", authenticatedTTL)
+		if err != nil {
+			return err
+		}
+	}
+
+	if resp.Rcode == dns.RcodeSuccess {
+		return nil
+	}
+
+	// a negative answer can only be cached with the SOA of the zone (RFC 2308)
+	for _, rr := range resp.Ns {
+		soa, ok := rr.(*dns.SOA)
+		if !ok {
+			continue
 		}
+		ttl := time.Duration(min(soa.Hdr.Ttl, soa.Minttl)) * time.Second
+		return d.redisRepo.HSet(ctx, key, domain.RcodeField, strconv.Itoa(resp.Rcode), ttl)
 	}
 
 	return nil
 }
 
 func NewDNSUseCase(injector *do.Injector) (domain.DNSUseCase, error) {
+	env := do.MustInvoke[*domain.Options](injector)
+
+	var ecsPrefixes map[uint16]uint8
+	if env.EcsForwarding {
+		var err error
+		ecsPrefixes, err = parseEcsPrefixes(env.EcsPrefixes)
+		if err != nil {
+			return nil, fmt.Errorf("ecs-prefixes: %w", err)
+		}
+	}
+
 	return &dnsUseCase{
 		do.MustInvoke[domain.RedisRepo](injector),
-		do.MustInvoke[[]string](injector),
+		do.MustInvoke[domain.RecordRepo](injector),
+		do.MustInvoke[domain.ZoneRepo](injector),
+		do.MustInvoke[domain.ForwardingRuleUseCase](injector),
+		uint16(env.EdnsUdpSize),
+		ecsPrefixes,
+		do.MustInvoke[domain.ValidateUseCase](injector),
 	}, nil
 }
+
+// parseEcsPrefixes parses the IPv4 and IPv6 prefixes, e.g. 24,56
+func parseEcsPrefixes(s string) (map[uint16]uint8, error) {
+	items := strings.Split(s, ",")
+	if len(items) != 2 {
+		return nil, fmt.Errorf("%s isn't an IPv4 and an IPv6 prefix", s)
+	}
+	prefixes := make(map[uint16]uint8)
+	for i, family := range []uint16{ecsIPv4, ecsIPv6} {
+		bits := 8 * net.IPv4len
+		if family == ecsIPv6 {
+			bits = 8 * net.IPv6len
+		}
+		prefix, err := strconv.Atoi(strings.TrimSpace(items[i]))
+		if err != nil || prefix < 0 || prefix > bits {
+			return nil, fmt.Errorf("invalid prefix %s", items[i])
+		}
+		prefixes[family] = uint8(prefix)
+	}
+	return prefixes, nil
+}
+
+// findSubnet returns the client subnet of the message
+func findSubnet(msg *dns.Msg) *dns.EDNS0_SUBNET {
+	opt := msg.IsEdns0()
+	if opt == nil {
+		return nil
+	}
+	for _, option := range opt.Option {
+		if subnet, ok := option.(*dns.EDNS0_SUBNET); ok {
+			return subnet
+		}
+	}
+	return nil
+}
+
+// newSubnet returns the subnet of the address shortened to the prefix
+func newSubnet(family uint16, ip net.IP, prefix uint8) *dns.EDNS0_SUBNET {
+	bits := 8 * net.IPv6len
+	if family == ecsIPv4 {
+		ip, bits = ip.To4(), 8*net.IPv4len
+	}
+	return &dns.EDNS0_SUBNET{
+		Code:          dns.EDNS0SUBNET,
+		Family:        family,
+		SourceNetmask: prefix,
+		Address:       ip.Mask(net.CIDRMask(int(prefix), bits)),
+	}
+}
+
+// subnetKey is the cache key of the answers for the subnet of the scope
+func subnetKey(q dns.Question, subnet *dns.EDNS0_SUBNET, scope uint8) string {
+	return fmt.Sprintf("%s %s/%d", q.String(), newSubnet(subnet.Family, subnet.Address, scope).Address, scope)
+}
+
+// scopesKey is the cache key of the scopes which the answers of the question are cached for
+func scopesKey(q dns.Question) string {
+	return fmt.Sprintf("%s ECS", q.String())
+}
+
+// removeOpt removes the OPT of the upstream forwarder, whose options are hop by hop
+func removeOpt(rrs []dns.RR) []dns.RR {
+	kept := rrs[:0]
+	for _, rr := range rrs {
+		if rr.Header().Rrtype != dns.TypeOPT {
+			kept = append(kept, rr)
+		}
+	}
+	return kept
+}
@@ -7,10 +7,14 @@ import (
 	"github.com/samber/do"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"net"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/cewuandy/go-restful-dns/internal/domain"
 	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+	"github.com/cewuandy/go-restful-dns/pkg/forwarder"
 )
 
 type dnsUseCaseTestSuite struct {
@@ -18,7 +22,11 @@ type dnsUseCaseTestSuite struct {
 
 	usecase domain.DNSUseCase
 
-	redisRepo *mocks.RedisRepo
+	redisRepo  *mocks.RedisRepo
+	recordRepo *mocks.RecordRepo
+	zoneRepo   *mocks.ZoneRepo
+
+	upstream *dns.Server
 }
 
 func TestDnsUseCase(t *testing.T) {
@@ -29,11 +37,100 @@ func (t *dnsUseCaseTestSuite) SetupSuite() {
 	injector := do.New()
 	t.redisRepo = &mocks.RedisRepo{}
 	do.ProvideValue[domain.RedisRepo](injector, t.redisRepo)
-	do.ProvideValue[[]string](injector, []string{"1.1.1.1:53"})
+	t.recordRepo = &mocks.RecordRepo{}
+	do.ProvideValue[domain.RecordRepo](injector, t.recordRepo)
+	t.zoneRepo = &mocks.ZoneRepo{}
+	do.ProvideValue[domain.ZoneRepo](injector, t.zoneRepo)
+	t.upstream = t.startFakeUpstream()
+	t.provideUpstreams(injector)
+	do.ProvideValue(injector, &domain.Options{EdnsUdpSize: 1232})
+	do.ProvideValue[domain.ValidateUseCase](injector, nil)
 
 	t.usecase, _ = NewDNSUseCase(injector)
 }
 
+func (t *dnsUseCaseTestSuite) TearDownSuite() {
+	t.Nil(t.upstream.Shutdown())
+}
+
+// provideUpstreams forwards every name to the fake upstream, since no forwarding rule is stored
+func (t *dnsUseCaseTestSuite) provideUpstreams(injector *do.Injector) {
+	forwarders, _ := forwarder.NewPool("", false)
+	upstreams, _ := forwarder.NewGroup(
+		forwarders, []string{t.upstream.PacketConn.LocalAddr().String()}, forwarder.Sequential, 1, 3, time.Minute,
+	)
+	forwardingRuleRepo := &mocks.ForwardingRuleRepo{}
+	forwardingRuleRepo.On("List", mock.Anything).Return(nil, nil)
+	do.ProvideValue(injector, forwarders)
+	do.ProvideValue(injector, upstreams)
+	do.ProvideValue[domain.ForwardingRuleRepo](injector, forwardingRuleRepo)
+	do.ProvideValue[domain.ResolveUseCase](injector, nil)
+	do.Provide(injector, NewForwardingRuleUseCase)
+}
+
+// startFakeUpstream serves google.com. as an existing name, servfail.test. as a
+// broken zone, edns.test. as the EDNS0 of the request, ecs.test. as the client subnet of the
+// request with the scope 16, ecs-mismatch.test. as another subnet and NXDOMAIN for everything else
+func (t *dnsUseCaseTestSuite) startFakeUpstream() *dns.Server {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	t.Nil(err)
+
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn:        pc,
+		NotifyStartedFunc: func() { close(started) },
+		Handler: dns.HandlerFunc(
+			func(w dns.ResponseWriter, req *dns.Msg) {
+				resp := new(dns.Msg)
+				resp.SetReply(req)
+				switch req.Question[0].Name {
+				case "google.com.":
+					rr, _ := dns.NewRR("google.com.\t300\tIN\tA\t142.250.0.1")
+					resp.Answer = append(resp.Answer, rr)
+				case "servfail.test.":
+					resp.Rcode = dns.RcodeServerFailure
+				case "edns.test.":
+					opt := req.IsEdns0()
+					rr, _ := dns.NewRR(
+						fmt.Sprintf(
+							"edns.test.\t300\tIN\tTXT\t\"size=%d do=%t options=%d extra=%d\"",
+							opt.UDPSize(), opt.Do(), len(opt.Option), len(req.Extra),
+						),
+					)
+					resp.Answer = append(resp.Answer, rr)
+					resp.SetEdns0(4096, false)
+					resp.IsEdns0().Option = append(resp.IsEdns0().Option, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE})
+				case "ecs.test.", "ecs-mismatch.test.":
+					forwarded := "none"
+					resp.SetEdns0(1232, false)
+					if subnet := findSubnet(req); subnet != nil {
+						forwarded = fmt.Sprintf("%s/%d", subnet.Address, subnet.SourceNetmask)
+						answered := *subnet
+						answered.SourceScope = min(subnet.SourceNetmask, 16)
+						if req.Question[0].Name == "ecs-mismatch.test." {
+							answered.Address = net.ParseIP("192.0.2.0")
+						}
+						resp.IsEdns0().Option = append(resp.IsEdns0().Option, &answered)
+					}
+					rr, _ := dns.NewRR(fmt.Sprintf("%s\t300\tIN\tTXT\t%s", req.Question[0].Name, forwarded))
+					resp.Answer = append(resp.Answer, rr)
+				default:
+					soa, _ := dns.NewRR("test.\t600\tIN\tSOA\tns.test. admin.test. 1 7200 3600 86400 300")
+					resp.Rcode = dns.RcodeNameError
+					resp.Ns = append(resp.Ns, soa)
+				}
+				_ = w.WriteMsg(resp)
+			},
+		),
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	<-started
+
+	return server
+}
+
 func (t *dnsUseCaseTestSuite) SetupTest() {
 	var (
 		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
@@ -50,6 +147,44 @@ func (t *dnsUseCaseTestSuite) SetupTest() {
 	t.redisRepo.
 		On("HDel", anyContext, anyString).
 		Return(nil)
+	t.setupZones(nil)
+}
+
+// setupZones hosts the zones of the SOA records, the other names are outside any zone
+func (t *dnsUseCaseTestSuite) setupZones(soas []*dns.SOA) {
+	closest := func(name string) *domain.Zone {
+		var zone *domain.Zone
+		for _, soa := range soas {
+			if !dns.IsSubDomain(soa.Hdr.Name, name) || (zone != nil && len(zone.Name) > len(soa.Hdr.Name)) {
+				continue
+			}
+			zone = &domain.Zone{
+				Name:        soa.Hdr.Name,
+				Nameservers: []string{soa.Ns},
+				Mbox:        soa.Mbox,
+				Serial:      soa.Serial,
+				Refresh:     soa.Refresh,
+				Retry:       soa.Retry,
+				Expire:      soa.Expire,
+				Minttl:      soa.Minttl,
+				Ttl:         soa.Hdr.Ttl,
+			}
+		}
+		return zone
+	}
+
+	t.zoneRepo.ExpectedCalls = nil
+	t.zoneRepo.
+		On("GetClosest", mock.Anything, mock.AnythingOfType("string")).
+		Return(
+			func(_ context.Context, name string) *domain.Zone { return closest(name) },
+			func(_ context.Context, name string) error {
+				if closest(name) == nil {
+					return &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound}
+				}
+				return nil
+			},
+		)
 }
 
 func (t *dnsUseCaseTestSuite) SetupErrorTest() {
@@ -57,6 +192,447 @@ func (t *dnsUseCaseTestSuite) SetupErrorTest() {
 	t.redisRepo.ExpectedCalls = nil
 }
 
+func (t *dnsUseCaseTestSuite) SetupZoneTest(rrMaps map[string]map[string]string) {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+	)
+
+	t.redisRepo.ExpectedCalls = nil
+	t.recordRepo.ExpectedCalls = nil
+	// the SOA records of the zones in the cache are hosted in the zone table too
+	var soas []*dns.SOA
+	for key, rrMap := range rrMaps {
+		t.redisRepo.
+			On("HGetAll", anyContext, key).
+			Return(rrMap, nil)
+		rr, _ := dns.NewRR(rrMap["Answer-0"])
+		if soa, ok := rr.(*dns.SOA); ok {
+			soas = append(soas, soa)
+		}
+	}
+	t.setupZones(soas)
+	t.redisRepo.
+		On("HGetAll", anyContext, anyString).
+		Return(map[string]string{}, nil)
+}
+
+func (t *dnsUseCaseTestSuite) TestQueryAuthoritative() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		soaKey     = ";test.com.\tIN\t SOA"
+		soa        = "test.com.\t3600\tIN\tSOA\tns1.test.com. admin.test.com. 2024010100 7200 3600 1209600 300"
+	)
+
+	newReq := func(name string, qtype uint16) *dns.Msg {
+		req := new(dns.Msg)
+		req.SetQuestion(name, qtype)
+		return req
+	}
+
+	t.Run(
+		"not_authoritative", func() {
+			t.SetupZoneTest(nil)
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), newReq("other.org.", dns.TypeA))
+			t.Nil(err)
+			t.Nil(resp)
+		},
+	)
+
+	t.Run(
+		"cached_upstream_soa_not_authoritative", func() {
+			t.SetupZoneTest(nil)
+			// the SOA of com. is cached from an upstream answer like the hosted ones
+			t.redisRepo.ExpectedCalls = nil
+			t.redisRepo.
+				On("HGetAll", anyContext, ";com.\tIN\t SOA").
+				Return(
+					map[string]string{
+						"Answer-0": "com.\t900\tIN\tSOA\ta.gtld-servers.net. nstld.verisign-grs.com. 1 1800 900 604800 86400",
+					}, nil,
+				)
+			t.redisRepo.
+				On("HGetAll", anyContext, anyString).
+				Return(map[string]string{}, nil)
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, mock.AnythingOfType("time.Duration")).
+				Return(nil)
+
+			req := newReq("google.com.", dns.TypeA)
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), req)
+			t.Nil(err)
+			t.Nil(resp)
+			resp, err = t.usecase.QueryUpstream(context.Background(), req)
+			t.Nil(err)
+			t.False(resp.Authoritative)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Equal("142.250.0.1", resp.Answer[0].(*dns.A).A.String())
+		},
+	)
+
+	t.Run(
+		"success_answer", func() {
+			t.SetupZoneTest(
+				map[string]map[string]string{
+					soaKey: {"Answer-0": soa},
+					";www.test.com.\tIN\t A": {
+						"Answer-0": "www.test.com.\t300\tIN\tA\t1.1.1.1",
+						"Ns-0":     "www.test.com.\t300\tIN\tA\t1.1.1.1",
+					},
+				},
+			)
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), newReq("www.test.com.", dns.TypeA))
+			t.Nil(err)
+			t.True(resp.Authoritative)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Len(resp.Answer, 1)
+			t.Empty(resp.Ns)
+		},
+	)
+
+	t.Run(
+		"success_apex_soa", func() {
+			t.SetupZoneTest(map[string]map[string]string{soaKey: {"Answer-0": soa}})
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), newReq("test.com.", dns.TypeSOA))
+			t.Nil(err)
+			t.True(resp.Authoritative)
+			t.Equal(dns.TypeSOA, resp.Answer[0].Header().Rrtype)
+		},
+	)
+
+	t.Run(
+		"nodata", func() {
+			t.SetupZoneTest(map[string]map[string]string{soaKey: {"Answer-0": soa}})
+			t.recordRepo.
+				On("ExistName", anyContext, "www.test.com.").
+				Return(true, nil)
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), newReq("www.test.com.", dns.TypeAAAA))
+			t.Nil(err)
+			t.True(resp.Authoritative)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Empty(resp.Answer)
+			t.Equal(dns.TypeSOA, resp.Ns[0].Header().Rrtype)
+			t.Equal(uint32(300), resp.Ns[0].Header().Ttl)
+		},
+	)
+
+	t.Run(
+		"nxdomain", func() {
+			t.SetupZoneTest(map[string]map[string]string{soaKey: {"Answer-0": soa}})
+			t.recordRepo.
+				On("ExistName", anyContext, anyString).
+				Return(false, nil)
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), newReq("nope.test.com.", dns.TypeA))
+			t.Nil(err)
+			t.True(resp.Authoritative)
+			t.Equal(dns.RcodeNameError, resp.Rcode)
+			t.Equal(dns.TypeSOA, resp.Ns[0].Header().Rrtype)
+		},
+	)
+
+	t.Run(
+		"ExistName_error", func() {
+			t.SetupZoneTest(map[string]map[string]string{soaKey: {"Answer-0": soa}})
+			t.recordRepo.
+				On("ExistName", anyContext, anyString).
+				Return(false, fmt.Errorf("test-error"))
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), newReq("nope.test.com.", dns.TypeA))
+			t.Nil(resp)
+			t.Equal("test-error", err.Error())
+		},
+	)
+
+	t.Run(
+		"HGetAll_error", func() {
+			t.SetupErrorTest()
+			rr, _ := dns.NewRR(soa)
+			t.setupZones([]*dns.SOA{rr.(*dns.SOA)})
+			t.redisRepo.
+				On("HGetAll", anyContext, anyString).
+				Return(nil, fmt.Errorf("test-error"))
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), newReq("www.test.com.", dns.TypeA))
+			t.Nil(resp)
+			t.Equal("test-error", err.Error())
+		},
+	)
+
+	t.Run(
+		"GetClosest_error", func() {
+			t.SetupZoneTest(nil)
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("GetClosest", anyContext, anyString).
+				Return(nil, &domain.Error{Message: "DB error", StatusCode: http.StatusInternalServerError})
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), newReq("www.test.com.", dns.TypeA))
+			t.Nil(resp)
+			t.Equal(http.StatusInternalServerError, err.(*domain.Error).StatusCode)
+		},
+	)
+
+	t.SetupErrorTest()
+	t.SetupTest()
+}
+
+func (t *dnsUseCaseTestSuite) TestChaseCNAME() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		soaKey     = ";test.com.\tIN\t SOA"
+		soa        = "test.com.\t3600\tIN\tSOA\tns1.test.com. admin.test.com. 2024010100 7200 3600 1209600 300"
+	)
+
+	newReq := func(name string, qtype uint16) *dns.Msg {
+		req := new(dns.Msg)
+		req.SetQuestion(name, qtype)
+		return req
+	}
+
+	t.Run(
+		"success_in_zone", func() {
+			t.SetupZoneTest(
+				map[string]map[string]string{
+					soaKey:                       {"Answer-0": soa},
+					";www.test.com.\tIN\t CNAME": {"Answer-0": "www.test.com.\t300\tIN\tCNAME\tweb.test.com."},
+					";web.test.com.\tIN\t A":     {"Answer-0": "web.test.com.\t300\tIN\tA\t1.1.1.1"},
+				},
+			)
+			t.recordRepo.
+				On("ExistName", anyContext, "www.test.com.").
+				Return(true, nil)
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), newReq("www.test.com.", dns.TypeA))
+			t.Nil(err)
+			t.True(resp.Authoritative)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Len(resp.Answer, 2)
+			t.Equal(dns.TypeCNAME, resp.Answer[0].Header().Rrtype)
+			t.Equal("web.test.com.", resp.Answer[1].Header().Name)
+			t.Empty(resp.Ns)
+		},
+	)
+
+	t.Run(
+		"success_cname_query", func() {
+			t.SetupZoneTest(
+				map[string]map[string]string{
+					soaKey:                       {"Answer-0": soa},
+					";www.test.com.\tIN\t CNAME": {"Answer-0": "www.test.com.\t300\tIN\tCNAME\tweb.test.com."},
+				},
+			)
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), newReq("www.test.com.", dns.TypeCNAME))
+			t.Nil(err)
+			t.Len(resp.Answer, 1)
+		},
+	)
+
+	t.Run(
+		"success_target_nxdomain", func() {
+			t.SetupZoneTest(
+				map[string]map[string]string{
+					soaKey:                       {"Answer-0": soa},
+					";www.test.com.\tIN\t CNAME": {"Answer-0": "www.test.com.\t300\tIN\tCNAME\tnope.test.com."},
+				},
+			)
+			t.recordRepo.
+				On("ExistName", anyContext, "www.test.com.").
+				Return(true, nil)
+			t.recordRepo.
+				On("ExistName", anyContext, mock.AnythingOfType("string")).
+				Return(false, nil)
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), newReq("www.test.com.", dns.TypeA))
+			t.Nil(err)
+			t.Equal(dns.RcodeNameError, resp.Rcode)
+			t.Len(resp.Answer, 1)
+			t.Equal(dns.TypeSOA, resp.Ns[0].Header().Rrtype)
+		},
+	)
+
+	t.Run(
+		"success_out_of_zone", func() {
+			t.SetupZoneTest(
+				map[string]map[string]string{
+					";alias.local.\tIN\t CNAME": {"Answer-0": "alias.local.\t300\tIN\tCNAME\tgoogle.com."},
+				},
+			)
+			t.redisRepo.
+				On("HSet", anyContext, mock.AnythingOfType("string"), mock.AnythingOfType("string"),
+					mock.AnythingOfType("string"), mock.AnythingOfType("time.Duration")).
+				Return(nil)
+			resp, err := t.usecase.QueryRedisCache(context.Background(), newReq("alias.local.", dns.TypeA))
+			t.Nil(err)
+			t.Len(resp.Answer, 2)
+			t.Equal("google.com.", resp.Answer[1].Header().Name)
+		},
+	)
+
+	t.Run(
+		"loop_error", func() {
+			t.SetupZoneTest(
+				map[string]map[string]string{
+					soaKey:                     {"Answer-0": soa},
+					";a.test.com.\tIN\t CNAME": {"Answer-0": "a.test.com.\t300\tIN\tCNAME\tb.test.com."},
+					";b.test.com.\tIN\t CNAME": {"Answer-0": "b.test.com.\t300\tIN\tCNAME\ta.test.com."},
+				},
+			)
+			t.recordRepo.
+				On("ExistName", anyContext, mock.AnythingOfType("string")).
+				Return(true, nil)
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), newReq("a.test.com.", dns.TypeA))
+			t.Nil(resp)
+			t.NotNil(err)
+			t.Contains(err.Error(), "CNAME loop detected")
+		},
+	)
+
+	t.SetupErrorTest()
+	t.SetupTest()
+}
+
+func (t *dnsUseCaseTestSuite) TestWildcard() {
+	var (
+		anyContext  = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString   = mock.AnythingOfType("string")
+		wildcardKey = ";*.preview.corp.\tIN\t A"
+		wildcard    = map[string]string{"Answer-0": "*.preview.corp.\t60\tIN\tA\t10.0.0.1"}
+		soaKey      = ";test.com.\tIN\t SOA"
+		soa         = "test.com.\t3600\tIN\tSOA\tns1.test.com. admin.test.com. 2024010100 7200 3600 1209600 300"
+	)
+
+	newReq := func(name string, qtype uint16) *dns.Msg {
+		req := new(dns.Msg)
+		req.SetQuestion(name, qtype)
+		return req
+	}
+
+	t.Run(
+		"success", func() {
+			t.SetupZoneTest(map[string]map[string]string{wildcardKey: wildcard})
+			t.recordRepo.
+				On("ExistName", anyContext, "pr-42.preview.corp.").
+				Return(false, nil)
+			resp, err := t.usecase.QueryRedisCache(context.Background(), newReq("pr-42.preview.corp.", dns.TypeA))
+			t.Nil(err)
+			t.Len(resp.Answer, 1)
+			t.Equal("pr-42.preview.corp.\t60\tIN\tA\t10.0.0.1", resp.Answer[0].String())
+		},
+	)
+
+	t.Run(
+		"success_multiple_labels", func() {
+			t.SetupZoneTest(map[string]map[string]string{wildcardKey: wildcard})
+			t.recordRepo.
+				On("ExistName", anyContext, "b.preview.corp.").
+				Return(false, nil)
+			resp, err := t.usecase.QueryRedisCache(context.Background(), newReq("a.b.preview.corp.", dns.TypeA))
+			t.Nil(err)
+			t.Len(resp.Answer, 1)
+			t.Equal("a.b.preview.corp.", resp.Answer[0].Header().Name)
+		},
+	)
+
+	t.Run(
+		"explicit_name_priority", func() {
+			t.SetupZoneTest(map[string]map[string]string{wildcardKey: wildcard})
+			t.recordRepo.
+				On("ExistName", anyContext, "www.preview.corp.").
+				Return(true, nil)
+			resp, err := t.usecase.QueryRedisCache(context.Background(), newReq("www.preview.corp.", dns.TypeA))
+			t.Nil(err)
+			t.Empty(resp.Answer)
+		},
+	)
+
+	t.Run(
+		"closest_encloser", func() {
+			t.SetupZoneTest(map[string]map[string]string{wildcardKey: wildcard})
+			// b.preview.corp. exists, so *.preview.corp. isn't the source of a.b.preview.corp.
+			t.recordRepo.
+				On("ExistName", anyContext, "b.preview.corp.").
+				Return(true, nil)
+			resp, err := t.usecase.QueryRedisCache(context.Background(), newReq("a.b.preview.corp.", dns.TypeA))
+			t.Nil(err)
+			t.Empty(resp.Answer)
+		},
+	)
+
+	t.Run(
+		"success_authoritative", func() {
+			t.SetupZoneTest(
+				map[string]map[string]string{
+					soaKey:                 {"Answer-0": soa},
+					";*.test.com.\tIN\t A": {"Answer-0": "*.test.com.\t300\tIN\tA\t1.1.1.1"},
+				},
+			)
+			t.recordRepo.
+				On("ExistName", anyContext, "*.test.com.").
+				Return(true, nil)
+			t.recordRepo.
+				On("ExistName", anyContext, anyString).
+				Return(false, nil)
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), newReq("x.test.com.", dns.TypeA))
+			t.Nil(err)
+			t.True(resp.Authoritative)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Equal("x.test.com.\t300\tIN\tA\t1.1.1.1", resp.Answer[0].String())
+		},
+	)
+
+	t.Run(
+		"nodata_authoritative", func() {
+			t.SetupZoneTest(
+				map[string]map[string]string{
+					soaKey:                 {"Answer-0": soa},
+					";*.test.com.\tIN\t A": {"Answer-0": "*.test.com.\t300\tIN\tA\t1.1.1.1"},
+				},
+			)
+			t.recordRepo.
+				On("ExistName", anyContext, "*.test.com.").
+				Return(true, nil)
+			t.recordRepo.
+				On("ExistName", anyContext, anyString).
+				Return(false, nil)
+			resp, err := t.usecase.QueryAuthoritative(context.Background(), newReq("x.test.com.", dns.TypeTXT))
+			t.Nil(err)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Empty(resp.Answer)
+			t.Equal(dns.TypeSOA, resp.Ns[0].Header().Rrtype)
+		},
+	)
+
+	t.Run(
+		"success_cname", func() {
+			t.SetupZoneTest(
+				map[string]map[string]string{
+					";*.preview.corp.\tIN\t CNAME": {"Answer-0": "*.preview.corp.\t60\tIN\tCNAME\tlb.corp."},
+					";lb.corp.\tIN\t A":            {"Answer-0": "lb.corp.\t60\tIN\tA\t10.0.0.2"},
+				},
+			)
+			t.recordRepo.
+				On("ExistName", anyContext, anyString).
+				Return(false, nil)
+			resp, err := t.usecase.QueryRedisCache(context.Background(), newReq("pr-1.preview.corp.", dns.TypeA))
+			t.Nil(err)
+			t.Len(resp.Answer, 2)
+			t.Equal("pr-1.preview.corp.\t60\tIN\tCNAME\tlb.corp.", resp.Answer[0].String())
+			t.Equal("lb.corp.", resp.Answer[1].Header().Name)
+		},
+	)
+
+	t.Run(
+		"ExistName_error", func() {
+			t.SetupZoneTest(map[string]map[string]string{wildcardKey: wildcard})
+			t.recordRepo.
+				On("ExistName", anyContext, anyString).
+				Return(false, fmt.Errorf("test-error"))
+			resp, err := t.usecase.QueryRedisCache(context.Background(), newReq("pr-42.preview.corp.", dns.TypeA))
+			t.Nil(resp)
+			t.Equal("test-error", err.Error())
+		},
+	)
+
+	t.SetupErrorTest()
+	t.SetupTest()
+}
+
 func (t *dnsUseCaseTestSuite) TestQueryRedisCache() {
 	var (
 		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
@@ -85,6 +661,52 @@ func (t *dnsUseCaseTestSuite) TestQueryRedisCache() {
 		},
 	)
 
+	t.Run(
+		"success_authenticated", func() {
+			t.SetupErrorTest()
+			t.redisRepo.
+				On("HGetAll", anyContext, anyString).
+				Return(
+					map[string]string{
+						"Answer-0":                "test.com.\t1440\tIN\tA\t1.1.1.1",
+						"Answer-1":                "test.com.\t1440\tIN\tRRSIG\tA 13 2 1440 20300101000000 20200101000000 12345 test.com. AAAA",
+						domain.AuthenticatedField: "1",
+					}, nil,
+				)
+
+			signed := req.Copy()
+			signed.SetEdns0(dns.DefaultMsgSize, true)
+			resp, err := t.usecase.QueryRedisCache(context.Background(), signed)
+			t.Nil(err)
+			t.True(resp.AuthenticatedData)
+			t.Len(resp.Answer, 2)
+
+			// the signatures and the AD bit are only for the clients which understand them
+			resp, err = t.usecase.QueryRedisCache(context.Background(), req)
+			t.Nil(err)
+			t.False(resp.AuthenticatedData)
+			t.Len(resp.Answer, 1)
+			t.Equal(dns.TypeA, resp.Answer[0].Header().Rrtype)
+		},
+	)
+
+	t.Run(
+		"success_rrset", func() {
+			t.SetupErrorTest()
+			t.redisRepo.
+				On("HGetAll", anyContext, anyString).
+				Return(
+					map[string]string{
+						"Answer-0": "test.com.\t1440\tIN\tA\t1.1.1.1",
+						"Answer-1": "test.com.\t1440\tIN\tA\t2.2.2.2",
+					}, nil,
+				)
+			resp, err := t.usecase.QueryRedisCache(context.Background(), req)
+			t.Nil(err)
+			t.Len(resp.Answer, 2)
+		},
+	)
+
 	t.Run(
 		"success_ns", func() {
 			t.SetupErrorTest()
@@ -117,6 +739,24 @@ func (t *dnsUseCaseTestSuite) TestQueryRedisCache() {
 		},
 	)
 
+	t.Run(
+		"success_rcode", func() {
+			t.SetupErrorTest()
+			t.redisRepo.
+				On("HGetAll", anyContext, anyString).
+				Return(
+					map[string]string{
+						"Ns-0":            "test.com.\t300\tIN\tSOA\tns.test.com. admin.test.com. 1 7200 3600 86400 300",
+						domain.RcodeField: "3",
+					}, nil,
+				)
+			resp, err := t.usecase.QueryRedisCache(context.Background(), req)
+			t.Nil(err)
+			t.Equal(dns.RcodeNameError, resp.Rcode)
+			t.Len(resp.Ns, 1)
+		},
+	)
+
 	t.Run(
 		"HGetAll_error", func() {
 			t.SetupErrorTest()
@@ -159,7 +799,27 @@ func (t *dnsUseCaseTestSuite) TestQueryUpstream() {
 	)
 
 	t.Run(
-		"exchange_error", func() {
+		"edns_hop_by_hop_success", func() {
+			request := new(dns.Msg)
+			request.SetQuestion("edns.test.", dns.TypeTXT)
+			request.SetEdns0(4096, true)
+			request.IsEdns0().Option = append(
+				request.IsEdns0().Option, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "0102030405060708"},
+			)
+
+			resp, err := t.usecase.QueryUpstream(context.Background(), request)
+			t.Nil(err)
+			t.Equal([]string{"size=1232 do=true options=0 extra=1"}, resp.Answer[0].(*dns.TXT).Txt)
+			t.Nil(resp.IsEdns0())
+		},
+	)
+
+	t.Run(
+		"nxdomain", func() {
+			t.SetupErrorTest()
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+				Return(nil)
 			request := &dns.Msg{
 				Question: []dns.Question{
 					{
@@ -170,10 +830,51 @@ func (t *dnsUseCaseTestSuite) TestQueryUpstream() {
 				},
 			}
 
+			resp, err := t.usecase.QueryUpstream(context.Background(), request)
+			t.Nil(err)
+			t.Equal(dns.RcodeNameError, resp.Rcode)
+			t.Equal(dns.TypeSOA, resp.Ns[0].Header().Rrtype)
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";notexisted.test.\tIN\t A", domain.RcodeField, "3",
+				300*time.Second,
+			)
+		},
+	)
+
+	t.Run(
+		"exchange_error", func() {
+			request := &dns.Msg{
+				Question: []dns.Question{
+					{
+						Name:   "servfail.test.",
+						Qtype:  1,
+						Qclass: 1,
+					},
+				},
+			}
+
 			resp, err := t.usecase.QueryUpstream(context.Background(), request)
 			t.Nil(resp)
 			t.NotNil(err)
-			t.Contains(err.Error(), "upstream forwarder error")
+			t.Contains(err.Error(), "cannot get servfail.test. from upstream forwarder")
+		},
+	)
+
+	t.Run(
+		"refused", func() {
+			request := &dns.Msg{
+				Question: []dns.Question{
+					{
+						Name:   "version.bind.",
+						Qtype:  dns.TypeTXT,
+						Qclass: dns.ClassCHAOS,
+					},
+				},
+			}
+
+			resp, err := t.usecase.QueryUpstream(context.Background(), request)
+			t.Nil(resp)
+			t.ErrorIs(err, domain.ErrRefused)
 		},
 	)
 
@@ -190,3 +891,273 @@ func (t *dnsUseCaseTestSuite) TestQueryUpstream() {
 		},
 	)
 }
+
+func (t *dnsUseCaseTestSuite) TestQueryUpstreamValidated() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		anyTime    = mock.AnythingOfType("time.Duration")
+		anyMsg     = mock.AnythingOfType("*dns.Msg")
+	)
+
+	validateUseCase := &mocks.ValidateUseCase{}
+	injector := do.New()
+	do.ProvideValue[domain.RedisRepo](injector, t.redisRepo)
+	do.ProvideValue[domain.RecordRepo](injector, t.recordRepo)
+	do.ProvideValue[domain.ZoneRepo](injector, t.zoneRepo)
+	t.provideUpstreams(injector)
+	do.ProvideValue(injector, &domain.Options{EdnsUdpSize: 1232})
+	do.ProvideValue[domain.ValidateUseCase](injector, validateUseCase)
+	usecase, err := NewDNSUseCase(injector)
+	t.Nil(err)
+
+	req := new(dns.Msg)
+	req.SetQuestion("google.com.", dns.TypeA)
+	req.SetEdns0(dns.DefaultMsgSize, true)
+
+	t.Run(
+		"secure_success", func() {
+			t.SetupErrorTest()
+			t.redisRepo.Calls = nil
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+				Return(nil)
+			validateUseCase.ExpectedCalls = nil
+			validateUseCase.
+				On("Validate", anyContext, anyMsg).
+				Return(true, nil)
+
+			resp, err := usecase.QueryUpstream(context.Background(), req)
+			t.Nil(err)
+			t.True(resp.AuthenticatedData)
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";google.com.\tIN\t A", domain.AuthenticatedField, "1", 300*time.Second,
+			)
+		},
+	)
+
+	t.Run(
+		"insecure_success", func() {
+			t.SetupErrorTest()
+			t.redisRepo.Calls = nil
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+				Return(nil)
+			validateUseCase.ExpectedCalls = nil
+			validateUseCase.
+				On("Validate", anyContext, anyMsg).
+				Return(false, nil)
+
+			resp, err := usecase.QueryUpstream(context.Background(), req)
+			t.Nil(err)
+			t.False(resp.AuthenticatedData)
+			t.redisRepo.AssertNotCalled(
+				t.T(), "HSet", anyContext, anyString, domain.AuthenticatedField, anyString, anyTime,
+			)
+		},
+	)
+
+	t.Run(
+		"bogus_error", func() {
+			t.SetupErrorTest()
+			t.redisRepo.Calls = nil
+			validateUseCase.ExpectedCalls = nil
+			validateUseCase.
+				On("Validate", anyContext, anyMsg).
+				Return(false, domain.Error{Message: "bogus DNSSEC of google.com.: no signature of A is valid"})
+
+			resp, err := usecase.QueryUpstream(context.Background(), req)
+			t.Nil(resp)
+			t.Contains(err.Error(), "bogus")
+			t.redisRepo.AssertNotCalled(t.T(), "HSet", anyContext, anyString, anyString, anyString, anyTime)
+		},
+	)
+
+	t.Run(
+		"bogus_checking_disabled_success", func() {
+			t.SetupErrorTest()
+			t.redisRepo.Calls = nil
+			validateUseCase.ExpectedCalls = nil
+			validateUseCase.
+				On("Validate", anyContext, anyMsg).
+				Return(false, domain.Error{Message: "bogus DNSSEC of google.com.: no signature of A is valid"})
+
+			unchecked := req.Copy()
+			unchecked.CheckingDisabled = true
+			resp, err := usecase.QueryUpstream(context.Background(), unchecked)
+			t.Nil(err)
+			t.True(resp.CheckingDisabled)
+			t.Equal("google.com.", resp.Answer[0].Header().Name)
+			t.redisRepo.AssertNotCalled(t.T(), "HSet", anyContext, anyString, anyString, anyString, anyTime)
+		},
+	)
+}
+
+func (t *dnsUseCaseTestSuite) TestClientSubnet() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		anyTime    = mock.AnythingOfType("time.Duration")
+	)
+
+	injector := do.New()
+	do.ProvideValue[domain.RedisRepo](injector, t.redisRepo)
+	do.ProvideValue[domain.RecordRepo](injector, t.recordRepo)
+	do.ProvideValue[domain.ZoneRepo](injector, t.zoneRepo)
+	t.provideUpstreams(injector)
+	do.ProvideValue(injector, &domain.Options{EdnsUdpSize: 1232, EcsForwarding: true, EcsPrefixes: "24,56"})
+	do.ProvideValue[domain.ValidateUseCase](injector, nil)
+	usecase, err := NewDNSUseCase(injector)
+	t.Nil(err)
+
+	client := func(addr string) context.Context {
+		udpAddr, _ := net.ResolveUDPAddr("udp", addr)
+		return domain.WithClientAddr(context.Background(), udpAddr)
+	}
+	subnetReq := func(name string, ip string, prefix uint8) *dns.Msg {
+		req := new(dns.Msg)
+		req.SetQuestion(name, dns.TypeTXT)
+		req.SetEdns0(1232, false)
+		family := uint16(ecsIPv4)
+		if net.ParseIP(ip).To4() == nil {
+			family = ecsIPv6
+		}
+		req.IsEdns0().Option = append(req.IsEdns0().Option, newSubnet(family, net.ParseIP(ip), prefix))
+		return req
+	}
+	req := new(dns.Msg)
+	req.SetQuestion("ecs.test.", dns.TypeTXT)
+
+	t.Run(
+		"client_address_success", func() {
+			t.SetupErrorTest()
+			t.redisRepo.Calls = nil
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+				Return(nil)
+
+			resp, err := usecase.QueryUpstream(client("203.0.113.77:5353"), req)
+			t.Nil(err)
+			t.Equal([]string{"203.0.113.0/24"}, resp.Answer[0].(*dns.TXT).Txt)
+			t.Nil(resp.IsEdns0())
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";ecs.test.\tIN\t TXT 203.0.0.0/16", "Answer-0", anyString, anyTime,
+			)
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";ecs.test.\tIN\t TXT ECS", "16", "1", 300*time.Second,
+			)
+		},
+	)
+
+	t.Run(
+		"client_ipv6_address_success", func() {
+			resp, err := usecase.QueryUpstream(client("[2001:db8:1234:5678::1]:5353"), req)
+			t.Nil(err)
+			t.Equal([]string{"2001:db8:1234:5600::/56"}, resp.Answer[0].(*dns.TXT).Txt)
+		},
+	)
+
+	t.Run(
+		"client_subnet_shortened_success", func() {
+			resp, err := usecase.QueryUpstream(client("203.0.113.77:5353"), subnetReq("ecs.test.", "198.51.100.16", 28))
+			t.Nil(err)
+			t.Equal([]string{"198.51.100.0/24"}, resp.Answer[0].(*dns.TXT).Txt)
+
+			subnet := findSubnet(resp)
+			t.NotNil(subnet)
+			t.Equal(uint8(28), subnet.SourceNetmask)
+			t.Equal(uint8(16), subnet.SourceScope)
+			t.True(net.ParseIP("198.51.100.16").Equal(subnet.Address))
+		},
+	)
+
+	t.Run(
+		"client_opt_out_success", func() {
+			t.SetupErrorTest()
+			t.redisRepo.Calls = nil
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+				Return(nil)
+
+			resp, err := usecase.QueryUpstream(client("203.0.113.77:5353"), subnetReq("ecs.test.", "0.0.0.0", 0))
+			t.Nil(err)
+			t.Equal([]string{"0.0.0.0/0"}, resp.Answer[0].(*dns.TXT).Txt)
+			t.Equal(uint8(0), findSubnet(resp).SourceScope)
+			t.redisRepo.AssertCalled(t.T(), "HSet", anyContext, ";ecs.test.\tIN\t TXT", "Answer-0", anyString, anyTime)
+		},
+	)
+
+	t.Run(
+		"private_client_not_forwarded", func() {
+			t.SetupErrorTest()
+			t.redisRepo.Calls = nil
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+				Return(nil)
+
+			resp, err := usecase.QueryUpstream(client("10.0.0.1:5353"), req)
+			t.Nil(err)
+			t.Equal([]string{"none"}, resp.Answer[0].(*dns.TXT).Txt)
+			t.redisRepo.AssertCalled(t.T(), "HSet", anyContext, ";ecs.test.\tIN\t TXT", "Answer-0", anyString, anyTime)
+		},
+	)
+
+	t.Run(
+		"subnet_mismatch_error", func() {
+			mismatch := new(dns.Msg)
+			mismatch.SetQuestion("ecs-mismatch.test.", dns.TypeTXT)
+			resp, err := usecase.QueryUpstream(client("203.0.113.77:5353"), mismatch)
+			t.Nil(resp)
+			t.ErrorIs(err, domain.ErrNoUpstream)
+		},
+	)
+
+	t.Run(
+		"cache_scoped_success", func() {
+			t.redisRepo.ExpectedCalls = nil
+			t.redisRepo.
+				On("HGetAll", anyContext, ";ecs.test.\tIN\t TXT ECS").
+				Return(map[string]string{"16": "1", "24": "1", "32": "1"}, nil)
+			t.redisRepo.
+				On("HGetAll", anyContext, ";ecs.test.\tIN\t TXT 203.0.113.0/24").
+				Return(map[string]string{}, nil)
+			t.redisRepo.
+				On("HGetAll", anyContext, ";ecs.test.\tIN\t TXT 203.0.0.0/16").
+				Return(map[string]string{"Answer-0": "ecs.test.\t300\tIN\tTXT\t\"scoped\""}, nil)
+			for _, other := range []string{"198.51.100.0/24", "198.51.0.0/16"} {
+				t.redisRepo.
+					On("HGetAll", anyContext, ";ecs.test.\tIN\t TXT "+other).
+					Return(map[string]string{}, nil)
+			}
+			t.redisRepo.
+				On("HGetAll", anyContext, ";ecs.test.\tIN\t TXT").
+				Return(map[string]string{"Answer-0": "ecs.test.\t300\tIN\tTXT\t\"global\""}, nil)
+			defer t.SetupErrorTest()
+			defer t.SetupTest()
+
+			resp, err := usecase.QueryRedisCache(client("203.0.113.77:5353"), req)
+			t.Nil(err)
+			t.Equal([]string{"scoped"}, resp.Answer[0].(*dns.TXT).Txt)
+			t.Nil(resp.IsEdns0())
+
+			resp, err = usecase.QueryRedisCache(client("203.0.113.77:5353"), subnetReq("ecs.test.", "203.0.113.0", 24))
+			t.Nil(err)
+			t.Equal([]string{"scoped"}, resp.Answer[0].(*dns.TXT).Txt)
+			t.Equal(uint8(16), findSubnet(resp).SourceScope)
+
+			// the other subnets get the answer for every subnet
+			resp, err = usecase.QueryRedisCache(client("198.51.100.1:5353"), req)
+			t.Nil(err)
+			t.Equal([]string{"global"}, resp.Answer[0].(*dns.TXT).Txt)
+		},
+	)
+
+	t.Run(
+		"prefixes_error", func() {
+			for _, prefixes := range []string{"24", "33,56", "24,129", "a,56"} {
+				_, err := parseEcsPrefixes(prefixes)
+				t.NotNil(err, prefixes)
+			}
+		},
+	)
+}
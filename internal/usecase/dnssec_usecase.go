@@ -0,0 +1,813 @@
+package usecase
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/utils"
+)
+
+// dnssecAlgorithms are the algorithms of the generated keys, which are recommended for signing
+// (RFC 8624 3.1)
+var dnssecAlgorithms = map[string]uint8{
+	dns.AlgorithmToString[dns.ECDSAP256SHA256]: dns.ECDSAP256SHA256,
+	dns.AlgorithmToString[dns.ED25519]:         dns.ED25519,
+}
+
+const (
+	// rrsigValidity is how long a signature is valid, it's cached for a fraction of that
+	rrsigValidity = 7 * 24 * time.Hour
+	rrsigCacheTTL = 24 * time.Hour
+	// rrsigSkew backdates the inception for the clients whose clocks are behind
+	rrsigSkew = time.Hour
+	// rrsigField is the cache field of a signature
+	rrsigField = "RRSIG"
+
+	// maxNsec3Iterations bounds the iterations, resolvers may treat more as insecure (RFC 9276 3.2)
+	maxNsec3Iterations = 100
+)
+
+type dnssecUseCase struct {
+	redisRepo domain.RedisRepo
+
+	zoneRepo domain.ZoneRepo
+
+	recordRepo domain.RecordRepo
+
+	dnssecRepo domain.DnssecRepo
+
+	// signers are the parsed keys by their DNSKEY records
+	signers sync.Map
+}
+
+// signedZone is a zone with the policy which signs it
+type signedZone struct {
+	zone   *domain.Zone
+	dnssec *domain.Dnssec
+}
+
+// keySigner is a key which is ready to sign
+type keySigner struct {
+	dnskey *dns.DNSKEY
+	signer crypto.Signer
+}
+
+func (s *dnssecUseCase) EnableDnssec(ctx context.Context, dnssec *domain.Dnssec) error {
+	dnssec.Zone = utils.GetFQDNFromDomainName(dnssec.Zone)
+	zone, err := s.zoneRepo.Get(ctx, dnssec.Zone)
+	if err != nil {
+		return err
+	}
+	if zone.IsSecondary() {
+		return &domain.Error{
+			Message:    fmt.Sprintf("the secondary zone %s is signed by its primary", zone.Name),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	err = s.checkPolicy(dnssec)
+	if err != nil {
+		return err
+	}
+
+	existed, _ := s.dnssecRepo.Get(ctx, dnssec.Zone)
+	if existed != nil {
+		return &domain.Error{
+			Message:    "the DNSSEC of the zone is already enabled.",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	dnssec.Keys, dnssec.DS = nil, nil
+	err = s.dnssecRepo.Create(ctx, dnssec)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, keyType := range []domain.DnssecKeyType{domain.DnssecKSK, domain.DnssecZSK} {
+		key, err := s.generateKey(zone, dnssec.Algorithm, keyType, now)
+		if err != nil {
+			return err
+		}
+		err = s.dnssecRepo.CreateKey(ctx, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	enabled, err := s.GetDnssec(ctx, dnssec.Zone)
+	if err != nil {
+		return err
+	}
+	*dnssec = *enabled
+	return nil
+}
+
+func (s *dnssecUseCase) GetDnssec(ctx context.Context, zone string) (*domain.Dnssec, error) {
+	dnssec, err := s.dnssecRepo.Get(ctx, utils.GetFQDNFromDomainName(zone))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, key := range dnssec.Keys {
+		key.State = key.StateAt(now)
+	}
+	dnssec.DS, err = s.dsRecords(dnssec)
+	if err != nil {
+		return nil, err
+	}
+	return dnssec, nil
+}
+
+func (s *dnssecUseCase) DisableDnssec(ctx context.Context, zone string) error {
+	dnssec, err := s.dnssecRepo.Get(ctx, utils.GetFQDNFromDomainName(zone))
+	if err != nil {
+		return err
+	}
+	return s.dnssecRepo.Delete(ctx, dnssec.Zone)
+}
+
+func (s *dnssecUseCase) ListDS(ctx context.Context, zone string) ([]string, error) {
+	dnssec, err := s.GetDnssec(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return dnssec.DS, nil
+}
+
+// Rollover publishes a new key at once, which takes over from the active keys of the type at the
+// given time. The old keys stay published for the DNSKEY TTL afterwards, so that the signatures
+// made by them expire from the caches first.
+func (s *dnssecUseCase) Rollover(ctx context.Context, zone string,
+	rollover *domain.DnssecRollover) (*domain.Dnssec, error) {
+	dnssec, err := s.GetDnssec(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	z, err := s.zoneRepo.Get(ctx, dnssec.Zone)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ttl := time.Duration(z.Ttl) * time.Second
+	at := now.Add(ttl)
+	if rollover.At != nil {
+		if rollover.At.Before(now) {
+			return nil, &domain.Error{
+				Message:    "the rollover cannot be scheduled in the past",
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+		at = *rollover.At
+	}
+	remove := at.Add(ttl)
+
+	for _, key := range dnssec.Keys {
+		if key.Type != rollover.Type || key.Retire != nil {
+			continue
+		}
+		key.Retire, key.Remove = &at, &remove
+		err = s.dnssecRepo.UpdateKey(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := s.generateKey(z, dnssec.Algorithm, rollover.Type, at)
+	if err != nil {
+		return nil, err
+	}
+	err = s.dnssecRepo.CreateKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetDnssec(ctx, dnssec.Zone)
+}
+
+func (s *dnssecUseCase) Sign(ctx context.Context, req *dns.Msg, resp *dns.Msg) (*dns.Msg, error) {
+	q := req.Question[0]
+	zones := make(map[string]*signedZone)
+	signed, err := s.signedZone(ctx, q.Name, zones)
+	if err != nil || signed == nil {
+		return resp, err
+	}
+
+	now := time.Now()
+	if strings.EqualFold(q.Name, signed.zone.Name) && len(resp.Answer) == 0 {
+		switch q.Qtype {
+		case dns.TypeDNSKEY:
+			resp.Answer, err = signed.dnskeys(now)
+			if err != nil {
+				return nil, err
+			}
+		case dns.TypeNSEC3PARAM:
+			if signed.dnssec.Nsec3 {
+				resp.Answer = []dns.RR{signed.nsec3param()}
+			}
+		}
+		if len(resp.Answer) > 0 {
+			resp.Rcode = dns.RcodeSuccess
+			resp.Ns = nil
+		}
+	}
+
+	opt := req.IsEdns0()
+	if opt == nil || !opt.Do() {
+		return resp, nil
+	}
+	if resp.IsEdns0() == nil {
+		resp.SetEdns0(dns.DefaultMsgSize, true)
+	}
+
+	denial, err := s.deny(ctx, q, resp, zones)
+	if err != nil {
+		return nil, err
+	}
+	resp.Ns = append(resp.Ns, denial...)
+
+	resp.Answer, err = s.signSection(ctx, resp.Answer, zones, now)
+	if err != nil {
+		return nil, err
+	}
+	resp.Ns, err = s.signSection(ctx, resp.Ns, zones, now)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *dnssecUseCase) checkPolicy(dnssec *domain.Dnssec) error {
+	if dnssec.Algorithm == "" {
+		dnssec.Algorithm = dns.AlgorithmToString[dns.ECDSAP256SHA256]
+	}
+	dnssec.Algorithm = strings.ToUpper(dnssec.Algorithm)
+	if _, ok := dnssecAlgorithms[dnssec.Algorithm]; !ok {
+		return &domain.Error{
+			Message:    fmt.Sprintf("the algorithm %s isn't supported", dnssec.Algorithm),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	if !dnssec.Nsec3 {
+		dnssec.Iterations, dnssec.Salt = 0, ""
+		return nil
+	}
+	if dnssec.Iterations > maxNsec3Iterations {
+		return &domain.Error{
+			Message:    fmt.Sprintf("the iterations of NSEC3 should be at most %d", maxNsec3Iterations),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	salt, err := hex.DecodeString(dnssec.Salt)
+	if err != nil || len(salt) > 255 {
+		return &domain.Error{
+			Message:    "the salt of NSEC3 should be at most 255 octets in hex",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	dnssec.Salt = strings.ToUpper(dnssec.Salt)
+	return nil
+}
+
+// generateKey generates a key which is published now and signs from the activation
+func (s *dnssecUseCase) generateKey(zone *domain.Zone, algorithm string, keyType domain.DnssecKeyType,
+	activate time.Time) (*domain.DnssecKey, error) {
+	dnskey := &dns.DNSKEY{
+		Hdr: dns.RR_Header{
+			Name:   zone.Name,
+			Rrtype: dns.TypeDNSKEY,
+			Class:  dns.ClassINET,
+			Ttl:    zone.Ttl,
+		},
+		Flags:     dns.ZONE,
+		Protocol:  3,
+		Algorithm: dnssecAlgorithms[algorithm],
+	}
+	if keyType == domain.DnssecKSK {
+		dnskey.Flags |= dns.SEP
+	}
+	// both algorithms have 256 bits keys
+	private, err := dnskey.Generate(256)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.DnssecKey{
+		Zone:       zone.Name,
+		Type:       keyType,
+		KeyTag:     dnskey.KeyTag(),
+		DNSKEY:     dnskey.String(),
+		PrivateKey: dnskey.PrivateKeyString(private),
+		Publish:    time.Now(),
+		Activate:   activate,
+	}, nil
+}
+
+// dsRecords returns the DS records of the KSKs which are published
+func (s *dnssecUseCase) dsRecords(dnssec *domain.Dnssec) ([]string, error) {
+	var records []string
+	for _, key := range dnssec.Keys {
+		if key.Type != domain.DnssecKSK || key.State == domain.DnssecKeyRemoved {
+			continue
+		}
+		dnskey, err := parseDNSKEY(key.DNSKEY)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, dnskey.ToDS(dns.SHA256).String())
+	}
+	return records, nil
+}
+
+// signedZone returns the signed zone which contains the name, or nil when the zone is unsigned,
+// the zones are cached by the names for a response
+func (s *dnssecUseCase) signedZone(ctx context.Context, name string,
+	zones map[string]*signedZone) (*signedZone, error) {
+	name = dns.CanonicalName(name)
+	if signed, ok := zones[name]; ok {
+		return signed, nil
+	}
+
+	var signed *signedZone
+	zone, err := getClosestZone(ctx, s.zoneRepo, name)
+	if err != nil {
+		return nil, err
+	}
+	if zone != nil && !zone.IsSecondary() {
+		dnssec, _ := s.dnssecRepo.Get(ctx, zone.Name)
+		if dnssec != nil {
+			signed = &signedZone{zone, dnssec}
+		}
+	}
+	zones[name] = signed
+	return signed, nil
+}
+
+// deny returns the NSEC or NSEC3 records which prove the NXDOMAIN or NODATA of the name which the
+// CNAME chain of the question ends at, the denial is proven in the zone of the SOA of the response
+func (s *dnssecUseCase) deny(ctx context.Context, q dns.Question, resp *dns.Msg,
+	zones map[string]*signedZone) ([]dns.RR, error) {
+	if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+		return nil, nil
+	}
+
+	name, positive := answerTarget(q, resp.Answer)
+	if positive {
+		return nil, nil
+	}
+
+	var soa *dns.SOA
+	for _, rr := range resp.Ns {
+		if rr, ok := rr.(*dns.SOA); ok {
+			soa = rr
+		}
+	}
+	if soa == nil {
+		return nil, nil
+	}
+	signed, err := s.signedZone(ctx, soa.Hdr.Name, zones)
+	if err != nil || signed == nil {
+		return nil, err
+	}
+
+	if resp.Rcode == dns.RcodeNameError {
+		return s.denyName(ctx, signed, name, soa.Hdr.Ttl)
+	}
+	types, err := s.types(ctx, signed, name)
+	if err != nil {
+		return nil, err
+	}
+	if signed.dnssec.Nsec3 {
+		return []dns.RR{signed.matchNSEC3(name, types, soa.Hdr.Ttl)}, nil
+	}
+	return []dns.RR{signed.nsec(name, successor(name), types, soa.Hdr.Ttl)}, nil
+}
+
+// denyName proves that neither the name nor the wildcard at its closest encloser exists, by NSEC
+// (RFC 4035 3.1.3.2) or NSEC3 (RFC 5155 7.2.2)
+func (s *dnssecUseCase) denyName(ctx context.Context, signed *signedZone, name string, ttl uint32) ([]dns.RR, error) {
+	encloser := signed.zone.Name
+	for ancestor := parentName(name); dns.IsSubDomain(signed.zone.Name, ancestor) &&
+		!strings.EqualFold(ancestor, signed.zone.Name); ancestor = parentName(ancestor) {
+		exists, err := s.recordRepo.ExistName(ctx, ancestor)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			encloser = ancestor
+			break
+		}
+	}
+	wildcard := "*." + encloser
+
+	var rrs []dns.RR
+	if !signed.dnssec.Nsec3 {
+		rrs = []dns.RR{
+			signed.nsec(predecessor(name), successor(name), nil, ttl),
+			signed.nsec(predecessor(wildcard), successor(wildcard), nil, ttl),
+		}
+	} else {
+		types, err := s.types(ctx, signed, encloser)
+		if err != nil {
+			return nil, err
+		}
+		labels := dns.SplitDomainName(name)
+		nextCloser := dns.Fqdn(strings.Join(labels[len(labels)-dns.CountLabel(encloser)-1:], "."))
+		rrs = []dns.RR{
+			signed.matchNSEC3(encloser, types, ttl),
+			signed.coverNSEC3(nextCloser, ttl),
+			signed.coverNSEC3(wildcard, ttl),
+		}
+	}
+
+	// the covering records are the same when the name is the wildcard
+	var unique []dns.RR
+	for _, rr := range rrs {
+		duplicated := false
+		for _, existed := range unique {
+			duplicated = duplicated || dns.IsDuplicate(rr, existed)
+		}
+		if !duplicated {
+			unique = append(unique, rr)
+		}
+	}
+	return unique, nil
+}
+
+// types returns the types of the RRsets owned by the name for the bitmap of NSEC or NSEC3
+func (s *dnssecUseCase) types(ctx context.Context, signed *signedZone, name string) ([]uint16, error) {
+	records, err := s.recordRepo.ListByName(ctx, name, dns.ClassINET)
+	if err != nil {
+		return nil, err
+	}
+
+	set := map[uint16]bool{dns.TypeRRSIG: true}
+	if !signed.dnssec.Nsec3 {
+		set[dns.TypeNSEC] = true
+	}
+	for _, record := range records {
+		set[record.RrType] = true
+	}
+	if strings.EqualFold(name, signed.zone.Name) {
+		set[dns.TypeSOA], set[dns.TypeNS], set[dns.TypeDNSKEY] = true, true, true
+		if signed.dnssec.Nsec3 {
+			set[dns.TypeNSEC3PARAM] = true
+		}
+	}
+
+	var types []uint16
+	for t := range set {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types, nil
+}
+
+// answerTarget follows the CNAME chain of the question in the answer, it returns the name which the
+// chain ends at and whether the answer has the records of the question type owned by it
+func answerTarget(q dns.Question, answer []dns.RR) (string, bool) {
+	name := q.Name
+	for _, rr := range answer {
+		cname, ok := rr.(*dns.CNAME)
+		if ok && q.Qtype != dns.TypeCNAME && strings.EqualFold(cname.Hdr.Name, name) {
+			name = cname.Target
+		}
+	}
+	for _, rr := range answer {
+		header := rr.Header()
+		if strings.EqualFold(header.Name, name) && (header.Rrtype == q.Qtype || q.Qtype == dns.TypeANY) {
+			return name, true
+		}
+	}
+	return name, false
+}
+
+// signSection appends the signatures of each RRset right after it, the RRsets outside the signed
+// zones are kept unsigned
+func (s *dnssecUseCase) signSection(ctx context.Context, rrs []dns.RR, zones map[string]*signedZone,
+	now time.Time) ([]dns.RR, error) {
+	var (
+		keys   []string
+		rrsets = make(map[string][]dns.RR)
+	)
+	for _, rr := range rrs {
+		header := rr.Header()
+		key := fmt.Sprintf("%s %d %d", dns.CanonicalName(header.Name), header.Class, header.Rrtype)
+		if _, ok := rrsets[key]; !ok {
+			keys = append(keys, key)
+		}
+		rrsets[key] = append(rrsets[key], rr)
+	}
+
+	var signed []dns.RR
+	for _, key := range keys {
+		rrset := rrsets[key]
+		signed = append(signed, rrset...)
+
+		header := rrset[0].Header()
+		if header.Rrtype == dns.TypeRRSIG || header.Rrtype == dns.TypeOPT {
+			continue
+		}
+		zone, err := s.signedZone(ctx, header.Name, zones)
+		if err != nil {
+			return nil, err
+		}
+		if zone == nil {
+			continue
+		}
+		for _, k := range zone.signingKeys(header.Rrtype == dns.TypeDNSKEY, now) {
+			sig, err := s.rrsig(ctx, zone, k, rrset, now)
+			if err != nil {
+				return nil, err
+			}
+			signed = append(signed, sig)
+		}
+	}
+	return signed, nil
+}
+
+// rrsig returns the signature of the RRset by the key, the signatures are cached by the RRsets
+// which they sign
+func (s *dnssecUseCase) rrsig(ctx context.Context, signed *signedZone, key *domain.DnssecKey, rrset []dns.RR,
+	now time.Time) (*dns.RRSIG, error) {
+	var records []string
+	for _, rr := range rrset {
+		records = append(records, rr.String())
+	}
+	sort.Strings(records)
+	cacheKey := fmt.Sprintf(
+		"%s %s %d %x", rrsigField, signed.zone.Name, key.KeyTag,
+		sha256.Sum256([]byte(strings.Join(records, "\n"))),
+	)
+
+	cached, err := s.redisRepo.HGetAll(ctx, cacheKey)
+	if err != nil {
+		return nil, err
+	}
+	if rr, _ := dns.NewRR(cached[rrsigField]); rr != nil {
+		if sig, ok := rr.(*dns.RRSIG); ok {
+			return sig, nil
+		}
+	}
+
+	k, err := s.signer(key)
+	if err != nil {
+		return nil, err
+	}
+	sig := &dns.RRSIG{
+		Hdr:        dns.RR_Header{Ttl: rrset[0].Header().Ttl},
+		Algorithm:  k.dnskey.Algorithm,
+		KeyTag:     key.KeyTag,
+		SignerName: signed.zone.Name,
+		Inception:  uint32(now.Add(-rrsigSkew).Unix()),
+		Expiration: uint32(now.Add(rrsigValidity).Unix()),
+	}
+	err = sig.Sign(k.signer, rrset)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.redisRepo.HSet(ctx, cacheKey, rrsigField, sig.String(), rrsigCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+func (s *dnssecUseCase) signer(key *domain.DnssecKey) (*keySigner, error) {
+	if k, ok := s.signers.Load(key.DNSKEY); ok {
+		return k.(*keySigner), nil
+	}
+
+	dnskey, err := parseDNSKEY(key.DNSKEY)
+	if err != nil {
+		return nil, err
+	}
+	private, err := dnskey.NewPrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("the key %d of %s cannot sign", key.KeyTag, key.Zone)
+	}
+
+	k := &keySigner{dnskey, signer}
+	s.signers.Store(key.DNSKEY, k)
+	return k, nil
+}
+
+// dnskeys returns the DNSKEY RRset of the keys which are published
+func (z *signedZone) dnskeys(now time.Time) ([]dns.RR, error) {
+	var rrs []dns.RR
+	for _, key := range z.dnssec.Keys {
+		if key.StateAt(now) == domain.DnssecKeyRemoved {
+			continue
+		}
+		dnskey, err := parseDNSKEY(key.DNSKEY)
+		if err != nil {
+			return nil, err
+		}
+		dnskey.Hdr.Ttl = z.zone.Ttl
+		rrs = append(rrs, dnskey)
+	}
+	return rrs, nil
+}
+
+// signingKeys returns the active KSKs for the DNSKEY RRset, and the active ZSKs for the others
+func (z *signedZone) signingKeys(dnskey bool, now time.Time) []*domain.DnssecKey {
+	keyType := domain.DnssecZSK
+	if dnskey {
+		keyType = domain.DnssecKSK
+	}
+
+	var keys []*domain.DnssecKey
+	for _, key := range z.dnssec.Keys {
+		if key.Type == keyType && key.StateAt(now) == domain.DnssecKeyActive {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (z *signedZone) nsec3param() *dns.NSEC3PARAM {
+	return &dns.NSEC3PARAM{
+		Hdr: dns.RR_Header{
+			Name:   z.zone.Name,
+			Rrtype: dns.TypeNSEC3PARAM,
+			Class:  dns.ClassINET,
+		},
+		Hash:       dns.SHA1,
+		Iterations: z.dnssec.Iterations,
+		SaltLength: uint8(len(z.dnssec.Salt) / 2),
+		Salt:       z.dnssec.Salt,
+	}
+}
+
+// nsec returns the NSEC record from the owner to the next name, a covering record owns no types
+func (z *signedZone) nsec(owner string, next string, types []uint16, ttl uint32) *dns.NSEC {
+	if types == nil {
+		types = []uint16{dns.TypeRRSIG, dns.TypeNSEC}
+	}
+	return &dns.NSEC{
+		Hdr: dns.RR_Header{
+			Name:   owner,
+			Rrtype: dns.TypeNSEC,
+			Class:  dns.ClassINET,
+			Ttl:    ttl,
+		},
+		NextDomain: next,
+		TypeBitMap: types,
+	}
+}
+
+// matchNSEC3 returns the NSEC3 record of the hash of the name
+func (z *signedZone) matchNSEC3(name string, types []uint16, ttl uint32) *dns.NSEC3 {
+	hash := dns.HashName(name, dns.SHA1, z.dnssec.Iterations, z.dnssec.Salt)
+	return z.nsec3(hash, addHash(hash, 1), types, ttl)
+}
+
+// coverNSEC3 returns the NSEC3 record which covers the hash of the name alone (RFC 7129 B)
+func (z *signedZone) coverNSEC3(name string, ttl uint32) *dns.NSEC3 {
+	hash := dns.HashName(name, dns.SHA1, z.dnssec.Iterations, z.dnssec.Salt)
+	return z.nsec3(addHash(hash, -1), addHash(hash, 1), nil, ttl)
+}
+
+func (z *signedZone) nsec3(hash string, next string, types []uint16, ttl uint32) *dns.NSEC3 {
+	return &dns.NSEC3{
+		Hdr: dns.RR_Header{
+			Name:   strings.ToLower(hash) + "." + z.zone.Name,
+			Rrtype: dns.TypeNSEC3,
+			Class:  dns.ClassINET,
+			Ttl:    ttl,
+		},
+		Hash:       dns.SHA1,
+		Iterations: z.dnssec.Iterations,
+		SaltLength: uint8(len(z.dnssec.Salt) / 2),
+		Salt:       z.dnssec.Salt,
+		HashLength: sha1Size,
+		NextDomain: next,
+		TypeBitMap: types,
+	}
+}
+
+// sha1Size is the length of the hashes of NSEC3
+const sha1Size = 20
+
+// addHash adds the delta to the base32hex hash as a big endian number
+func addHash(hash string, delta int) string {
+	raw, err := base32.HexEncoding.DecodeString(strings.ToUpper(hash))
+	if err != nil {
+		return hash
+	}
+	for i := len(raw) - 1; i >= 0; i-- {
+		sum := int(raw[i]) + delta
+		raw[i] = byte(sum)
+		if sum >= 0 && sum <= 0xFF {
+			break
+		}
+	}
+	return base32.HexEncoding.EncodeToString(raw)
+}
+
+// successor returns the name right after the name in the canonical order (RFC 4034 6.1), which is
+// its child of the lowest label (RFC 4471 3.1.2)
+func successor(name string) string {
+	next := `\000.` + name
+	if _, ok := dns.IsDomainName(next); ok {
+		return next
+	}
+
+	label, rest := firstLabel(name)
+	for i := len(label) - 1; i >= 0; i-- {
+		if label[i] < 0xFF {
+			label[i]++
+			return joinLabel(label[:i+1], rest)
+		}
+	}
+	return name
+}
+
+// predecessor returns a name before the name in the canonical order, no existing name is between
+// them unless it has an octet of \255
+func predecessor(name string) string {
+	label, rest := firstLabel(name)
+	last := label[len(label)-1]
+	if last == 0 {
+		if len(label) == 1 {
+			return rest
+		}
+		return joinLabel(label[:len(label)-1], rest)
+	}
+
+	last--
+	// the upper cases sort as the lower cases, '@' is right before them
+	if last >= 'A' && last <= 'Z' {
+		last = 'A' - 1
+	}
+	label[len(label)-1] = last
+	if len(label) < 63 {
+		label = append(label, 0xFF)
+	}
+	return joinLabel(label, rest)
+}
+
+// firstLabel returns the lower cased octets of the first label of the name and the rest of it
+func firstLabel(name string) ([]byte, string) {
+	wire := make([]byte, 256)
+	n, err := dns.PackDomainName(dns.CanonicalName(name), wire, 0, nil, false)
+	if err != nil || n < 2 {
+		return []byte{0}, name
+	}
+	label := lowerLabel(wire[1 : 1+wire[0]])
+	rest, _, _ := dns.UnpackDomainName(wire[:n], 1+int(wire[0]))
+	return label, rest
+}
+
+func joinLabel(label []byte, rest string) string {
+	wire := append(append([]byte{byte(len(label))}, label...), 0)
+	name, _, err := dns.UnpackDomainName(wire, 0)
+	if err != nil {
+		return rest
+	}
+	if rest == "." {
+		return name
+	}
+	return name + rest
+}
+
+func parseDNSKEY(s string) (*dns.DNSKEY, error) {
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		return nil, err
+	}
+	dnskey, ok := rr.(*dns.DNSKEY)
+	if !ok {
+		return nil, fmt.Errorf("%s isn't a DNSKEY record", s)
+	}
+	return dnskey, nil
+}
+
+func NewDnssecUseCase(injector *do.Injector) (domain.DnssecUseCase, error) {
+	return &dnssecUseCase{
+		redisRepo:  do.MustInvoke[domain.RedisRepo](injector),
+		zoneRepo:   do.MustInvoke[domain.ZoneRepo](injector),
+		recordRepo: do.MustInvoke[domain.RecordRepo](injector),
+		dnssecRepo: do.MustInvoke[domain.DnssecRepo](injector),
+	}, nil
+}
@@ -0,0 +1,701 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+)
+
+type dnssecUseCaseTestSuite struct {
+	suite.Suite
+
+	usecase domain.DnssecUseCase
+
+	redisRepo  *mocks.RedisRepo
+	zoneRepo   *mocks.ZoneRepo
+	recordRepo *mocks.RecordRepo
+	dnssecRepo *mocks.DnssecRepo
+
+	zone      *domain.Zone
+	nsec3Zone *domain.Zone
+
+	// keys are the KSK and ZSK of test.com. and nsec3.com.
+	keys      []*domain.DnssecKey
+	nsec3Keys []*domain.DnssecKey
+}
+
+func TestDnssecUseCase(t *testing.T) {
+	suite.Run(t, &dnssecUseCaseTestSuite{})
+}
+
+func (t *dnssecUseCaseTestSuite) SetupSuite() {
+	injector := do.New()
+	t.redisRepo = &mocks.RedisRepo{}
+	do.ProvideValue[domain.RedisRepo](injector, t.redisRepo)
+	t.zoneRepo = &mocks.ZoneRepo{}
+	do.ProvideValue[domain.ZoneRepo](injector, t.zoneRepo)
+	t.recordRepo = &mocks.RecordRepo{}
+	do.ProvideValue[domain.RecordRepo](injector, t.recordRepo)
+	t.dnssecRepo = &mocks.DnssecRepo{}
+	do.ProvideValue[domain.DnssecRepo](injector, t.dnssecRepo)
+
+	t.usecase, _ = NewDnssecUseCase(injector)
+
+	t.zone = &domain.Zone{Name: "test.com.", Type: domain.ZonePrimary, Ttl: 3600}
+	t.nsec3Zone = &domain.Zone{Name: "nsec3.com.", Type: domain.ZonePrimary, Ttl: 3600}
+
+	activate := time.Now().Add(-time.Hour)
+	s := &dnssecUseCase{}
+	for _, keyType := range []domain.DnssecKeyType{domain.DnssecKSK, domain.DnssecZSK} {
+		key, err := s.generateKey(t.zone, "ECDSAP256SHA256", keyType, activate)
+		t.Nil(err)
+		t.keys = append(t.keys, key)
+		key, err = s.generateKey(t.nsec3Zone, "ED25519", keyType, activate)
+		t.Nil(err)
+		t.nsec3Keys = append(t.nsec3Keys, key)
+	}
+}
+
+func (t *dnssecUseCaseTestSuite) SetupTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		anyTime    = mock.AnythingOfType("time.Duration")
+		anyUint16  = mock.AnythingOfType("uint16")
+		under      = func(zone string) interface{} {
+			return mock.MatchedBy(func(name string) bool { return dns.IsSubDomain(zone, name) })
+		}
+	)
+
+	t.redisRepo.ExpectedCalls = nil
+	t.redisRepo.Calls = nil
+	t.zoneRepo.ExpectedCalls = nil
+	t.zoneRepo.Calls = nil
+	t.recordRepo.ExpectedCalls = nil
+	t.recordRepo.Calls = nil
+	t.dnssecRepo.ExpectedCalls = nil
+	t.dnssecRepo.Calls = nil
+
+	t.redisRepo.
+		On("HGetAll", anyContext, anyString).
+		Return(map[string]string{}, nil)
+	t.redisRepo.
+		On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+		Return(nil)
+
+	t.zoneRepo.
+		On("Get", anyContext, "test.com.").
+		Return(t.zone, nil)
+	t.zoneRepo.
+		On("Get", anyContext, "nsec3.com.").
+		Return(t.nsec3Zone, nil)
+	t.zoneRepo.
+		On("Get", anyContext, "secondary.com.").
+		Return(&domain.Zone{Name: "secondary.com.", Type: domain.ZoneSecondary}, nil)
+	t.zoneRepo.
+		On("Get", anyContext, anyString).
+		Return(nil, &domain.Error{Message: "zone not found", StatusCode: http.StatusNotFound})
+	t.zoneRepo.
+		On("GetClosest", anyContext, under("test.com.")).
+		Return(t.zone, nil)
+	t.zoneRepo.
+		On("GetClosest", anyContext, under("nsec3.com.")).
+		Return(t.nsec3Zone, nil)
+	t.zoneRepo.
+		On("GetClosest", anyContext, anyString).
+		Return(nil, &domain.Error{Message: "zone not found", StatusCode: http.StatusNotFound})
+
+	www, _ := dns.NewRR("www.test.com.\t3600\tIN\tA\t1.1.1.1")
+	t.recordRepo.
+		On("ListByName", anyContext, "www.test.com.", anyUint16).
+		Return([]*domain.Record{{Name: "www.test.com.", RrType: dns.TypeA, Record: www.String()}}, nil)
+	t.recordRepo.
+		On("ListByName", anyContext, anyString, anyUint16).
+		Return([]*domain.Record{}, nil)
+	t.recordRepo.
+		On("ExistName", anyContext, "www.test.com.").
+		Return(true, nil)
+	t.recordRepo.
+		On("ExistName", anyContext, anyString).
+		Return(false, nil)
+
+	t.dnssecRepo.
+		On("Get", anyContext, "test.com.").
+		Return(
+			func(context.Context, string) *domain.Dnssec {
+				return &domain.Dnssec{Zone: "test.com.", Algorithm: "ECDSAP256SHA256", Keys: copyKeys(t.keys)}
+			}, nil,
+		)
+	t.dnssecRepo.
+		On("Get", anyContext, "nsec3.com.").
+		Return(
+			func(context.Context, string) *domain.Dnssec {
+				return &domain.Dnssec{
+					Zone:       "nsec3.com.",
+					Algorithm:  "ED25519",
+					Nsec3:      true,
+					Iterations: 1,
+					Salt:       "AABB",
+					Keys:       copyKeys(t.nsec3Keys),
+				}
+			}, nil,
+		)
+	t.dnssecRepo.
+		On("Get", anyContext, anyString).
+		Return(nil, &domain.Error{Message: "DB error: record not found", StatusCode: http.StatusNotFound})
+	t.dnssecRepo.
+		On("Create", anyContext, mock.AnythingOfType("*domain.Dnssec")).
+		Return(nil)
+	t.dnssecRepo.
+		On("CreateKey", anyContext, mock.AnythingOfType("*domain.DnssecKey")).
+		Return(nil)
+	t.dnssecRepo.
+		On("UpdateKey", anyContext, mock.AnythingOfType("*domain.DnssecKey")).
+		Return(nil)
+	t.dnssecRepo.
+		On("Delete", anyContext, anyString).
+		Return(nil)
+}
+
+func copyKeys(keys []*domain.DnssecKey) []*domain.DnssecKey {
+	var copied []*domain.DnssecKey
+	for _, key := range keys {
+		k := *key
+		copied = append(copied, &k)
+	}
+	return copied
+}
+
+// query returns the request with the DO bit and the response of the authoritative answer path
+func (t *dnssecUseCaseTestSuite) query(name string, qtype uint16, rcode int, answer ...string) (*dns.Msg, *dns.Msg) {
+	req := new(dns.Msg)
+	req.SetQuestion(name, qtype)
+	req.SetEdns0(dns.DefaultMsgSize, true)
+
+	resp := new(dns.Msg)
+	resp.SetRcode(req, rcode)
+	resp.Authoritative = true
+	for _, s := range answer {
+		rr, err := dns.NewRR(s)
+		t.Nil(err)
+		resp.Answer = append(resp.Answer, rr)
+	}
+	if len(resp.Answer) == 0 {
+		apex := "test.com."
+		if dns.IsSubDomain("nsec3.com.", name) {
+			apex = "nsec3.com."
+		}
+		soa, err := dns.NewRR(apex + "\t300\tIN\tSOA\tns1." + apex + " admin." + apex + " 1 7200 3600 86400 300")
+		t.Nil(err)
+		resp.Ns = append(resp.Ns, soa)
+	}
+	return req, resp
+}
+
+// verify checks that every RRset of the section is followed by its signatures made by the keys
+func (t *dnssecUseCaseTestSuite) verify(rrs []dns.RR, keys ...*domain.DnssecKey) {
+	var dnskeys []*dns.DNSKEY
+	for _, key := range keys {
+		dnskey, err := parseDNSKEY(key.DNSKEY)
+		t.Nil(err)
+		dnskeys = append(dnskeys, dnskey)
+	}
+
+	var rrset []dns.RR
+	signed := 0
+	for _, rr := range rrs {
+		sig, ok := rr.(*dns.RRSIG)
+		if !ok {
+			if len(rrset) > 0 && (rrset[0].Header().Rrtype != rr.Header().Rrtype ||
+				!strings.EqualFold(rrset[0].Header().Name, rr.Header().Name)) {
+				rrset = nil
+			}
+			rrset = append(rrset, rr)
+			continue
+		}
+		t.NotEmpty(rrset)
+		verified := false
+		for _, dnskey := range dnskeys {
+			if dnskey.KeyTag() == sig.KeyTag {
+				t.Nil(sig.Verify(dnskey, rrset))
+				t.True(sig.ValidityPeriod(time.Now()))
+				verified = true
+			}
+		}
+		t.True(verified, "%s isn't signed by the keys", rrset[0].Header().Name)
+		signed++
+	}
+	t.NotZero(signed)
+}
+
+func (t *dnssecUseCaseTestSuite) TestEnableDnssec() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			var created []*domain.DnssecKey
+			t.dnssecRepo.ExpectedCalls = nil
+			t.dnssecRepo.
+				On("Get", anyContext, "nsec3.com.").
+				Return(nil, &domain.Error{Message: "DB error: record not found", StatusCode: http.StatusNotFound}).
+				Once()
+			t.dnssecRepo.
+				On("Create", anyContext, mock.AnythingOfType("*domain.Dnssec")).
+				Return(nil)
+			t.dnssecRepo.
+				On("CreateKey", anyContext, mock.AnythingOfType("*domain.DnssecKey")).
+				Run(func(args mock.Arguments) { created = append(created, args.Get(1).(*domain.DnssecKey)) }).
+				Return(nil)
+			t.dnssecRepo.
+				On("Get", anyContext, "nsec3.com.").
+				Return(
+					func(context.Context, string) *domain.Dnssec {
+						return &domain.Dnssec{Zone: "nsec3.com.", Algorithm: "ED25519", Keys: created}
+					}, nil,
+				)
+
+			dnssec := &domain.Dnssec{Zone: "nsec3.com", Algorithm: "ed25519", Iterations: 10, Salt: "aabb"}
+			err := t.usecase.EnableDnssec(context.Background(), dnssec)
+			t.Nil(err)
+			t.dnssecRepo.AssertCalled(
+				t.T(), "Create", anyContext, mock.MatchedBy(
+					func(d *domain.Dnssec) bool {
+						// the iterations and salt are only of NSEC3
+						return d.Zone == "nsec3.com." && d.Algorithm == "ED25519" && d.Iterations == 0 && d.Salt == ""
+					},
+				),
+			)
+
+			t.Len(created, 2)
+			for i, flags := range []uint16{dns.ZONE | dns.SEP, dns.ZONE} {
+				dnskey, err := parseDNSKEY(created[i].DNSKEY)
+				t.Nil(err)
+				t.Equal(flags, dnskey.Flags)
+				t.Equal(dns.ED25519, dnskey.Algorithm)
+				t.Equal(dnskey.KeyTag(), created[i].KeyTag)
+				_, err = dnskey.NewPrivateKey(created[i].PrivateKey)
+				t.Nil(err)
+			}
+			t.Equal(domain.DnssecKeyActive, dnssec.Keys[0].State)
+			t.Len(dnssec.DS, 1)
+			t.Contains(dnssec.DS[0], fmt.Sprintf("DS\t%d 15 2 ", created[0].KeyTag))
+		},
+	)
+
+	errorCases := []struct {
+		name   string
+		dnssec *domain.Dnssec
+		status int
+	}{
+		{"zone_not_found_error", &domain.Dnssec{Zone: "other.com."}, http.StatusNotFound},
+		{"secondary_error", &domain.Dnssec{Zone: "secondary.com."}, http.StatusBadRequest},
+		{"algorithm_error", &domain.Dnssec{Zone: "nsec3.com.", Algorithm: "RSASHA1"}, http.StatusBadRequest},
+		{"iterations_error", &domain.Dnssec{Zone: "nsec3.com.", Nsec3: true, Iterations: 150}, http.StatusBadRequest},
+		{"salt_error", &domain.Dnssec{Zone: "nsec3.com.", Nsec3: true, Salt: "xyz"}, http.StatusBadRequest},
+		{"enabled_error", &domain.Dnssec{Zone: "test.com."}, http.StatusBadRequest},
+	}
+	for _, c := range errorCases {
+		t.Run(
+			c.name, func() {
+				t.SetupTest()
+				err := t.usecase.EnableDnssec(context.Background(), c.dnssec)
+				t.NotNil(err)
+				t.Equal(c.status, err.(*domain.Error).StatusCode)
+				t.dnssecRepo.AssertNotCalled(t.T(), "Create", anyContext, mock.Anything)
+			},
+		)
+	}
+}
+
+func (t *dnssecUseCaseTestSuite) TestGetDnssec() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			dnssec, err := t.usecase.GetDnssec(context.Background(), "test.com")
+			t.Nil(err)
+			t.Len(dnssec.Keys, 2)
+			t.Equal(domain.DnssecKeyActive, dnssec.Keys[0].State)
+			// only the KSK has a DS
+			t.Len(dnssec.DS, 1)
+			t.Contains(dnssec.DS[0], fmt.Sprintf("test.com.\t3600\tIN\tDS\t%d 13 2 ", t.keys[0].KeyTag))
+		},
+	)
+
+	t.Run(
+		"removed_success", func() {
+			t.SetupTest()
+			removed := time.Now().Add(-time.Minute)
+			keys := copyKeys(t.keys)
+			keys[0].Retire, keys[0].Remove = &removed, &removed
+			t.dnssecRepo.ExpectedCalls = nil
+			t.dnssecRepo.
+				On("Get", anyContext, "test.com.").
+				Return(&domain.Dnssec{Zone: "test.com.", Keys: keys}, nil)
+
+			dnssec, err := t.usecase.GetDnssec(context.Background(), "test.com.")
+			t.Nil(err)
+			t.Equal(domain.DnssecKeyRemoved, dnssec.Keys[0].State)
+			t.Empty(dnssec.DS)
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			t.SetupTest()
+			dnssec, err := t.usecase.GetDnssec(context.Background(), "other.com.")
+			t.Nil(dnssec)
+			t.Equal(http.StatusNotFound, err.(*domain.Error).StatusCode)
+		},
+	)
+}
+
+func (t *dnssecUseCaseTestSuite) TestDisableDnssec() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			err := t.usecase.DisableDnssec(context.Background(), "test.com")
+			t.Nil(err)
+			t.dnssecRepo.AssertCalled(t.T(), "Delete", anyContext, "test.com.")
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			t.SetupTest()
+			err := t.usecase.DisableDnssec(context.Background(), "other.com.")
+			t.NotNil(err)
+			t.dnssecRepo.AssertNotCalled(t.T(), "Delete", anyContext, mock.Anything)
+		},
+	)
+}
+
+func (t *dnssecUseCaseTestSuite) TestListDS() {
+	records, err := t.usecase.ListDS(context.Background(), "test.com.")
+	t.Nil(err)
+	t.Len(records, 1)
+	ds, err := dns.NewRR(records[0])
+	t.Nil(err)
+	t.Equal(t.keys[0].KeyTag, ds.(*dns.DS).KeyTag)
+}
+
+func (t *dnssecUseCaseTestSuite) TestRollover() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"default_success", func() {
+			t.SetupTest()
+			now := time.Now()
+			_, err := t.usecase.Rollover(context.Background(), "test.com.", &domain.DnssecRollover{Type: domain.DnssecZSK})
+			t.Nil(err)
+
+			var retired *domain.DnssecKey
+			for _, call := range t.dnssecRepo.Calls {
+				if call.Method == "UpdateKey" {
+					retired = call.Arguments.Get(1).(*domain.DnssecKey)
+				}
+			}
+			// the old ZSK retires after the DNSKEY TTL and is removed after another one
+			t.Equal(t.keys[1].KeyTag, retired.KeyTag)
+			t.WithinDuration(now.Add(time.Hour), *retired.Retire, time.Minute)
+			t.Equal(retired.Retire.Add(time.Hour), *retired.Remove)
+			t.dnssecRepo.AssertCalled(
+				t.T(), "CreateKey", anyContext, mock.MatchedBy(
+					func(key *domain.DnssecKey) bool {
+						return key.Type == domain.DnssecZSK && key.Activate.Equal(*retired.Retire) &&
+							key.KeyTag != t.keys[1].KeyTag
+					},
+				),
+			)
+		},
+	)
+
+	t.Run(
+		"scheduled_success", func() {
+			t.SetupTest()
+			at := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+			_, err := t.usecase.Rollover(
+				context.Background(), "test.com.", &domain.DnssecRollover{Type: domain.DnssecKSK, At: &at},
+			)
+			t.Nil(err)
+			t.dnssecRepo.AssertNumberOfCalls(t.T(), "UpdateKey", 1)
+			t.dnssecRepo.AssertCalled(
+				t.T(), "UpdateKey", anyContext, mock.MatchedBy(
+					func(key *domain.DnssecKey) bool {
+						return key.Type == domain.DnssecKSK && key.Retire.Equal(at)
+					},
+				),
+			)
+		},
+	)
+
+	t.Run(
+		"past_error", func() {
+			t.SetupTest()
+			at := time.Now().Add(-time.Hour)
+			_, err := t.usecase.Rollover(
+				context.Background(), "test.com.", &domain.DnssecRollover{Type: domain.DnssecKSK, At: &at},
+			)
+			t.NotNil(err)
+			t.Equal(http.StatusBadRequest, err.(*domain.Error).StatusCode)
+			t.dnssecRepo.AssertNotCalled(t.T(), "CreateKey", anyContext, mock.Anything)
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			t.SetupTest()
+			_, err := t.usecase.Rollover(context.Background(), "other.com.", &domain.DnssecRollover{Type: domain.DnssecKSK})
+			t.NotNil(err)
+		},
+	)
+}
+
+func (t *dnssecUseCaseTestSuite) TestSign() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		anyTime    = mock.AnythingOfType("time.Duration")
+	)
+
+	t.Run(
+		"answer_success", func() {
+			t.SetupTest()
+			req, resp := t.query("www.test.com.", dns.TypeA, dns.RcodeSuccess, "www.test.com.\t3600\tIN\tA\t1.1.1.1")
+			resp, err := t.usecase.Sign(context.Background(), req, resp)
+			t.Nil(err)
+			t.Len(resp.Answer, 2)
+			t.verify(resp.Answer, t.keys[1])
+			t.True(resp.IsEdns0().Do())
+			t.redisRepo.AssertCalled(t.T(), "HSet", anyContext, anyString, "RRSIG", anyString, anyTime)
+		},
+	)
+
+	t.Run(
+		"cached_success", func() {
+			t.SetupTest()
+			req, resp := t.query("www.test.com.", dns.TypeA, dns.RcodeSuccess, "www.test.com.\t3600\tIN\tA\t1.1.1.1")
+			resp, err := t.usecase.Sign(context.Background(), req, resp)
+			t.Nil(err)
+
+			t.SetupTest()
+			t.redisRepo.ExpectedCalls = nil
+			t.redisRepo.
+				On("HGetAll", anyContext, anyString).
+				Return(map[string]string{"RRSIG": resp.Answer[1].String()}, nil)
+			req, resp = t.query("www.test.com.", dns.TypeA, dns.RcodeSuccess, "www.test.com.\t3600\tIN\tA\t1.1.1.1")
+			resp, err = t.usecase.Sign(context.Background(), req, resp)
+			t.Nil(err)
+			t.verify(resp.Answer, t.keys[1])
+			t.redisRepo.AssertNotCalled(t.T(), "HSet", anyContext, anyString, anyString, anyString, anyTime)
+		},
+	)
+
+	t.Run(
+		"dnskey_success", func() {
+			t.SetupTest()
+			req, resp := t.query("test.com.", dns.TypeDNSKEY, dns.RcodeSuccess)
+			resp, err := t.usecase.Sign(context.Background(), req, resp)
+			t.Nil(err)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Empty(resp.Ns)
+			// the DNSKEY RRset is signed by the KSK
+			t.Len(resp.Answer, 3)
+			t.verify(resp.Answer, t.keys[0])
+		},
+	)
+
+	t.Run(
+		"dnskey_unsigned_success", func() {
+			t.SetupTest()
+			req, resp := t.query("test.com.", dns.TypeDNSKEY, dns.RcodeSuccess)
+			req.Extra = nil
+			resp, err := t.usecase.Sign(context.Background(), req, resp)
+			t.Nil(err)
+			t.Len(resp.Answer, 2)
+			t.Nil(resp.IsEdns0())
+		},
+	)
+
+	t.Run(
+		"no_do_success", func() {
+			t.SetupTest()
+			req, resp := t.query("www.test.com.", dns.TypeA, dns.RcodeSuccess, "www.test.com.\t3600\tIN\tA\t1.1.1.1")
+			req.IsEdns0().SetDo(false)
+			resp, err := t.usecase.Sign(context.Background(), req, resp)
+			t.Nil(err)
+			t.Len(resp.Answer, 1)
+			t.Nil(resp.IsEdns0())
+		},
+	)
+
+	t.Run(
+		"unsigned_zone_success", func() {
+			t.SetupTest()
+			req, resp := t.query("www.other.com.", dns.TypeA, dns.RcodeSuccess, "www.other.com.\t3600\tIN\tA\t1.1.1.1")
+			resp, err := t.usecase.Sign(context.Background(), req, resp)
+			t.Nil(err)
+			t.Len(resp.Answer, 1)
+			t.dnssecRepo.AssertNotCalled(t.T(), "Get", anyContext, mock.Anything)
+		},
+	)
+
+	t.Run(
+		"nsec_nxdomain_success", func() {
+			t.SetupTest()
+			req, resp := t.query("a.b.test.com.", dns.TypeA, dns.RcodeNameError)
+			resp, err := t.usecase.Sign(context.Background(), req, resp)
+			t.Nil(err)
+			t.verify(resp.Ns, t.keys[1])
+
+			var covered []string
+			for _, rr := range resp.Ns {
+				if nsec, ok := rr.(*dns.NSEC); ok {
+					t.Equal(uint32(300), nsec.Hdr.Ttl)
+					for _, name := range []string{"a.b.test.com.", "*.test.com."} {
+						if compareCanonical(nsec.Hdr.Name, name) < 0 && compareCanonical(name, nsec.NextDomain) < 0 {
+							covered = append(covered, name)
+						}
+					}
+					// the covering records don't reveal the names of the zone
+					t.Equal([]uint16{dns.TypeRRSIG, dns.TypeNSEC}, nsec.TypeBitMap)
+				}
+			}
+			t.Equal([]string{"a.b.test.com.", "*.test.com."}, covered)
+		},
+	)
+
+	t.Run(
+		"nsec_nodata_success", func() {
+			t.SetupTest()
+			req, resp := t.query("www.test.com.", dns.TypeAAAA, dns.RcodeSuccess)
+			resp, err := t.usecase.Sign(context.Background(), req, resp)
+			t.Nil(err)
+			t.verify(resp.Ns, t.keys[1])
+
+			nsec := resp.Ns[2].(*dns.NSEC)
+			t.Equal("www.test.com.", nsec.Hdr.Name)
+			t.Equal([]uint16{dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC}, nsec.TypeBitMap)
+			t.True(compareCanonical("www.test.com.", nsec.NextDomain) < 0)
+		},
+	)
+
+	t.Run(
+		"nsec_cname_nodata_success", func() {
+			t.SetupTest()
+			req, resp := t.query(
+				"alias.test.com.", dns.TypeAAAA, dns.RcodeSuccess,
+				"alias.test.com.\t3600\tIN\tCNAME\twww.test.com.",
+			)
+			soa, _ := dns.NewRR("test.com.\t300\tIN\tSOA\tns1.test.com. admin.test.com. 1 7200 3600 86400 300")
+			resp.Ns = []dns.RR{soa}
+			resp, err := t.usecase.Sign(context.Background(), req, resp)
+			t.Nil(err)
+			t.verify(resp.Answer, t.keys[1])
+			// the denial is of the target of the CNAME
+			t.Equal("www.test.com.", resp.Ns[2].Header().Name)
+		},
+	)
+
+	t.Run(
+		"nsec3_nxdomain_success", func() {
+			t.SetupTest()
+			req, resp := t.query("a.b.nsec3.com.", dns.TypeA, dns.RcodeNameError)
+			resp, err := t.usecase.Sign(context.Background(), req, resp)
+			t.Nil(err)
+			t.verify(resp.Ns, t.nsec3Keys[1])
+
+			var nsec3s []*dns.NSEC3
+			for _, rr := range resp.Ns {
+				if nsec3, ok := rr.(*dns.NSEC3); ok {
+					nsec3s = append(nsec3s, nsec3)
+				}
+			}
+			t.Len(nsec3s, 3)
+			// the closest encloser is matched, the next closer name and the wildcard are covered
+			t.True(nsec3s[0].Match("nsec3.com."))
+			t.Contains(nsec3s[0].TypeBitMap, dns.TypeNSEC3PARAM)
+			t.True(nsec3s[1].Cover("b.nsec3.com."))
+			t.True(nsec3s[2].Cover("*.nsec3.com."))
+			t.Equal(uint16(1), nsec3s[0].Iterations)
+			t.Equal("AABB", nsec3s[0].Salt)
+		},
+	)
+
+	t.Run(
+		"nsec3_nodata_success", func() {
+			t.SetupTest()
+			req, resp := t.query("nsec3.com.", dns.TypeA, dns.RcodeSuccess)
+			resp, err := t.usecase.Sign(context.Background(), req, resp)
+			t.Nil(err)
+
+			nsec3 := resp.Ns[2].(*dns.NSEC3)
+			t.True(nsec3.Match("nsec3.com."))
+			t.NotContains(nsec3.TypeBitMap, dns.TypeA)
+			t.NotContains(nsec3.TypeBitMap, dns.TypeNSEC)
+		},
+	)
+
+	t.Run(
+		"nsec3param_success", func() {
+			t.SetupTest()
+			req, resp := t.query("nsec3.com.", dns.TypeNSEC3PARAM, dns.RcodeSuccess)
+			resp, err := t.usecase.Sign(context.Background(), req, resp)
+			t.Nil(err)
+			t.verify(resp.Answer, t.nsec3Keys[1])
+			param := resp.Answer[0].(*dns.NSEC3PARAM)
+			t.Equal(uint16(1), param.Iterations)
+			t.Equal("AABB", param.Salt)
+		},
+	)
+
+	t.Run(
+		"HSet_error", func() {
+			t.SetupTest()
+			t.redisRepo.ExpectedCalls = nil
+			t.redisRepo.
+				On("HGetAll", anyContext, anyString).
+				Return(map[string]string{}, nil)
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+				Return(fmt.Errorf("test-error"))
+
+			req, resp := t.query("www.test.com.", dns.TypeA, dns.RcodeSuccess, "www.test.com.\t3600\tIN\tA\t1.1.1.1")
+			resp, err := t.usecase.Sign(context.Background(), req, resp)
+			t.Nil(resp)
+			t.Equal("test-error", err.Error())
+		},
+	)
+}
+
+func (t *dnssecUseCaseTestSuite) TestPredecessor() {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"b.test.com.", "a\\255.test.com."},
+		{"\\000.test.com.", "test.com."},
+		{"a\\000.test.com.", "a.test.com."},
+		{"*.test.com.", "\\)\\255.test.com."},
+		{"[.test.com.", "\\@\\255.test.com."},
+	}
+	for _, c := range cases {
+		t.Equal(c.want, predecessor(c.name), c.name)
+		t.True(compareCanonical(predecessor(c.name), c.name) < 0, c.name)
+		t.True(compareCanonical(c.name, successor(c.name)) < 0, c.name)
+	}
+}
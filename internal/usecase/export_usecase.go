@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/utils"
+)
+
+type exportUseCase struct {
+	recordRepo domain.RecordRepo
+
+	zoneRepo domain.ZoneRepo
+}
+
+// recordBatchSize is the number of records read from the DB at a time
+const recordBatchSize = 500
+
+func (e *exportUseCase) Export(ctx context.Context, filter domain.ExportFilter,
+	write func(section *domain.ExportSection) error) error {
+	allZones, err := e.zoneRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	zones := slices.Clone(allZones)
+	if filter.Zone != "" {
+		zone, err := e.zoneRepo.Get(ctx, utils.GetFQDNFromDomainName(filter.Zone))
+		if err != nil {
+			return err
+		}
+		zones = []*domain.Zone{zone}
+	}
+
+	var name string
+	if filter.Name != "" {
+		name = utils.GetFQDNFromDomainName(filter.Name)
+	}
+
+	slices.SortFunc(zones, func(a, b *domain.Zone) int { return compareCanonical(a.Name, b.Name) })
+
+	for _, zone := range zones {
+		section := &domain.ExportSection{Origin: zone.Name, Ttl: zone.Ttl}
+		err = e.readSection(ctx, allZones, zone.Name, name, section)
+		if err != nil {
+			return err
+		}
+		if name == "" || strings.EqualFold(name, zone.Name) {
+			section.RRs = append(append([]dns.RR{zone.SOA()}, zone.NS()...), section.RRs...)
+		}
+		if len(section.RRs) == 0 {
+			continue
+		}
+		err = write(section)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the records outside any zone are only exported without the zone filter
+	if filter.Zone != "" {
+		return nil
+	}
+	section := &domain.ExportSection{Origin: "."}
+	err = e.readSection(ctx, allZones, "", name, section)
+	if err != nil || len(section.RRs) == 0 {
+		return err
+	}
+	return write(section)
+}
+
+// readSection reads the records of the zone batch by batch into the section in the canonical order, the
+// records of its sub-zones are skipped, the empty zone reads the records outside any zone
+func (e *exportUseCase) readSection(ctx context.Context, zones []*domain.Zone, zone string, name string,
+	section *domain.ExportSection) error {
+	query := zone
+	if name != "" {
+		// only the closest zone of the name has its records
+		if closestZone(zones, name) != zone {
+			return nil
+		}
+		query = name
+	}
+
+	err := e.recordRepo.ListInBatches(
+		ctx, query, recordBatchSize, func(records []*domain.Record) error {
+			for _, record := range records {
+				if name != "" && !strings.EqualFold(record.Name, name) {
+					continue
+				}
+				if closestZone(zones, record.Name) != zone {
+					continue
+				}
+				rr, err := dns.NewRR(record.Record)
+				if err != nil {
+					return err
+				}
+				section.RRs = append(section.RRs, rr)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	sortCanonical(section.RRs)
+	return nil
+}
+
+// closestZone returns the name of the most specific zone which contains the name, it's empty
+// when the name is outside any zone
+func closestZone(zones []*domain.Zone, name string) string {
+	var closest string
+	for _, zone := range zones {
+		if dns.IsSubDomain(zone.Name, name) && dns.CountLabel(zone.Name) >= dns.CountLabel(closest) {
+			closest = zone.Name
+		}
+	}
+	return closest
+}
+
+// sortCanonical sorts the records by the canonical order of their owners (RFC 4034 6.1), then
+// by their types and rdata, so that the exports can be diffed
+func sortCanonical(rrs []dns.RR) {
+	sort.SliceStable(
+		rrs, func(i, j int) bool {
+			a, b := rrs[i].Header(), rrs[j].Header()
+			if c := compareCanonical(a.Name, b.Name); c != 0 {
+				return c < 0
+			}
+			if a.Rrtype != b.Rrtype {
+				return a.Rrtype < b.Rrtype
+			}
+			return rrs[i].String() < rrs[j].String()
+		},
+	)
+}
+
+func NewExportUseCase(injector *do.Injector) (domain.ExportUseCase, error) {
+	return &exportUseCase{
+		do.MustInvoke[domain.RecordRepo](injector),
+		do.MustInvoke[domain.ZoneRepo](injector),
+	}, nil
+}
@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+)
+
+type exportUseCaseTestSuite struct {
+	suite.Suite
+
+	usecase domain.ExportUseCase
+
+	recordRepo *mocks.RecordRepo
+	zoneRepo   *mocks.ZoneRepo
+
+	zone    *domain.Zone
+	subZone *domain.Zone
+}
+
+func TestExportUseCase(t *testing.T) {
+	suite.Run(t, &exportUseCaseTestSuite{})
+}
+
+func (t *exportUseCaseTestSuite) SetupSuite() {
+	injector := do.New()
+	t.recordRepo = &mocks.RecordRepo{}
+	t.zoneRepo = &mocks.ZoneRepo{}
+	do.ProvideValue[domain.RecordRepo](injector, t.recordRepo)
+	do.ProvideValue[domain.ZoneRepo](injector, t.zoneRepo)
+
+	t.usecase, _ = NewExportUseCase(injector)
+}
+
+func (t *exportUseCaseTestSuite) SetupTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+	)
+
+	t.zone = &domain.Zone{
+		Name:        "test.com.",
+		Nameservers: []string{"ns1.test.com."},
+		Mbox:        "admin.test.com.",
+		Serial:      2024010100,
+		Ttl:         3600,
+	}
+	t.subZone = &domain.Zone{
+		Name:        "sub.test.com.",
+		Nameservers: []string{"ns1.test.com."},
+		Mbox:        "admin.test.com.",
+		Serial:      2024010100,
+		Ttl:         300,
+	}
+
+	t.recordRepo.ExpectedCalls = nil
+	t.zoneRepo.ExpectedCalls = nil
+
+	t.zoneRepo.
+		On("List", anyContext).
+		Return([]*domain.Zone{t.subZone, t.zone}, nil)
+	t.zoneRepo.
+		On("Get", anyContext, "test.com.").
+		Return(t.zone, nil)
+	t.zoneRepo.
+		On("Get", anyContext, anyString).
+		Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+
+	var records []*domain.Record
+	for _, s := range []string{
+		"www.test.com.\t3600\tIN\tA\t2.2.2.2",
+		"other.com.\t60\tIN\tA\t9.9.9.9",
+		"www.test.com.\t3600\tIN\tA\t1.1.1.1",
+		"a.test.com.\t3600\tIN\tTXT\t\"a\"",
+		"host.sub.test.com.\t300\tIN\tA\t3.3.3.3",
+		"www.test.com.\t3600\tIN\tAAAA\t::1",
+		"test.com.\t3600\tIN\tMX\t10 mail.test.com.",
+	} {
+		rr, _ := dns.NewRR(s)
+		records = append(records, domain.NewRecord(rr))
+	}
+	t.recordRepo.
+		On("ListInBatches", anyContext, anyString, recordBatchSize, mock.Anything).
+		Return(
+			func(_ context.Context, zone string, _ int, fn func(records []*domain.Record) error) error {
+				// the records are sent two by two like the pages of the DB
+				var batch []*domain.Record
+				for _, record := range records {
+					if zone != "" && !dns.IsSubDomain(zone, record.Name) {
+						continue
+					}
+					batch = append(batch, record)
+					if len(batch) == 2 {
+						if err := fn(batch); err != nil {
+							return err
+						}
+						batch = nil
+					}
+				}
+				if len(batch) == 0 {
+					return nil
+				}
+				return fn(batch)
+			},
+		)
+}
+
+// export returns the sections written by the use case
+func (t *exportUseCaseTestSuite) export(filter domain.ExportFilter) ([]*domain.ExportSection, error) {
+	var sections []*domain.ExportSection
+	err := t.usecase.Export(
+		context.Background(), filter, func(section *domain.ExportSection) error {
+			sections = append(sections, section)
+			return nil
+		},
+	)
+	return sections, err
+}
+
+func (t *exportUseCaseTestSuite) TestExport() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+	)
+
+	toStrings := func(rrs []dns.RR) []string {
+		var output []string
+		for _, rr := range rrs {
+			output = append(output, rr.String())
+		}
+		return output
+	}
+
+	t.Run(
+		"success_all", func() {
+			sections, err := t.export(domain.ExportFilter{})
+			t.Nil(err)
+			t.Len(sections, 3)
+
+			t.Equal("test.com.", sections[0].Origin)
+			t.Equal(uint32(3600), sections[0].Ttl)
+			t.Equal(
+				[]string{
+					t.zone.SOA().String(),
+					"test.com.\t3600\tIN\tNS\tns1.test.com.",
+					"test.com.\t3600\tIN\tMX\t10 mail.test.com.",
+					"a.test.com.\t3600\tIN\tTXT\t\"a\"",
+					"www.test.com.\t3600\tIN\tA\t1.1.1.1",
+					"www.test.com.\t3600\tIN\tA\t2.2.2.2",
+					"www.test.com.\t3600\tIN\tAAAA\t::1",
+				}, toStrings(sections[0].RRs),
+			)
+
+			t.Equal("sub.test.com.", sections[1].Origin)
+			t.Len(sections[1].RRs, 3)
+			t.Equal("host.sub.test.com.\t300\tIN\tA\t3.3.3.3", sections[1].RRs[2].String())
+
+			t.Equal(".", sections[2].Origin)
+			t.Zero(sections[2].Ttl)
+			t.Equal([]string{"other.com.\t60\tIN\tA\t9.9.9.9"}, toStrings(sections[2].RRs))
+		},
+	)
+
+	t.Run(
+		"success_zone", func() {
+			sections, err := t.export(domain.ExportFilter{Zone: "test.com"})
+			t.Nil(err)
+			t.Len(sections, 1)
+			t.Len(sections[0].RRs, 7)
+		},
+	)
+
+	t.Run(
+		"success_name", func() {
+			sections, err := t.export(domain.ExportFilter{Name: "WWW.test.com"})
+			t.Nil(err)
+			t.Len(sections, 1)
+			t.Equal("test.com.", sections[0].Origin)
+			t.Len(sections[0].RRs, 3)
+		},
+	)
+
+	t.Run(
+		"success_zone_apex", func() {
+			sections, err := t.export(domain.ExportFilter{Zone: "test.com.", Name: "test.com."})
+			t.Nil(err)
+			t.Len(sections, 1)
+			t.Len(sections[0].RRs, 3)
+		},
+	)
+
+	t.Run(
+		"zone_not_found_error", func() {
+			_, err := t.export(domain.ExportFilter{Zone: "none.com"})
+			t.NotNil(err)
+			t.Contains(err.Error(), "record not found")
+		},
+	)
+
+	t.Run(
+		"success_name_query", func() {
+			t.recordRepo.Calls = nil
+			_, err := t.export(domain.ExportFilter{Name: "host.sub.test.com."})
+			t.Nil(err)
+			// only the closest zone of the name is read
+			t.recordRepo.AssertNumberOfCalls(t.T(), "ListInBatches", 1)
+			t.recordRepo.AssertCalled(
+				t.T(), "ListInBatches", anyContext, "host.sub.test.com.", recordBatchSize, mock.Anything,
+			)
+		},
+	)
+
+	t.Run(
+		"write_error", func() {
+			var written int
+			err := t.usecase.Export(
+				context.Background(), domain.ExportFilter{}, func(section *domain.ExportSection) error {
+					written++
+					return fmt.Errorf("test-error")
+				},
+			)
+			t.NotNil(err)
+			t.Equal("test-error", err.Error())
+			t.Equal(1, written)
+		},
+	)
+
+	t.Run(
+		"ListInBatches_error", func() {
+			t.SetupTest()
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("ListInBatches", anyContext, anyString, recordBatchSize, mock.Anything).
+				Return(fmt.Errorf("test-error"))
+			sections, err := t.export(domain.ExportFilter{})
+			t.NotNil(err)
+			t.Equal("test-error", err.Error())
+			t.Len(sections, 0)
+		},
+	)
+}
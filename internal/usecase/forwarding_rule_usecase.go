@@ -0,0 +1,279 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/pkg/forwarder"
+)
+
+type forwardingRuleUseCase struct {
+	forwardingRuleRepo domain.ForwardingRuleRepo
+	forwarders         *forwarder.Pool
+	// upstreams are the default upstream forwarders of the names matching no rule
+	upstreams *forwarder.Group
+	// resolveUseCase resolves the names matching no rule in place of the default upstream forwarders,
+	// it's nil when the recursion isn't enabled
+	resolveUseCase domain.ResolveUseCase
+
+	// the health options of the forwarder groups of the rules
+	parallel  uint
+	maxFails  uint
+	ejectTime time.Duration
+
+	// groups are the forwarder groups by the suffixes of the rules, which keep the health of their
+	// upstreams across the queries, it's nil until the rules are loaded
+	mu     sync.RWMutex
+	groups map[string]*forwarder.Group
+}
+
+func (f *forwardingRuleUseCase) Forward(ctx context.Context, req *dns.Msg, accept func(*dns.Msg) bool) (
+	*dns.Msg, error,
+) {
+	group, err := f.match(ctx, req.Question[0].Name)
+	if err != nil {
+		return nil, err
+	}
+	if group != nil {
+		return group.Exchange(ctx, req, accept)
+	}
+
+	resp, err := f.resolveUseCase.Resolve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if !accept(resp) {
+		return nil, fmt.Errorf("the resolved answer of %s isn't accepted", req.Question[0].Name)
+	}
+	return resp, nil
+}
+
+func (f *forwardingRuleUseCase) MatchSuffix(ctx context.Context, name string) (string, error) {
+	groups, err := f.load(ctx)
+	if err != nil {
+		return "", err
+	}
+	return ruleSuffix(groups, name), nil
+}
+
+func (f *forwardingRuleUseCase) CreateRule(ctx context.Context, rule *domain.ForwardingRule) error {
+	group, err := f.newGroup(rule)
+	if err != nil {
+		return err
+	}
+
+	existed, _ := f.forwardingRuleRepo.Get(ctx, rule.Suffix)
+	if existed != nil {
+		return &domain.Error{
+			Message:    "the forwarding rule is already existed.",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	err = f.forwardingRuleRepo.Create(ctx, rule)
+	if err != nil {
+		return err
+	}
+	f.setGroup(rule.Suffix, group)
+	return nil
+}
+
+func (f *forwardingRuleUseCase) GetRule(ctx context.Context, suffix string) (*domain.ForwardingRule, error) {
+	return f.forwardingRuleRepo.Get(ctx, dns.CanonicalName(suffix))
+}
+
+func (f *forwardingRuleUseCase) ListRules(ctx context.Context) ([]*domain.ForwardingRule, error) {
+	return f.forwardingRuleRepo.List(ctx)
+}
+
+func (f *forwardingRuleUseCase) UpdateRule(ctx context.Context, rule *domain.ForwardingRule) error {
+	group, err := f.newGroup(rule)
+	if err != nil {
+		return err
+	}
+
+	err = f.forwardingRuleRepo.Update(ctx, rule)
+	if err != nil {
+		return err
+	}
+	f.setGroup(rule.Suffix, group)
+	return nil
+}
+
+func (f *forwardingRuleUseCase) DeleteRule(ctx context.Context, suffix string) error {
+	suffix = dns.CanonicalName(suffix)
+	err := f.forwardingRuleRepo.Delete(ctx, suffix)
+	if err != nil {
+		return err
+	}
+	f.setGroup(suffix, nil)
+	return nil
+}
+
+func (f *forwardingRuleUseCase) ProbeUpstreams(ctx context.Context) error {
+	groups, err := f.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, group := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			group.Probe(ctx)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (f *forwardingRuleUseCase) ListUpstreams(ctx context.Context) ([]*domain.UpstreamGroup, error) {
+	groups, err := f.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	suffixes := make([]string, 0, len(groups))
+	for suffix := range groups {
+		suffixes = append(suffixes, suffix)
+	}
+	slices.Sort(suffixes)
+	upstreamGroups := make([]*domain.UpstreamGroup, 0, len(suffixes))
+	for _, suffix := range suffixes {
+		upstreamGroups = append(upstreamGroups, newUpstreamGroup(suffix, groups[suffix]))
+	}
+	return upstreamGroups, nil
+}
+
+// match returns the forwarder group of the longest suffix of the name, or the default upstreams, which
+// are nil when the names are resolved from the root hints
+func (f *forwardingRuleUseCase) match(ctx context.Context, name string) (*forwarder.Group, error) {
+	groups, err := f.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if suffix := ruleSuffix(groups, name); suffix != "" {
+		return groups[suffix], nil
+	}
+	if f.resolveUseCase != nil {
+		return nil, nil
+	}
+	return f.upstreams, nil
+}
+
+// ruleSuffix returns the longest suffix of the name in the groups, or empty when none matches
+func ruleSuffix(groups map[string]*forwarder.Group, name string) string {
+	name = dns.CanonicalName(name)
+	for _, i := range dns.Split(name) {
+		if _, ok := groups[name[i:]]; ok {
+			return name[i:]
+		}
+	}
+	if _, ok := groups["."]; ok {
+		return "."
+	}
+	return ""
+}
+
+// load builds the forwarder groups of the stored rules once
+func (f *forwardingRuleUseCase) load(ctx context.Context) (map[string]*forwarder.Group, error) {
+	f.mu.RLock()
+	groups := f.groups
+	f.mu.RUnlock()
+	if groups != nil {
+		return groups, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.groups != nil {
+		return f.groups, nil
+	}
+	rules, err := f.forwardingRuleRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groups = make(map[string]*forwarder.Group)
+	for _, rule := range rules {
+		group, err := f.newGroup(rule)
+		if err != nil {
+			return nil, err
+		}
+		groups[rule.Suffix] = group
+	}
+	f.groups = groups
+	return groups, nil
+}
+
+// setGroup replaces the forwarder group of the suffix in the loaded rules, or removes it when the group
+// is nil. The map is copied, since the loaded ones are read without the lock.
+func (f *forwardingRuleUseCase) setGroup(suffix string, group *forwarder.Group) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.groups == nil {
+		return
+	}
+	groups := make(map[string]*forwarder.Group, len(f.groups)+1)
+	for s, g := range f.groups {
+		groups[s] = g
+	}
+	if group == nil {
+		delete(groups, suffix)
+	} else {
+		groups[suffix] = group
+	}
+	f.groups = groups
+}
+
+// newGroup canonicalizes the suffix and the upstreams of the rule, and returns the forwarder group of
+// its upstreams by its strategy, which is sequential when not given
+func (f *forwardingRuleUseCase) newGroup(rule *domain.ForwardingRule) (*forwarder.Group, error) {
+	if _, ok := dns.IsDomainName(rule.Suffix); !ok {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("the suffix %s isn't a domain name", rule.Suffix),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	rule.Suffix = dns.CanonicalName(rule.Suffix)
+	for i := range rule.Upstreams {
+		rule.Upstreams[i] = strings.TrimSpace(rule.Upstreams[i])
+	}
+	if rule.Strategy == "" {
+		rule.Strategy = forwarder.Sequential
+	}
+
+	group, err := forwarder.NewGroup(f.forwarders, rule.Upstreams, rule.Strategy, f.parallel, f.maxFails, f.ejectTime)
+	if err != nil {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("the forwarding rule of %s is invalid: %s", rule.Suffix, err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+	return group, nil
+}
+
+func NewForwardingRuleUseCase(injector *do.Injector) (domain.ForwardingRuleUseCase, error) {
+	env := do.MustInvoke[*domain.Options](injector)
+	return &forwardingRuleUseCase{
+		forwardingRuleRepo: do.MustInvoke[domain.ForwardingRuleRepo](injector),
+		forwarders:         do.MustInvoke[*forwarder.Pool](injector),
+		upstreams:          do.MustInvoke[*forwarder.Group](injector),
+		resolveUseCase:     do.MustInvoke[domain.ResolveUseCase](injector),
+		parallel:           env.UpstreamParallel,
+		maxFails:           env.UpstreamMaxFails,
+		ejectTime:          time.Duration(env.UpstreamEjectTime) * time.Second,
+	}, nil
+}
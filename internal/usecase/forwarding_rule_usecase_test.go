@@ -0,0 +1,356 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+	"github.com/cewuandy/go-restful-dns/pkg/forwarder"
+)
+
+type forwardingRuleUseCaseTestSuite struct {
+	suite.Suite
+
+	forwardingRuleRepo *mocks.ForwardingRuleRepo
+
+	// public, corp and cluster are the fake upstreams, which answer their own addresses in TXT records
+	public  *dns.Server
+	corp    *dns.Server
+	cluster *dns.Server
+
+	usecase domain.ForwardingRuleUseCase
+}
+
+func TestForwardingRuleUseCase(t *testing.T) {
+	suite.Run(t, &forwardingRuleUseCaseTestSuite{})
+}
+
+func (t *forwardingRuleUseCaseTestSuite) SetupSuite() {
+	t.public = t.startFakeUpstream()
+	t.corp = t.startFakeUpstream()
+	t.cluster = t.startFakeUpstream()
+}
+
+func (t *forwardingRuleUseCaseTestSuite) TearDownSuite() {
+	for _, server := range []*dns.Server{t.public, t.corp, t.cluster} {
+		t.Nil(server.Shutdown())
+	}
+}
+
+func (t *forwardingRuleUseCaseTestSuite) SetupTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyRule    = mock.AnythingOfType("*domain.ForwardingRule")
+	)
+
+	t.forwardingRuleRepo = &mocks.ForwardingRuleRepo{}
+	t.forwardingRuleRepo.
+		On("List", anyContext).
+		Return(
+			[]*domain.ForwardingRule{
+				{Suffix: "corp.example.", Upstreams: []string{t.addr(t.corp)}, Strategy: forwarder.Sequential},
+				{Suffix: "example.", Upstreams: []string{t.addr(t.cluster)}, Strategy: forwarder.Sequential},
+			}, nil,
+		)
+	t.forwardingRuleRepo.
+		On("Get", anyContext, "corp.example.").
+		Return(&domain.ForwardingRule{Suffix: "corp.example.", Upstreams: []string{t.addr(t.corp)}}, nil)
+	t.forwardingRuleRepo.
+		On("Get", anyContext, mock.AnythingOfType("string")).
+		Return(nil, &domain.Error{Message: "DB error: record not found", StatusCode: http.StatusNotFound})
+	t.forwardingRuleRepo.On("Create", anyContext, anyRule).Return(nil)
+	t.forwardingRuleRepo.On("Update", anyContext, anyRule).Return(nil)
+	t.forwardingRuleRepo.On("Delete", anyContext, mock.AnythingOfType("string")).Return(nil)
+
+	injector := do.New()
+	forwarders, _ := forwarder.NewPool("", false)
+	upstreams, _ := forwarder.NewGroup(forwarders, []string{t.addr(t.public)}, forwarder.Sequential, 1, 3, 0)
+	do.ProvideValue(injector, forwarders)
+	do.ProvideValue(injector, upstreams)
+	do.ProvideValue(injector, &domain.Options{UpstreamParallel: 2, UpstreamMaxFails: 3, UpstreamEjectTime: 30})
+	do.ProvideValue[domain.ForwardingRuleRepo](injector, t.forwardingRuleRepo)
+	do.ProvideValue[domain.ResolveUseCase](injector, nil)
+	t.usecase, _ = NewForwardingRuleUseCase(injector)
+}
+
+func (t *forwardingRuleUseCaseTestSuite) startFakeUpstream() *dns.Server {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	t.Nil(err)
+	addr := pc.LocalAddr().String()
+
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn: pc,
+		Handler: dns.HandlerFunc(
+			func(w dns.ResponseWriter, req *dns.Msg) {
+				resp := new(dns.Msg)
+				resp.SetReply(req)
+				txt, _ := dns.NewRR(req.Question[0].Name + "\t300\tIN\tTXT\t" + addr)
+				resp.Answer = append(resp.Answer, txt)
+				_ = w.WriteMsg(resp)
+			},
+		),
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	<-started
+	return server
+}
+
+func (t *forwardingRuleUseCaseTestSuite) addr(server *dns.Server) string {
+	return server.PacketConn.LocalAddr().String()
+}
+
+// forward returns the address of the upstream which answers the name
+func (t *forwardingRuleUseCaseTestSuite) forward(name string) string {
+	req := new(dns.Msg)
+	req.SetQuestion(name, dns.TypeTXT)
+	resp, err := t.usecase.Forward(
+		context.Background(), req, func(resp *dns.Msg) bool { return resp.Rcode == dns.RcodeSuccess },
+	)
+	t.Nil(err)
+	if err != nil {
+		return ""
+	}
+	return resp.Answer[0].(*dns.TXT).Txt[0]
+}
+
+func (t *forwardingRuleUseCaseTestSuite) TestForward() {
+	t.Run(
+		"longest_suffix_success", func() {
+			t.SetupTest()
+			t.Equal(t.addr(t.corp), t.forward("dc1.corp.example."))
+			t.Equal(t.addr(t.corp), t.forward("CORP.example."))
+			t.Equal(t.addr(t.cluster), t.forward("www.example."))
+			t.Equal(t.addr(t.cluster), t.forward("example."))
+			t.Equal(t.addr(t.public), t.forward("www.notcorp.test."))
+			t.Equal(t.addr(t.public), t.forward("."))
+			// the rules are loaded once
+			t.forwardingRuleRepo.AssertNumberOfCalls(t.T(), "List", 1)
+		},
+	)
+
+	t.Run(
+		"root_rule_success", func() {
+			t.SetupTest()
+			t.forwardingRuleRepo.ExpectedCalls = nil
+			t.forwardingRuleRepo.
+				On("List", mock.Anything).
+				Return([]*domain.ForwardingRule{{Suffix: ".", Upstreams: []string{t.addr(t.cluster)}}}, nil)
+			t.Equal(t.addr(t.cluster), t.forward("www.test."))
+		},
+	)
+
+	t.Run(
+		"List_error", func() {
+			t.SetupTest()
+			t.forwardingRuleRepo.ExpectedCalls = nil
+			t.forwardingRuleRepo.On("List", mock.Anything).Return(nil, errors.New("DB error"))
+
+			req := new(dns.Msg)
+			req.SetQuestion("www.test.", dns.TypeTXT)
+			_, err := t.usecase.Forward(context.Background(), req, func(*dns.Msg) bool { return true })
+			t.NotNil(err)
+		},
+	)
+}
+
+func (t *forwardingRuleUseCaseTestSuite) TestMatchSuffix() {
+	t.Run(
+		"longest_suffix_success", func() {
+			t.SetupTest()
+			for name, suffix := range map[string]string{
+				"dc1.CORP.example.": "corp.example.",
+				"www.example.":      "example.",
+				"www.test.":         "",
+			} {
+				matched, err := t.usecase.MatchSuffix(context.Background(), name)
+				t.Nil(err)
+				t.Equal(suffix, matched)
+			}
+		},
+	)
+
+	t.Run(
+		"List_error", func() {
+			t.SetupTest()
+			t.forwardingRuleRepo.ExpectedCalls = nil
+			t.forwardingRuleRepo.On("List", mock.Anything).Return(nil, errors.New("DB error"))
+			_, err := t.usecase.MatchSuffix(context.Background(), "www.test.")
+			t.NotNil(err)
+		},
+	)
+}
+
+func (t *forwardingRuleUseCaseTestSuite) TestCreateRule() {
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			t.Equal(t.addr(t.public), t.forward("web.svc.cluster.local."))
+
+			rule := &domain.ForwardingRule{Suffix: "SVC.cluster.local", Upstreams: []string{" " + t.addr(t.cluster)}}
+			err := t.usecase.CreateRule(context.Background(), rule)
+			t.Nil(err)
+			t.Equal(
+				&domain.ForwardingRule{
+					Suffix:    "svc.cluster.local.",
+					Upstreams: []string{t.addr(t.cluster)},
+					Strategy:  forwarder.Sequential,
+				}, rule,
+			)
+			t.Equal(t.addr(t.cluster), t.forward("web.svc.cluster.local."))
+		},
+	)
+
+	t.Run(
+		"invalid_error", func() {
+			t.SetupTest()
+			for _, rule := range []*domain.ForwardingRule{
+				{Suffix: "bad..suffix.", Upstreams: []string{t.addr(t.corp)}},
+				{Suffix: "corp.test.", Upstreams: []string{"quic://10.0.0.1"}},
+				{Suffix: "corp.test.", Upstreams: []string{t.addr(t.corp)}, Strategy: "random"},
+				{Suffix: "corp.test."},
+			} {
+				err := t.usecase.CreateRule(context.Background(), rule)
+				t.NotNil(err)
+				t.Equal(http.StatusBadRequest, err.(*domain.Error).StatusCode)
+			}
+			t.forwardingRuleRepo.AssertNotCalled(t.T(), "Create", mock.Anything, mock.Anything)
+		},
+	)
+
+	t.Run(
+		"existed_error", func() {
+			t.SetupTest()
+			err := t.usecase.CreateRule(
+				context.Background(), &domain.ForwardingRule{Suffix: "corp.example.", Upstreams: []string{t.addr(t.corp)}},
+			)
+			t.NotNil(err)
+			t.Contains(err.Error(), "already existed")
+		},
+	)
+}
+
+func (t *forwardingRuleUseCaseTestSuite) TestUpdateRule() {
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			t.Equal(t.addr(t.corp), t.forward("dc1.corp.example."))
+
+			err := t.usecase.UpdateRule(
+				context.Background(), &domain.ForwardingRule{
+					Suffix:    "corp.example.",
+					Upstreams: []string{t.addr(t.public)},
+					Strategy:  forwarder.Fastest,
+				},
+			)
+			t.Nil(err)
+			t.Equal(t.addr(t.public), t.forward("dc1.corp.example."))
+		},
+	)
+
+	t.Run(
+		"Update_error", func() {
+			t.SetupTest()
+			t.forwardingRuleRepo.ExpectedCalls = nil
+			t.forwardingRuleRepo.
+				On("Update", mock.Anything, mock.Anything).
+				Return(&domain.Error{Message: "DB error: record not found", StatusCode: http.StatusNotFound})
+
+			err := t.usecase.UpdateRule(
+				context.Background(), &domain.ForwardingRule{Suffix: "other.", Upstreams: []string{t.addr(t.corp)}},
+			)
+			t.NotNil(err)
+		},
+	)
+}
+
+func (t *forwardingRuleUseCaseTestSuite) TestDeleteRule() {
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			t.Equal(t.addr(t.corp), t.forward("dc1.corp.example."))
+
+			err := t.usecase.DeleteRule(context.Background(), "Corp.Example.")
+			t.Nil(err)
+			t.forwardingRuleRepo.AssertCalled(t.T(), "Delete", mock.Anything, "corp.example.")
+			// the names fall back to the next longest suffix
+			t.Equal(t.addr(t.cluster), t.forward("dc1.corp.example."))
+		},
+	)
+
+	t.Run(
+		"Delete_error", func() {
+			t.SetupTest()
+			t.forwardingRuleRepo.ExpectedCalls = nil
+			t.forwardingRuleRepo.
+				On("Delete", mock.Anything, mock.Anything).
+				Return(&domain.Error{Message: "DB error: record not found", StatusCode: http.StatusNotFound})
+
+			err := t.usecase.DeleteRule(context.Background(), "other.")
+			t.NotNil(err)
+		},
+	)
+}
+
+func (t *forwardingRuleUseCaseTestSuite) TestListUpstreams() {
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			t.Nil(t.usecase.ProbeUpstreams(context.Background()))
+
+			groups, err := t.usecase.ListUpstreams(context.Background())
+			t.Nil(err)
+			t.Len(groups, 2)
+			t.Equal("corp.example.", groups[0].Suffix)
+			t.Equal(t.addr(t.corp), groups[0].Upstreams[0].Upstream)
+			t.NotNil(groups[0].Upstreams[0].LastProbe)
+			t.Equal("example.", groups[1].Suffix)
+			t.Equal(t.addr(t.cluster), groups[1].Upstreams[0].Upstream)
+			t.True(groups[1].Upstreams[0].Healthy)
+
+			// a deleted rule isn't listed any more
+			t.Nil(t.usecase.DeleteRule(context.Background(), "corp.example."))
+			groups, err = t.usecase.ListUpstreams(context.Background())
+			t.Nil(err)
+			t.Len(groups, 1)
+			t.Equal("example.", groups[0].Suffix)
+		},
+	)
+
+	t.Run(
+		"List_error", func() {
+			t.SetupTest()
+			t.forwardingRuleRepo.ExpectedCalls = nil
+			t.forwardingRuleRepo.
+				On("List", mock.Anything).
+				Return(nil, &domain.Error{Message: "DB error", StatusCode: http.StatusBadRequest})
+
+			t.NotNil(t.usecase.ProbeUpstreams(context.Background()))
+			_, err := t.usecase.ListUpstreams(context.Background())
+			t.NotNil(err)
+		},
+	)
+}
+
+func (t *forwardingRuleUseCaseTestSuite) TestGetRule() {
+	t.SetupTest()
+	rule, err := t.usecase.GetRule(context.Background(), "CORP.example")
+	t.Nil(err)
+	t.Equal("corp.example.", rule.Suffix)
+
+	rules, err := t.usecase.ListRules(context.Background())
+	t.Nil(err)
+	t.Len(rules, 2)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/miekg/dns"
 	"github.com/samber/do"
+	"time"
 
 	"github.com/cewuandy/go-restful-dns/internal/domain"
 )
@@ -12,6 +13,7 @@ import (
 type initUseCase struct {
 	redisRepo  domain.RedisRepo
 	recordRepo domain.RecordRepo
+	zoneRepo   domain.ZoneRepo
 }
 
 func (i *initUseCase) ClearRedisData(ctx context.Context) error {
@@ -24,19 +26,33 @@ func (i *initUseCase) RecoverRecords(ctx context.Context) error {
 		return err
 	}
 
+	var (
+		questions []dns.Question
+		rrsets    = map[dns.Question][]dns.RR{}
+	)
 	for _, r := range records {
 		q := dns.Question{
 			Name:   r.Name,
 			Qtype:  r.RrType,
 			Qclass: r.Class,
 		}
+		rr, _ := dns.NewRR(r.Record)
+		if rr == nil {
+			continue
+		}
+		if _, ok := rrsets[q]; !ok {
+			questions = append(questions, q)
+		}
+		rrsets[q] = append(rrsets[q], rr)
+	}
+
+	for _, q := range questions {
 		record, _ := i.redisRepo.HGetAll(ctx, q.String())
 		if len(record) != 0 {
 			continue
 		}
 
-		field := fmt.Sprintf("%s-0", domain.Answer)
-		err = i.redisRepo.HSet(ctx, q.String(), field, r.Record, 0)
+		err = cacheRRset(ctx, i.redisRepo, q, rrsets[q])
 		if err != nil {
 			return err
 		}
@@ -50,6 +66,26 @@ func (i *initUseCase) RecoverRecords(ctx context.Context) error {
 	return nil
 }
 
+func (i *initUseCase) RecoverZones(ctx context.Context) error {
+	zones, err := i.zoneRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, zone := range zones {
+		// a secondary zone is answered once it's transferred and until it expires
+		if zone.Expired(time.Now()) {
+			continue
+		}
+		err = cacheZone(ctx, i.redisRepo, zone)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (i *initUseCase) createFakeAAAA(ctx context.Context, q dns.Question) error {
 	soa, err := i.getFakeSOA(ctx, q)
 	if err != nil {
@@ -93,5 +129,6 @@ func NewInitUseCase(injector *do.Injector) (domain.InitUseCase, error) {
 	return &initUseCase{
 		do.MustInvoke[domain.RedisRepo](injector),
 		do.MustInvoke[domain.RecordRepo](injector),
+		do.MustInvoke[domain.ZoneRepo](injector),
 	}, nil
 }
@@ -19,6 +19,7 @@ type initUseCaseTestSuite struct {
 
 	redisRepo  *mocks.RedisRepo
 	recordRepo *mocks.RecordRepo
+	zoneRepo   *mocks.ZoneRepo
 }
 
 func TestInitUseCase(t *testing.T) {
@@ -31,7 +32,9 @@ func (t *initUseCaseTestSuite) SetupSuite() {
 	t.redisRepo = &mocks.RedisRepo{}
 	t.recordRepo = &mocks.RecordRepo{}
 	do.ProvideValue[domain.RedisRepo](injector, t.redisRepo)
+	t.zoneRepo = &mocks.ZoneRepo{}
 	do.ProvideValue[domain.RecordRepo](injector, t.recordRepo)
+	do.ProvideValue[domain.ZoneRepo](injector, t.zoneRepo)
 	t.usecase, _ = NewInitUseCase(injector)
 }
 
@@ -45,6 +48,9 @@ func (t *initUseCaseTestSuite) SetupTest() {
 	t.redisRepo.
 		On("HSet", anyContext, anyString, anyString, anyString, anyTime).
 		Return(nil)
+	t.redisRepo.
+		On("HDel", anyContext, anyString).
+		Return(nil)
 	t.redisRepo.
 		On("HGetAll", anyContext, anyString).
 		Return(map[string]string{}, nil).Once()
@@ -66,13 +72,35 @@ func (t *initUseCaseTestSuite) SetupTest() {
 	t.redisRepo.
 		On("FlushAll", anyContext).
 		Return(nil)
+	t.zoneRepo.
+		On("List", anyContext).
+		Return(
+			[]*domain.Zone{
+				{
+					Name:        "test.com.",
+					Nameservers: []string{"ns1.test.com."},
+					Mbox:        "admin.test.com.",
+					Serial:      1,
+					Ttl:         3600,
+				},
+			}, nil,
+		)
 }
 
 func (t *initUseCaseTestSuite) SetupErrorTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+	)
+
 	t.redisRepo.ExpectedCalls = nil
 	t.recordRepo.ExpectedCalls = nil
+	t.zoneRepo.ExpectedCalls = nil
 	t.SetupTest()
 	t.redisRepo.ExpectedCalls = nil
+	t.redisRepo.
+		On("HDel", anyContext, anyString).
+		Return(nil)
 
 	// t.redisRepo.
 	// 	On("HGetAll", anyContext, anyString).
@@ -94,6 +122,42 @@ func (t *initUseCaseTestSuite) TestRecoverRecords() {
 		},
 	)
 
+	t.Run(
+		"success_rrset", func() {
+			t.SetupErrorTest()
+			t.redisRepo.Calls = nil
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("List", anyContext).
+				Return(
+					[]*domain.Record{
+						{Name: "test.com.", RrType: 1, Class: 1, Record: "test.com.\t1440\tIN\tA\t1.1.1.1"},
+						{Name: "test.com.", RrType: 1, Class: 1, Record: "test.com.\t1440\tIN\tA\t2.2.2.2"},
+					}, nil,
+				)
+			t.redisRepo.
+				On("HGetAll", anyContext, anyString).
+				Return(map[string]string{}, nil).Once()
+			t.redisRepo.
+				On("HGetAll", anyContext, anyString).
+				Return(map[string]string{"Answer-0": "test.com.\t1440\tIN\tA\t1.1.1.1"}, nil)
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+				Return(nil)
+
+			err := t.usecase.RecoverRecords(context.Background())
+			t.Nil(err)
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";test.com.\tIN\t A", "Answer-0", "test.com.\t1440\tIN\tA\t1.1.1.1",
+				anyTime,
+			)
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";test.com.\tIN\t A", "Answer-1", "test.com.\t1440\tIN\tA\t2.2.2.2",
+				anyTime,
+			)
+		},
+	)
+
 	t.Run(
 		"List_error", func() {
 			t.SetupTest()
@@ -200,6 +264,63 @@ func (t *initUseCaseTestSuite) TestRecoverRecords() {
 	)
 }
 
+func (t *initUseCaseTestSuite) TestRecoverZones() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		anyTime    = mock.AnythingOfType("time.Duration")
+	)
+
+	t.Run(
+		"success", func() {
+			t.SetupErrorTest()
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+				Return(nil)
+			t.redisRepo.
+				On("HDel", anyContext, anyString).
+				Return(nil)
+
+			err := t.usecase.RecoverZones(context.Background())
+			t.Nil(err)
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";test.com.\tIN\t SOA", "Answer-0", anyString, anyTime,
+			)
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";test.com.\tIN\t NS", "Answer-0",
+				"test.com.\t3600\tIN\tNS\tns1.test.com.", anyTime,
+			)
+		},
+	)
+
+	t.Run(
+		"List_error", func() {
+			t.SetupTest()
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("List", anyContext).
+				Return(nil, fmt.Errorf("test-error"))
+
+			err := t.usecase.RecoverZones(context.Background())
+			t.NotNil(err)
+			t.Equal("test-error", err.Error())
+		},
+	)
+
+	t.Run(
+		"HSet_error", func() {
+			t.SetupErrorTest()
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+				Return(fmt.Errorf("test-error"))
+
+			err := t.usecase.RecoverZones(context.Background())
+			t.NotNil(err)
+			t.Equal("test-error", err.Error())
+		},
+	)
+}
+
 func (t *initUseCaseTestSuite) TestClearRedisData() {
 	t.Run(
 		"success", func() {
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"sync"
+	"time"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/utils"
+)
+
+const (
+	// notifyRetries is the number of attempts of a NOTIFY before it's given up
+	notifyRetries = 5
+	// notifyInterval is the wait before the first retry of a NOTIFY, it doubles every retry
+	notifyInterval = 2 * time.Second
+
+	notifyTimeout = 5 * time.Second
+)
+
+type notifyUseCase struct {
+	zoneRepo domain.ZoneRepo
+
+	interval time.Duration
+
+	mutex sync.Mutex
+
+	// statuses are the latest NOTIFY of the zones by their targets
+	statuses map[string]map[string]*domain.NotifyStatus
+
+	// cancels stop the retries of the NOTIFY which are superseded, by the zone and the target
+	cancels map[string]context.CancelFunc
+}
+
+func (n *notifyUseCase) Notify(ctx context.Context, name string) error {
+	zone, err := n.zoneRepo.Get(ctx, utils.GetFQDNFromDomainName(name))
+	if err != nil {
+		return err
+	}
+
+	for _, target := range zone.Notify {
+		n.start(zone, target)
+	}
+	return nil
+}
+
+func (n *notifyUseCase) ListStatus(ctx context.Context, name string) ([]*domain.NotifyStatus, error) {
+	zone, err := n.zoneRepo.Get(ctx, utils.GetFQDNFromDomainName(name))
+	if err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	statuses := []*domain.NotifyStatus{}
+	for _, target := range zone.Notify {
+		if status, ok := n.statuses[zone.Name][target]; ok {
+			copied := *status
+			statuses = append(statuses, &copied)
+		}
+	}
+	return statuses, nil
+}
+
+func (n *notifyUseCase) start(zone *domain.Zone, target string) {
+	status := &domain.NotifyStatus{
+		Target:  target,
+		Serial:  zone.Serial,
+		State:   domain.NotifyPending,
+		Updated: time.Now(),
+	}
+	// the NOTIFY outlives the request which causes it
+	ctx, cancel := context.WithCancel(context.Background())
+
+	n.mutex.Lock()
+	key := fmt.Sprintf("%s %s", zone.Name, target)
+	if superseded, ok := n.cancels[key]; ok {
+		superseded()
+	}
+	n.cancels[key] = cancel
+	if _, ok := n.statuses[zone.Name]; !ok {
+		n.statuses[zone.Name] = map[string]*domain.NotifyStatus{}
+	}
+	n.statuses[zone.Name][target] = status
+	n.mutex.Unlock()
+
+	go n.send(ctx, zone.SOA(), target, status)
+}
+
+// send delivers the NOTIFY to the target, the wait between the attempts doubles every retry
+func (n *notifyUseCase) send(ctx context.Context, soa *dns.SOA, target string, status *domain.NotifyStatus) {
+	interval := n.interval
+	for attempt := 1; ; attempt++ {
+		err := n.exchange(ctx, soa, target)
+
+		n.mutex.Lock()
+		status.Attempts = attempt
+		status.Updated = time.Now()
+		status.LastError = ""
+		if err != nil {
+			status.LastError = err.Error()
+		}
+		switch {
+		case err == nil:
+			status.State = domain.NotifyDelivered
+		case attempt >= notifyRetries:
+			status.State = domain.NotifyFailed
+		}
+		done := status.State != domain.NotifyPending
+		n.mutex.Unlock()
+
+		if done {
+			return
+		}
+		if err != nil {
+			fmt.Printf("Error notifying %s of %s: %s\n", target, soa.Hdr.Name, err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+}
+
+func (n *notifyUseCase) exchange(ctx context.Context, soa *dns.SOA, target string) error {
+	req := new(dns.Msg)
+	req.SetNotify(soa.Hdr.Name)
+	req.Authoritative = true
+	// the SOA tells the secondary the new serial (RFC 1996 3.7)
+	req.Answer = []dns.RR{soa}
+
+	client := &dns.Client{Timeout: notifyTimeout}
+	resp, _, err := client.ExchangeContext(ctx, req, target)
+	if err != nil {
+		return err
+	}
+	if resp.Opcode != dns.OpcodeNotify || resp.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("the NOTIFY is answered with %s", dns.RcodeToString[resp.Rcode])
+	}
+	return nil
+}
+
+func NewNotifyUseCase(injector *do.Injector) (domain.NotifyUseCase, error) {
+	return &notifyUseCase{
+		zoneRepo: do.MustInvoke[domain.ZoneRepo](injector),
+		interval: notifyInterval,
+		statuses: map[string]map[string]*domain.NotifyStatus{},
+		cancels:  map[string]context.CancelFunc{},
+	}, nil
+}
@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"context"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+)
+
+type notifyUseCaseTestSuite struct {
+	suite.Suite
+
+	usecase domain.NotifyUseCase
+
+	zoneRepo *mocks.ZoneRepo
+
+	// the secondary acknowledges the NOTIFY, the refusing one answers REFUSED
+	secondary string
+	refusing  string
+	servers   []*dns.Server
+
+	mutex    sync.Mutex
+	received []*dns.Msg
+
+	zone *domain.Zone
+}
+
+func TestNotifyUseCase(t *testing.T) {
+	suite.Run(t, &notifyUseCaseTestSuite{})
+}
+
+func (t *notifyUseCaseTestSuite) SetupSuite() {
+	injector := do.New()
+	t.zoneRepo = &mocks.ZoneRepo{}
+	do.ProvideValue[domain.ZoneRepo](injector, t.zoneRepo)
+
+	t.usecase, _ = NewNotifyUseCase(injector)
+	t.usecase.(*notifyUseCase).interval = 10 * time.Millisecond
+
+	for _, rcode := range []int{dns.RcodeSuccess, dns.RcodeRefused} {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		t.Require().Nil(err)
+		if rcode == dns.RcodeSuccess {
+			t.secondary = conn.LocalAddr().String()
+		} else {
+			t.refusing = conn.LocalAddr().String()
+		}
+
+		server := &dns.Server{PacketConn: conn, Handler: t.serveSecondary(rcode)}
+		started := make(chan struct{})
+		server.NotifyStartedFunc = func() { close(started) }
+		go func() {
+			_ = server.ActivateAndServe()
+		}()
+		<-started
+		t.servers = append(t.servers, server)
+	}
+}
+
+func (t *notifyUseCaseTestSuite) TearDownSuite() {
+	for _, server := range t.servers {
+		_ = server.Shutdown()
+	}
+}
+
+func (t *notifyUseCaseTestSuite) serveSecondary(rcode int) dns.HandlerFunc {
+	return func(respWriter dns.ResponseWriter, req *dns.Msg) {
+		t.mutex.Lock()
+		t.received = append(t.received, req)
+		t.mutex.Unlock()
+
+		resp := new(dns.Msg)
+		resp.SetRcode(req, rcode)
+		_ = respWriter.WriteMsg(resp)
+	}
+}
+
+func (t *notifyUseCaseTestSuite) SetupTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+	)
+
+	t.zone = &domain.Zone{
+		Name:        "test.com.",
+		Nameservers: []string{"ns1.test.com."},
+		Mbox:        "admin.test.com.",
+		Serial:      2024010101,
+		Notify:      []string{t.secondary},
+	}
+
+	t.mutex.Lock()
+	t.received = nil
+	t.mutex.Unlock()
+
+	t.zoneRepo.ExpectedCalls = nil
+	t.zoneRepo.
+		On("Get", anyContext, "test.com.").
+		Return(t.zone, nil)
+	t.zoneRepo.
+		On("Get", anyContext, anyString).
+		Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+}
+
+// waitStatus waits until the NOTIFY to the target isn't pending
+func (t *notifyUseCaseTestSuite) waitStatus(target string) *domain.NotifyStatus {
+	var status *domain.NotifyStatus
+	t.Eventually(
+		func() bool {
+			statuses, _ := t.usecase.ListStatus(context.Background(), "test.com.")
+			for _, s := range statuses {
+				if s.Target == target && s.State != domain.NotifyPending {
+					status = s
+					return true
+				}
+			}
+			return false
+		}, 5*time.Second, 10*time.Millisecond,
+	)
+	return status
+}
+
+func (t *notifyUseCaseTestSuite) TestNotify() {
+	t.Run(
+		"delivered", func() {
+			t.SetupTest()
+			err := t.usecase.Notify(context.Background(), "test.com")
+			t.Nil(err)
+
+			status := t.waitStatus(t.secondary)
+			t.Equal(domain.NotifyDelivered, status.State)
+			t.Equal(uint32(2024010101), status.Serial)
+			t.Equal(1, status.Attempts)
+			t.Empty(status.LastError)
+
+			t.mutex.Lock()
+			defer t.mutex.Unlock()
+			t.Len(t.received, 1)
+			t.Equal(dns.OpcodeNotify, t.received[0].Opcode)
+			t.True(t.received[0].Authoritative)
+			t.Equal(dns.Question{Name: "test.com.", Qtype: dns.TypeSOA, Qclass: dns.ClassINET}, t.received[0].Question[0])
+			t.Equal(uint32(2024010101), t.received[0].Answer[0].(*dns.SOA).Serial)
+		},
+	)
+
+	t.Run(
+		"failed_after_retries", func() {
+			t.SetupTest()
+			t.zone.Notify = []string{t.secondary, t.refusing}
+			err := t.usecase.Notify(context.Background(), "test.com.")
+			t.Nil(err)
+
+			status := t.waitStatus(t.refusing)
+			t.Equal(domain.NotifyFailed, status.State)
+			t.Equal(notifyRetries, status.Attempts)
+			t.Contains(status.LastError, "REFUSED")
+
+			statuses, err := t.usecase.ListStatus(context.Background(), "test.com.")
+			t.Nil(err)
+			t.Len(statuses, 2)
+			t.Equal(t.secondary, statuses[0].Target)
+		},
+	)
+
+	t.Run(
+		"superseded", func() {
+			t.SetupTest()
+			t.usecase.(*notifyUseCase).interval = time.Hour
+			defer func() { t.usecase.(*notifyUseCase).interval = 10 * time.Millisecond }()
+
+			t.zone.Notify = []string{t.refusing}
+			err := t.usecase.Notify(context.Background(), "test.com.")
+			t.Nil(err)
+			t.Eventually(
+				func() bool {
+					statuses, _ := t.usecase.ListStatus(context.Background(), "test.com.")
+					return len(statuses) == 1 && statuses[0].Attempts == 1
+				}, 5*time.Second, 10*time.Millisecond,
+			)
+
+			t.zone.Serial = 2024010102
+			err = t.usecase.Notify(context.Background(), "test.com.")
+			t.Nil(err)
+			t.Eventually(
+				func() bool {
+					statuses, _ := t.usecase.ListStatus(context.Background(), "test.com.")
+					return len(statuses) == 1 && statuses[0].Serial == 2024010102 && statuses[0].Attempts == 1
+				}, 5*time.Second, 10*time.Millisecond,
+			)
+		},
+	)
+
+	t.Run(
+		"no_targets", func() {
+			t.SetupTest()
+			t.zone.Notify = nil
+			err := t.usecase.Notify(context.Background(), "test.com.")
+			t.Nil(err)
+
+			statuses, err := t.usecase.ListStatus(context.Background(), "test.com.")
+			t.Nil(err)
+			t.Empty(statuses)
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			t.SetupTest()
+			err := t.usecase.Notify(context.Background(), "other.com.")
+			t.NotNil(err)
+			t.Contains(err.Error(), "record not found")
+
+			_, err = t.usecase.ListStatus(context.Background(), "other.com.")
+			t.NotNil(err)
+		},
+	)
+}
@@ -2,11 +2,13 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/miekg/dns"
 	"github.com/samber/do"
-	"gorm.io/gorm"
+	"net"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -15,85 +17,118 @@ import (
 	"github.com/cewuandy/go-restful-dns/internal/utils"
 )
 
+// allowedWithCNAME are the DNSSEC types which may sit next to a CNAME (RFC 4035 2.5)
+var allowedWithCNAME = map[uint16]bool{
+	dns.TypeRRSIG: true,
+	dns.TypeNSEC:  true,
+	dns.TypeNSEC3: true,
+}
+
+// journalSize is the number of changes kept per zone for IXFR, older serials get the whole zone
+const journalSize = 1000
+
 type recordUseCase struct {
 	redisRepo domain.RedisRepo
 
 	recordRepo domain.RecordRepo
+
+	zoneRepo domain.ZoneRepo
+
+	journalRepo domain.JournalRepo
+
+	notifyUseCase domain.NotifyUseCase
 }
 
-func (r *recordUseCase) CreateRecord(ctx context.Context, rr dns.RR) error {
+func (r *recordUseCase) CreateRecord(ctx context.Context, rr dns.RR, opts domain.RecordOptions) error {
 	header := rr.Header()
 	q := dns.Question{
 		Name:   header.Name,
 		Qtype:  header.Rrtype,
 		Qclass: header.Class,
 	}
-	field := fmt.Sprintf("%s-%d", domain.Answer, 0)
 
-	if header.Rrtype == dns.TypeAAAA {
-		if r.isNsExisted(ctx, q.String()) {
-			err := r.redisRepo.HDel(ctx, q.String())
-			if err != nil {
-				return err
+	rrs, err := r.getRRset(ctx, q)
+	if err != nil {
+		return err
+	}
+	for _, member := range rrs {
+		if dns.IsDuplicate(member, rr) {
+			return &domain.Error{
+				Message:    "the record is already existed.",
+				StatusCode: http.StatusBadRequest,
 			}
 		}
 	}
 
-	record, err := r.recordRepo.Get(ctx, q.Name, q.Qtype, q.Qclass)
-	if err != nil && !strings.Contains(err.Error(), gorm.ErrRecordNotFound.Error()) {
+	zone, err := getClosestZone(ctx, r.zoneRepo, header.Name)
+	if err != nil {
+		return err
+	}
+	err = checkWritable(zone)
+	if err != nil {
 		return err
 	}
-	if record != nil {
+	err = r.checkCNAME(ctx, q, zone, len(rrs)+1, nil)
+	if err != nil {
+		return err
+	}
+	if header.Ttl == 0 {
+		switch {
+		case len(rrs) > 0:
+			header.Ttl = rrs[0].Header().Ttl
+		case zone != nil:
+			header.Ttl = zone.Ttl
+		}
+	}
+	// the members of a RRset share the same TTL (RFC 2181 5.2)
+	if len(rrs) > 0 && rrs[0].Header().Ttl != header.Ttl {
 		return &domain.Error{
-			Message:    "the record is already existed.",
+			Message:    fmt.Sprintf("the TTL should be %d as the other records of the RRset", rrs[0].Header().Ttl),
 			StatusCode: http.StatusBadRequest,
 		}
 	}
 
-	record = &domain.Record{
-		Name:   header.Name,
-		RrType: header.Rrtype,
-		Class:  header.Class,
-		Record: rr.String(),
+	if opts.CreatePtr {
+		err = r.checkPtr(ctx, []dns.RR{rr})
+		if err != nil {
+			return err
+		}
+		return r.writeWithPtr(ctx, &rrsetChange{q, zone, rrs, append(rrs, rr)})
 	}
 
-	err = r.recordRepo.Create(ctx, record)
+	err = r.recordRepo.Create(ctx, domain.NewRecord(rr))
 	if err != nil {
 		return err
 	}
 
-	err = r.redisRepo.HSet(ctx, q.String(), field, rr.String(), 0)
+	err = cacheRRset(ctx, r.redisRepo, q, append(rrs, rr))
 	if err != nil {
 		return err
 	}
 
-	if header.Rrtype != dns.TypeA {
-		return nil
-	}
-
-	return r.createFakeAAAA(ctx, header)
+	return r.afterChange(ctx, zone, q, nil, []dns.RR{rr})
 }
 
-func (r *recordUseCase) GetRecord(ctx context.Context, question domain.Question) (dns.RR, error) {
-	var (
-		record *domain.Record
-		rr     dns.RR
-		err    error
-	)
+func (r *recordUseCase) GetRecord(ctx context.Context, question domain.Question) ([]dns.RR, error) {
 	question.Name = utils.GetFQDNFromDomainName(question.Name)
-	t := domain.RRTypeMap[question.Qtype]
-	c := domain.ClassMap[question.Qclass]
-	record, err = r.recordRepo.Get(ctx, question.Name, t, c)
-	if err != nil {
-		return nil, err
+	q := dns.Question{
+		Name:   question.Name,
+		Qtype:  domain.RRTypeMap[question.Qtype],
+		Qclass: domain.ClassMap[question.Qclass],
 	}
 
-	rr, err = dns.NewRR(record.Record)
+	rrs, err := r.getRRset(ctx, q)
 	if err != nil {
 		return nil, err
 	}
+	if len(rrs) == 0 {
+		return nil, &domain.Error{
+			Message:    "record not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
 
-	return rr, nil
+	return rrs, nil
 }
 
 func (r *recordUseCase) ListRecords(ctx context.Context) ([]dns.RR, error) {
@@ -120,38 +155,155 @@ func (r *recordUseCase) ListRecords(ctx context.Context) ([]dns.RR, error) {
 	return rrs, nil
 }
 
-func (r *recordUseCase) UpdateRecord(ctx context.Context, rr dns.RR) error {
-	record := &domain.Record{
-		Name:   utils.GetFQDNFromDomainName(rr.Header().Name),
-		RrType: rr.Header().Rrtype,
-		Class:  rr.Header().Class,
-		Record: rr.String(),
+func (r *recordUseCase) UpdateRecord(ctx context.Context, rr dns.RR, opts domain.RecordOptions) error {
+	rr.Header().Name = utils.GetFQDNFromDomainName(rr.Header().Name)
+	q := dns.Question{
+		Name:   rr.Header().Name,
+		Qtype:  rr.Header().Rrtype,
+		Qclass: rr.Header().Class,
+	}
+
+	rrs, err := r.getRRset(ctx, q)
+	if err != nil {
+		return err
+	}
+	if len(rrs) == 0 {
+		return &domain.Error{
+			Message:    "record not found",
+			StatusCode: http.StatusNotFound,
+		}
+	}
+
+	return r.ReplaceRRset(ctx, []dns.RR{rr}, opts)
+}
+
+func (r *recordUseCase) ReplaceRRset(ctx context.Context, rrs []dns.RR, opts domain.RecordOptions) error {
+	if len(rrs) == 0 {
+		return &domain.Error{
+			Message:    "the RRset should have at least one record",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	header := rrs[0].Header()
+	q := dns.Question{
+		Name:   header.Name,
+		Qtype:  header.Rrtype,
+		Qclass: header.Class,
+	}
+	zone, err := getClosestZone(ctx, r.zoneRepo, q.Name)
+	if err != nil {
+		return err
+	}
+	err = checkWritable(zone)
+	if err != nil {
+		return err
+	}
+	err = r.checkCNAME(ctx, q, zone, len(rrs), nil)
+	if err != nil {
+		return err
+	}
+	err = checkRRset(q, zone, rrs)
+	if err != nil {
+		return err
+	}
+	records := make([]*domain.Record, 0, len(rrs))
+	for _, rr := range rrs {
+		records = append(records, domain.NewRecord(rr))
+	}
+
+	before, err := r.getRRset(ctx, q)
+	if err != nil {
+		return err
+	}
+	if opts.CreatePtr {
+		err = r.checkPtr(ctx, rrs)
+		if err != nil {
+			return err
+		}
+		return r.writeWithPtr(ctx, &rrsetChange{q, zone, before, rrs})
 	}
 
-	err := r.recordRepo.Update(ctx, record)
+	err = r.recordRepo.ReplaceRRset(ctx, q.Name, q.Qtype, q.Qclass, records)
 	if err != nil {
 		return err
 	}
 
-	err = r.redisRepo.HDel(ctx, rr.String())
+	err = cacheRRset(ctx, r.redisRepo, q, rrs)
 	if err != nil {
 		return err
 	}
 
+	return r.afterChange(ctx, zone, q, before, rrs)
+}
+
+func (r *recordUseCase) RemoveRecord(ctx context.Context, rr dns.RR, opts domain.RecordOptions) error {
+	header := rr.Header()
 	q := dns.Question{
-		Name:   rr.Header().Name,
-		Qtype:  rr.Header().Rrtype,
-		Qclass: rr.Header().Class,
+		Name:   header.Name,
+		Qtype:  header.Rrtype,
+		Qclass: header.Class,
+	}
+
+	rrs, err := r.getRRset(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	var remains []dns.RR
+	for _, member := range rrs {
+		if !dns.IsDuplicate(member, rr) {
+			remains = append(remains, member)
+		}
+	}
+	if len(remains) == len(rrs) {
+		return &domain.Error{
+			Message:    "record not found",
+			StatusCode: http.StatusNotFound,
+		}
 	}
-	field := fmt.Sprintf("%s-%d", domain.Answer, 0)
-	return r.redisRepo.HSet(ctx, q.String(), field, rr.String(), 0)
+
+	if len(remains) == 0 {
+		return r.DeleteRecord(
+			ctx, domain.Question{
+				Name:   q.Name,
+				Qtype:  domain.RRTypeOf(q.Qtype),
+				Qclass: domain.ClassOf(q.Qclass),
+			}, opts,
+		)
+	}
+	return r.ReplaceRRset(ctx, remains, opts)
 }
 
-func (r *recordUseCase) DeleteRecord(ctx context.Context, question domain.Question) error {
+func (r *recordUseCase) DeleteRecord(ctx context.Context, question domain.Question,
+	opts domain.RecordOptions) error {
 	question.Name = utils.GetFQDNFromDomainName(question.Name)
 	t := domain.RRTypeMap[question.Qtype]
 	c := domain.ClassMap[question.Qclass]
-	err := r.recordRepo.Delete(ctx, question.Name, t, c)
+	zone, err := getClosestZone(ctx, r.zoneRepo, question.Name)
+	if err != nil {
+		return err
+	}
+	err = checkWritable(zone)
+	if err != nil {
+		return err
+	}
+	q := dns.Question{Name: question.Name, Qtype: t, Qclass: c}
+	before, err := r.getRRset(ctx, q)
+	if err != nil {
+		return err
+	}
+	if opts.CreatePtr {
+		if len(before) == 0 {
+			return &domain.Error{
+				Message:    "record not found",
+				StatusCode: http.StatusNotFound,
+			}
+		}
+		return r.writeWithPtr(ctx, &rrsetChange{q, zone, before, nil})
+	}
+
+	err = r.recordRepo.Delete(ctx, question.Name, t, c)
 	if err != nil {
 		return err
 	}
@@ -161,7 +313,395 @@ func (r *recordUseCase) DeleteRecord(ctx context.Context, question domain.Questi
 		return err
 	}
 
-	return r.redisRepo.HDel(ctx, question.String())
+	err = r.redisRepo.HDel(ctx, question.String())
+	if err != nil {
+		return err
+	}
+
+	// the serial of the zone which contains the deleted RRset is bumped, if any
+	if zone != nil {
+		err = r.increaseSerial(ctx, zone, before, nil)
+	}
+	return err
+}
+
+func (r *recordUseCase) ReplaceRRsets(ctx context.Context, rrsets []*domain.RRset, opts domain.RecordOptions) error {
+	changes := make([]*rrsetChange, 0, len(rrsets))
+	for _, rrset := range rrsets {
+		q := rrset.Question
+		q.Name = utils.GetFQDNFromDomainName(q.Name)
+		zone, err := getClosestZone(ctx, r.zoneRepo, q.Name)
+		if err != nil {
+			return err
+		}
+		err = checkWritable(zone)
+		if err != nil {
+			return err
+		}
+		err = checkRRset(q, zone, rrset.Records)
+		if err != nil {
+			return err
+		}
+		before, err := r.getRRset(ctx, q)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, &rrsetChange{q, zone, before, rrset.Records})
+	}
+
+	// the RRsets are checked against each other, so a CNAME may take the place of deleted data
+	for _, change := range changes {
+		if len(change.after) == 0 {
+			continue
+		}
+		err := r.checkCNAME(ctx, change.q, change.zone, len(change.after), changes)
+		if err != nil {
+			return err
+		}
+	}
+	if opts.CreatePtr {
+		for _, change := range changes {
+			err := r.checkPtr(ctx, change.after)
+			if err != nil {
+				return err
+			}
+			ptrChanges, err := r.ptrChanges(ctx, change.before, change.after)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, ptrChanges...)
+		}
+	}
+	return r.writeChanges(ctx, changes)
+}
+
+// getClosestZone returns the zone which contains the name, or nil when the name is outside any zone, the
+// other errors of the DB are returned
+func getClosestZone(ctx context.Context, zoneRepo domain.ZoneRepo, name string) (*domain.Zone, error) {
+	zone, err := zoneRepo.GetClosest(ctx, name)
+	var e *domain.Error
+	if err != nil && errors.As(err, &e) && e.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	return zone, err
+}
+
+// checkWritable rejects the changes of a secondary zone, its records come from the primary
+func checkWritable(zone *domain.Zone) error {
+	if zone != nil && zone.IsSecondary() {
+		return &domain.Error{
+			Message:    fmt.Sprintf("the records of the secondary zone %s are read-only", zone.Name),
+			StatusCode: http.StatusForbidden,
+		}
+	}
+	return nil
+}
+
+// checkRRset checks the members of the RRset of the question and gives them the same TTL
+// (RFC 2181 5.2), which is the zone TTL when the first record has none
+func checkRRset(q dns.Question, zone *domain.Zone, rrs []dns.RR) error {
+	if len(rrs) == 0 {
+		return nil
+	}
+	ttl := rrs[0].Header().Ttl
+	if ttl == 0 && zone != nil {
+		ttl = zone.Ttl
+	}
+	for i, rr := range rrs {
+		h := rr.Header()
+		if h.Name != q.Name || h.Rrtype != q.Qtype || h.Class != q.Qclass {
+			return &domain.Error{
+				Message:    "the records of a RRset should have the same name, type and class",
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+		for _, member := range rrs[:i] {
+			if dns.IsDuplicate(member, rr) {
+				return &domain.Error{
+					Message:    fmt.Sprintf("the record %s is duplicated", rr.String()),
+					StatusCode: http.StatusBadRequest,
+				}
+			}
+		}
+		h.Ttl = ttl
+	}
+	return nil
+}
+
+// checkCNAME rejects a RRset which would make a CNAME coexist with other data at the same
+// name (RFC 1034 3.6.2), size is the number of records the RRset will have. The pending
+// changes written with the RRset take the place of the stored RRsets.
+func (r *recordUseCase) checkCNAME(ctx context.Context, q dns.Question, zone *domain.Zone, size int,
+	pending []*rrsetChange) error {
+	if q.Qtype == dns.TypeCNAME {
+		if size > 1 {
+			return &domain.Error{
+				Message:    fmt.Sprintf("%s can only have one CNAME record", q.Name),
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+		if zone != nil && zone.Name == q.Name {
+			return &domain.Error{
+				Message:    fmt.Sprintf("the zone apex %s cannot have a CNAME record", q.Name),
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+	}
+
+	records, err := r.recordRepo.ListByName(ctx, q.Name, q.Qclass)
+	if err != nil {
+		return err
+	}
+	types := make([]uint16, 0, len(records))
+	for _, record := range records {
+		types = append(types, record.RrType)
+	}
+	for _, change := range pending {
+		if change.q.Qclass != q.Qclass || dns.CanonicalName(change.q.Name) != dns.CanonicalName(q.Name) {
+			continue
+		}
+		types = slices.DeleteFunc(types, func(rrtype uint16) bool { return rrtype == change.q.Qtype })
+		if len(change.after) > 0 {
+			types = append(types, change.q.Qtype)
+		}
+	}
+	for _, rrtype := range types {
+		if rrtype == q.Qtype || allowedWithCNAME[rrtype] || allowedWithCNAME[q.Qtype] {
+			continue
+		}
+		if rrtype == dns.TypeCNAME || q.Qtype == dns.TypeCNAME {
+			return &domain.Error{
+				Message:    fmt.Sprintf("CNAME and other data cannot coexist at %s", q.Name),
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+	}
+	return nil
+}
+
+// checkPtr reports a conflict when the address of an A or AAAA record already has a PTR
+// record pointing to another name
+func (r *recordUseCase) checkPtr(ctx context.Context, rrs []dns.RR) error {
+	for _, ptr := range ptrRecords(rrs) {
+		existed, err := r.getRRset(ctx, dns.Question{Name: ptr.Hdr.Name, Qtype: dns.TypePTR, Qclass: ptr.Hdr.Class})
+		if err != nil {
+			return err
+		}
+		for _, rr := range existed {
+			if target := rr.(*dns.PTR).Ptr; !strings.EqualFold(target, ptr.Ptr) {
+				return &domain.Error{
+					Message:    fmt.Sprintf("the address of %s is already owned by %s", ptr.Hdr.Name, target),
+					StatusCode: http.StatusConflict,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// rrsetChange is the change of a RRset from before to after, an empty after deletes the RRset
+type rrsetChange struct {
+	q      dns.Question
+	zone   *domain.Zone
+	before []dns.RR
+	after  []dns.RR
+}
+
+// writeWithPtr writes the change of the A or AAAA RRset and the changes of the PTR records of
+// its addresses in one transaction, then bumps the serial of every changed zone once
+func (r *recordUseCase) writeWithPtr(ctx context.Context, change *rrsetChange) error {
+	ptrChanges, err := r.ptrChanges(ctx, change.before, change.after)
+	if err != nil {
+		return err
+	}
+	return r.writeChanges(ctx, append([]*rrsetChange{change}, ptrChanges...))
+}
+
+// writeChanges writes the changes of the RRsets in one transaction and updates their cache, then
+// bumps the serial of every changed zone once
+func (r *recordUseCase) writeChanges(ctx context.Context, changes []*rrsetChange) error {
+	var deleted, records []*domain.Record
+	for _, c := range changes {
+		deleted = append(deleted, &domain.Record{Name: c.q.Name, RrType: c.q.Qtype, Class: c.q.Qclass})
+		for _, rr := range c.after {
+			records = append(records, domain.NewRecord(rr))
+		}
+	}
+	err := r.recordRepo.ReplaceRRsets(ctx, deleted, records)
+	if err != nil {
+		return err
+	}
+
+	// the changes of a zone are journaled as one
+	var zones []*rrsetChange
+	for _, c := range changes {
+		if len(c.after) == 0 {
+			err = r.redisRepo.HDel(ctx, c.q.String())
+			if err == nil {
+				err = r.deleteFakeAAAA(
+					ctx, domain.Question{
+						Name:   c.q.Name,
+						Qtype:  domain.RRTypeOf(c.q.Qtype),
+						Qclass: domain.ClassOf(c.q.Qclass),
+					},
+				)
+			}
+		} else {
+			err = cacheRRset(ctx, r.redisRepo, c.q, c.after)
+			if err == nil && c.zone == nil {
+				err = r.afterChange(ctx, nil, c.q, c.before, c.after)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if c.zone == nil {
+			continue
+		}
+
+		i := slices.IndexFunc(zones, func(z *rrsetChange) bool { return z.zone.Name == c.zone.Name })
+		if i < 0 {
+			zones = append(zones, &rrsetChange{zone: c.zone})
+			i = len(zones) - 1
+		}
+		zones[i].before = append(zones[i].before, c.before...)
+		zones[i].after = append(zones[i].after, c.after...)
+	}
+	for _, z := range zones {
+		err = r.increaseSerial(ctx, z.zone, z.before, z.after)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ptrChanges returns the changes which remove the PTR records of the addresses which are gone
+// and create the ones of the new addresses
+func (r *recordUseCase) ptrChanges(ctx context.Context, before []dns.RR, after []dns.RR) (
+	[]*rrsetChange, error,
+) {
+	var changes []*rrsetChange
+	olds, news := ptrRecords(before), ptrRecords(after)
+	for owner, ptr := range olds {
+		if _, ok := news[owner]; ok {
+			continue
+		}
+		change, err := r.ptrChange(ctx, ptr)
+		if err != nil {
+			return nil, err
+		}
+		for _, rr := range change.before {
+			if !dns.IsDuplicate(rr, ptr) {
+				change.after = append(change.after, rr)
+			}
+		}
+		if len(change.after) < len(change.before) {
+			changes = append(changes, change)
+		}
+	}
+
+	for owner, ptr := range news {
+		if _, ok := olds[owner]; ok {
+			continue
+		}
+		change, err := r.ptrChange(ctx, ptr)
+		if err != nil {
+			return nil, err
+		}
+		// checkPtr has made sure the existing PTR record points to the name
+		if len(change.before) > 0 {
+			continue
+		}
+		err = r.checkCNAME(ctx, change.q, change.zone, 1, nil)
+		if err != nil {
+			return nil, err
+		}
+		change.after = []dns.RR{ptr}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// ptrChange returns the change of the PTR RRset of the record with its current members, its
+// zone should be writable
+func (r *recordUseCase) ptrChange(ctx context.Context, ptr *dns.PTR) (*rrsetChange, error) {
+	q := dns.Question{Name: ptr.Hdr.Name, Qtype: dns.TypePTR, Qclass: ptr.Hdr.Class}
+	zone, err := getClosestZone(ctx, r.zoneRepo, q.Name)
+	if err != nil {
+		return nil, err
+	}
+	err = checkWritable(zone)
+	if err != nil {
+		return nil, err
+	}
+	rrs, err := r.getRRset(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return &rrsetChange{q: q, zone: zone, before: rrs}, nil
+}
+
+func (r *recordUseCase) getRRset(ctx context.Context, q dns.Question) ([]dns.RR, error) {
+	records, err := r.recordRepo.GetRRset(ctx, q.Name, q.Qtype, q.Qclass)
+	if err != nil {
+		return nil, err
+	}
+
+	rrs := make([]dns.RR, 0, len(records))
+	for _, record := range records {
+		rr, err := dns.NewRR(record.Record)
+		if err != nil {
+			return nil, err
+		}
+		rrs = append(rrs, rr)
+	}
+	return rrs, nil
+}
+
+// afterChange bumps the serial of the zone which contains the changed RRset, names outside
+// any zone get the fake AAAA answer of their A records instead
+func (r *recordUseCase) afterChange(ctx context.Context, zone *domain.Zone, q dns.Question, before []dns.RR,
+	after []dns.RR) error {
+	// names inside a zone are answered authoritatively, so NODATA comes from the zone SOA
+	if zone != nil {
+		return r.increaseSerial(ctx, zone, before, after)
+	}
+
+	if q.Qtype != dns.TypeA {
+		return nil
+	}
+
+	return r.createFakeAAAA(ctx, &dns.RR_Header{Name: q.Name, Rrtype: q.Qtype, Class: q.Qclass})
+}
+
+// increaseSerial bumps the serial of the zone, journals the change of the RRset for IXFR and
+// notifies the secondaries
+func (r *recordUseCase) increaseSerial(ctx context.Context, zone *domain.Zone, before []dns.RR,
+	after []dns.RR) error {
+	from := zone.Serial
+	zone.Serial = nextSerial(zone.Serial)
+	err := r.zoneRepo.Update(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	err = cacheZone(ctx, r.redisRepo, zone)
+	if err != nil {
+		return err
+	}
+
+	journal := &domain.Journal{Zone: zone.Name, From: from, To: zone.Serial}
+	journal.Deleted, journal.Added = diffRRs(before, after)
+	err = r.journalRepo.Create(ctx, journal)
+	if err != nil {
+		return err
+	}
+	err = r.journalRepo.Trim(ctx, zone.Name, journalSize)
+	if err != nil {
+		return err
+	}
+	return r.notifyUseCase.Notify(ctx, zone.Name)
 }
 
 func (r *recordUseCase) createFakeAAAA(ctx context.Context, header *dns.RR_Header) error {
@@ -219,6 +759,82 @@ func (r *recordUseCase) getFakeSOA(ctx context.Context, q dns.Question) (*dns.SO
 	return nil, fmt.Errorf("the A record isn't existed")
 }
 
+// ptrRecords returns the PTR records of the addresses of the A and AAAA records keyed by
+// their reverse names
+func ptrRecords(rrs []dns.RR) map[string]*dns.PTR {
+	ptrs := map[string]*dns.PTR{}
+	for _, rr := range rrs {
+		var ip net.IP
+		switch v := rr.(type) {
+		case *dns.A:
+			ip = v.A
+		case *dns.AAAA:
+			ip = v.AAAA
+		default:
+			continue
+		}
+
+		owner, err := dns.ReverseAddr(ip.String())
+		if err != nil {
+			continue
+		}
+		ptrs[owner] = &dns.PTR{
+			Hdr: dns.RR_Header{
+				Name:   owner,
+				Rrtype: dns.TypePTR,
+				Class:  rr.Header().Class,
+				Ttl:    rr.Header().Ttl,
+			},
+			Ptr: rr.Header().Name,
+		}
+	}
+	return ptrs
+}
+
+// diffRRs returns the records which are only in before and the ones which are only in after,
+// a record whose TTL is changed is in both
+func diffRRs(before []dns.RR, after []dns.RR) ([]string, []string) {
+	var (
+		deleted []string
+		added   []string
+		olds    = map[string]bool{}
+		news    = map[string]bool{}
+	)
+
+	for _, rr := range before {
+		olds[rr.String()] = true
+	}
+	for _, rr := range after {
+		news[rr.String()] = true
+		if !olds[rr.String()] {
+			added = append(added, rr.String())
+		}
+	}
+	for _, rr := range before {
+		if !news[rr.String()] {
+			deleted = append(deleted, rr.String())
+		}
+	}
+	return deleted, added
+}
+
+// cacheRRset overwrites the cached answer of the question with all members of the RRset
+func cacheRRset(ctx context.Context, redisRepo domain.RedisRepo, q dns.Question, rrs []dns.RR) error {
+	err := redisRepo.HDel(ctx, q.String())
+	if err != nil {
+		return err
+	}
+
+	for i, rr := range rrs {
+		field := fmt.Sprintf("%s-%d", domain.Answer, i)
+		err = redisRepo.HSet(ctx, q.String(), field, rr.String(), 0)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *recordUseCase) isNsExisted(ctx context.Context, key string) bool {
 	rrMap, _ := r.redisRepo.HGetAll(ctx, key)
 	if len(rrMap) == 0 {
@@ -237,5 +853,8 @@ func NewRecordUseCase(injector *do.Injector) (domain.RecordUseCase, error) {
 	return &recordUseCase{
 		do.MustInvoke[domain.RedisRepo](injector),
 		do.MustInvoke[domain.RecordRepo](injector),
+		do.MustInvoke[domain.ZoneRepo](injector),
+		do.MustInvoke[domain.JournalRepo](injector),
+		do.MustInvoke[domain.NotifyUseCase](injector),
 	}, nil
 }
@@ -8,6 +8,8 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"net"
+	"net/http"
+	"slices"
 	"testing"
 
 	"github.com/cewuandy/go-restful-dns/internal/domain"
@@ -19,8 +21,11 @@ type recordUseCaseTestSuite struct {
 
 	usecase domain.RecordUseCase
 
-	recordRepo *mocks.RecordRepo
-	redisRepo  *mocks.RedisRepo
+	recordRepo  *mocks.RecordRepo
+	redisRepo   *mocks.RedisRepo
+	zoneRepo    *mocks.ZoneRepo
+	journalRepo *mocks.JournalRepo
+	notify      *mocks.NotifyUseCase
 }
 
 func TestRecordUseCase(t *testing.T) {
@@ -33,6 +38,12 @@ func (t *recordUseCaseTestSuite) SetupSuite() {
 	t.redisRepo = &mocks.RedisRepo{}
 	do.ProvideValue[domain.RecordRepo](injector, t.recordRepo)
 	do.ProvideValue[domain.RedisRepo](injector, t.redisRepo)
+	t.zoneRepo = &mocks.ZoneRepo{}
+	do.ProvideValue[domain.ZoneRepo](injector, t.zoneRepo)
+	t.journalRepo = &mocks.JournalRepo{}
+	do.ProvideValue[domain.JournalRepo](injector, t.journalRepo)
+	t.notify = &mocks.NotifyUseCase{}
+	do.ProvideValue[domain.NotifyUseCase](injector, t.notify)
 
 	t.usecase, _ = NewRecordUseCase(injector)
 }
@@ -41,6 +52,7 @@ func (t *recordUseCaseTestSuite) SetupTest() {
 	var (
 		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
 		anyRecord  = mock.AnythingOfType("*domain.Record")
+		anyRecords = mock.AnythingOfType("[]*domain.Record")
 		anyString  = mock.AnythingOfType("string")
 		anyUint16  = mock.AnythingOfType("uint16")
 		anyTime    = mock.AnythingOfType("time.Duration")
@@ -48,13 +60,36 @@ func (t *recordUseCaseTestSuite) SetupTest() {
 
 	t.recordRepo.ExpectedCalls = nil
 	t.redisRepo.ExpectedCalls = nil
+	t.zoneRepo.ExpectedCalls = nil
+	t.journalRepo.ExpectedCalls = nil
+	t.journalRepo.Calls = nil
+	t.notify.ExpectedCalls = nil
+	t.notify.Calls = nil
+
+	t.notify.
+		On("Notify", anyContext, anyString).
+		Return(nil)
+
+	t.journalRepo.
+		On("Create", anyContext, mock.AnythingOfType("*domain.Journal")).
+		Return(nil)
+	t.journalRepo.
+		On("Trim", anyContext, anyString, mock.AnythingOfType("int")).
+		Return(nil)
+
+	t.zoneRepo.
+		On("GetClosest", anyContext, anyString).
+		Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
 
 	t.recordRepo.
 		On("Create", anyContext, anyRecord).
 		Return(nil)
 	t.recordRepo.
-		On("Get", anyContext, anyString, anyUint16, anyUint16).
-		Return(nil, nil)
+		On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+		Return([]*domain.Record{}, nil)
+	t.recordRepo.
+		On("ListByName", anyContext, anyString, anyUint16).
+		Return([]*domain.Record{}, nil)
 	t.recordRepo.
 		On("List", anyContext).
 		Return(
@@ -68,7 +103,10 @@ func (t *recordUseCaseTestSuite) SetupTest() {
 			}, nil,
 		)
 	t.recordRepo.
-		On("Update", anyContext, anyRecord).
+		On("ReplaceRRset", anyContext, anyString, anyUint16, anyUint16, anyRecords).
+		Return(nil)
+	t.recordRepo.
+		On("ReplaceRRsets", anyContext, anyRecords, anyRecords).
 		Return(nil)
 	t.recordRepo.
 		On("Delete", anyContext, anyString, anyUint16, anyUint16).
@@ -118,8 +156,88 @@ func (t *recordUseCaseTestSuite) TestCreateRecord() {
 
 	t.Run(
 		"success_TypeA", func() {
-			err := t.usecase.CreateRecord(context.Background(), rrA)
+			err := t.usecase.CreateRecord(context.Background(), rrA, domain.RecordOptions{})
+			t.Nil(err)
+		},
+	)
+
+	t.Run(
+		"success_second_member", func() {
+			t.SetupTest()
+			t.redisRepo.Calls = nil
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("ListByName", anyContext, anyString, anyUint16).
+				Return([]*domain.Record{}, nil)
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return(
+					[]*domain.Record{
+						{
+							Name:   "test.com.",
+							RrType: 1,
+							Class:  1,
+							Rdata:  "2.2.2.2",
+							Record: "test.com.\t1440\tIN\tA\t2.2.2.2",
+						},
+					}, nil,
+				)
+			t.recordRepo.
+				On("Create", anyContext, anyRecord).
+				Return(nil)
+
+			err := t.usecase.CreateRecord(context.Background(), rrA, domain.RecordOptions{})
+			t.Nil(err)
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";test.com.\tIN\t A", "Answer-0", "test.com.\t1440\tIN\tA\t2.2.2.2",
+				anyTime,
+			)
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";test.com.\tIN\t A", "Answer-1", "test.com.\t1440\tIN\tA\t1.1.1.1",
+				anyTime,
+			)
+		},
+	)
+
+	t.Run(
+		"success_in_zone", func() {
+			t.SetupTest()
+			t.redisRepo.Calls = nil
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("GetClosest", anyContext, anyString).
+				Return(
+					&domain.Zone{
+						Name:        "test.com.",
+						Nameservers: []string{"ns1.test.com."},
+						Mbox:        "admin.test.com.",
+						Serial:      4000000000,
+						Ttl:         3600,
+					}, nil,
+				)
+			t.zoneRepo.
+				On("Update", anyContext, mock.AnythingOfType("*domain.Zone")).
+				Return(nil)
+
+			err := t.usecase.CreateRecord(context.Background(), rrA, domain.RecordOptions{})
 			t.Nil(err)
+			t.zoneRepo.AssertCalled(
+				t.T(), "Update", anyContext,
+				mock.MatchedBy(func(zone *domain.Zone) bool { return zone.Serial == 4000000001 }),
+			)
+			t.journalRepo.AssertCalled(
+				t.T(), "Create", anyContext, &domain.Journal{
+					Zone:  "test.com.",
+					From:  4000000000,
+					To:    4000000001,
+					Added: []string{"test.com.\t1440\tIN\tA\t1.1.1.1"},
+				},
+			)
+			t.journalRepo.AssertCalled(t.T(), "Trim", anyContext, "test.com.", journalSize)
+			t.notify.AssertCalled(t.T(), "Notify", anyContext, "test.com.")
+			t.redisRepo.AssertNotCalled(
+				t.T(), "HSet", anyContext, ";test.com.\tIN\t AAAA", "Ns-0", anyString, anyTime,
+			)
 		},
 	)
 
@@ -137,7 +255,7 @@ func (t *recordUseCaseTestSuite) TestCreateRecord() {
 				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
 				Return(nil)
 
-			err := t.usecase.CreateRecord(context.Background(), rrAAAA)
+			err := t.usecase.CreateRecord(context.Background(), rrAAAA, domain.RecordOptions{})
 			t.Nil(err)
 		},
 	)
@@ -156,7 +274,7 @@ func (t *recordUseCaseTestSuite) TestCreateRecord() {
 				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
 				Return(nil)
 
-			err := t.usecase.CreateRecord(context.Background(), rrAAAA)
+			err := t.usecase.CreateRecord(context.Background(), rrAAAA, domain.RecordOptions{})
 			t.Nil(err)
 		},
 	)
@@ -175,7 +293,7 @@ func (t *recordUseCaseTestSuite) TestCreateRecord() {
 				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
 				Return(nil)
 
-			err := t.usecase.CreateRecord(context.Background(), rrAAAA)
+			err := t.usecase.CreateRecord(context.Background(), rrAAAA, domain.RecordOptions{})
 			t.NotNil(err)
 			t.Equal("test-error", err.Error())
 		},
@@ -186,29 +304,134 @@ func (t *recordUseCaseTestSuite) TestCreateRecord() {
 			t.SetupTest()
 			t.recordRepo.ExpectedCalls = nil
 			t.recordRepo.
-				On("Get", anyContext, anyString, anyUint16, anyUint16).
+				On("ListByName", anyContext, anyString, anyUint16).
+				Return([]*domain.Record{}, nil)
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
 				Return(
-					&domain.Record{
-						Name:   "test.com.",
-						RrType: 1,
-						Class:  1,
-						Record: "test.com.\t1440\tIN\tA\t1.1.1.1",
+					[]*domain.Record{
+						{
+							Name:   "test.com.",
+							RrType: 1,
+							Class:  1,
+							Rdata:  "1.1.1.1",
+							Record: "test.com.\t300\tIN\tA\t1.1.1.1",
+						},
 					}, nil,
 				)
-			err := t.usecase.CreateRecord(context.Background(), rrA)
+			err := t.usecase.CreateRecord(context.Background(), rrA, domain.RecordOptions{})
 			t.NotNil(err)
 			t.Contains(err.Error(), "the record is already existed.")
 		},
 	)
 
 	t.Run(
-		"Get_error", func() {
+		"ttl_mismatch_error", func() {
 			t.SetupTest()
 			t.recordRepo.ExpectedCalls = nil
 			t.recordRepo.
-				On("Get", anyContext, anyString, anyUint16, anyUint16).
+				On("ListByName", anyContext, anyString, anyUint16).
+				Return([]*domain.Record{}, nil)
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return(
+					[]*domain.Record{
+						{
+							Name:   "test.com.",
+							RrType: 1,
+							Class:  1,
+							Rdata:  "2.2.2.2",
+							Record: "test.com.\t300\tIN\tA\t2.2.2.2",
+						},
+					}, nil,
+				)
+			err := t.usecase.CreateRecord(context.Background(), rrA, domain.RecordOptions{})
+			t.NotNil(err)
+			t.Contains(err.Error(), "the TTL should be 300")
+		},
+	)
+
+	t.Run(
+		"cname_existed_error", func() {
+			t.SetupTest()
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("ListByName", anyContext, anyString, anyUint16).
+				Return([]*domain.Record{{Name: "test.com.", RrType: dns.TypeCNAME, Class: 1}}, nil)
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return([]*domain.Record{}, nil)
+			err := t.usecase.CreateRecord(context.Background(), rrA, domain.RecordOptions{})
+			t.NotNil(err)
+			t.Contains(err.Error(), "CNAME and other data cannot coexist at test.com.")
+		},
+	)
+
+	t.Run(
+		"cname_other_data_error", func() {
+			t.SetupTest()
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("ListByName", anyContext, anyString, anyUint16).
+				Return([]*domain.Record{{Name: "www.test.com.", RrType: dns.TypeA, Class: 1}}, nil)
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return([]*domain.Record{}, nil)
+			cname, _ := dns.NewRR("www.test.com.\t1440\tIN\tCNAME\ttest.com.")
+			err := t.usecase.CreateRecord(context.Background(), cname, domain.RecordOptions{})
+			t.NotNil(err)
+			t.Contains(err.Error(), "CNAME and other data cannot coexist at www.test.com.")
+		},
+	)
+
+	t.Run(
+		"cname_singleton_error", func() {
+			t.SetupTest()
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return(
+					[]*domain.Record{
+						{
+							Name:   "www.test.com.",
+							RrType: dns.TypeCNAME,
+							Class:  1,
+							Record: "www.test.com.\t1440\tIN\tCNAME\tother.com.",
+						},
+					}, nil,
+				)
+			cname, _ := dns.NewRR("www.test.com.\t1440\tIN\tCNAME\ttest.com.")
+			err := t.usecase.CreateRecord(context.Background(), cname, domain.RecordOptions{})
+			t.NotNil(err)
+			t.Contains(err.Error(), "can only have one CNAME record")
+		},
+	)
+
+	t.Run(
+		"cname_apex_error", func() {
+			t.SetupTest()
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("GetClosest", anyContext, anyString).
+				Return(&domain.Zone{Name: "test.com.", Ttl: 3600}, nil)
+			cname, _ := dns.NewRR("test.com.\t1440\tIN\tCNAME\tother.com.")
+			err := t.usecase.CreateRecord(context.Background(), cname, domain.RecordOptions{})
+			t.NotNil(err)
+			t.Contains(err.Error(), "the zone apex test.com. cannot have a CNAME record")
+		},
+	)
+
+	t.Run(
+		"GetRRset_error", func() {
+			t.SetupTest()
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("ListByName", anyContext, anyString, anyUint16).
+				Return([]*domain.Record{}, nil)
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
 				Return(nil, fmt.Errorf("test-error"))
-			err := t.usecase.CreateRecord(context.Background(), rrA)
+			err := t.usecase.CreateRecord(context.Background(), rrA, domain.RecordOptions{})
 			t.NotNil(err)
 			t.Equal("test-error", err.Error())
 		},
@@ -219,12 +442,15 @@ func (t *recordUseCaseTestSuite) TestCreateRecord() {
 			t.SetupTest()
 			t.recordRepo.ExpectedCalls = nil
 			t.recordRepo.
-				On("Get", anyContext, anyString, anyUint16, anyUint16).
-				Return(nil, nil)
+				On("ListByName", anyContext, anyString, anyUint16).
+				Return([]*domain.Record{}, nil)
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return([]*domain.Record{}, nil)
 			t.recordRepo.
 				On("Create", anyContext, anyRecord).
 				Return(fmt.Errorf("test-error"))
-			err := t.usecase.CreateRecord(context.Background(), rrA)
+			err := t.usecase.CreateRecord(context.Background(), rrA, domain.RecordOptions{})
 			t.NotNil(err)
 			t.Equal("test-error", err.Error())
 		},
@@ -237,10 +463,13 @@ func (t *recordUseCaseTestSuite) TestCreateRecord() {
 			t.redisRepo.
 				On("HGetAll", anyContext, anyString).
 				Return(map[string]string{"Answer-1": "test.com.\t1440\tIN\tA\t1.1.1.1"}, nil)
+			t.redisRepo.
+				On("HDel", anyContext, anyString).
+				Return(nil)
 			t.redisRepo.
 				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
 				Return(fmt.Errorf("test-error"))
-			err := t.usecase.CreateRecord(context.Background(), rrA)
+			err := t.usecase.CreateRecord(context.Background(), rrA, domain.RecordOptions{})
 			t.NotNil(err)
 			t.Equal("test-error", err.Error())
 		},
@@ -253,10 +482,13 @@ func (t *recordUseCaseTestSuite) TestCreateRecord() {
 			t.redisRepo.
 				On("HGetAll", anyContext, anyString).
 				Return(nil, fmt.Errorf("test-error"))
+			t.redisRepo.
+				On("HDel", anyContext, anyString).
+				Return(nil)
 			t.redisRepo.
 				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
 				Return(nil)
-			err := t.usecase.CreateRecord(context.Background(), rrA)
+			err := t.usecase.CreateRecord(context.Background(), rrA, domain.RecordOptions{})
 			t.NotNil(err)
 			t.Equal("test-error", err.Error())
 		},
@@ -269,10 +501,13 @@ func (t *recordUseCaseTestSuite) TestCreateRecord() {
 			t.redisRepo.
 				On("HGetAll", anyContext, anyString).
 				Return(map[string]string{}, nil)
+			t.redisRepo.
+				On("HDel", anyContext, anyString).
+				Return(nil)
 			t.redisRepo.
 				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
 				Return(nil)
-			err := t.usecase.CreateRecord(context.Background(), rrA)
+			err := t.usecase.CreateRecord(context.Background(), rrA, domain.RecordOptions{})
 			t.NotNil(err)
 			t.Contains(err.Error(), "the A record isn't existed")
 		},
@@ -285,13 +520,19 @@ func (t *recordUseCaseTestSuite) TestCreateRecord() {
 			t.redisRepo.
 				On("HGetAll", anyContext, anyString).
 				Return(map[string]string{"Answer-1": "test.com.\t1440\tIN\tA\t1.1.1.1"}, nil)
+			t.redisRepo.
+				On("HDel", anyContext, anyString).
+				Return(nil)
 			t.redisRepo.
 				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
 				Return(nil).Once()
+			t.redisRepo.
+				On("HDel", anyContext, anyString).
+				Return(nil)
 			t.redisRepo.
 				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
 				Return(fmt.Errorf("test-error"))
-			err := t.usecase.CreateRecord(context.Background(), rrA)
+			err := t.usecase.CreateRecord(context.Background(), rrA, domain.RecordOptions{})
 			t.NotNil(err)
 			t.Equal("test-error", err.Error())
 		},
@@ -314,36 +555,55 @@ func (t *recordUseCaseTestSuite) TestGetRecord() {
 	t.SetupTest()
 	t.recordRepo.ExpectedCalls = nil
 	t.recordRepo.
-		On("Get", anyContext, anyString, anyUint16, anyUint16).
+		On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
 		Return(
-			&domain.Record{
-				Name:   "test.com.",
-				RrType: 1,
-				Class:  1,
-				Record: "test.com.\t1440\tIN\tA\t1.1.1.1",
+			[]*domain.Record{
+				{
+					Name:   "test.com.",
+					RrType: 1,
+					Class:  1,
+					Record: "test.com.\t1440\tIN\tA\t1.1.1.1",
+				},
+				{
+					Name:   "test.com.",
+					RrType: 1,
+					Class:  1,
+					Record: "test.com.\t1440\tIN\tA\t2.2.2.2",
+				},
 			}, nil,
 		)
 
 	t.Run(
 		"success", func() {
-			rr, err := t.usecase.GetRecord(context.Background(), q)
-			t.NotNil(rr)
-			t.Equal("test.com.", rr.Header().Name)
-			t.Equal(uint16(1), rr.Header().Rrtype)
-			t.Equal(uint16(1), rr.Header().Class)
+			rrs, err := t.usecase.GetRecord(context.Background(), q)
 			t.Nil(err)
+			t.Len(rrs, 2)
+			t.Equal("test.com.", rrs[0].Header().Name)
+			t.Equal(uint16(1), rrs[0].Header().Rrtype)
+			t.Equal(uint16(1), rrs[0].Header().Class)
+			t.Equal("test.com.\t1440\tIN\tA\t2.2.2.2", rrs[1].String())
 		},
 	)
 
 	t.Run(
-		"Get_error", func() {
+		"not_found_error", func() {
+			t.SetupTest()
+			rrs, err := t.usecase.GetRecord(context.Background(), q)
+			t.Nil(rrs)
+			t.NotNil(err)
+			t.Equal(http.StatusNotFound, err.(*domain.Error).StatusCode)
+		},
+	)
+
+	t.Run(
+		"GetRRset_error", func() {
 			t.SetupTest()
 			t.recordRepo.ExpectedCalls = nil
 			t.recordRepo.
-				On("Get", anyContext, anyString, anyUint16, anyUint16).
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
 				Return(nil, fmt.Errorf("test-error"))
-			rr, err := t.usecase.GetRecord(context.Background(), q)
-			t.Nil(rr)
+			rrs, err := t.usecase.GetRecord(context.Background(), q)
+			t.Nil(rrs)
 			t.NotNil(err)
 			t.Equal("test-error", err.Error())
 		},
@@ -354,10 +614,10 @@ func (t *recordUseCaseTestSuite) TestGetRecord() {
 			t.SetupTest()
 			t.recordRepo.ExpectedCalls = nil
 			t.recordRepo.
-				On("Get", anyContext, anyString, anyUint16, anyUint16).
-				Return(&domain.Record{Record: "test-error"}, nil)
-			rr, err := t.usecase.GetRecord(context.Background(), q)
-			t.Nil(rr)
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return([]*domain.Record{{Record: "test-error"}}, nil)
+			rrs, err := t.usecase.GetRecord(context.Background(), q)
+			t.Nil(rrs)
 			t.NotNil(err)
 			t.Equal("dns: not a TTL: \"test-error\" at line: 1:10", err.Error())
 		},
@@ -410,37 +670,68 @@ func (t *recordUseCaseTestSuite) TestListRecords() {
 func (t *recordUseCaseTestSuite) TestUpdateRecord() {
 	var (
 		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
-		anyRecord  = mock.AnythingOfType("*domain.Record")
+		anyRecords = mock.AnythingOfType("[]*domain.Record")
 		anyString  = mock.AnythingOfType("string")
+		anyUint16  = mock.AnythingOfType("uint16")
 	)
 
-	a := dns.A{
-		Hdr: dns.RR_Header{
+	rr, _ := dns.NewRR("test.com.\t1440\tIN\tA\t1.1.1.1")
+	existed := []*domain.Record{
+		{
 			Name:   "test.com.",
-			Rrtype: 1,
+			RrType: 1,
 			Class:  1,
-			Ttl:    1440,
+			Record: "test.com.\t1440\tIN\tA\t2.2.2.2",
 		},
-		A: net.ParseIP("1.1.1.1"),
 	}
 
-	rr, _ := dns.NewRR(a.String())
-
 	t.Run(
 		"success", func() {
-			err := t.usecase.UpdateRecord(context.Background(), rr)
+			t.SetupTest()
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("ListByName", anyContext, anyString, anyUint16).
+				Return([]*domain.Record{}, nil)
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return(existed, nil)
+			t.recordRepo.
+				On("ReplaceRRset", anyContext, anyString, anyUint16, anyUint16, anyRecords).
+				Return(nil)
+			err := t.usecase.UpdateRecord(context.Background(), rr, domain.RecordOptions{})
 			t.Nil(err)
+			t.recordRepo.AssertCalled(
+				t.T(), "ReplaceRRset", anyContext, "test.com.", dns.TypeA, uint16(dns.ClassINET),
+				mock.MatchedBy(func(records []*domain.Record) bool {
+					return len(records) == 1 && records[0].Rdata == "1.1.1.1"
+				}),
+			)
 		},
 	)
 
 	t.Run(
-		"Get_error", func() {
+		"not_found_error", func() {
+			t.SetupTest()
+			err := t.usecase.UpdateRecord(context.Background(), rr, domain.RecordOptions{})
+			t.NotNil(err)
+			t.Equal(http.StatusNotFound, err.(*domain.Error).StatusCode)
+		},
+	)
+
+	t.Run(
+		"ReplaceRRset_error", func() {
 			t.SetupTest()
 			t.recordRepo.ExpectedCalls = nil
 			t.recordRepo.
-				On("Update", anyContext, anyRecord).
+				On("ListByName", anyContext, anyString, anyUint16).
+				Return([]*domain.Record{}, nil)
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return(existed, nil)
+			t.recordRepo.
+				On("ReplaceRRset", anyContext, anyString, anyUint16, anyUint16, anyRecords).
 				Return(fmt.Errorf("test-error"))
-			err := t.usecase.UpdateRecord(context.Background(), rr)
+			err := t.usecase.UpdateRecord(context.Background(), rr, domain.RecordOptions{})
 			t.NotNil(err)
 			t.Equal("test-error", err.Error())
 		},
@@ -449,11 +740,393 @@ func (t *recordUseCaseTestSuite) TestUpdateRecord() {
 	t.Run(
 		"HDel_error", func() {
 			t.SetupTest()
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("ListByName", anyContext, anyString, anyUint16).
+				Return([]*domain.Record{}, nil)
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return(existed, nil)
+			t.recordRepo.
+				On("ReplaceRRset", anyContext, anyString, anyUint16, anyUint16, anyRecords).
+				Return(nil)
 			t.redisRepo.ExpectedCalls = nil
 			t.redisRepo.
 				On("HDel", anyContext, anyString).
 				Return(fmt.Errorf("test-error"))
-			err := t.usecase.UpdateRecord(context.Background(), rr)
+			err := t.usecase.UpdateRecord(context.Background(), rr, domain.RecordOptions{})
+			t.NotNil(err)
+			t.Equal("test-error", err.Error())
+		},
+	)
+}
+
+func (t *recordUseCaseTestSuite) TestReplaceRRset() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		anyTime    = mock.AnythingOfType("time.Duration")
+	)
+
+	newRRs := func(records ...string) []dns.RR {
+		var rrs []dns.RR
+		for _, record := range records {
+			rr, _ := dns.NewRR(record)
+			rrs = append(rrs, rr)
+		}
+		return rrs
+	}
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			t.redisRepo.Calls = nil
+			err := t.usecase.ReplaceRRset(
+				context.Background(), newRRs(
+					"test.com.\t1440\tIN\tA\t1.1.1.1",
+					"test.com.\t300\tIN\tA\t2.2.2.2",
+				), domain.RecordOptions{},
+			)
+			t.Nil(err)
+			t.redisRepo.AssertCalled(t.T(), "HDel", anyContext, ";test.com.\tIN\t A")
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";test.com.\tIN\t A", "Answer-1", "test.com.\t1440\tIN\tA\t2.2.2.2",
+				anyTime,
+			)
+		},
+	)
+
+	t.Run(
+		"success_in_zone", func() {
+			t.SetupTest()
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("GetClosest", anyContext, anyString).
+				Return(
+					&domain.Zone{
+						Name:        "test.com.",
+						Nameservers: []string{"ns1.test.com."},
+						Mbox:        "admin.test.com.",
+						Serial:      4000000000,
+						Ttl:         3600,
+					}, nil,
+				)
+			t.zoneRepo.
+				On("Update", anyContext, mock.AnythingOfType("*domain.Zone")).
+				Return(nil)
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, mock.AnythingOfType("uint16"), mock.AnythingOfType("uint16")).
+				Return(
+					[]*domain.Record{
+						{Name: "test.com.", RrType: 1, Class: 1, Record: "test.com.\t1440\tIN\tA\t1.1.1.1"},
+						{Name: "test.com.", RrType: 1, Class: 1, Record: "test.com.\t1440\tIN\tA\t3.3.3.3"},
+					}, nil,
+				)
+			t.recordRepo.
+				On("ReplaceRRset", anyContext, anyString, mock.AnythingOfType("uint16"),
+					mock.AnythingOfType("uint16"), mock.AnythingOfType("[]*domain.Record")).
+				Return(nil)
+			t.recordRepo.
+				On("ListByName", anyContext, anyString, mock.AnythingOfType("uint16")).
+				Return([]*domain.Record{}, nil)
+
+			err := t.usecase.ReplaceRRset(
+				context.Background(), newRRs(
+					"test.com.\t1440\tIN\tA\t1.1.1.1",
+					"test.com.\t1440\tIN\tA\t2.2.2.2",
+				), domain.RecordOptions{},
+			)
+			t.Nil(err)
+			t.journalRepo.AssertCalled(
+				t.T(), "Create", anyContext, &domain.Journal{
+					Zone:    "test.com.",
+					From:    4000000000,
+					To:      4000000001,
+					Deleted: []string{"test.com.\t1440\tIN\tA\t3.3.3.3"},
+					Added:   []string{"test.com.\t1440\tIN\tA\t2.2.2.2"},
+				},
+			)
+		},
+	)
+
+	t.Run(
+		"empty_error", func() {
+			t.SetupTest()
+			err := t.usecase.ReplaceRRset(context.Background(), nil, domain.RecordOptions{})
+			t.NotNil(err)
+			t.Contains(err.Error(), "at least one record")
+		},
+	)
+
+	t.Run(
+		"different_name_error", func() {
+			t.SetupTest()
+			err := t.usecase.ReplaceRRset(
+				context.Background(), newRRs(
+					"test.com.\t1440\tIN\tA\t1.1.1.1",
+					"www.test.com.\t1440\tIN\tA\t2.2.2.2",
+				), domain.RecordOptions{},
+			)
+			t.NotNil(err)
+			t.Contains(err.Error(), "should have the same name, type and class")
+		},
+	)
+
+	t.Run(
+		"duplicated_error", func() {
+			t.SetupTest()
+			err := t.usecase.ReplaceRRset(
+				context.Background(), newRRs(
+					"test.com.\t1440\tIN\tA\t1.1.1.1",
+					"test.com.\t300\tIN\tA\t1.1.1.1",
+				), domain.RecordOptions{},
+			)
+			t.NotNil(err)
+			t.Contains(err.Error(), "is duplicated")
+		},
+	)
+
+	t.Run(
+		"HSet_error", func() {
+			t.SetupTest()
+			t.redisRepo.ExpectedCalls = nil
+			t.redisRepo.
+				On("HDel", anyContext, anyString).
+				Return(nil)
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+				Return(fmt.Errorf("test-error"))
+			err := t.usecase.ReplaceRRset(
+				context.Background(), newRRs("test.com.\t1440\tIN\tA\t1.1.1.1"), domain.RecordOptions{},
+			)
+			t.NotNil(err)
+			t.Equal("test-error", err.Error())
+		},
+	)
+}
+
+func (t *recordUseCaseTestSuite) TestReplaceRRsets() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyRecords = mock.AnythingOfType("[]*domain.Record")
+		anyString  = mock.AnythingOfType("string")
+		anyUint16  = mock.AnythingOfType("uint16")
+		a, _       = dns.NewRR("www.test.com.\t300\tIN\tA\t1.1.1.1")
+		cname, _   = dns.NewRR("www.test.com.\t300\tIN\tCNAME\tmail.test.com.")
+		txt, _     = dns.NewRR("mail.test.com.\t300\tIN\tTXT\t\"text\"")
+	)
+
+	// setup serves the A record of www.test.com. from the zone test.com.
+	setup := func() {
+		t.SetupTest()
+		t.recordRepo.Calls = nil
+		t.redisRepo.Calls = nil
+		t.zoneRepo.Calls = nil
+		t.zoneRepo.ExpectedCalls = nil
+		t.zoneRepo.
+			On("GetClosest", anyContext, anyString).
+			Return(&domain.Zone{Name: "test.com.", Serial: 10, Ttl: 3600}, nil)
+		t.zoneRepo.
+			On("Update", anyContext, mock.AnythingOfType("*domain.Zone")).
+			Return(nil)
+		t.recordRepo.ExpectedCalls = nil
+		t.recordRepo.
+			On("GetRRset", anyContext, "www.test.com.", dns.TypeA, anyUint16).
+			Return([]*domain.Record{domain.NewRecord(a)}, nil)
+		t.recordRepo.
+			On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+			Return([]*domain.Record{}, nil)
+		t.recordRepo.
+			On("ListByName", anyContext, "www.test.com.", anyUint16).
+			Return([]*domain.Record{domain.NewRecord(a)}, nil)
+		t.recordRepo.
+			On("ListByName", anyContext, anyString, anyUint16).
+			Return([]*domain.Record{}, nil)
+		t.recordRepo.
+			On("ReplaceRRsets", anyContext, anyRecords, anyRecords).
+			Return(nil)
+	}
+
+	t.Run(
+		"success", func() {
+			setup()
+			// the CNAME takes the place of the A RRset deleted in the same write
+			err := t.usecase.ReplaceRRsets(
+				context.Background(), []*domain.RRset{
+					{Question: dns.Question{Name: "www.test.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET}},
+					{
+						Question: dns.Question{Name: "www.test.com.", Qtype: dns.TypeCNAME, Qclass: dns.ClassINET},
+						Records:  []dns.RR{cname},
+					},
+					{
+						Question: dns.Question{Name: "mail.test.com.", Qtype: dns.TypeTXT, Qclass: dns.ClassINET},
+						Records:  []dns.RR{txt},
+					},
+				}, domain.RecordOptions{},
+			)
+			t.Nil(err)
+			t.recordRepo.AssertNumberOfCalls(t.T(), "ReplaceRRsets", 1)
+			t.recordRepo.AssertCalled(
+				t.T(), "ReplaceRRsets", anyContext,
+				[]*domain.Record{
+					{Name: "www.test.com.", RrType: dns.TypeA, Class: dns.ClassINET},
+					{Name: "www.test.com.", RrType: dns.TypeCNAME, Class: dns.ClassINET},
+					{Name: "mail.test.com.", RrType: dns.TypeTXT, Class: dns.ClassINET},
+				},
+				[]*domain.Record{domain.NewRecord(cname), domain.NewRecord(txt)},
+			)
+			t.redisRepo.AssertCalled(t.T(), "HDel", anyContext, ";www.test.com.\tIN\t A")
+			// the changes are journaled as one and notified once
+			t.zoneRepo.AssertNumberOfCalls(t.T(), "Update", 1)
+			t.journalRepo.AssertNumberOfCalls(t.T(), "Create", 1)
+			t.journalRepo.AssertCalled(
+				t.T(), "Create", anyContext, mock.MatchedBy(
+					func(journal *domain.Journal) bool {
+						return journal.Zone == "test.com." && journal.From == 10 &&
+							slices.Equal(journal.Deleted, []string{a.String()}) &&
+							slices.Equal(journal.Added, []string{cname.String(), txt.String()})
+					},
+				),
+			)
+			t.notify.AssertNumberOfCalls(t.T(), "Notify", 1)
+		},
+	)
+
+	t.Run(
+		"second_rrset_error", func() {
+			setup()
+			// the CNAME would coexist with the A RRset, which is kept
+			err := t.usecase.ReplaceRRsets(
+				context.Background(), []*domain.RRset{
+					{
+						Question: dns.Question{Name: "mail.test.com.", Qtype: dns.TypeTXT, Qclass: dns.ClassINET},
+						Records:  []dns.RR{txt},
+					},
+					{
+						Question: dns.Question{Name: "www.test.com.", Qtype: dns.TypeCNAME, Qclass: dns.ClassINET},
+						Records:  []dns.RR{cname},
+					},
+				}, domain.RecordOptions{},
+			)
+			t.NotNil(err)
+			t.Contains(err.Error(), "CNAME and other data cannot coexist")
+			// the first RRset isn't written either
+			t.recordRepo.AssertNotCalled(t.T(), "ReplaceRRsets", anyContext, anyRecords, anyRecords)
+			t.redisRepo.AssertNotCalled(t.T(), "HSet", anyContext, anyString, anyString, anyString, mock.Anything)
+			t.zoneRepo.AssertNotCalled(t.T(), "Update", anyContext, mock.Anything)
+			t.journalRepo.AssertNotCalled(t.T(), "Create", anyContext, mock.Anything)
+			t.notify.AssertNotCalled(t.T(), "Notify", anyContext, anyString)
+		},
+	)
+
+	t.Run(
+		"write_error", func() {
+			setup()
+			t.recordRepo.ExpectedCalls = slices.DeleteFunc(
+				t.recordRepo.ExpectedCalls, func(call *mock.Call) bool { return call.Method == "ReplaceRRsets" },
+			)
+			t.recordRepo.
+				On("ReplaceRRsets", anyContext, anyRecords, anyRecords).
+				Return(&domain.Error{Message: "DB error: UNIQUE constraint failed", StatusCode: http.StatusBadRequest})
+			err := t.usecase.ReplaceRRsets(
+				context.Background(), []*domain.RRset{
+					{
+						Question: dns.Question{Name: "mail.test.com.", Qtype: dns.TypeTXT, Qclass: dns.ClassINET},
+						Records:  []dns.RR{txt},
+					},
+					{Question: dns.Question{Name: "www.test.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET}},
+				}, domain.RecordOptions{},
+			)
+			t.NotNil(err)
+			t.redisRepo.AssertNotCalled(t.T(), "HDel", anyContext, anyString)
+			t.redisRepo.AssertNotCalled(t.T(), "HSet", anyContext, anyString, anyString, anyString, mock.Anything)
+			t.zoneRepo.AssertNotCalled(t.T(), "Update", anyContext, mock.Anything)
+			t.journalRepo.AssertNotCalled(t.T(), "Create", anyContext, mock.Anything)
+		},
+	)
+}
+
+func (t *recordUseCaseTestSuite) TestRemoveRecord() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyRecords = mock.AnythingOfType("[]*domain.Record")
+		anyString  = mock.AnythingOfType("string")
+		anyUint16  = mock.AnythingOfType("uint16")
+	)
+
+	rr, _ := dns.NewRR("test.com.\t1440\tIN\tA\t1.1.1.1")
+	setupRRset := func(records ...string) {
+		var rrset []*domain.Record
+		for _, record := range records {
+			rrset = append(rrset, &domain.Record{Name: "test.com.", RrType: 1, Class: 1, Record: record})
+		}
+		t.recordRepo.ExpectedCalls = nil
+		t.recordRepo.
+			On("ListByName", anyContext, anyString, anyUint16).
+			Return([]*domain.Record{}, nil)
+		t.recordRepo.
+			On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+			Return(rrset, nil)
+		t.recordRepo.
+			On("ReplaceRRset", anyContext, anyString, anyUint16, anyUint16, anyRecords).
+			Return(nil)
+		t.recordRepo.
+			On("Delete", anyContext, anyString, anyUint16, anyUint16).
+			Return(nil)
+	}
+
+	t.Run(
+		"success_member", func() {
+			t.SetupTest()
+			t.recordRepo.Calls = nil
+			setupRRset("test.com.\t1440\tIN\tA\t1.1.1.1", "test.com.\t1440\tIN\tA\t2.2.2.2")
+			err := t.usecase.RemoveRecord(context.Background(), rr, domain.RecordOptions{})
+			t.Nil(err)
+			t.recordRepo.AssertCalled(
+				t.T(), "ReplaceRRset", anyContext, "test.com.", dns.TypeA, uint16(dns.ClassINET),
+				mock.MatchedBy(func(records []*domain.Record) bool {
+					return len(records) == 1 && records[0].Rdata == "2.2.2.2"
+				}),
+			)
+			t.recordRepo.AssertNotCalled(t.T(), "Delete", anyContext, anyString, anyUint16, anyUint16)
+		},
+	)
+
+	t.Run(
+		"success_last_member", func() {
+			t.SetupTest()
+			t.recordRepo.Calls = nil
+			setupRRset("test.com.\t1440\tIN\tA\t1.1.1.1")
+			err := t.usecase.RemoveRecord(context.Background(), rr, domain.RecordOptions{})
+			t.Nil(err)
+			t.recordRepo.AssertCalled(
+				t.T(), "Delete", anyContext, "test.com.", dns.TypeA, uint16(dns.ClassINET),
+			)
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			t.SetupTest()
+			setupRRset("test.com.\t1440\tIN\tA\t2.2.2.2")
+			err := t.usecase.RemoveRecord(context.Background(), rr, domain.RecordOptions{})
+			t.NotNil(err)
+			t.Equal(http.StatusNotFound, err.(*domain.Error).StatusCode)
+		},
+	)
+
+	t.Run(
+		"GetRRset_error", func() {
+			t.SetupTest()
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("ListByName", anyContext, anyString, anyUint16).
+				Return([]*domain.Record{}, nil)
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return(nil, fmt.Errorf("test-error"))
+			err := t.usecase.RemoveRecord(context.Background(), rr, domain.RecordOptions{})
 			t.NotNil(err)
 			t.Equal("test-error", err.Error())
 		},
@@ -475,7 +1148,7 @@ func (t *recordUseCaseTestSuite) TestDeleteRecord() {
 
 	t.Run(
 		"success", func() {
-			err := t.usecase.DeleteRecord(context.Background(), q)
+			err := t.usecase.DeleteRecord(context.Background(), q, domain.RecordOptions{})
 			t.Nil(err)
 		},
 	)
@@ -484,10 +1157,13 @@ func (t *recordUseCaseTestSuite) TestDeleteRecord() {
 		"Get_error", func() {
 			t.SetupTest()
 			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return([]*domain.Record{}, nil)
 			t.recordRepo.
 				On("Delete", anyContext, anyString, anyUint16, anyUint16).
 				Return(fmt.Errorf("test-error"))
-			err := t.usecase.DeleteRecord(context.Background(), q)
+			err := t.usecase.DeleteRecord(context.Background(), q, domain.RecordOptions{})
 			t.NotNil(err)
 			t.Equal("test-error", err.Error())
 		},
@@ -503,9 +1179,356 @@ func (t *recordUseCaseTestSuite) TestDeleteRecord() {
 			t.redisRepo.
 				On("HDel", anyContext, anyString).
 				Return(fmt.Errorf("test-error"))
-			err := t.usecase.DeleteRecord(context.Background(), q)
+			err := t.usecase.DeleteRecord(context.Background(), q, domain.RecordOptions{})
 			t.NotNil(err)
 			t.Equal("test-error", err.Error())
 		},
 	)
 }
+
+func (t *recordUseCaseTestSuite) TestCreatePtr() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyRecord  = mock.AnythingOfType("*domain.Record")
+		anyRecords = mock.AnythingOfType("[]*domain.Record")
+		anyString  = mock.AnythingOfType("string")
+		anyUint16  = mock.AnythingOfType("uint16")
+		ptrName    = "1.1.1.1.in-addr.arpa."
+		opts       = domain.RecordOptions{CreatePtr: true}
+	)
+
+	a, _ := dns.NewRR("test.com.\t1440\tIN\tA\t1.1.1.1")
+	aaaa, _ := dns.NewRR("test.com.\t1440\tIN\tAAAA\t2001:db8::1")
+	ptr, _ := dns.NewRR(ptrName + "\t1440\tIN\tPTR\ttest.com.")
+
+	t.Run(
+		"success_create_ptr", func() {
+			t.SetupTest()
+			t.recordRepo.Calls = nil
+			err := t.usecase.CreateRecord(context.Background(), a, opts)
+			t.Nil(err)
+			// the A record and its PTR record are written in one transaction
+			t.recordRepo.AssertNotCalled(t.T(), "Create", anyContext, anyRecord)
+			t.recordRepo.AssertCalled(
+				t.T(), "ReplaceRRsets", anyContext,
+				[]*domain.Record{
+					{Name: "test.com.", RrType: dns.TypeA, Class: dns.ClassINET},
+					{Name: ptrName, RrType: dns.TypePTR, Class: dns.ClassINET},
+				},
+				[]*domain.Record{domain.NewRecord(a), domain.NewRecord(ptr)},
+			)
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";"+ptrName+"\tIN\t PTR", "Answer-0", ptrName+"\t1440\tIN\tPTR\ttest.com.",
+				mock.AnythingOfType("time.Duration"),
+			)
+		},
+	)
+
+	t.Run(
+		"success_create_ptr_AAAA", func() {
+			t.SetupTest()
+			t.recordRepo.Calls = nil
+			err := t.usecase.CreateRecord(context.Background(), aaaa, opts)
+			t.Nil(err)
+			t.recordRepo.AssertCalled(
+				t.T(), "ReplaceRRsets", anyContext, anyRecords, mock.MatchedBy(
+					func(records []*domain.Record) bool {
+						return len(records) == 2 &&
+							records[1].Name == "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa." &&
+							records[1].RrType == dns.TypePTR
+					},
+				),
+			)
+		},
+	)
+
+	t.Run(
+		"success_without_ptr", func() {
+			t.SetupTest()
+			t.recordRepo.Calls = nil
+			err := t.usecase.CreateRecord(context.Background(), a, domain.RecordOptions{})
+			t.Nil(err)
+			t.recordRepo.AssertNumberOfCalls(t.T(), "Create", 1)
+		},
+	)
+
+	t.Run(
+		"ptr_conflict", func() {
+			t.SetupTest()
+			t.recordRepo.Calls = nil
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("ListByName", anyContext, anyString, anyUint16).
+				Return([]*domain.Record{}, nil)
+			t.recordRepo.
+				On("GetRRset", anyContext, ptrName, anyUint16, anyUint16).
+				Return(
+					[]*domain.Record{
+						{
+							Name:   ptrName,
+							RrType: dns.TypePTR,
+							Class:  1,
+							Rdata:  "other.com.",
+							Record: ptrName + "\t1440\tIN\tPTR\tother.com.",
+						},
+					}, nil,
+				)
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return([]*domain.Record{}, nil)
+			t.recordRepo.
+				On("Create", anyContext, anyRecord).
+				Return(nil)
+			err := t.usecase.CreateRecord(context.Background(), a, opts)
+			t.NotNil(err)
+			t.Equal(http.StatusConflict, err.(*domain.Error).StatusCode)
+			t.Contains(err.Error(), "is already owned by other.com.")
+			t.recordRepo.AssertNotCalled(t.T(), "Create", anyContext, anyRecord)
+		},
+	)
+
+	t.Run(
+		"success_delete_ptr", func() {
+			t.SetupTest()
+			t.recordRepo.Calls = nil
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("GetRRset", anyContext, ptrName, anyUint16, anyUint16).
+				Return(
+					[]*domain.Record{
+						{
+							Name:   ptrName,
+							RrType: dns.TypePTR,
+							Class:  1,
+							Rdata:  "test.com.",
+							Record: ptrName + "\t1440\tIN\tPTR\ttest.com.",
+						},
+					}, nil,
+				)
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return(
+					[]*domain.Record{
+						{
+							Name:   "test.com.",
+							RrType: 1,
+							Class:  1,
+							Rdata:  "1.1.1.1",
+							Record: "test.com.\t1440\tIN\tA\t1.1.1.1",
+						},
+					}, nil,
+				)
+			t.recordRepo.
+				On("ReplaceRRsets", anyContext, anyRecords, anyRecords).
+				Return(nil)
+			err := t.usecase.DeleteRecord(
+				context.Background(), domain.Question{
+					Name:   "test.com.",
+					Qtype:  domain.TypeA,
+					Qclass: domain.ClassINET,
+				}, opts,
+			)
+			t.Nil(err)
+			t.recordRepo.AssertCalled(
+				t.T(), "ReplaceRRsets", anyContext,
+				[]*domain.Record{
+					{Name: "test.com.", RrType: dns.TypeA, Class: dns.ClassINET},
+					{Name: ptrName, RrType: dns.TypePTR, Class: dns.ClassINET},
+				},
+				[]*domain.Record(nil),
+			)
+			t.redisRepo.AssertCalled(t.T(), "HDel", anyContext, ";"+ptrName+"\tIN\t PTR")
+		},
+	)
+
+	t.Run(
+		"ptr_zone_error", func() {
+			t.SetupTest()
+			t.recordRepo.Calls = nil
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("GetClosest", anyContext, ptrName).
+				Return(&domain.Zone{Name: "in-addr.arpa.", Type: domain.ZoneSecondary, Primary: "192.0.2.1:53"}, nil)
+			t.zoneRepo.
+				On("GetClosest", anyContext, anyString).
+				Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+			err := t.usecase.CreateRecord(context.Background(), a, opts)
+			t.NotNil(err)
+			t.Contains(err.Error(), "read-only")
+			t.recordRepo.AssertNotCalled(t.T(), "Create", anyContext, anyRecord)
+			t.recordRepo.AssertNotCalled(t.T(), "ReplaceRRsets", anyContext, anyRecords, anyRecords)
+		},
+	)
+
+	t.Run(
+		"ptr_write_error", func() {
+			t.SetupTest()
+			t.redisRepo.Calls = nil
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("GetClosest", anyContext, anyString).
+				Return(&domain.Zone{Name: "test.com.", Serial: 1, Ttl: 3600}, nil)
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return([]*domain.Record{}, nil)
+			t.recordRepo.
+				On("ListByName", anyContext, anyString, anyUint16).
+				Return([]*domain.Record{}, nil)
+			t.recordRepo.
+				On("ReplaceRRsets", anyContext, anyRecords, anyRecords).
+				Return(&domain.Error{Message: "DB error: UNIQUE constraint failed", StatusCode: http.StatusBadRequest})
+			err := t.usecase.CreateRecord(context.Background(), a, opts)
+			t.NotNil(err)
+			// neither the A record nor its PTR record is written, cached or journaled
+			t.recordRepo.AssertNotCalled(t.T(), "Create", anyContext, anyRecord)
+			t.redisRepo.AssertNotCalled(t.T(), "HSet", anyContext, anyString, anyString, anyString, mock.Anything)
+			t.zoneRepo.AssertNotCalled(t.T(), "Update", anyContext, mock.Anything)
+			t.journalRepo.AssertNotCalled(t.T(), "Create", anyContext, mock.Anything)
+		},
+	)
+
+	t.Run(
+		"success_one_serial_per_zone", func() {
+			t.SetupTest()
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("GetClosest", anyContext, ptrName).
+				Return(&domain.Zone{Name: "in-addr.arpa.", Serial: 10, Ttl: 3600}, nil)
+			t.zoneRepo.
+				On("GetClosest", anyContext, anyString).
+				Return(&domain.Zone{Name: "test.com.", Serial: 20, Ttl: 3600}, nil)
+			t.zoneRepo.
+				On("Update", anyContext, mock.AnythingOfType("*domain.Zone")).
+				Return(nil)
+			err := t.usecase.CreateRecord(context.Background(), a, opts)
+			t.Nil(err)
+			t.journalRepo.AssertNumberOfCalls(t.T(), "Create", 2)
+			t.journalRepo.AssertCalled(
+				t.T(), "Create", anyContext, mock.MatchedBy(
+					func(journal *domain.Journal) bool {
+						return journal.Zone == "in-addr.arpa." && journal.From == 10 &&
+							slices.Equal(journal.Added, []string{ptrName + "\t1440\tIN\tPTR\ttest.com."})
+					},
+				),
+			)
+			t.notify.AssertNumberOfCalls(t.T(), "Notify", 2)
+		},
+	)
+}
+
+func (t *recordUseCaseTestSuite) TestSecondaryZone() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		anyUint16  = mock.AnythingOfType("uint16")
+	)
+
+	setup := func() {
+		t.SetupTest()
+		t.recordRepo.Calls = nil
+		t.zoneRepo.ExpectedCalls = nil
+		t.zoneRepo.
+			On("GetClosest", anyContext, anyString).
+			Return(
+				&domain.Zone{
+					Name:    "test.com.",
+					Type:    domain.ZoneSecondary,
+					Primary: "192.0.2.1:53",
+					Serial:  2024010100,
+				}, nil,
+			)
+	}
+
+	rr, _ := dns.NewRR("www.test.com.\t3600\tIN\tA\t1.1.1.1")
+
+	t.Run(
+		"create_error", func() {
+			setup()
+			err := t.usecase.CreateRecord(context.Background(), rr, domain.RecordOptions{})
+			t.NotNil(err)
+			t.Equal(http.StatusForbidden, err.(*domain.Error).StatusCode)
+			t.Contains(err.Error(), "read-only")
+			t.recordRepo.AssertNotCalled(t.T(), "Create", anyContext, mock.AnythingOfType("*domain.Record"))
+		},
+	)
+
+	t.Run(
+		"replace_error", func() {
+			setup()
+			err := t.usecase.ReplaceRRset(context.Background(), []dns.RR{rr}, domain.RecordOptions{})
+			t.NotNil(err)
+			t.Contains(err.Error(), "read-only")
+		},
+	)
+
+	t.Run(
+		"delete_error", func() {
+			setup()
+			err := t.usecase.DeleteRecord(
+				context.Background(), domain.Question{
+					Name:   "www.test.com.",
+					Qtype:  domain.TypeA,
+					Qclass: domain.ClassINET,
+				}, domain.RecordOptions{},
+			)
+			t.NotNil(err)
+			t.Contains(err.Error(), "read-only")
+			t.recordRepo.AssertNotCalled(t.T(), "Delete", anyContext, anyString, anyUint16, anyUint16)
+		},
+	)
+}
+
+func (t *recordUseCaseTestSuite) TestGetClosestError() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		anyUint16  = mock.AnythingOfType("uint16")
+	)
+
+	setup := func() {
+		t.SetupTest()
+		t.recordRepo.Calls = nil
+		t.zoneRepo.ExpectedCalls = nil
+		t.zoneRepo.
+			On("GetClosest", anyContext, anyString).
+			Return(nil, &domain.Error{Message: "DB error: database is locked", StatusCode: http.StatusInternalServerError})
+	}
+
+	rr, _ := dns.NewRR("www.test.com.\t3600\tIN\tA\t1.1.1.1")
+
+	t.Run(
+		"create_error", func() {
+			setup()
+			err := t.usecase.CreateRecord(context.Background(), rr, domain.RecordOptions{})
+			t.NotNil(err)
+			t.Contains(err.Error(), "database is locked")
+			t.recordRepo.AssertNotCalled(t.T(), "Create", anyContext, mock.AnythingOfType("*domain.Record"))
+		},
+	)
+
+	t.Run(
+		"replace_error", func() {
+			setup()
+			err := t.usecase.ReplaceRRset(context.Background(), []dns.RR{rr}, domain.RecordOptions{})
+			t.NotNil(err)
+			t.Contains(err.Error(), "database is locked")
+		},
+	)
+
+	t.Run(
+		"delete_error", func() {
+			setup()
+			err := t.usecase.DeleteRecord(
+				context.Background(), domain.Question{
+					Name:   "www.test.com.",
+					Qtype:  domain.TypeA,
+					Qclass: domain.ClassINET,
+				}, domain.RecordOptions{},
+			)
+			t.NotNil(err)
+			t.Contains(err.Error(), "database is locked")
+			t.recordRepo.AssertNotCalled(t.T(), "Delete", anyContext, anyString, anyUint16, anyUint16)
+		},
+	)
+}
@@ -0,0 +1,396 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/pkg/forwarder"
+)
+
+// rootHints are the IANA root servers (https://www.internic.net/domain/named.root)
+const rootHints = `
+.                   3600000 NS   A.ROOT-SERVERS.NET.
+.                   3600000 NS   B.ROOT-SERVERS.NET.
+.                   3600000 NS   C.ROOT-SERVERS.NET.
+.                   3600000 NS   D.ROOT-SERVERS.NET.
+.                   3600000 NS   E.ROOT-SERVERS.NET.
+.                   3600000 NS   F.ROOT-SERVERS.NET.
+.                   3600000 NS   G.ROOT-SERVERS.NET.
+.                   3600000 NS   H.ROOT-SERVERS.NET.
+.                   3600000 NS   I.ROOT-SERVERS.NET.
+.                   3600000 NS   J.ROOT-SERVERS.NET.
+.                   3600000 NS   K.ROOT-SERVERS.NET.
+.                   3600000 NS   L.ROOT-SERVERS.NET.
+.                   3600000 NS   M.ROOT-SERVERS.NET.
+A.ROOT-SERVERS.NET. 3600000 A    198.41.0.4
+A.ROOT-SERVERS.NET. 3600000 AAAA 2001:503:ba3e::2:30
+B.ROOT-SERVERS.NET. 3600000 A    170.247.170.2
+B.ROOT-SERVERS.NET. 3600000 AAAA 2801:1b8:10::b
+C.ROOT-SERVERS.NET. 3600000 A    192.33.4.12
+C.ROOT-SERVERS.NET. 3600000 AAAA 2001:500:2::c
+D.ROOT-SERVERS.NET. 3600000 A    199.7.91.13
+D.ROOT-SERVERS.NET. 3600000 AAAA 2001:500:2d::d
+E.ROOT-SERVERS.NET. 3600000 A    192.203.230.10
+E.ROOT-SERVERS.NET. 3600000 AAAA 2001:500:a8::e
+F.ROOT-SERVERS.NET. 3600000 A    192.5.5.241
+F.ROOT-SERVERS.NET. 3600000 AAAA 2001:500:2f::f
+G.ROOT-SERVERS.NET. 3600000 A    192.112.36.4
+G.ROOT-SERVERS.NET. 3600000 AAAA 2001:500:12::d0d
+H.ROOT-SERVERS.NET. 3600000 A    198.97.190.53
+H.ROOT-SERVERS.NET. 3600000 AAAA 2001:500:1::53
+I.ROOT-SERVERS.NET. 3600000 A    192.36.148.17
+I.ROOT-SERVERS.NET. 3600000 AAAA 2001:7fe::53
+J.ROOT-SERVERS.NET. 3600000 A    192.58.128.30
+J.ROOT-SERVERS.NET. 3600000 AAAA 2001:503:c27::2:30
+K.ROOT-SERVERS.NET. 3600000 A    193.0.14.129
+K.ROOT-SERVERS.NET. 3600000 AAAA 2001:7fd::1
+L.ROOT-SERVERS.NET. 3600000 A    199.7.83.42
+L.ROOT-SERVERS.NET. 3600000 AAAA 2001:500:9f::42
+M.ROOT-SERVERS.NET. 3600000 A    202.12.27.33
+M.ROOT-SERVERS.NET. 3600000 AAAA 2001:dc3::35
+`
+
+const (
+	// maxReferrals bounds the referrals followed by a lookup
+	maxReferrals = 16
+	// maxServerDepth bounds the nested lookups of the name servers without glue
+	maxServerDepth = 4
+	// maxDelegationTTL bounds how long the name servers of a zone are cached
+	maxDelegationTTL = 24 * time.Hour
+)
+
+type resolveUseCase struct {
+	forwarders *forwarder.Pool
+
+	// roots are the addresses of the root servers
+	roots []string
+	// port is the port of every name server
+	port string
+	// udpSize is the EDNS0 buffer size advertised to the name servers
+	udpSize uint16
+
+	// delegations are the addresses of the name servers by the zones learnt from the referrals
+	delegations sync.Map
+}
+
+// delegation is the addresses of the name servers of a zone
+type delegation struct {
+	servers []string
+	expire  time.Time
+}
+
+func (r *resolveUseCase) Resolve(ctx context.Context, req *dns.Msg) (*dns.Msg, error) {
+	q := req.Question[0]
+	dnssecOK := false
+	if opt := req.IsEdns0(); opt != nil {
+		dnssecOK = opt.Do()
+	}
+
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	resp.RecursionAvailable = true
+
+	name := q.Name
+	for i := 0; i <= maxCNAMEChain; i++ {
+		answer, zone, err := r.lookup(ctx, name, q.Qtype, dnssecOK, 0)
+		if err != nil {
+			return nil, err
+		}
+		// only the records inside the zone of the answering servers are trusted (RFC 2181 5.4.1, RFC 5452)
+		answer.Answer = inZone(answer.Answer, zone)
+		answer.Ns = inZone(answer.Ns, zone)
+		resp.Rcode = answer.Rcode
+		resp.Answer = append(resp.Answer, answer.Answer...)
+		resp.Ns = answer.Ns
+
+		// the rcode and the authority of the answer are the ones of the end of the chain (RFC 6604 3), and
+		// a target outside the zone is resolved on its own whatever the servers of the zone say about it
+		target, answered := cnameTarget(answer.Answer, name, q.Qtype)
+		if answered || target == name {
+			return resp, nil
+		}
+		if answer.Rcode != dns.RcodeSuccess && dns.IsSubDomain(zone, target) {
+			return resp, nil
+		}
+		name = target
+	}
+	return nil, domain.Error{
+		Message: fmt.Sprintf("the CNAME chain of %s is too long", q.Name),
+		Err:     domain.ErrNoUpstream,
+	}
+}
+
+// lookup asks the name servers of the closest known zone of the name, and follows their referrals
+// down to the authoritative answer, which is returned with the zone of the servers giving it
+func (r *resolveUseCase) lookup(ctx context.Context, name string, qtype uint16, dnssecOK bool, depth int) (
+	*dns.Msg, string, error,
+) {
+	// the DS records are answered by the parent zone (RFC 4035 3.1.4.1)
+	bound := name
+	if qtype == dns.TypeDS && dns.CanonicalName(name) != "." {
+		bound = parentName(name)
+	}
+
+	zone, servers := r.closest(bound)
+	for i := 0; i < maxReferrals; i++ {
+		resp, err := r.ask(ctx, servers, name, qtype, dnssecOK)
+		if err != nil {
+			return nil, "", err
+		}
+
+		child, names, ttl := referral(resp, zone, bound)
+		if child == "" {
+			return resp, zone, nil
+		}
+		servers = glue(resp, zone, names)
+		if len(servers) == 0 {
+			servers = r.resolveServers(ctx, names, depth)
+		}
+		if len(servers) == 0 {
+			return nil, "", domain.Error{
+				Message: fmt.Sprintf("cannot get the addresses of the name servers of %s", child),
+				Err:     domain.ErrNoUpstream,
+			}
+		}
+		r.delegations.Store(child, &delegation{servers, time.Now().Add(min(ttl, maxDelegationTTL))})
+		zone = child
+	}
+	return nil, "", domain.Error{
+		Message: fmt.Sprintf("too many referrals of %s", name),
+		Err:     domain.ErrNoUpstream,
+	}
+}
+
+// closest returns the deepest zone of the name whose name servers are known, which is the root at
+// worst
+func (r *resolveUseCase) closest(name string) (string, []string) {
+	name = dns.CanonicalName(name)
+	now := time.Now()
+	for _, i := range dns.Split(name) {
+		value, ok := r.delegations.Load(name[i:])
+		if !ok {
+			continue
+		}
+		if d := value.(*delegation); now.Before(d.expire) {
+			return name[i:], d.servers
+		}
+		r.delegations.Delete(name[i:])
+	}
+	return ".", r.roots
+}
+
+// ask sends the question to the name servers in turn until one of them answers it, a response to
+// another question is ignored (RFC 5452 9.1)
+func (r *resolveUseCase) ask(ctx context.Context, servers []string, name string, qtype uint16, dnssecOK bool) (
+	*dns.Msg, error,
+) {
+	req := new(dns.Msg)
+	req.SetQuestion(name, qtype)
+	req.RecursionDesired = false
+	req.SetEdns0(r.udpSize, dnssecOK)
+
+	var lastErr error
+	for _, server := range servers {
+		resp, err := r.forwarders.Exchange(ctx, req, net.JoinHostPort(server, r.port))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(resp.Question) != 1 || !strings.EqualFold(resp.Question[0].Name, name) ||
+			resp.Question[0].Qtype != qtype || resp.Question[0].Qclass != dns.ClassINET {
+			lastErr = fmt.Errorf("%s answers another question", server)
+			continue
+		}
+		if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+			lastErr = fmt.Errorf("%s answers %s", server, dns.RcodeToString[resp.Rcode])
+			continue
+		}
+		return resp, nil
+	}
+	return nil, domain.Error{
+		Message: fmt.Sprintf("cannot resolve %s %s: %v", name, dns.TypeToString[qtype], lastErr),
+		Err:     domain.ErrNoUpstream,
+	}
+}
+
+// resolveServers looks up the addresses of the name servers without glue, until one of them has any
+func (r *resolveUseCase) resolveServers(ctx context.Context, names []string, depth int) []string {
+	if depth >= maxServerDepth {
+		return nil
+	}
+	for _, name := range names {
+		var servers []string
+		for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			resp, zone, err := r.lookup(ctx, name, qtype, false, depth+1)
+			if err != nil {
+				continue
+			}
+			servers = append(servers, addresses(inZone(resp.Answer, zone), name)...)
+		}
+		if len(servers) > 0 {
+			return servers
+		}
+	}
+	return nil
+}
+
+// referral returns the child zone of the referral to a zone between the asked zone and the name, the
+// names of its name servers and the TTL of their NS records. It returns an empty zone when the
+// response is an answer.
+func referral(resp *dns.Msg, zone, name string) (string, []string, time.Duration) {
+	if resp.Rcode != dns.RcodeSuccess || len(resp.Answer) > 0 {
+		return "", nil, 0
+	}
+
+	var (
+		child string
+		names []string
+		ttl   uint32
+	)
+	for _, rr := range resp.Ns {
+		ns, ok := rr.(*dns.NS)
+		if !ok {
+			continue
+		}
+		owner := dns.CanonicalName(ns.Hdr.Name)
+		if owner == dns.CanonicalName(zone) || !dns.IsSubDomain(zone, owner) || !dns.IsSubDomain(owner, name) {
+			continue
+		}
+		if child != "" && owner != child {
+			continue
+		}
+		child = owner
+		names = append(names, dns.CanonicalName(ns.Ns))
+		if ttl == 0 || ns.Hdr.Ttl < ttl {
+			ttl = ns.Hdr.Ttl
+		}
+	}
+	return child, names, time.Duration(ttl) * time.Second
+}
+
+// glue returns the addresses of the name servers in the additional section, only the ones inside the
+// asked zone are trusted (RFC 2181 5.4.1)
+func glue(resp *dns.Msg, zone string, names []string) []string {
+	var servers []string
+	for _, name := range names {
+		if dns.IsSubDomain(zone, name) {
+			servers = append(servers, addresses(resp.Extra, name)...)
+		}
+	}
+	return servers
+}
+
+// inZone returns the records owned by the names inside the zone
+func inZone(rrs []dns.RR, zone string) []dns.RR {
+	var kept []dns.RR
+	for _, rr := range rrs {
+		if dns.IsSubDomain(zone, rr.Header().Name) {
+			kept = append(kept, rr)
+		}
+	}
+	return kept
+}
+
+// addresses returns the IPv4 addresses of the name before its IPv6 ones
+func addresses(rrs []dns.RR, name string) []string {
+	var ipv4s, ipv6s []string
+	for _, rr := range rrs {
+		if !strings.EqualFold(rr.Header().Name, name) {
+			continue
+		}
+		switch rr := rr.(type) {
+		case *dns.A:
+			ipv4s = append(ipv4s, rr.A.String())
+		case *dns.AAAA:
+			ipv6s = append(ipv6s, rr.AAAA.String())
+		}
+	}
+	return append(ipv4s, ipv6s...)
+}
+
+// cnameTarget follows the CNAME chain of the name in the records, and returns its end and whether the
+// end is answered
+func cnameTarget(rrs []dns.RR, name string, qtype uint16) (string, bool) {
+	for i := 0; i <= maxCNAMEChain; i++ {
+		next := ""
+		for _, rr := range rrs {
+			if !strings.EqualFold(rr.Header().Name, name) {
+				continue
+			}
+			if rr.Header().Rrtype == qtype || qtype == dns.TypeANY {
+				return name, true
+			}
+			if cname, ok := rr.(*dns.CNAME); ok {
+				next = cname.Target
+			}
+		}
+		if next == "" {
+			return name, false
+		}
+		name = next
+	}
+	return name, false
+}
+
+// loadRootHints reads the addresses of the root servers from the master file, or from the IANA root
+// hints when no file is given
+func loadRootHints(file string) ([]string, error) {
+	hints := rootHints
+	if file != "" {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		hints = string(raw)
+	}
+
+	var names []string
+	var rrs []dns.RR
+	parser := dns.NewZoneParser(strings.NewReader(hints), ".", file)
+	for rr, ok := parser.Next(); ok; rr, ok = parser.Next() {
+		rrs = append(rrs, rr)
+		if ns, isNS := rr.(*dns.NS); isNS && ns.Hdr.Name == "." {
+			names = append(names, ns.Ns)
+		}
+	}
+	if err := parser.Err(); err != nil {
+		return nil, err
+	}
+
+	var roots []string
+	for _, name := range names {
+		roots = append(roots, addresses(rrs, name)...)
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no address of the root servers in %s", file)
+	}
+	return roots, nil
+}
+
+// NewResolveUseCase returns nil when the recursion isn't enabled
+func NewResolveUseCase(injector *do.Injector) (domain.ResolveUseCase, error) {
+	env := do.MustInvoke[*domain.Options](injector)
+	if !env.Recursion {
+		return nil, nil
+	}
+
+	roots, err := loadRootHints(env.RootHintsFile)
+	if err != nil {
+		return nil, err
+	}
+	return &resolveUseCase{
+		forwarders: do.MustInvoke[*forwarder.Pool](injector),
+		roots:      roots,
+		port:       "53",
+		udpSize:    uint16(max(dns.MinMsgSize, env.EdnsUdpSize)),
+	}, nil
+}
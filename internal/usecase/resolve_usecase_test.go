@@ -0,0 +1,448 @@
+package usecase
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+	"github.com/cewuandy/go-restful-dns/pkg/forwarder"
+)
+
+// fakeZones are the zones of the fake authoritative servers by their addresses, every server listens on
+// the same port like the real ones
+var fakeZones = map[string]string{
+	"127.0.0.2": `
+.                3600 IN SOA a.root.test. admin.test. 1 7200 3600 1209600 300
+test.            3600 IN NS  ns.test.
+ns.test.         3600 IN A   127.0.0.3
+other.           3600 IN NS  ns.other.
+ns.other.        3600 IN A   127.0.0.5`,
+	"127.0.0.3": `
+test.            3600 IN SOA ns.test. admin.test. 1 7200 3600 1209600 300
+example.test.    3600 IN NS  ns.example.test.
+example.test.    3600 IN DS  12345 13 2 0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+ns.example.test. 3600 IN A   127.0.0.4
+noglue.test.     3600 IN NS  ns1.other.`,
+	"127.0.0.4": `
+example.test.    3600 IN SOA ns.example.test. admin.example.test. 1 7200 3600 1209600 300
+www.example.test.   300 IN A     192.0.2.1
+alias.example.test. 300 IN CNAME www.other.
+www.other.          300 IN A     192.0.2.66`,
+	"127.0.0.5": `
+other.           3600 IN SOA ns.other. admin.other. 1 7200 3600 1209600 300
+www.other.       300  IN A   192.0.2.2
+ns1.other.       3600 IN A   127.0.0.6`,
+	"127.0.0.6": `
+noglue.test.     3600 IN SOA ns1.other. admin.other. 1 7200 3600 1209600 300
+host.noglue.test. 300 IN A   192.0.2.3`,
+}
+
+type resolveUseCaseTestSuite struct {
+	suite.Suite
+
+	port      string
+	hintsFile string
+
+	servers []*dns.Server
+	// queries are the numbers of the queries received by the fake servers by their addresses
+	queries map[string]*atomic.Int32
+
+	usecase *resolveUseCase
+}
+
+func TestResolveUseCase(t *testing.T) {
+	suite.Run(t, &resolveUseCaseTestSuite{})
+}
+
+func (t *resolveUseCaseTestSuite) SetupSuite() {
+	t.queries = make(map[string]*atomic.Int32)
+
+	// the root server picks the port of the others
+	pc, err := net.ListenPacket("udp", "127.0.0.2:0")
+	t.Nil(err)
+	_, t.port, _ = net.SplitHostPort(pc.LocalAddr().String())
+	t.startFakeServer(pc, "127.0.0.2")
+	for _, addr := range []string{"127.0.0.3", "127.0.0.4", "127.0.0.5", "127.0.0.6"} {
+		pc, err = net.ListenPacket("udp", net.JoinHostPort(addr, t.port))
+		t.Nil(err)
+		t.startFakeServer(pc, addr)
+	}
+
+	t.hintsFile = filepath.Join(t.T().TempDir(), "root.hints")
+	t.Nil(os.WriteFile(t.hintsFile, []byte(".\t3600000\tNS\ta.root.test.\na.root.test.\t3600000\tA\t127.0.0.2\n"), 0644))
+}
+
+func (t *resolveUseCaseTestSuite) TearDownSuite() {
+	for _, server := range t.servers {
+		t.Nil(server.Shutdown())
+	}
+}
+
+func (t *resolveUseCaseTestSuite) SetupTest() {
+	for _, count := range t.queries {
+		count.Store(0)
+	}
+
+	injector := do.New()
+	forwarders, _ := forwarder.NewPool("", false)
+	do.ProvideValue(injector, forwarders)
+	do.ProvideValue(injector, &domain.Options{Recursion: true, RootHintsFile: t.hintsFile, EdnsUdpSize: 1232})
+	usecase, err := NewResolveUseCase(injector)
+	t.Nil(err)
+	t.usecase = usecase.(*resolveUseCase)
+	t.usecase.port = t.port
+}
+
+// startFakeServer serves the zone of the address, it refers the names below the zone cuts to the child
+// zones with the glue in the zone, and answers the others authoritatively. The records outside the zone
+// are injected into every answer like a poisoning server does.
+func (t *resolveUseCaseTestSuite) startFakeServer(pc net.PacketConn, addr string) {
+	var (
+		apex string
+		rrs  []dns.RR
+	)
+	parser := dns.NewZoneParser(strings.NewReader(fakeZones[addr]), "", "")
+	for rr, ok := parser.Next(); ok; rr, ok = parser.Next() {
+		if rr.Header().Rrtype == dns.TypeSOA {
+			apex = rr.Header().Name
+		}
+		rrs = append(rrs, rr)
+	}
+	t.Nil(parser.Err())
+
+	count := &atomic.Int32{}
+	t.queries[addr] = count
+
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn:        pc,
+		NotifyStartedFunc: func() { close(started) },
+		Handler: dns.HandlerFunc(
+			func(w dns.ResponseWriter, req *dns.Msg) {
+				count.Add(1)
+				_ = w.WriteMsg(fakeAnswer(req, apex, rrs))
+			},
+		),
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	<-started
+	t.servers = append(t.servers, server)
+}
+
+func fakeAnswer(req *dns.Msg, apex string, rrs []dns.RR) *dns.Msg {
+	q := req.Question[0]
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+
+	for _, rr := range rrs {
+		owner := rr.Header().Name
+		if rr.Header().Rrtype != dns.TypeNS || owner == apex || !dns.IsSubDomain(owner, q.Name) {
+			continue
+		}
+		// the DS records of a child zone are answered by the parent
+		if q.Qtype == dns.TypeDS && owner == q.Name {
+			break
+		}
+		resp.Ns = append(resp.Ns, rr)
+		resp.Extra = append(resp.Extra, addressesOf(rrs, rr.(*dns.NS).Ns)...)
+	}
+	if len(resp.Ns) > 0 {
+		return resp
+	}
+
+	resp.Authoritative = true
+	existed := false
+	var injected []dns.RR
+	for _, rr := range rrs {
+		if !dns.IsSubDomain(apex, rr.Header().Name) {
+			injected = append(injected, rr)
+			continue
+		}
+		if rr.Header().Name != q.Name {
+			continue
+		}
+		existed = true
+		if rr.Header().Rrtype == q.Qtype || rr.Header().Rrtype == dns.TypeCNAME {
+			resp.Answer = append(resp.Answer, rr)
+		}
+	}
+	if len(resp.Answer) > 0 {
+		resp.Answer = append(resp.Answer, injected...)
+		return resp
+	}
+	if !existed {
+		resp.Rcode = dns.RcodeNameError
+	}
+	for _, rr := range rrs {
+		if rr.Header().Rrtype == dns.TypeSOA {
+			resp.Ns = append(resp.Ns, rr)
+		}
+	}
+	resp.Ns = append(resp.Ns, injected...)
+	return resp
+}
+
+func addressesOf(rrs []dns.RR, name string) []dns.RR {
+	var glue []dns.RR
+	for _, rr := range rrs {
+		if rr.Header().Name == name && rr.Header().Rrtype == dns.TypeA {
+			glue = append(glue, rr)
+		}
+	}
+	return glue
+}
+
+func (t *resolveUseCaseTestSuite) resolve(name string, qtype uint16) *dns.Msg {
+	req := new(dns.Msg)
+	req.SetQuestion(name, qtype)
+	resp, err := t.usecase.Resolve(context.Background(), req)
+	t.Nil(err)
+	return resp
+}
+
+func (t *resolveUseCaseTestSuite) TestResolve() {
+	t.Run(
+		"glue_referral_success", func() {
+			t.SetupTest()
+			resp := t.resolve("www.example.test.", dns.TypeA)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.True(resp.RecursionAvailable)
+			t.Len(resp.Answer, 1)
+			t.Equal("192.0.2.1", resp.Answer[0].(*dns.A).A.String())
+			for _, addr := range []string{"127.0.0.2", "127.0.0.3", "127.0.0.4"} {
+				t.Equal(int32(1), t.queries[addr].Load(), addr)
+			}
+		},
+	)
+
+	t.Run(
+		"cname_across_zones_success", func() {
+			t.SetupTest()
+			resp := t.resolve("alias.example.test.", dns.TypeA)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Len(resp.Answer, 2)
+			t.Equal("www.other.", resp.Answer[0].(*dns.CNAME).Target)
+			t.Equal("192.0.2.2", resp.Answer[1].(*dns.A).A.String())
+		},
+	)
+
+	t.Run(
+		"out_of_zone_ignored", func() {
+			t.SetupTest()
+			// the servers of example.test. inject an A record of www.other. after the CNAME
+			resp := t.resolve("alias.example.test.", dns.TypeA)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Equal(
+				[]string{
+					"alias.example.test.\t300\tIN\tCNAME\twww.other.",
+					"www.other.\t300\tIN\tA\t192.0.2.2",
+				}, toStrings(resp.Answer),
+			)
+			// the target is resolved from the servers of other.
+			t.Equal(int32(1), t.queries["127.0.0.5"].Load())
+
+			resp = t.resolve("notexisted.example.test.", dns.TypeA)
+			t.Equal(dns.RcodeNameError, resp.Rcode)
+			t.Len(resp.Ns, 1)
+		},
+	)
+
+	t.Run(
+		"no_glue_success", func() {
+			t.SetupTest()
+			resp := t.resolve("host.noglue.test.", dns.TypeA)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Len(resp.Answer, 1)
+			t.Equal("192.0.2.3", resp.Answer[0].(*dns.A).A.String())
+			// the name server of noglue.test. is looked up in other.
+			t.Equal(int32(2), t.queries["127.0.0.5"].Load())
+		},
+	)
+
+	t.Run(
+		"ds_success", func() {
+			t.SetupTest()
+			resp := t.resolve("example.test.", dns.TypeDS)
+			t.Len(resp.Answer, 1)
+			t.Equal(dns.TypeDS, resp.Answer[0].Header().Rrtype)
+			t.Equal(int32(0), t.queries["127.0.0.4"].Load())
+		},
+	)
+
+	t.Run(
+		"nxdomain", func() {
+			t.SetupTest()
+			resp := t.resolve("notexisted.example.test.", dns.TypeA)
+			t.Equal(dns.RcodeNameError, resp.Rcode)
+			t.Empty(resp.Answer)
+			t.Equal(dns.TypeSOA, resp.Ns[0].Header().Rrtype)
+		},
+	)
+
+	t.Run(
+		"nodata", func() {
+			t.SetupTest()
+			resp := t.resolve("www.example.test.", dns.TypeAAAA)
+			t.Equal(dns.RcodeSuccess, resp.Rcode)
+			t.Empty(resp.Answer)
+			t.Equal(dns.TypeSOA, resp.Ns[0].Header().Rrtype)
+		},
+	)
+
+	t.Run(
+		"cached_delegation_success", func() {
+			t.SetupTest()
+			t.resolve("www.example.test.", dns.TypeA)
+			t.resolve("www.example.test.", dns.TypeAAAA)
+			t.resolve("alias.example.test.", dns.TypeA)
+			// the name servers of example.test. and other. are asked directly once they are known
+			t.Equal(int32(2), t.queries["127.0.0.2"].Load())
+			t.Equal(int32(1), t.queries["127.0.0.3"].Load())
+			t.Equal(int32(3), t.queries["127.0.0.4"].Load())
+		},
+	)
+
+	t.Run(
+		"another_question_error", func() {
+			t.SetupTest()
+			pc, err := net.ListenPacket("udp", net.JoinHostPort("127.0.0.7", t.port))
+			t.Require().Nil(err)
+			started := make(chan struct{})
+			server := &dns.Server{
+				PacketConn:        pc,
+				NotifyStartedFunc: func() { close(started) },
+				Handler: dns.HandlerFunc(
+					func(w dns.ResponseWriter, req *dns.Msg) {
+						resp := new(dns.Msg)
+						resp.SetReply(req)
+						resp.Question[0].Name = "www.other."
+						a, _ := dns.NewRR("www.other.\t300\tIN\tA\t192.0.2.66")
+						resp.Answer = append(resp.Answer, a)
+						_ = w.WriteMsg(resp)
+					},
+				),
+			}
+			go func() {
+				_ = server.ActivateAndServe()
+			}()
+			<-started
+			defer func() { t.Nil(server.Shutdown()) }()
+
+			t.usecase.roots = []string{"127.0.0.7"}
+			req := new(dns.Msg)
+			req.SetQuestion("www.example.test.", dns.TypeA)
+			resp, err := t.usecase.Resolve(context.Background(), req)
+			t.Nil(resp)
+			t.ErrorIs(err, domain.ErrNoUpstream)
+			t.Contains(err.Error(), "another question")
+		},
+	)
+
+	t.Run(
+		"unreachable_error", func() {
+			t.SetupTest()
+			t.usecase.roots = []string{"127.0.0.9"}
+			req := new(dns.Msg)
+			req.SetQuestion("www.example.test.", dns.TypeA)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			resp, err := t.usecase.Resolve(ctx, req)
+			t.Nil(resp)
+			t.ErrorIs(err, domain.ErrNoUpstream)
+		},
+	)
+}
+
+func (t *resolveUseCaseTestSuite) TestQueryUpstream() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		anyTime    = mock.AnythingOfType("time.Duration")
+	)
+	t.SetupTest()
+
+	injector := do.New()
+	redisRepo := &mocks.RedisRepo{}
+	redisRepo.On("HSet", anyContext, anyString, anyString, anyString, anyTime).Return(nil)
+	forwardingRuleRepo := &mocks.ForwardingRuleRepo{}
+	forwardingRuleRepo.On("List", mock.Anything).Return(nil, nil)
+	forwarders, _ := forwarder.NewPool("", false)
+	upstreams, _ := forwarder.NewGroup(forwarders, []string{"127.0.0.9:53"}, forwarder.Sequential, 1, 3, time.Minute)
+	do.ProvideValue[domain.RedisRepo](injector, redisRepo)
+	do.ProvideValue[domain.RecordRepo](injector, &mocks.RecordRepo{})
+	do.ProvideValue[domain.ZoneRepo](injector, &mocks.ZoneRepo{})
+	do.ProvideValue[domain.ForwardingRuleRepo](injector, forwardingRuleRepo)
+	do.ProvideValue[domain.ResolveUseCase](injector, t.usecase)
+	do.ProvideValue[domain.ValidateUseCase](injector, nil)
+	do.ProvideValue(injector, forwarders)
+	do.ProvideValue(injector, upstreams)
+	do.ProvideValue(injector, &domain.Options{Recursion: true, EdnsUdpSize: 1232})
+	do.Provide(injector, NewForwardingRuleUseCase)
+	usecase, err := NewDNSUseCase(injector)
+	t.Nil(err)
+
+	req := new(dns.Msg)
+	req.SetQuestion("alias.example.test.", dns.TypeA)
+	resp, err := usecase.QueryUpstream(context.Background(), req)
+	t.Nil(err)
+	t.Len(resp.Answer, 2)
+	// the resolved answers are cached like the forwarded ones
+	redisRepo.AssertCalled(
+		t.T(), "HSet", anyContext, ";alias.example.test.\tIN\t A", "Answer-1", resp.Answer[1].String(),
+		300*time.Second,
+	)
+}
+
+func (t *resolveUseCaseTestSuite) TestNewResolveUseCase() {
+	newUseCase := func(env *domain.Options) (domain.ResolveUseCase, error) {
+		injector := do.New()
+		forwarders, _ := forwarder.NewPool("", false)
+		do.ProvideValue(injector, forwarders)
+		do.ProvideValue(injector, env)
+		return NewResolveUseCase(injector)
+	}
+
+	t.Run(
+		"disabled", func() {
+			usecase, err := newUseCase(&domain.Options{})
+			t.Nil(err)
+			t.Nil(usecase)
+		},
+	)
+
+	t.Run(
+		"iana_hints_success", func() {
+			usecase, err := newUseCase(&domain.Options{Recursion: true})
+			t.Nil(err)
+			roots := usecase.(*resolveUseCase).roots
+			t.Len(roots, 26)
+			t.Equal("198.41.0.4", roots[0])
+		},
+	)
+
+	t.Run(
+		"hints_file_error", func() {
+			_, err := newUseCase(&domain.Options{Recursion: true, RootHintsFile: "notexisted.hints"})
+			t.NotNil(err)
+
+			file := filepath.Join(t.T().TempDir(), "empty.hints")
+			t.Nil(os.WriteFile(file, []byte(".\t3600000\tNS\ta.root.test.\n"), 0644))
+			_, err = newUseCase(&domain.Options{Recursion: true, RootHintsFile: file})
+			t.NotNil(err)
+		},
+	)
+}
@@ -0,0 +1,497 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/utils"
+)
+
+const (
+	// secondaryTick is how often the timers of the secondary zones are checked
+	secondaryTick = time.Second
+
+	primaryTimeout  = 5 * time.Second
+	transferTimeout = 30 * time.Second
+)
+
+type secondaryUseCase struct {
+	redisRepo domain.RedisRepo
+
+	zoneRepo domain.ZoneRepo
+
+	recordRepo domain.RecordRepo
+
+	tsigKeyRepo domain.TsigKeyRepo
+
+	mutex sync.Mutex
+
+	// due is when the secondary zones are checked against their primaries next time, the zones
+	// which aren't here are checked at once
+	due map[string]time.Time
+
+	wake chan struct{}
+}
+
+func (s *secondaryUseCase) Run(ctx context.Context) error {
+	ticker := time.NewTicker(secondaryTick)
+	defer ticker.Stop()
+
+	for {
+		s.refreshDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *secondaryUseCase) Refresh(ctx context.Context, name string) error {
+	name = utils.GetFQDNFromDomainName(name)
+	zone, err := s.zoneRepo.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !zone.IsSecondary() {
+		return &domain.Error{
+			Message:    fmt.Sprintf("%s isn't a secondary zone", name),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	err = s.refresh(ctx, zone, time.Now())
+	if err != nil {
+		return err
+	}
+	s.schedule(zone.Name, time.Now().Add(time.Duration(zone.Refresh)*time.Second))
+	return nil
+}
+
+func (s *secondaryUseCase) Notify(ctx context.Context, name string, addr net.Addr) error {
+	name = utils.GetFQDNFromDomainName(name)
+	zone, err := s.zoneRepo.Get(ctx, name)
+	if err != nil || !zone.IsSecondary() {
+		return &domain.Error{
+			Message:    fmt.Sprintf("%s isn't a secondary zone hosted here", name),
+			StatusCode: http.StatusNotFound,
+			Err:        domain.ErrNotAuth,
+		}
+	}
+	if !s.fromPrimary(ctx, zone, addr) {
+		return &domain.Error{
+			Message:    fmt.Sprintf("%s isn't the primary of %s", addr, name),
+			StatusCode: http.StatusForbidden,
+			Err:        domain.ErrRefused,
+		}
+	}
+
+	s.schedule(name, time.Time{})
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// refreshDue refreshes the secondary zones whose timers are expired, a zone which fails is
+// retried after the retry time of its SOA record and stops being answered once it expires
+func (s *secondaryUseCase) refreshDue(ctx context.Context) {
+	zones, err := s.zoneRepo.List(ctx)
+	if err != nil {
+		fmt.Printf("Error listing zones: %s\n", err.Error())
+		return
+	}
+
+	for _, zone := range zones {
+		now := time.Now()
+		if !zone.IsSecondary() || !s.isDue(zone.Name, now) {
+			continue
+		}
+
+		err = s.refresh(ctx, zone, now)
+		if err == nil {
+			s.schedule(zone.Name, now.Add(time.Duration(zone.Refresh)*time.Second))
+			continue
+		}
+
+		fmt.Printf("Error refreshing %s from %s: %s\n", zone.Name, zone.Primary, err.Error())
+		s.schedule(zone.Name, now.Add(time.Duration(zone.Retry)*time.Second))
+		err = s.expire(ctx, zone, now)
+		if err != nil {
+			fmt.Printf("Error expiring %s: %s\n", zone.Name, err.Error())
+		}
+	}
+}
+
+func (s *secondaryUseCase) isDue(name string, now time.Time) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	due, ok := s.due[name]
+	return !ok || !now.Before(due)
+}
+
+func (s *secondaryUseCase) schedule(name string, due time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.due[name] = due
+}
+
+// refresh transfers the zone when the primary has a newer serial (RFC 1034 4.3.5), an expired
+// zone is transferred again even if the serial is the same since it's no longer cached
+func (s *secondaryUseCase) refresh(ctx context.Context, zone *domain.Zone, now time.Time) error {
+	serial, err := s.primarySerial(ctx, zone)
+	if err != nil {
+		return err
+	}
+	if !zone.Expired(now) && !serialLess(zone.Serial, serial) {
+		zone.Refreshed = &now
+		return s.zoneRepo.Update(ctx, zone)
+	}
+
+	rrs, err := s.transfer(ctx, zone)
+	if err != nil {
+		return err
+	}
+	soa, rrs, err := s.transferredZone(ctx, zone, rrs)
+	if err != nil {
+		return err
+	}
+	return s.store(ctx, zone, soa, rrs, now)
+}
+
+func (s *secondaryUseCase) primarySerial(ctx context.Context, zone *domain.Zone) (uint32, error) {
+	req := new(dns.Msg)
+	req.SetQuestion(zone.Name, dns.TypeSOA)
+	tsigSecret, err := s.sign(ctx, zone, req)
+	if err != nil {
+		return 0, err
+	}
+
+	client := &dns.Client{Timeout: primaryTimeout, TsigSecret: tsigSecret}
+	resp, _, err := client.ExchangeContext(ctx, req, zone.Primary)
+	if err != nil {
+		return 0, err
+	}
+	if resp.Rcode != dns.RcodeSuccess || !resp.Authoritative {
+		return 0, fmt.Errorf("the primary isn't authoritative for %s: %s", zone.Name, dns.RcodeToString[resp.Rcode])
+	}
+	for _, rr := range resp.Answer {
+		if soa, ok := rr.(*dns.SOA); ok && soa.Hdr.Name == zone.Name {
+			return soa.Serial, nil
+		}
+	}
+	return 0, fmt.Errorf("the primary has no SOA record of %s", zone.Name)
+}
+
+// transfer asks the changes since the serial of the zone by IXFR, a zone which was never
+// transferred asks the whole zone by AXFR
+func (s *secondaryUseCase) transfer(ctx context.Context, zone *domain.Zone) ([]dns.RR, error) {
+	req := new(dns.Msg)
+	if zone.Refreshed != nil {
+		soa := zone.SOA()
+		req.SetIxfr(zone.Name, zone.Serial, soa.Ns, soa.Mbox)
+	} else {
+		req.SetAxfr(zone.Name)
+	}
+	tsigSecret, err := s.sign(ctx, zone, req)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer := &dns.Transfer{DialTimeout: primaryTimeout, ReadTimeout: transferTimeout, TsigSecret: tsigSecret}
+	envelopes, err := transfer.In(req, zone.Primary)
+	if err != nil {
+		return nil, err
+	}
+
+	var rrs []dns.RR
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			return nil, envelope.Error
+		}
+		rrs = append(rrs, envelope.RR...)
+	}
+	return rrs, nil
+}
+
+// sign adds the TSIG of the key of the zone to the request, and returns the secret which the client signs
+// it and verifies the responses by, the requests of a zone without a key are left unsigned
+func (s *secondaryUseCase) sign(ctx context.Context, zone *domain.Zone, req *dns.Msg) (map[string]string, error) {
+	if zone.TsigKey == "" {
+		return nil, nil
+	}
+	key, err := s.tsigKeyRepo.Get(ctx, zone.TsigKey)
+	if err != nil {
+		return nil, fmt.Errorf("the TSIG key %s of %s isn't found: %w", zone.TsigKey, zone.Name, err)
+	}
+
+	req.SetTsig(key.Name, key.Algorithm, 300, time.Now().Unix())
+	return map[string]string{key.Name: key.Secret}, nil
+}
+
+// transferredZone returns the SOA and the other records of the zone from a transfer, the
+// differences of an incremental transfer are applied to the records which are stored
+func (s *secondaryUseCase) transferredZone(ctx context.Context, zone *domain.Zone,
+	rrs []dns.RR) (*dns.SOA, []dns.RR, error) {
+	if len(rrs) == 0 {
+		return nil, nil, fmt.Errorf("the transfer of %s is empty", zone.Name)
+	}
+	soa, ok := rrs[0].(*dns.SOA)
+	if !ok {
+		return nil, nil, fmt.Errorf("the transfer of %s doesn't start with its SOA record", zone.Name)
+	}
+
+	if len(rrs) > 1 {
+		last, ok := rrs[len(rrs)-1].(*dns.SOA)
+		if !ok || last.Serial != soa.Serial {
+			return nil, nil, fmt.Errorf("the transfer of %s doesn't end with its SOA record", zone.Name)
+		}
+	}
+	// the whole zone is sent, which is an AXFR or the IXFR which falls back to it
+	if len(rrs) > 1 && (len(rrs) == 2 || rrs[1].Header().Rrtype != dns.TypeSOA) {
+		return soa, rrs[1 : len(rrs)-1], nil
+	}
+
+	current, err := zoneRRs(ctx, s.zoneRepo, s.recordRepo, zone)
+	if err != nil {
+		return nil, nil, err
+	}
+	// a single SOA record means the zone is up to date since the IXFR was sent (RFC 1995 4)
+	var body []dns.RR
+	if len(rrs) > 1 {
+		body = rrs[1 : len(rrs)-1]
+	}
+
+	// every difference is the old SOA, the deleted records, the new SOA and the added records
+	var (
+		keys     []string
+		records  = map[string]dns.RR{}
+		deleting bool
+	)
+	for _, rr := range current {
+		key := rrKey(rr)
+		keys = append(keys, key)
+		records[key] = rr
+	}
+	for _, rr := range body {
+		if rr.Header().Rrtype == dns.TypeSOA {
+			deleting = !deleting
+			continue
+		}
+		key := rrKey(rr)
+		if deleting {
+			delete(records, key)
+			continue
+		}
+		if _, ok := records[key]; !ok {
+			keys = append(keys, key)
+		}
+		records[key] = rr
+	}
+
+	var result []dns.RR
+	for _, key := range keys {
+		if rr, ok := records[key]; ok {
+			result = append(result, rr)
+			delete(records, key)
+		}
+	}
+	return soa, result, nil
+}
+
+// store replaces the records of the zone with the transferred ones, the records of the sub
+// zones hosted here are left to them
+func (s *secondaryUseCase) store(ctx context.Context, zone *domain.Zone, soa *dns.SOA, rrs []dns.RR,
+	now time.Time) error {
+	zones, err := s.zoneRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	stale, err := s.storedRRsets(ctx, zone, zones)
+	if err != nil {
+		return err
+	}
+
+	var (
+		nameservers []string
+		questions   []dns.Question
+		rrsets      = map[dns.Question][]dns.RR{}
+	)
+	for _, rr := range rrs {
+		header := rr.Header()
+		switch {
+		case !dns.IsSubDomain(zone.Name, header.Name) || inSubZone(zones, zone.Name, header.Name):
+			continue
+		case header.Rrtype == dns.TypeSOA:
+			continue
+		case header.Rrtype == dns.TypeNS && header.Name == zone.Name:
+			nameservers = append(nameservers, rr.(*dns.NS).Ns)
+			continue
+		}
+
+		q := dns.Question{Name: header.Name, Qtype: header.Rrtype, Qclass: header.Class}
+		if _, ok := rrsets[q]; !ok {
+			questions = append(questions, q)
+		}
+		rrsets[q] = append(rrsets[q], rr)
+	}
+
+	deleted, records := stale, []*domain.Record(nil)
+	for _, q := range questions {
+		deleted = append(deleted, domain.NewRecord(rrsets[q][0]))
+		for _, rr := range rrsets[q] {
+			records = append(records, domain.NewRecord(rr))
+		}
+	}
+	err = s.recordRepo.ReplaceRRsets(ctx, deleted, records)
+	if err != nil {
+		return err
+	}
+
+	zone.Nameservers = nameservers
+	zone.Mbox = soa.Mbox
+	zone.Serial = soa.Serial
+	zone.Refresh = soa.Refresh
+	zone.Retry = soa.Retry
+	zone.Expire = soa.Expire
+	zone.Minttl = soa.Minttl
+	zone.Ttl = soa.Hdr.Ttl
+	zone.Refreshed = &now
+	err = s.zoneRepo.Update(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range stale {
+		q := dns.Question{Name: record.Name, Qtype: record.RrType, Qclass: record.Class}
+		err = s.redisRepo.HDel(ctx, q.String())
+		if err != nil {
+			return err
+		}
+	}
+	err = cacheZone(ctx, s.redisRepo, zone)
+	if err != nil {
+		return err
+	}
+	for _, q := range questions {
+		err = cacheRRset(ctx, s.redisRepo, q, rrsets[q])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expire removes an expired zone from the cache, so that it's no longer answered
+func (s *secondaryUseCase) expire(ctx context.Context, zone *domain.Zone, now time.Time) error {
+	if zone.Refreshed == nil || !zone.Expired(now) {
+		return nil
+	}
+
+	zones, err := s.zoneRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	stale, err := s.storedRRsets(ctx, zone, zones)
+	if err != nil {
+		return err
+	}
+
+	err = uncacheZone(ctx, s.redisRepo, zone)
+	if err != nil {
+		return err
+	}
+	for _, record := range stale {
+		q := dns.Question{Name: record.Name, Qtype: record.RrType, Qclass: record.Class}
+		err = s.redisRepo.HDel(ctx, q.String())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storedRRsets returns a record of every RRset which is stored for the zone
+func (s *secondaryUseCase) storedRRsets(ctx context.Context, zone *domain.Zone,
+	zones []*domain.Zone) ([]*domain.Record, error) {
+	var (
+		stale []*domain.Record
+		seen  = map[dns.Question]bool{}
+	)
+	err := s.recordRepo.ListInBatches(
+		ctx, zone.Name, recordBatchSize, func(records []*domain.Record) error {
+			for _, record := range records {
+				q := dns.Question{Name: record.Name, Qtype: record.RrType, Qclass: record.Class}
+				if seen[q] || closestZone(zones, record.Name) != zone.Name {
+					continue
+				}
+				seen[q] = true
+				stale = append(stale, record)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return stale, nil
+}
+
+// fromPrimary reports whether the address is one of the primary of the zone
+func (s *secondaryUseCase) fromPrimary(ctx context.Context, zone *domain.Zone, addr net.Addr) bool {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return false
+	}
+	primary, _, err := net.SplitHostPort(zone.Primary)
+	if err != nil {
+		return false
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	if primaryIP := net.ParseIP(primary); primaryIP != nil {
+		return primaryIP.Equal(ip)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, primary)
+	if err != nil {
+		return false
+	}
+	for _, a := range addrs {
+		if a.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// rrKey identifies a record regardless of its TTL
+func rrKey(rr dns.RR) string {
+	copied := dns.Copy(rr)
+	copied.Header().Ttl = 0
+	return copied.String()
+}
+
+func NewSecondaryUseCase(injector *do.Injector) (domain.SecondaryUseCase, error) {
+	return &secondaryUseCase{
+		redisRepo:   do.MustInvoke[domain.RedisRepo](injector),
+		zoneRepo:    do.MustInvoke[domain.ZoneRepo](injector),
+		recordRepo:  do.MustInvoke[domain.RecordRepo](injector),
+		tsigKeyRepo: do.MustInvoke[domain.TsigKeyRepo](injector),
+		due:         map[string]time.Time{},
+		wake:        make(chan struct{}, 1),
+	}, nil
+}
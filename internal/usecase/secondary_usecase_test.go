@@ -0,0 +1,467 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+)
+
+// transferSecret is the secret of the TSIG key which the primary may require
+const transferSecret = "c2VjcmV0LW9mLXRoZS10cmFuc2Zlci1rZXk="
+
+type secondaryUseCaseTestSuite struct {
+	suite.Suite
+
+	usecase domain.SecondaryUseCase
+
+	redisRepo   *mocks.RedisRepo
+	zoneRepo    *mocks.ZoneRepo
+	recordRepo  *mocks.RecordRepo
+	tsigKeyRepo *mocks.TsigKeyRepo
+
+	// the primary server answers the SOA and the transfers of test.com.
+	primary     string
+	servers     []*dns.Server
+	primarySOA  *dns.SOA
+	primaryAXFR []dns.RR
+	primaryIXFR []dns.RR
+	// primaryKey is the TSIG key which the primary requires when it's set, signed counts the requests
+	// which are signed by it
+	primaryKey *domain.TsigKey
+	signed     int
+
+	zone *domain.Zone
+}
+
+func TestSecondaryUseCase(t *testing.T) {
+	suite.Run(t, &secondaryUseCaseTestSuite{})
+}
+
+func (t *secondaryUseCaseTestSuite) SetupSuite() {
+	injector := do.New()
+	t.redisRepo = &mocks.RedisRepo{}
+	t.zoneRepo = &mocks.ZoneRepo{}
+	t.recordRepo = &mocks.RecordRepo{}
+	t.tsigKeyRepo = &mocks.TsigKeyRepo{}
+	do.ProvideValue[domain.RedisRepo](injector, t.redisRepo)
+	do.ProvideValue[domain.ZoneRepo](injector, t.zoneRepo)
+	do.ProvideValue[domain.RecordRepo](injector, t.recordRepo)
+	do.ProvideValue[domain.TsigKeyRepo](injector, t.tsigKeyRepo)
+
+	t.usecase, _ = NewSecondaryUseCase(injector)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	t.Require().Nil(err)
+	t.primary = listener.Addr().String()
+	conn, err := net.ListenPacket("udp", t.primary)
+	t.Require().Nil(err)
+
+	tsigSecret := map[string]string{"transfer.": transferSecret}
+	t.servers = []*dns.Server{
+		{Listener: listener, Handler: dns.HandlerFunc(t.servePrimary), TsigSecret: tsigSecret},
+		{PacketConn: conn, Handler: dns.HandlerFunc(t.servePrimary), TsigSecret: tsigSecret},
+	}
+	for _, server := range t.servers {
+		started := make(chan struct{})
+		server.NotifyStartedFunc = func() { close(started) }
+		go func() {
+			_ = server.ActivateAndServe()
+		}()
+		<-started
+	}
+}
+
+func (t *secondaryUseCaseTestSuite) TearDownSuite() {
+	for _, server := range t.servers {
+		_ = server.Shutdown()
+	}
+}
+
+func (t *secondaryUseCaseTestSuite) servePrimary(respWriter dns.ResponseWriter, req *dns.Msg) {
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	resp.Authoritative = true
+
+	tsig := req.IsTsig()
+	if t.primaryKey != nil {
+		if tsig == nil || tsig.Hdr.Name != t.primaryKey.Name || respWriter.TsigStatus() != nil {
+			resp.SetRcode(req, dns.RcodeNotAuth)
+			_ = respWriter.WriteMsg(resp)
+			return
+		}
+		t.signed++
+	}
+
+	switch req.Question[0].Qtype {
+	case dns.TypeAXFR, dns.TypeIXFR:
+		rrs := t.primaryAXFR
+		if req.Question[0].Qtype == dns.TypeIXFR && t.primaryIXFR != nil {
+			rrs = t.primaryIXFR
+		}
+		envelopes := make(chan *dns.Envelope, 1)
+		envelopes <- &dns.Envelope{RR: rrs}
+		close(envelopes)
+		_ = new(dns.Transfer).Out(respWriter, req, envelopes)
+		return
+	case dns.TypeSOA:
+		resp.Answer = []dns.RR{t.primarySOA}
+	}
+	if tsig != nil {
+		resp.SetTsig(tsig.Hdr.Name, tsig.Algorithm, tsig.Fudge, time.Now().Unix())
+	}
+	_ = respWriter.WriteMsg(resp)
+}
+
+func (t *secondaryUseCaseTestSuite) SetupTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+		anyTime    = mock.AnythingOfType("time.Duration")
+		anyRecords = mock.AnythingOfType("[]*domain.Record")
+	)
+
+	t.usecase.(*secondaryUseCase).due = map[string]time.Time{}
+	t.zone = &domain.Zone{
+		Name:    "test.com.",
+		Type:    domain.ZoneSecondary,
+		Primary: t.primary,
+		Refresh: 7200,
+		Retry:   3600,
+		Expire:  1209600,
+	}
+
+	t.primarySOA = parseRRs(
+		"test.com.\t3600\tIN\tSOA\tns1.test.com. admin.test.com. 2024010102 1800 900 86400 300",
+	)[0].(*dns.SOA)
+	t.primaryAXFR = parseRRs(
+		t.primarySOA.String(),
+		"test.com.\t3600\tIN\tNS\tns1.test.com.",
+		"www.test.com.\t3600\tIN\tA\t1.1.1.1",
+		"www.test.com.\t3600\tIN\tA\t2.2.2.2",
+		"mail.test.com.\t3600\tIN\tA\t3.3.3.3",
+		"other.com.\t3600\tIN\tA\t9.9.9.9",
+		t.primarySOA.String(),
+	)
+	t.primaryIXFR = nil
+	t.primaryKey = nil
+	t.signed = 0
+
+	t.tsigKeyRepo.ExpectedCalls = nil
+	t.tsigKeyRepo.
+		On("Get", anyContext, "transfer.").
+		Return(
+			&domain.TsigKey{
+				Name: "transfer.", Algorithm: dns.HmacSHA256, Secret: transferSecret,
+			}, nil,
+		)
+	t.tsigKeyRepo.
+		On("Get", anyContext, anyString).
+		Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+
+	t.redisRepo.ExpectedCalls = nil
+	t.zoneRepo.ExpectedCalls = nil
+	t.recordRepo.ExpectedCalls = nil
+	t.redisRepo.Calls = nil
+	t.zoneRepo.Calls = nil
+	t.recordRepo.Calls = nil
+
+	t.zoneRepo.
+		On("Get", anyContext, "test.com.").
+		Return(t.zone, nil)
+	t.zoneRepo.
+		On("Get", anyContext, anyString).
+		Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+	t.zoneRepo.
+		On("List", anyContext).
+		Return([]*domain.Zone{t.zone}, nil)
+	t.zoneRepo.
+		On("Update", anyContext, mock.AnythingOfType("*domain.Zone")).
+		Return(nil)
+	t.recordRepo.
+		On("ListInBatches", anyContext, "test.com.", recordBatchSize, mock.Anything).
+		Return(
+			func(_ context.Context, _ string, _ int, fn func(records []*domain.Record) error) error {
+				return fn(
+					[]*domain.Record{
+						domain.NewRecord(parseRRs("www.test.com.\t3600\tIN\tA\t1.1.1.1")[0]),
+						domain.NewRecord(parseRRs("old.test.com.\t3600\tIN\tTXT\t\"old\"")[0]),
+					},
+				)
+			},
+		)
+	t.recordRepo.
+		On("ReplaceRRsets", anyContext, anyRecords, anyRecords).
+		Return(nil)
+	t.redisRepo.
+		On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+		Return(nil)
+	t.redisRepo.
+		On("HDel", anyContext, anyString).
+		Return(nil)
+}
+
+func parseRRs(records ...string) []dns.RR {
+	var rrs []dns.RR
+	for _, record := range records {
+		rr, _ := dns.NewRR(record)
+		rrs = append(rrs, rr)
+	}
+	return rrs
+}
+
+// records returns the records of the RRsets which are replaced, in presentation format
+func (t *secondaryUseCaseTestSuite) records() ([]string, []string) {
+	var deleted, added []string
+	for _, call := range t.recordRepo.Calls {
+		if call.Method != "ReplaceRRsets" {
+			continue
+		}
+		for _, record := range call.Arguments[1].([]*domain.Record) {
+			deleted = append(deleted, fmt.Sprintf("%s %d", record.Name, record.RrType))
+		}
+		for _, record := range call.Arguments[2].([]*domain.Record) {
+			added = append(added, record.Record)
+		}
+	}
+	return deleted, added
+}
+
+func (t *secondaryUseCaseTestSuite) TestRefresh() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyTime    = mock.AnythingOfType("time.Duration")
+	)
+
+	t.Run(
+		"axfr_success", func() {
+			t.SetupTest()
+			err := t.usecase.Refresh(context.Background(), "test.com")
+			t.Nil(err)
+
+			deleted, added := t.records()
+			t.Equal([]string{"www.test.com. 1", "old.test.com. 16", "www.test.com. 1", "mail.test.com. 1"}, deleted)
+			t.Equal(
+				[]string{
+					"www.test.com.\t3600\tIN\tA\t1.1.1.1",
+					"www.test.com.\t3600\tIN\tA\t2.2.2.2",
+					"mail.test.com.\t3600\tIN\tA\t3.3.3.3",
+				}, added,
+			)
+
+			t.Equal(uint32(2024010102), t.zone.Serial)
+			t.Equal([]string{"ns1.test.com."}, t.zone.Nameservers)
+			t.Equal("admin.test.com.", t.zone.Mbox)
+			t.Equal(uint32(1800), t.zone.Refresh)
+			t.NotNil(t.zone.Refreshed)
+			t.zoneRepo.AssertCalled(t.T(), "Update", anyContext, t.zone)
+			t.redisRepo.AssertCalled(t.T(), "HDel", anyContext, ";old.test.com.\tIN\t TXT")
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";test.com.\tIN\t SOA", "Answer-0", t.zone.SOA().String(), anyTime,
+			)
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";mail.test.com.\tIN\t A", "Answer-0",
+				"mail.test.com.\t3600\tIN\tA\t3.3.3.3", anyTime,
+			)
+		},
+	)
+
+	t.Run(
+		"ixfr_success", func() {
+			t.SetupTest()
+			refreshed := time.Now().Add(-time.Hour)
+			t.zone.Refreshed = &refreshed
+			t.zone.Serial = 2024010101
+			t.zone.Nameservers = []string{"ns1.test.com."}
+			t.zone.Mbox = "admin.test.com."
+			t.primaryIXFR = append(
+				[]dns.RR{t.primarySOA, soaWithSerial(t.primarySOA, 2024010101)},
+				parseRRs(
+					"old.test.com.\t3600\tIN\tTXT\t\"old\"",
+					t.primarySOA.String(),
+					"mail.test.com.\t3600\tIN\tA\t3.3.3.3",
+					t.primarySOA.String(),
+				)...,
+			)
+
+			err := t.usecase.Refresh(context.Background(), "test.com.")
+			t.Nil(err)
+
+			_, added := t.records()
+			t.Equal(
+				[]string{
+					"www.test.com.\t3600\tIN\tA\t1.1.1.1",
+					"mail.test.com.\t3600\tIN\tA\t3.3.3.3",
+				}, added,
+			)
+			t.Equal(uint32(2024010102), t.zone.Serial)
+		},
+	)
+
+	t.Run(
+		"up_to_date", func() {
+			t.SetupTest()
+			refreshed := time.Now().Add(-time.Hour)
+			t.zone.Refreshed = &refreshed
+			t.zone.Serial = 2024010102
+
+			err := t.usecase.Refresh(context.Background(), "test.com.")
+			t.Nil(err)
+			t.True(t.zone.Refreshed.After(refreshed))
+			t.zoneRepo.AssertCalled(t.T(), "Update", anyContext, t.zone)
+			t.recordRepo.AssertNotCalled(
+				t.T(), "ReplaceRRsets", anyContext, mock.Anything, mock.Anything,
+			)
+		},
+	)
+
+	t.Run(
+		"not_secondary_error", func() {
+			t.SetupTest()
+			t.zone.Type = domain.ZonePrimary
+			err := t.usecase.Refresh(context.Background(), "test.com.")
+			t.NotNil(err)
+			t.Contains(err.Error(), "isn't a secondary zone")
+		},
+	)
+
+	t.Run(
+		"primary_error", func() {
+			t.SetupTest()
+			t.zone.Primary = "127.0.0.1:1"
+			err := t.usecase.Refresh(context.Background(), "test.com.")
+			t.NotNil(err)
+			t.recordRepo.AssertNotCalled(
+				t.T(), "ReplaceRRsets", anyContext, mock.Anything, mock.Anything,
+			)
+		},
+	)
+
+	t.Run(
+		"tsig_success", func() {
+			t.SetupTest()
+			t.zone.TsigKey = "transfer."
+			t.primaryKey = &domain.TsigKey{Name: "transfer."}
+			err := t.usecase.Refresh(context.Background(), "test.com.")
+			t.Nil(err)
+			// both the SOA query and the AXFR are signed
+			t.Equal(2, t.signed)
+			t.Equal(uint32(2024010102), t.zone.Serial)
+		},
+	)
+
+	t.Run(
+		"tsig_unsigned_error", func() {
+			t.SetupTest()
+			t.primaryKey = &domain.TsigKey{Name: "transfer."}
+			err := t.usecase.Refresh(context.Background(), "test.com.")
+			t.NotNil(err)
+			t.Contains(err.Error(), "NOTAUTH")
+		},
+	)
+
+	t.Run(
+		"tsig_key_error", func() {
+			t.SetupTest()
+			t.zone.TsigKey = "deleted."
+			err := t.usecase.Refresh(context.Background(), "test.com.")
+			t.NotNil(err)
+			t.Contains(err.Error(), "the TSIG key deleted. of test.com. isn't found")
+			t.recordRepo.AssertNotCalled(
+				t.T(), "ReplaceRRsets", anyContext, mock.Anything, mock.Anything,
+			)
+		},
+	)
+
+	t.Run(
+		"ReplaceRRsets_error", func() {
+			t.SetupTest()
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("ListInBatches", anyContext, "test.com.", recordBatchSize, mock.Anything).
+				Return(nil)
+			t.recordRepo.
+				On("ReplaceRRsets", anyContext, mock.Anything, mock.Anything).
+				Return(fmt.Errorf("test-error"))
+			err := t.usecase.Refresh(context.Background(), "test.com.")
+			t.NotNil(err)
+			t.Equal("test-error", err.Error())
+		},
+	)
+}
+
+func (t *secondaryUseCaseTestSuite) TestRun() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"refresh_due_zones", func() {
+			t.SetupTest()
+			ctx, cancel := context.WithTimeout(context.Background(), secondaryTick+secondaryTick/2)
+			defer cancel()
+
+			err := t.usecase.Run(ctx)
+			t.Nil(err)
+			t.Equal(uint32(2024010102), t.zone.Serial)
+			// the zone isn't due again until its refresh timer
+			t.recordRepo.AssertNumberOfCalls(t.T(), "ReplaceRRsets", 1)
+		},
+	)
+
+	t.Run(
+		"expire", func() {
+			t.SetupTest()
+			refreshed := time.Now().Add(-30 * 24 * time.Hour)
+			t.zone.Refreshed = &refreshed
+			t.zone.Serial = 2024010101
+			t.zone.Primary = "127.0.0.1:1"
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			err := t.usecase.Run(ctx)
+			t.Nil(err)
+			t.redisRepo.AssertCalled(t.T(), "HDel", anyContext, ";test.com.\tIN\t SOA")
+			t.redisRepo.AssertCalled(t.T(), "HDel", anyContext, ";www.test.com.\tIN\t A")
+			t.redisRepo.AssertNotCalled(t.T(), "HDel", anyContext, ";other.com.\tIN\t A")
+		},
+	)
+}
+
+func (t *secondaryUseCaseTestSuite) TestNotify() {
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			addr, _ := net.ResolveUDPAddr("udp", t.primary)
+			err := t.usecase.Notify(context.Background(), "test.com.", addr)
+			t.Nil(err)
+		},
+	)
+
+	t.Run(
+		"not_primary_error", func() {
+			t.SetupTest()
+			err := t.usecase.Notify(context.Background(), "test.com.", &net.UDPAddr{IP: net.ParseIP("192.0.2.1")})
+			t.NotNil(err)
+			t.True(errors.Is(err, domain.ErrRefused))
+		},
+	)
+
+	t.Run(
+		"not_auth_error", func() {
+			t.SetupTest()
+			addr, _ := net.ResolveUDPAddr("udp", t.primary)
+			err := t.usecase.Notify(context.Background(), "other.com.", addr)
+			t.NotNil(err)
+			t.True(errors.Is(err, domain.ErrNotAuth))
+		},
+	)
+}
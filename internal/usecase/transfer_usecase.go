@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/utils"
+)
+
+type transferUseCase struct {
+	recordRepo domain.RecordRepo
+
+	zoneRepo domain.ZoneRepo
+
+	journalRepo domain.JournalRepo
+}
+
+func (t *transferUseCase) AXFR(ctx context.Context, name string) ([]dns.RR, error) {
+	zone, err := t.getZone(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	rrs, err := zoneRRs(ctx, t.zoneRepo, t.recordRepo, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	soa := zone.SOA()
+	return append(append([]dns.RR{soa}, rrs...), soa), nil
+}
+
+func (t *transferUseCase) IXFR(ctx context.Context, name string, serial uint32) ([]dns.RR, error) {
+	zone, err := t.getZone(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	soa := zone.SOA()
+	// the client is up to date
+	if !serialLess(serial, zone.Serial) {
+		return []dns.RR{soa}, nil
+	}
+
+	journals, err := t.journalRepo.List(ctx, zone.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	rrs := []dns.RR{soa}
+	from := serial
+	for _, journal := range journals {
+		if journal.From != from {
+			// the journals before the serial of the client are skipped, a gap ends the chain
+			if from == serial {
+				continue
+			}
+			break
+		}
+
+		rrs = append(rrs, soaWithSerial(soa, journal.From))
+		for _, s := range journal.Deleted {
+			rr, err := dns.NewRR(s)
+			if err != nil {
+				return nil, err
+			}
+			rrs = append(rrs, rr)
+		}
+		rrs = append(rrs, soaWithSerial(soa, journal.To))
+		for _, s := range journal.Added {
+			rr, err := dns.NewRR(s)
+			if err != nil {
+				return nil, err
+			}
+			rrs = append(rrs, rr)
+		}
+		from = journal.To
+	}
+
+	// the journal doesn't reach the current serial, the whole zone is sent instead (RFC 1995 4)
+	if from != zone.Serial {
+		return t.AXFR(ctx, name)
+	}
+	return append(rrs, soa), nil
+}
+
+func (t *transferUseCase) getZone(ctx context.Context, name string) (*domain.Zone, error) {
+	name = utils.GetFQDNFromDomainName(name)
+	zone, err := t.zoneRepo.Get(ctx, name)
+	if err != nil {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("%s isn't a zone hosted here", name),
+			StatusCode: http.StatusNotFound,
+			Err:        domain.ErrNotAuth,
+		}
+	}
+	return zone, nil
+}
+
+// zoneRRs returns the records of the zone except its SOA in the canonical order, the NS records
+// of the sub zones hosted here are included as the delegations
+func zoneRRs(ctx context.Context, zoneRepo domain.ZoneRepo, recordRepo domain.RecordRepo,
+	zone *domain.Zone) ([]dns.RR, error) {
+	zones, err := zoneRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rrs := zone.NS()
+	for _, sub := range zones {
+		if sub.Name != zone.Name && closestZone(zones, parentName(sub.Name)) == zone.Name {
+			rrs = append(rrs, sub.NS()...)
+		}
+	}
+	err = recordRepo.ListInBatches(
+		ctx, zone.Name, recordBatchSize, func(records []*domain.Record) error {
+			for _, record := range records {
+				if closestZone(zones, record.Name) != zone.Name {
+					continue
+				}
+				rr, err := dns.NewRR(record.Record)
+				if err != nil {
+					return err
+				}
+				rrs = append(rrs, rr)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sortCanonical(rrs)
+	return rrs, nil
+}
+
+func parentName(name string) string {
+	labels := dns.Split(name)
+	if len(labels) < 2 {
+		return "."
+	}
+	return name[labels[1]:]
+}
+
+func soaWithSerial(soa *dns.SOA, serial uint32) *dns.SOA {
+	copied := dns.Copy(soa).(*dns.SOA)
+	copied.Serial = serial
+	return copied
+}
+
+// serialLess compares the serials with the sequence space arithmetic (RFC 1982)
+func serialLess(a uint32, b uint32) bool {
+	return int32(a-b) < 0
+}
+
+func NewTransferUseCase(injector *do.Injector) (domain.TransferUseCase, error) {
+	return &transferUseCase{
+		do.MustInvoke[domain.RecordRepo](injector),
+		do.MustInvoke[domain.ZoneRepo](injector),
+		do.MustInvoke[domain.JournalRepo](injector),
+	}, nil
+}
@@ -0,0 +1,266 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+)
+
+type transferUseCaseTestSuite struct {
+	suite.Suite
+
+	usecase domain.TransferUseCase
+
+	recordRepo  *mocks.RecordRepo
+	zoneRepo    *mocks.ZoneRepo
+	journalRepo *mocks.JournalRepo
+
+	zone    *domain.Zone
+	subZone *domain.Zone
+}
+
+func TestTransferUseCase(t *testing.T) {
+	suite.Run(t, &transferUseCaseTestSuite{})
+}
+
+func (t *transferUseCaseTestSuite) SetupSuite() {
+	injector := do.New()
+	t.recordRepo = &mocks.RecordRepo{}
+	t.zoneRepo = &mocks.ZoneRepo{}
+	t.journalRepo = &mocks.JournalRepo{}
+	do.ProvideValue[domain.RecordRepo](injector, t.recordRepo)
+	do.ProvideValue[domain.ZoneRepo](injector, t.zoneRepo)
+	do.ProvideValue[domain.JournalRepo](injector, t.journalRepo)
+
+	t.usecase, _ = NewTransferUseCase(injector)
+}
+
+func (t *transferUseCaseTestSuite) SetupTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+	)
+
+	t.zone = &domain.Zone{
+		Name:        "test.com.",
+		Nameservers: []string{"ns1.test.com."},
+		Mbox:        "admin.test.com.",
+		Serial:      2024010103,
+		Ttl:         3600,
+	}
+	t.subZone = &domain.Zone{
+		Name:        "sub.test.com.",
+		Nameservers: []string{"ns2.test.com."},
+		Mbox:        "admin.test.com.",
+		Serial:      2024010100,
+		Ttl:         300,
+	}
+
+	t.recordRepo.ExpectedCalls = nil
+	t.zoneRepo.ExpectedCalls = nil
+	t.journalRepo.ExpectedCalls = nil
+	t.journalRepo.Calls = nil
+
+	t.zoneRepo.
+		On("List", anyContext).
+		Return([]*domain.Zone{t.subZone, t.zone}, nil)
+	t.zoneRepo.
+		On("Get", anyContext, "test.com.").
+		Return(t.zone, nil)
+	t.zoneRepo.
+		On("Get", anyContext, anyString).
+		Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+
+	var records []*domain.Record
+	for _, s := range []string{
+		"www.test.com.\t3600\tIN\tA\t2.2.2.2",
+		"other.com.\t60\tIN\tA\t9.9.9.9",
+		"a.test.com.\t3600\tIN\tTXT\t\"a\"",
+		"host.sub.test.com.\t300\tIN\tA\t3.3.3.3",
+	} {
+		rr, _ := dns.NewRR(s)
+		records = append(records, domain.NewRecord(rr))
+	}
+	t.recordRepo.
+		On("ListInBatches", anyContext, anyString, recordBatchSize, mock.Anything).
+		Return(
+			func(_ context.Context, zone string, _ int, fn func(records []*domain.Record) error) error {
+				// the DB reads the records under the zone, the ones of its sub-zones included
+				var batch []*domain.Record
+				for _, record := range records {
+					if dns.IsSubDomain(zone, record.Name) {
+						batch = append(batch, record)
+					}
+				}
+				return fn(batch)
+			},
+		)
+
+	t.journalRepo.
+		On("List", anyContext, "test.com.").
+		Return(
+			[]*domain.Journal{
+				{
+					Zone:  "test.com.",
+					From:  2024010100,
+					To:    2024010101,
+					Added: []string{"www.test.com.\t3600\tIN\tA\t1.1.1.1"},
+				},
+				{
+					Zone:    "test.com.",
+					From:    2024010101,
+					To:      2024010102,
+					Deleted: []string{"www.test.com.\t3600\tIN\tA\t1.1.1.1"},
+					Added:   []string{"www.test.com.\t3600\tIN\tA\t2.2.2.2"},
+				},
+				{
+					Zone:  "test.com.",
+					From:  2024010102,
+					To:    2024010103,
+					Added: []string{"a.test.com.\t3600\tIN\tTXT\t\"a\""},
+				},
+			}, nil,
+		)
+}
+
+func toStrings(rrs []dns.RR) []string {
+	var output []string
+	for _, rr := range rrs {
+		output = append(output, rr.String())
+	}
+	return output
+}
+
+func (t *transferUseCaseTestSuite) TestAXFR() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			rrs, err := t.usecase.AXFR(context.Background(), "test.com")
+			t.Nil(err)
+			t.Equal(
+				[]string{
+					t.zone.SOA().String(),
+					"test.com.\t3600\tIN\tNS\tns1.test.com.",
+					"a.test.com.\t3600\tIN\tTXT\t\"a\"",
+					"sub.test.com.\t300\tIN\tNS\tns2.test.com.",
+					"www.test.com.\t3600\tIN\tA\t2.2.2.2",
+					t.zone.SOA().String(),
+				}, toStrings(rrs),
+			)
+			// only the records under the zone are read
+			t.recordRepo.AssertCalled(t.T(), "ListInBatches", anyContext, "test.com.", recordBatchSize, mock.Anything)
+		},
+	)
+
+	t.Run(
+		"not_auth_error", func() {
+			t.SetupTest()
+			_, err := t.usecase.AXFR(context.Background(), "other.com.")
+			t.NotNil(err)
+			t.True(errors.Is(err, domain.ErrNotAuth))
+		},
+	)
+
+	t.Run(
+		"ListInBatches_error", func() {
+			t.SetupTest()
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("ListInBatches", anyContext, "test.com.", recordBatchSize, mock.Anything).
+				Return(fmt.Errorf("test-error"))
+			_, err := t.usecase.AXFR(context.Background(), "test.com.")
+			t.NotNil(err)
+			t.Equal("test-error", err.Error())
+		},
+	)
+}
+
+func (t *transferUseCaseTestSuite) TestIXFR() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	soa := func(serial uint32) string {
+		return soaWithSerial(t.zone.SOA(), serial).String()
+	}
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			rrs, err := t.usecase.IXFR(context.Background(), "test.com.", 2024010101)
+			t.Nil(err)
+			t.Equal(
+				[]string{
+					soa(2024010103),
+					soa(2024010101),
+					"www.test.com.\t3600\tIN\tA\t1.1.1.1",
+					soa(2024010102),
+					"www.test.com.\t3600\tIN\tA\t2.2.2.2",
+					soa(2024010102),
+					soa(2024010103),
+					"a.test.com.\t3600\tIN\tTXT\t\"a\"",
+					soa(2024010103),
+				}, toStrings(rrs),
+			)
+		},
+	)
+
+	t.Run(
+		"up_to_date", func() {
+			t.SetupTest()
+			rrs, err := t.usecase.IXFR(context.Background(), "test.com.", 2024010103)
+			t.Nil(err)
+			t.Equal([]string{soa(2024010103)}, toStrings(rrs))
+			t.journalRepo.AssertNotCalled(t.T(), "List", anyContext, "test.com.")
+		},
+	)
+
+	t.Run(
+		"fallback_to_axfr", func() {
+			t.SetupTest()
+			rrs, err := t.usecase.IXFR(context.Background(), "test.com.", 2023123100)
+			t.Nil(err)
+			t.Len(rrs, 6)
+			t.Equal(soa(2024010103), rrs[0].String())
+			t.Equal("test.com.\t3600\tIN\tNS\tns1.test.com.", rrs[1].String())
+		},
+	)
+
+	t.Run(
+		"not_auth_error", func() {
+			t.SetupTest()
+			_, err := t.usecase.IXFR(context.Background(), "other.com.", 1)
+			t.NotNil(err)
+			t.True(errors.Is(err, domain.ErrNotAuth))
+		},
+	)
+
+	t.Run(
+		"List_error", func() {
+			t.SetupTest()
+			t.journalRepo.ExpectedCalls = nil
+			t.journalRepo.
+				On("List", anyContext, "test.com.").
+				Return(nil, fmt.Errorf("test-error"))
+			_, err := t.usecase.IXFR(context.Background(), "test.com.", 2024010101)
+			t.NotNil(err)
+			t.Equal("test-error", err.Error())
+		},
+	)
+}
+
+func (t *transferUseCaseTestSuite) TestSerialLess() {
+	t.True(serialLess(1, 2))
+	t.False(serialLess(2, 2))
+	t.False(serialLess(3, 2))
+	t.True(serialLess(4294967295, 1))
+}
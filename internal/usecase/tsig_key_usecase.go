@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"hash"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+)
+
+// tsigHashes are the HMAC algorithms of TSIG (RFC 8945 6), the generated secrets are as long as
+// their digests
+var tsigHashes = map[string]func() hash.Hash{
+	dns.HmacSHA1:   sha1.New,
+	dns.HmacSHA224: sha256.New224,
+	dns.HmacSHA256: sha256.New,
+	dns.HmacSHA384: sha512.New384,
+	dns.HmacSHA512: sha512.New,
+}
+
+type tsigKeyUseCase struct {
+	tsigKeyRepo domain.TsigKeyRepo
+}
+
+func (t *tsigKeyUseCase) CreateKey(ctx context.Context, key *domain.TsigKey) error {
+	key.Name = dns.CanonicalName(key.Name)
+	if key.Algorithm == "" {
+		key.Algorithm = dns.HmacSHA256
+	}
+	err := t.setDefaults(key)
+	if err != nil {
+		return err
+	}
+
+	existed, _ := t.tsigKeyRepo.Get(ctx, key.Name)
+	if existed != nil {
+		return &domain.Error{
+			Message:    "the TSIG key is already existed.",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	return t.tsigKeyRepo.Create(ctx, key)
+}
+
+func (t *tsigKeyUseCase) GetKey(ctx context.Context, name string) (*domain.TsigKey, error) {
+	return t.tsigKeyRepo.Get(ctx, dns.CanonicalName(name))
+}
+
+func (t *tsigKeyUseCase) ListKeys(ctx context.Context) ([]*domain.TsigKey, error) {
+	return t.tsigKeyRepo.List(ctx)
+}
+
+func (t *tsigKeyUseCase) UpdateKey(ctx context.Context, key *domain.TsigKey) error {
+	key.Name = dns.CanonicalName(key.Name)
+	existed, err := t.tsigKeyRepo.Get(ctx, key.Name)
+	if err != nil {
+		return err
+	}
+	if key.Algorithm == "" {
+		key.Algorithm = existed.Algorithm
+	}
+	err = t.setDefaults(key)
+	if err != nil {
+		return err
+	}
+
+	return t.tsigKeyRepo.Update(ctx, key)
+}
+
+func (t *tsigKeyUseCase) DeleteKey(ctx context.Context, name string) error {
+	return t.tsigKeyRepo.Delete(ctx, dns.CanonicalName(name))
+}
+
+// setDefaults checks the algorithm and the secret of the key and canonicalizes its names, a secret
+// as long as the digest is generated when none is given
+func (t *tsigKeyUseCase) setDefaults(key *domain.TsigKey) error {
+	key.Algorithm = dns.CanonicalName(key.Algorithm)
+	newHash, ok := tsigHashes[key.Algorithm]
+	if !ok {
+		return &domain.Error{
+			Message:    fmt.Sprintf("the algorithm %s isn't supported", key.Algorithm),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	if key.Secret == "" {
+		secret := make([]byte, newHash().Size())
+		_, err := rand.Read(secret)
+		if err != nil {
+			return err
+		}
+		key.Secret = base64.StdEncoding.EncodeToString(secret)
+	}
+	_, err := base64.StdEncoding.DecodeString(key.Secret)
+	if err != nil {
+		return &domain.Error{
+			Message:    "the secret should be encoded in base64",
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+	for i := range key.Zones {
+		key.Zones[i] = dns.CanonicalName(key.Zones[i])
+	}
+	return nil
+}
+
+// Generate returns the MAC of the message by the key of the TSIG record
+func (t *tsigKeyUseCase) Generate(msg []byte, tsig *dns.TSIG) ([]byte, error) {
+	key, err := t.tsigKeyRepo.Get(context.Background(), dns.CanonicalName(tsig.Hdr.Name))
+	if err != nil {
+		return nil, dns.ErrSecret
+	}
+	// a key is only used with its own algorithm (RFC 8945 5.2.2)
+	newHash, ok := tsigHashes[dns.CanonicalName(tsig.Algorithm)]
+	if !ok || dns.CanonicalName(tsig.Algorithm) != key.Algorithm {
+		return nil, dns.ErrKeyAlg
+	}
+	secret, err := base64.StdEncoding.DecodeString(key.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(newHash, secret)
+	h.Write(msg)
+	return h.Sum(nil), nil
+}
+
+func (t *tsigKeyUseCase) Verify(msg []byte, tsig *dns.TSIG) error {
+	expected, err := t.Generate(msg, tsig)
+	if err != nil {
+		return err
+	}
+	mac, err := hex.DecodeString(tsig.MAC)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(expected, mac) {
+		return dns.ErrSig
+	}
+	return nil
+}
+
+func NewTsigKeyUseCase(injector *do.Injector) (domain.TsigKeyUseCase, error) {
+	return &tsigKeyUseCase{do.MustInvoke[domain.TsigKeyRepo](injector)}, nil
+}
@@ -0,0 +1,231 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+)
+
+type tsigKeyUseCaseTestSuite struct {
+	suite.Suite
+
+	usecase domain.TsigKeyUseCase
+
+	tsigKeyRepo *mocks.TsigKeyRepo
+}
+
+func TestTsigKeyUseCase(t *testing.T) {
+	suite.Run(t, &tsigKeyUseCaseTestSuite{})
+}
+
+func (t *tsigKeyUseCaseTestSuite) SetupSuite() {
+	injector := do.New()
+	t.tsigKeyRepo = &mocks.TsigKeyRepo{}
+	do.ProvideValue[domain.TsigKeyRepo](injector, t.tsigKeyRepo)
+
+	t.usecase, _ = NewTsigKeyUseCase(injector)
+}
+
+func (t *tsigKeyUseCaseTestSuite) SetupTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+	)
+
+	t.tsigKeyRepo.ExpectedCalls = nil
+	t.tsigKeyRepo.Calls = nil
+	t.tsigKeyRepo.
+		On("Get", anyContext, "update.").
+		Return(&domain.TsigKey{Name: "update.", Algorithm: dns.HmacSHA256, Secret: "c2VjcmV0"}, nil)
+	t.tsigKeyRepo.
+		On("Get", anyContext, anyString).
+		Return(nil, &domain.Error{Message: "TSIG key not found", StatusCode: http.StatusNotFound})
+	t.tsigKeyRepo.
+		On("Create", anyContext, mock.AnythingOfType("*domain.TsigKey")).
+		Return(nil)
+	t.tsigKeyRepo.
+		On("List", anyContext).
+		Return([]*domain.TsigKey{{Name: "update.", Algorithm: dns.HmacSHA256, Secret: "c2VjcmV0"}}, nil)
+	t.tsigKeyRepo.
+		On("Update", anyContext, mock.AnythingOfType("*domain.TsigKey")).
+		Return(nil)
+	t.tsigKeyRepo.
+		On("Delete", anyContext, anyString).
+		Return(nil)
+}
+
+func (t *tsigKeyUseCaseTestSuite) TestCreateKey() {
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			key := &domain.TsigKey{Name: "DHCP", Zones: []string{"Test.com"}}
+			err := t.usecase.CreateKey(context.Background(), key)
+			t.Nil(err)
+			t.Equal("dhcp.", key.Name)
+			t.Equal(dns.HmacSHA256, key.Algorithm)
+			t.Equal([]string{"test.com."}, key.Zones)
+			secret, err := base64.StdEncoding.DecodeString(key.Secret)
+			t.Nil(err)
+			t.Len(secret, 32)
+			t.tsigKeyRepo.AssertCalled(t.T(), "Create", mock.Anything, key)
+		},
+	)
+
+	t.Run(
+		"given_secret", func() {
+			t.SetupTest()
+			key := &domain.TsigKey{Name: "dhcp.", Algorithm: "HMAC-SHA512.", Secret: "c2VjcmV0"}
+			err := t.usecase.CreateKey(context.Background(), key)
+			t.Nil(err)
+			t.Equal(dns.HmacSHA512, key.Algorithm)
+			t.Equal("c2VjcmV0", key.Secret)
+		},
+	)
+
+	t.Run(
+		"algorithm_error", func() {
+			t.SetupTest()
+			err := t.usecase.CreateKey(
+				context.Background(), &domain.TsigKey{Name: "dhcp.", Algorithm: dns.HmacMD5},
+			)
+			t.Equal(http.StatusBadRequest, err.(*domain.Error).StatusCode)
+			t.tsigKeyRepo.AssertNotCalled(t.T(), "Create", mock.Anything, mock.Anything)
+		},
+	)
+
+	t.Run(
+		"secret_error", func() {
+			t.SetupTest()
+			err := t.usecase.CreateKey(context.Background(), &domain.TsigKey{Name: "dhcp.", Secret: "!secret"})
+			t.Equal(http.StatusBadRequest, err.(*domain.Error).StatusCode)
+			t.tsigKeyRepo.AssertNotCalled(t.T(), "Create", mock.Anything, mock.Anything)
+		},
+	)
+
+	t.Run(
+		"duplicated_error", func() {
+			t.SetupTest()
+			err := t.usecase.CreateKey(context.Background(), &domain.TsigKey{Name: "Update"})
+			t.Equal(http.StatusBadRequest, err.(*domain.Error).StatusCode)
+			t.tsigKeyRepo.AssertNotCalled(t.T(), "Create", mock.Anything, mock.Anything)
+		},
+	)
+}
+
+func (t *tsigKeyUseCaseTestSuite) TestGetKey() {
+	t.SetupTest()
+	key, err := t.usecase.GetKey(context.Background(), "UPDATE")
+	t.Nil(err)
+	t.Equal("update.", key.Name)
+}
+
+func (t *tsigKeyUseCaseTestSuite) TestListKeys() {
+	t.SetupTest()
+	keys, err := t.usecase.ListKeys(context.Background())
+	t.Nil(err)
+	t.Len(keys, 1)
+}
+
+func (t *tsigKeyUseCaseTestSuite) TestUpdateKey() {
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			key := &domain.TsigKey{Name: "Update", Zones: []string{"Test.com"}}
+			err := t.usecase.UpdateKey(context.Background(), key)
+			t.Nil(err)
+			// the algorithm is kept and the secret is rotated
+			t.Equal(dns.HmacSHA256, key.Algorithm)
+			t.NotEqual("c2VjcmV0", key.Secret)
+			t.Equal([]string{"test.com."}, key.Zones)
+			t.tsigKeyRepo.AssertCalled(t.T(), "Update", mock.Anything, key)
+		},
+	)
+
+	t.Run(
+		"given_secret", func() {
+			t.SetupTest()
+			key := &domain.TsigKey{Name: "update.", Algorithm: dns.HmacSHA512, Secret: "b3RoZXI="}
+			err := t.usecase.UpdateKey(context.Background(), key)
+			t.Nil(err)
+			t.Equal(dns.HmacSHA512, key.Algorithm)
+			t.Equal("b3RoZXI=", key.Secret)
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			t.SetupTest()
+			err := t.usecase.UpdateKey(context.Background(), &domain.TsigKey{Name: "other."})
+			t.Equal(http.StatusNotFound, err.(*domain.Error).StatusCode)
+			t.tsigKeyRepo.AssertNotCalled(t.T(), "Update", mock.Anything, mock.Anything)
+		},
+	)
+
+	t.Run(
+		"secret_error", func() {
+			t.SetupTest()
+			err := t.usecase.UpdateKey(context.Background(), &domain.TsigKey{Name: "update.", Secret: "!secret"})
+			t.Equal(http.StatusBadRequest, err.(*domain.Error).StatusCode)
+			t.tsigKeyRepo.AssertNotCalled(t.T(), "Update", mock.Anything, mock.Anything)
+		},
+	)
+}
+
+func (t *tsigKeyUseCaseTestSuite) TestDeleteKey() {
+	t.SetupTest()
+	err := t.usecase.DeleteKey(context.Background(), "update")
+	t.Nil(err)
+	t.tsigKeyRepo.AssertCalled(t.T(), "Delete", mock.Anything, "update.")
+}
+
+func (t *tsigKeyUseCaseTestSuite) TestGenerate() {
+	newMsg := func(name, algorithm string) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetQuestion("test.com.", dns.TypeSOA)
+		m.SetTsig(name, algorithm, 300, time.Now().Unix())
+		return m
+	}
+	sign := func(name, algorithm string) ([]byte, error) {
+		raw, _, err := dns.TsigGenerateWithProvider(newMsg(name, algorithm), t.usecase, "", false)
+		return raw, err
+	}
+
+	t.Run(
+		"success", func() {
+			t.SetupTest()
+			raw, err := sign("update.", dns.HmacSHA256)
+			t.Nil(err)
+			t.Nil(dns.TsigVerifyWithProvider(raw, t.usecase, "", false))
+
+			// the same message signed by another secret isn't verified
+			raw, _, err = dns.TsigGenerate(newMsg("update.", dns.HmacSHA256), "b3RoZXI=", "", false)
+			t.Nil(err)
+			t.ErrorIs(dns.TsigVerifyWithProvider(raw, t.usecase, "", false), dns.ErrSig)
+		},
+	)
+
+	t.Run(
+		"unknown_key_error", func() {
+			t.SetupTest()
+			_, err := sign("other.", dns.HmacSHA256)
+			t.ErrorIs(err, dns.ErrSecret)
+		},
+	)
+
+	t.Run(
+		"algorithm_error", func() {
+			t.SetupTest()
+			_, err := sign("update.", dns.HmacSHA1)
+			t.ErrorIs(err, dns.ErrKeyAlg)
+		},
+	)
+}
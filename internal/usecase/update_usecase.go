@@ -0,0 +1,436 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/utils"
+)
+
+// metaTypes are the types which cannot be added as records (RFC 2136 3.4.1.3)
+var metaTypes = map[uint16]bool{
+	dns.TypeANY:   true,
+	dns.TypeAXFR:  true,
+	dns.TypeIXFR:  true,
+	dns.TypeMAILA: true,
+	dns.TypeMAILB: true,
+}
+
+type updateUseCase struct {
+	zoneRepo domain.ZoneRepo
+
+	recordRepo domain.RecordRepo
+
+	tsigKeyRepo domain.TsigKeyRepo
+
+	recordUseCase domain.RecordUseCase
+
+	createPtr bool
+}
+
+// updateRRset is a RRset read by an update, it's written when after differs from before
+type updateRRset struct {
+	q      dns.Question
+	before []dns.RR
+	after  []dns.RR
+}
+
+// zoneUpdate is the state of an update of the zone, the RRsets are kept in the order they're read
+type zoneUpdate struct {
+	zone   *domain.Zone
+	class  uint16
+	keys   []string
+	rrsets map[string]*updateRRset
+}
+
+func (u *updateUseCase) Update(ctx context.Context, key string, req *dns.Msg) error {
+	if len(req.Question) != 1 || req.Question[0].Qtype != dns.TypeSOA {
+		return fmt.Errorf("the zone section should have one SOA question: %w", domain.ErrFormat)
+	}
+	name := utils.GetFQDNFromDomainName(req.Question[0].Name)
+	zone, err := u.zoneRepo.Get(ctx, name)
+	if err != nil {
+		return fmt.Errorf("%s isn't hosted here: %w", name, domain.ErrNotAuth)
+	}
+	if zone.IsSecondary() {
+		return fmt.Errorf("the secondary zone %s is read-only: %w", name, domain.ErrRefused)
+	}
+	tsigKey, err := u.tsigKeyRepo.Get(ctx, dns.CanonicalName(key))
+	if err != nil || !tsigKey.Allows(zone.Name) {
+		return fmt.Errorf("the key %s may not update %s: %w", key, name, domain.ErrRefused)
+	}
+
+	update := &zoneUpdate{
+		zone:   zone,
+		class:  req.Question[0].Qclass,
+		rrsets: map[string]*updateRRset{},
+	}
+	err = u.checkPrerequisites(ctx, update, req.Answer)
+	if err != nil {
+		return err
+	}
+	err = u.prescan(ctx, update, req.Ns)
+	if err != nil {
+		return err
+	}
+	for _, rr := range req.Ns {
+		err = u.apply(ctx, update, rr)
+		if err != nil {
+			return err
+		}
+	}
+
+	return u.write(ctx, update)
+}
+
+// checkPrerequisites checks the prerequisite section against the current RRsets (RFC 2136 3.2)
+func (u *updateUseCase) checkPrerequisites(ctx context.Context, update *zoneUpdate, rrs []dns.RR) error {
+	var (
+		keys     []string
+		expected = map[string][]dns.RR{}
+	)
+
+	for _, rr := range rrs {
+		h := rr.Header()
+		if h.Ttl != 0 {
+			return fmt.Errorf("the prerequisite %s has a TTL: %w", h.Name, domain.ErrFormat)
+		}
+		err := u.checkZone(ctx, update.zone, h.Name)
+		if err != nil {
+			return err
+		}
+
+		switch h.Class {
+		case dns.ClassANY, dns.ClassNONE:
+			if h.Rdlength != 0 {
+				return fmt.Errorf("the prerequisite %s has rdata: %w", h.Name, domain.ErrFormat)
+			}
+			if h.Rrtype == dns.TypeANY {
+				types, err := u.types(ctx, update, h.Name)
+				if err != nil {
+					return err
+				}
+				if h.Class == dns.ClassANY && len(types) == 0 {
+					return fmt.Errorf("%s: %w", h.Name, domain.ErrNXDomain)
+				}
+				if h.Class == dns.ClassNONE && len(types) > 0 {
+					return fmt.Errorf("%s: %w", h.Name, domain.ErrYXDomain)
+				}
+				continue
+			}
+
+			rrset, err := u.rrset(ctx, update, h.Name, h.Rrtype)
+			if err != nil {
+				return err
+			}
+			if h.Class == dns.ClassANY && len(rrset.after) == 0 {
+				return fmt.Errorf("%s %s: %w", h.Name, dns.TypeToString[h.Rrtype], domain.ErrNXRRSet)
+			}
+			if h.Class == dns.ClassNONE && len(rrset.after) > 0 {
+				return fmt.Errorf("%s %s: %w", h.Name, dns.TypeToString[h.Rrtype], domain.ErrYXRRSet)
+			}
+		case update.class:
+			// the value dependent prerequisites of a RRset are compared as a whole
+			key := updateKey(h.Name, h.Rrtype)
+			if _, ok := expected[key]; !ok {
+				keys = append(keys, key)
+			}
+			expected[key] = append(expected[key], rr)
+		default:
+			return fmt.Errorf("the prerequisite %s has an invalid class: %w", h.Name, domain.ErrFormat)
+		}
+	}
+
+	for _, key := range keys {
+		h := expected[key][0].Header()
+		rrset, err := u.rrset(ctx, update, h.Name, h.Rrtype)
+		if err != nil {
+			return err
+		}
+		if !sameRRs(rrset.after, expected[key]) {
+			return fmt.Errorf("%s %s: %w", h.Name, dns.TypeToString[h.Rrtype], domain.ErrNXRRSet)
+		}
+	}
+	return nil
+}
+
+// prescan checks the update section before anything is changed (RFC 2136 3.4.1)
+func (u *updateUseCase) prescan(ctx context.Context, update *zoneUpdate, rrs []dns.RR) error {
+	for _, rr := range rrs {
+		h := rr.Header()
+		err := u.checkZone(ctx, update.zone, h.Name)
+		if err != nil {
+			return err
+		}
+
+		var invalid bool
+		switch h.Class {
+		case update.class:
+			_, empty := rr.(*dns.RR_Header)
+			invalid = metaTypes[h.Rrtype] || empty
+		case dns.ClassANY:
+			invalid = h.Ttl != 0 || h.Rdlength != 0 || (metaTypes[h.Rrtype] && h.Rrtype != dns.TypeANY)
+		case dns.ClassNONE:
+			invalid = h.Ttl != 0 || metaTypes[h.Rrtype]
+		default:
+			invalid = true
+		}
+		if invalid {
+			return fmt.Errorf("the update %s is invalid: %w", rr.String(), domain.ErrFormat)
+		}
+	}
+	return nil
+}
+
+// apply changes the RRsets of the update in memory, the SOA and NS records of the apex are
+// managed by the zone API and the changes of them are ignored
+func (u *updateUseCase) apply(ctx context.Context, update *zoneUpdate, rr dns.RR) error {
+	h := rr.Header()
+	managed := func(rrtype uint16) bool {
+		return rrtype == dns.TypeSOA ||
+			(rrtype == dns.TypeNS && dns.CanonicalName(h.Name) == dns.CanonicalName(update.zone.Name))
+	}
+
+	switch {
+	case h.Class == update.class && !managed(h.Rrtype):
+		return u.add(ctx, update, rr)
+	case h.Class == dns.ClassANY && h.Rrtype == dns.TypeANY:
+		types, err := u.types(ctx, update, h.Name)
+		if err != nil {
+			return err
+		}
+		for _, rrtype := range types {
+			if !managed(rrtype) {
+				update.rrsets[updateKey(h.Name, rrtype)].after = nil
+			}
+		}
+	case h.Class == dns.ClassANY && !managed(h.Rrtype):
+		rrset, err := u.rrset(ctx, update, h.Name, h.Rrtype)
+		if err != nil {
+			return err
+		}
+		rrset.after = nil
+	case h.Class == dns.ClassNONE && !managed(h.Rrtype):
+		rrset, err := u.rrset(ctx, update, h.Name, h.Rrtype)
+		if err != nil {
+			return err
+		}
+		removed := dns.Copy(rr)
+		removed.Header().Class = update.class
+		var remains []dns.RR
+		for _, member := range rrset.after {
+			if !dns.IsDuplicate(member, removed) {
+				remains = append(remains, member)
+			}
+		}
+		rrset.after = remains
+	}
+	return nil
+}
+
+// add adds the record to its RRset, whose members take the TTL of the record. A CNAME replaces
+// the existed one, and an addition which would make a CNAME coexist with other data is ignored
+// (RFC 2136 3.4.2.2).
+func (u *updateUseCase) add(ctx context.Context, update *zoneUpdate, rr dns.RR) error {
+	h := rr.Header()
+	types, err := u.types(ctx, update, h.Name)
+	if err != nil {
+		return err
+	}
+	for _, rrtype := range types {
+		if rrtype == h.Rrtype || allowedWithCNAME[rrtype] || allowedWithCNAME[h.Rrtype] {
+			continue
+		}
+		if rrtype == dns.TypeCNAME || h.Rrtype == dns.TypeCNAME {
+			return nil
+		}
+	}
+
+	rrset, err := u.rrset(ctx, update, h.Name, h.Rrtype)
+	if err != nil {
+		return err
+	}
+	if h.Rrtype == dns.TypeCNAME {
+		rrset.after = []dns.RR{rr}
+		return nil
+	}
+
+	after := make([]dns.RR, 0, len(rrset.after)+1)
+	for _, member := range rrset.after {
+		if dns.IsDuplicate(member, rr) {
+			continue
+		}
+		member = dns.Copy(member)
+		member.Header().Ttl = h.Ttl
+		after = append(after, member)
+	}
+	rrset.after = append(after, rr)
+	return nil
+}
+
+// write writes the changed RRsets in one transaction of the record use case, so the update is
+// applied as a whole with one serial bump of the zone (RFC 2136 3.4.2)
+func (u *updateUseCase) write(ctx context.Context, update *zoneUpdate) error {
+	var rrsets []*domain.RRset
+	for _, key := range update.keys {
+		rrset := update.rrsets[key]
+		deleted, added := diffRRs(rrset.before, rrset.after)
+		if len(deleted) == 0 && len(added) == 0 {
+			continue
+		}
+		rrsets = append(rrsets, &domain.RRset{Question: rrset.q, Records: rrset.after})
+	}
+	if len(rrsets) == 0 {
+		return nil
+	}
+
+	err := u.recordUseCase.ReplaceRRsets(ctx, rrsets, domain.RecordOptions{CreatePtr: u.createPtr})
+	if err != nil {
+		return refusedError(err)
+	}
+	return nil
+}
+
+// checkZone rejects the names outside the zone, including the ones of its sub-zones
+func (u *updateUseCase) checkZone(ctx context.Context, zone *domain.Zone, name string) error {
+	if !dns.IsSubDomain(zone.Name, name) {
+		return fmt.Errorf("%s isn't in %s: %w", name, zone.Name, domain.ErrNotZone)
+	}
+	closest, err := getClosestZone(ctx, u.zoneRepo, name)
+	if err != nil {
+		return err
+	}
+	if closest != nil && dns.CanonicalName(closest.Name) != dns.CanonicalName(zone.Name) {
+		return fmt.Errorf("%s is in the sub-zone %s: %w", name, closest.Name, domain.ErrNotZone)
+	}
+	return nil
+}
+
+// rrset returns the RRset of the update, which is read at the first time
+func (u *updateUseCase) rrset(ctx context.Context, update *zoneUpdate, name string,
+	rrtype uint16) (*updateRRset, error) {
+	key := updateKey(name, rrtype)
+	if rrset, ok := update.rrsets[key]; ok {
+		return rrset, nil
+	}
+
+	var rrs []dns.RR
+	apex := dns.CanonicalName(name) == dns.CanonicalName(update.zone.Name)
+	switch {
+	case apex && rrtype == dns.TypeSOA:
+		rrs = []dns.RR{update.zone.SOA()}
+	case apex && rrtype == dns.TypeNS:
+		rrs = update.zone.NS()
+	default:
+		records, err := u.recordRepo.GetRRset(ctx, name, rrtype, update.class)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			rr, err := dns.NewRR(record.Record)
+			if err != nil {
+				return nil, err
+			}
+			rrs = append(rrs, rr)
+		}
+	}
+
+	rrset := &updateRRset{
+		q:      dns.Question{Name: name, Qtype: rrtype, Qclass: update.class},
+		before: rrs,
+		after:  rrs,
+	}
+	update.keys = append(update.keys, key)
+	update.rrsets[key] = rrset
+	return rrset, nil
+}
+
+// types returns the types of the non-empty RRsets of the name as changed by the update so far
+func (u *updateUseCase) types(ctx context.Context, update *zoneUpdate, name string) ([]uint16, error) {
+	var candidates []uint16
+	if dns.CanonicalName(name) == dns.CanonicalName(update.zone.Name) {
+		candidates = append(candidates, dns.TypeSOA, dns.TypeNS)
+	}
+	records, err := u.recordRepo.ListByName(ctx, name, update.class)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		candidates = append(candidates, record.RrType)
+	}
+	for _, rrset := range update.rrsets {
+		if dns.CanonicalName(rrset.q.Name) == dns.CanonicalName(name) {
+			candidates = append(candidates, rrset.q.Qtype)
+		}
+	}
+
+	var (
+		types []uint16
+		seen  = map[uint16]bool{}
+	)
+	for _, rrtype := range candidates {
+		if seen[rrtype] {
+			continue
+		}
+		seen[rrtype] = true
+		rrset, err := u.rrset(ctx, update, name, rrtype)
+		if err != nil {
+			return nil, err
+		}
+		if len(rrset.after) > 0 {
+			types = append(types, rrtype)
+		}
+	}
+	return types, nil
+}
+
+func updateKey(name string, rrtype uint16) string {
+	return fmt.Sprintf("%s %d", dns.CanonicalName(name), rrtype)
+}
+
+// sameRRs reports whether both have the same records regardless of their TTL
+func sameRRs(a []dns.RR, b []dns.RR) bool {
+	contains := func(rrs []dns.RR, rr dns.RR) bool {
+		for _, member := range rrs {
+			if dns.IsDuplicate(member, rr) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, rr := range a {
+		if !contains(b, rr) {
+			return false
+		}
+	}
+	for _, rr := range b {
+		if !contains(a, rr) {
+			return false
+		}
+	}
+	return true
+}
+
+// refusedError turns the rejection of a change by the record use case into REFUSED
+func refusedError(err error) error {
+	var e *domain.Error
+	if errors.As(err, &e) && e.StatusCode < http.StatusInternalServerError {
+		return fmt.Errorf("%s: %w", e.Message, domain.ErrRefused)
+	}
+	return err
+}
+
+func NewUpdateUseCase(injector *do.Injector) (domain.UpdateUseCase, error) {
+	return &updateUseCase{
+		do.MustInvoke[domain.ZoneRepo](injector),
+		do.MustInvoke[domain.RecordRepo](injector),
+		do.MustInvoke[domain.TsigKeyRepo](injector),
+		do.MustInvoke[domain.RecordUseCase](injector),
+		do.MustInvoke[*domain.Options](injector).CreatePtr,
+	}, nil
+}
@@ -0,0 +1,534 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+)
+
+type updateUseCaseTestSuite struct {
+	suite.Suite
+
+	usecase domain.UpdateUseCase
+
+	zoneRepo      *mocks.ZoneRepo
+	recordRepo    *mocks.RecordRepo
+	tsigKeyRepo   *mocks.TsigKeyRepo
+	recordUseCase *mocks.RecordUseCase
+
+	zone    *domain.Zone
+	records []*domain.Record
+}
+
+func TestUpdateUseCase(t *testing.T) {
+	suite.Run(t, &updateUseCaseTestSuite{})
+}
+
+func (t *updateUseCaseTestSuite) SetupSuite() {
+	injector := do.New()
+	t.zoneRepo = &mocks.ZoneRepo{}
+	do.ProvideValue[domain.ZoneRepo](injector, t.zoneRepo)
+	t.recordRepo = &mocks.RecordRepo{}
+	do.ProvideValue[domain.RecordRepo](injector, t.recordRepo)
+	t.tsigKeyRepo = &mocks.TsigKeyRepo{}
+	do.ProvideValue[domain.TsigKeyRepo](injector, t.tsigKeyRepo)
+	t.recordUseCase = &mocks.RecordUseCase{}
+	do.ProvideValue[domain.RecordUseCase](injector, t.recordUseCase)
+	do.ProvideValue(injector, &domain.Options{CreatePtr: true})
+
+	t.usecase, _ = NewUpdateUseCase(injector)
+}
+
+func (t *updateUseCaseTestSuite) SetupTest() {
+	var (
+		anyContext  = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString   = mock.AnythingOfType("string")
+		anyUint16   = mock.AnythingOfType("uint16")
+		anyOpts     = mock.AnythingOfType("domain.RecordOptions")
+		notFound    = &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound}
+		subZone     = &domain.Zone{Name: "sub.test.com.", Type: domain.ZonePrimary}
+		secondary   = &domain.Zone{Name: "secondary.com.", Type: domain.ZoneSecondary}
+		updateKey   = &domain.TsigKey{Name: "update.", Algorithm: dns.HmacSHA256}
+		scopedKey   = &domain.TsigKey{Name: "dhcp.", Algorithm: dns.HmacSHA256, Zones: []string{"other.com."}}
+		recordsText = []string{
+			"www.test.com.\t300\tIN\tA\t192.0.2.1",
+			"alias.test.com.\t300\tIN\tCNAME\twww.test.com.",
+			"mail.test.com.\t300\tIN\tMX\t10 mail.test.com.",
+			"mail.test.com.\t300\tIN\tTXT\t\"v=spf1 -all\"",
+		}
+	)
+
+	t.zone = &domain.Zone{
+		Name:        "test.com.",
+		Type:        domain.ZonePrimary,
+		Nameservers: []string{"ns1.test.com."},
+		Mbox:        "admin.test.com.",
+		Serial:      4000000000,
+		Ttl:         3600,
+	}
+	t.records = nil
+	for _, text := range recordsText {
+		rr, _ := dns.NewRR(text)
+		t.records = append(t.records, domain.NewRecord(rr))
+	}
+
+	t.zoneRepo.ExpectedCalls = nil
+	t.recordRepo.ExpectedCalls = nil
+	t.tsigKeyRepo.ExpectedCalls = nil
+	t.recordUseCase.ExpectedCalls = nil
+	t.recordUseCase.Calls = nil
+
+	t.zoneRepo.On("Get", anyContext, "test.com.").Return(t.zone, nil)
+	t.zoneRepo.On("Get", anyContext, "secondary.com.").Return(secondary, nil)
+	t.zoneRepo.On("Get", anyContext, anyString).Return(nil, notFound)
+	t.zoneRepo.
+		On("GetClosest", anyContext, anyString).
+		Return(
+			func(_ context.Context, name string) *domain.Zone {
+				switch {
+				case dns.IsSubDomain(subZone.Name, name):
+					return subZone
+				case dns.IsSubDomain(t.zone.Name, name):
+					return t.zone
+				}
+				return nil
+			}, nil,
+		)
+	t.tsigKeyRepo.On("Get", anyContext, "update.").Return(updateKey, nil)
+	t.tsigKeyRepo.On("Get", anyContext, "dhcp.").Return(scopedKey, nil)
+	t.tsigKeyRepo.On("Get", anyContext, anyString).Return(nil, notFound)
+	t.recordRepo.
+		On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+		Return(
+			func(_ context.Context, name string, rrType uint16, class uint16) []*domain.Record {
+				var records []*domain.Record
+				for _, record := range t.records {
+					if record.Name == name && record.RrType == rrType && record.Class == class {
+						records = append(records, record)
+					}
+				}
+				return records
+			}, nil,
+		)
+	t.recordRepo.
+		On("ListByName", anyContext, anyString, anyUint16).
+		Return(
+			func(_ context.Context, name string, class uint16) []*domain.Record {
+				var records []*domain.Record
+				for _, record := range t.records {
+					if record.Name == name && record.Class == class {
+						records = append(records, record)
+					}
+				}
+				return records
+			}, nil,
+		)
+	t.recordUseCase.
+		On("ReplaceRRsets", anyContext, mock.AnythingOfType("[]*domain.RRset"), anyOpts).
+		Return(nil)
+}
+
+// newUpdate returns the update of the zone as it comes from the wire
+func (t *updateUseCaseTestSuite) newUpdate(zone string, build func(req *dns.Msg)) *dns.Msg {
+	req := new(dns.Msg)
+	req.SetUpdate(zone)
+	build(req)
+
+	raw, err := req.Pack()
+	t.Require().Nil(err)
+	unpacked := new(dns.Msg)
+	t.Require().Nil(unpacked.Unpack(raw))
+	return unpacked
+}
+
+func (t *updateUseCaseTestSuite) rr(s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	t.Require().Nil(err)
+	return rr
+}
+
+// rrsets returns the RRsets given to ReplaceRRsets, which is called once per update
+func (t *updateUseCaseTestSuite) rrsets() []*domain.RRset {
+	var rrsets []*domain.RRset
+	for _, call := range t.recordUseCase.Calls {
+		if call.Method == "ReplaceRRsets" {
+			rrsets = append(rrsets, call.Arguments.Get(1).([]*domain.RRset)...)
+		}
+	}
+	return rrsets
+}
+
+// replaced returns the records of the replaced RRsets, in the order of the update
+func (t *updateUseCaseTestSuite) replaced() [][]string {
+	var rrsets [][]string
+	for _, rrset := range t.rrsets() {
+		if len(rrset.Records) > 0 {
+			rrsets = append(rrsets, toStrings(rrset.Records))
+		}
+	}
+	return rrsets
+}
+
+// deleted returns the questions of the deleted RRsets, in the order of the update
+func (t *updateUseCaseTestSuite) deleted() []domain.Question {
+	var questions []domain.Question
+	for _, rrset := range t.rrsets() {
+		if len(rrset.Records) == 0 {
+			q := rrset.Question
+			questions = append(
+				questions, domain.Question{
+					Name:   q.Name,
+					Qtype:  domain.RRTypeOf(q.Qtype),
+					Qclass: domain.ClassOf(q.Qclass),
+				},
+			)
+		}
+	}
+	return questions
+}
+
+func (t *updateUseCaseTestSuite) TestUpdate() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"add", func() {
+			t.SetupTest()
+			req := t.newUpdate(
+				"test.com.", func(req *dns.Msg) {
+					req.Insert([]dns.RR{t.rr("www.test.com.\t600\tIN\tA\t192.0.2.2")})
+				},
+			)
+			err := t.usecase.Update(context.Background(), "update.", req)
+			t.Nil(err)
+			// the members of the RRset take the TTL of the added record
+			t.Equal(
+				[][]string{{"www.test.com.\t600\tIN\tA\t192.0.2.1", "www.test.com.\t600\tIN\tA\t192.0.2.2"}},
+				t.replaced(),
+			)
+			t.recordUseCase.AssertCalled(
+				t.T(), "ReplaceRRsets", anyContext, mock.AnythingOfType("[]*domain.RRset"),
+				domain.RecordOptions{CreatePtr: true},
+			)
+		},
+	)
+
+	t.Run(
+		"add_duplicate", func() {
+			t.SetupTest()
+			req := t.newUpdate(
+				"test.com.", func(req *dns.Msg) {
+					req.Insert([]dns.RR{t.rr("www.test.com.\t300\tIN\tA\t192.0.2.1")})
+				},
+			)
+			err := t.usecase.Update(context.Background(), "update.", req)
+			t.Nil(err)
+			t.Empty(t.recordUseCase.Calls)
+		},
+	)
+
+	t.Run(
+		"add_conflicting_cname_ignored", func() {
+			t.SetupTest()
+			req := t.newUpdate(
+				"test.com.", func(req *dns.Msg) {
+					req.Insert(
+						[]dns.RR{
+							t.rr("www.test.com.\t300\tIN\tCNAME\tmail.test.com."),
+							t.rr("alias.test.com.\t300\tIN\tTXT\t\"text\""),
+							t.rr("test.com.\t300\tIN\tCNAME\tmail.test.com."),
+						},
+					)
+				},
+			)
+			err := t.usecase.Update(context.Background(), "update.", req)
+			t.Nil(err)
+			t.Empty(t.recordUseCase.Calls)
+		},
+	)
+
+	t.Run(
+		"replace_cname", func() {
+			t.SetupTest()
+			req := t.newUpdate(
+				"test.com.", func(req *dns.Msg) {
+					req.Insert([]dns.RR{t.rr("alias.test.com.\t300\tIN\tCNAME\tmail.test.com.")})
+				},
+			)
+			err := t.usecase.Update(context.Background(), "update.", req)
+			t.Nil(err)
+			t.Equal([][]string{{"alias.test.com.\t300\tIN\tCNAME\tmail.test.com."}}, t.replaced())
+		},
+	)
+
+	t.Run(
+		"delete_rrset", func() {
+			t.SetupTest()
+			req := t.newUpdate(
+				"test.com.", func(req *dns.Msg) {
+					req.RemoveRRset([]dns.RR{t.rr("mail.test.com.\t0\tIN\tTXT\t\"\"")})
+				},
+			)
+			err := t.usecase.Update(context.Background(), "update.", req)
+			t.Nil(err)
+			t.Equal([]domain.Question{{Name: "mail.test.com.", Qtype: "TXT", Qclass: "INET"}}, t.deleted())
+			t.Empty(t.replaced())
+		},
+	)
+
+	t.Run(
+		"delete_name", func() {
+			t.SetupTest()
+			req := t.newUpdate(
+				"test.com.", func(req *dns.Msg) {
+					req.RemoveName([]dns.RR{t.rr("mail.test.com.\t0\tIN\tA\t0.0.0.0")})
+				},
+			)
+			err := t.usecase.Update(context.Background(), "update.", req)
+			t.Nil(err)
+			t.ElementsMatch(
+				[]domain.Question{
+					{Name: "mail.test.com.", Qtype: "MX", Qclass: "INET"},
+					{Name: "mail.test.com.", Qtype: "TXT", Qclass: "INET"},
+				}, t.deleted(),
+			)
+		},
+	)
+
+	t.Run(
+		"delete_record", func() {
+			t.SetupTest()
+			t.records = append(t.records, domain.NewRecord(t.rr("www.test.com.\t300\tIN\tA\t192.0.2.3")))
+			req := t.newUpdate(
+				"test.com.", func(req *dns.Msg) {
+					req.Remove([]dns.RR{t.rr("www.test.com.\t300\tIN\tA\t192.0.2.1")})
+				},
+			)
+			err := t.usecase.Update(context.Background(), "update.", req)
+			t.Nil(err)
+			t.Equal([][]string{{"www.test.com.\t300\tIN\tA\t192.0.2.3"}}, t.replaced())
+			t.Empty(t.deleted())
+		},
+	)
+
+	t.Run(
+		"delete_before_cname", func() {
+			t.SetupTest()
+			req := t.newUpdate(
+				"test.com.", func(req *dns.Msg) {
+					req.Insert([]dns.RR{t.rr("mail.test.com.\t300\tIN\tA\t192.0.2.9")})
+					req.RemoveName([]dns.RR{t.rr("www.test.com.\t0\tIN\tA\t0.0.0.0")})
+					req.Insert([]dns.RR{t.rr("www.test.com.\t300\tIN\tCNAME\tmail.test.com.")})
+				},
+			)
+			err := t.usecase.Update(context.Background(), "update.", req)
+			t.Nil(err)
+			t.Equal([]domain.Question{{Name: "www.test.com.", Qtype: "A", Qclass: "INET"}}, t.deleted())
+			t.Equal(
+				[][]string{
+					{"mail.test.com.\t300\tIN\tA\t192.0.2.9"},
+					{"www.test.com.\t300\tIN\tCNAME\tmail.test.com."},
+				}, t.replaced(),
+			)
+			// the deletion and the CNAME which takes its place are written together
+			t.Len(t.recordUseCase.Calls, 1)
+		},
+	)
+
+	t.Run(
+		"apex_ignored", func() {
+			t.SetupTest()
+			req := t.newUpdate(
+				"test.com.", func(req *dns.Msg) {
+					req.Insert([]dns.RR{t.rr("test.com.\t300\tIN\tNS\tns3.test.com.")})
+					req.RemoveRRset([]dns.RR{t.rr("test.com.\t0\tIN\tSOA\t. . 0 0 0 0 0")})
+					req.RemoveName([]dns.RR{t.rr("test.com.\t0\tIN\tA\t0.0.0.0")})
+				},
+			)
+			err := t.usecase.Update(context.Background(), "update.", req)
+			t.Nil(err)
+			t.Empty(t.recordUseCase.Calls)
+		},
+	)
+
+	t.Run(
+		"ReplaceRRsets_error", func() {
+			t.SetupTest()
+			t.recordUseCase.ExpectedCalls = nil
+			t.recordUseCase.
+				On("ReplaceRRsets", anyContext, mock.AnythingOfType("[]*domain.RRset"), mock.AnythingOfType("domain.RecordOptions")).
+				Return(&domain.Error{Message: "the address is already owned", StatusCode: http.StatusConflict})
+			req := t.newUpdate(
+				"test.com.", func(req *dns.Msg) {
+					req.Insert([]dns.RR{t.rr("host.test.com.\t300\tIN\tA\t192.0.2.1")})
+				},
+			)
+			err := t.usecase.Update(context.Background(), "update.", req)
+			t.True(errors.Is(err, domain.ErrRefused))
+		},
+	)
+
+	t.Run(
+		"second_rrset_error", func() {
+			t.SetupTest()
+			t.recordUseCase.ExpectedCalls = nil
+			t.recordUseCase.
+				On("ReplaceRRsets", anyContext, mock.AnythingOfType("[]*domain.RRset"), mock.AnythingOfType("domain.RecordOptions")).
+				Return(&domain.Error{Message: "the address of host is already owned", StatusCode: http.StatusConflict})
+			req := t.newUpdate(
+				"test.com.", func(req *dns.Msg) {
+					req.RemoveRRset([]dns.RR{t.rr("mail.test.com.\t0\tIN\tTXT\t\"\"")})
+					req.Insert([]dns.RR{t.rr("host.test.com.\t300\tIN\tA\t192.0.2.1")})
+				},
+			)
+			err := t.usecase.Update(context.Background(), "update.", req)
+			t.True(errors.Is(err, domain.ErrRefused))
+			// the failed RRset takes the other one with it, nothing is written on its own
+			t.Len(t.recordUseCase.Calls, 1)
+			t.Equal([]domain.Question{{Name: "mail.test.com.", Qtype: "TXT", Qclass: "INET"}}, t.deleted())
+			t.Equal([][]string{{"host.test.com.\t300\tIN\tA\t192.0.2.1"}}, t.replaced())
+		},
+	)
+}
+
+func (t *updateUseCaseTestSuite) TestPrerequisites() {
+	cases := []struct {
+		name    string
+		build   func(req *dns.Msg)
+		wantErr error
+	}{
+		{
+			name:  "name_used",
+			build: func(req *dns.Msg) { req.NameUsed([]dns.RR{t.rr("www.test.com.\t0\tIN\tA\t0.0.0.0")}) },
+		},
+		{
+			name:  "apex_name_used",
+			build: func(req *dns.Msg) { req.NameUsed([]dns.RR{t.rr("test.com.\t0\tIN\tA\t0.0.0.0")}) },
+		},
+		{
+			name:    "name_used_error",
+			build:   func(req *dns.Msg) { req.NameUsed([]dns.RR{t.rr("none.test.com.\t0\tIN\tA\t0.0.0.0")}) },
+			wantErr: domain.ErrNXDomain,
+		},
+		{
+			name:    "name_not_used_error",
+			build:   func(req *dns.Msg) { req.NameNotUsed([]dns.RR{t.rr("www.test.com.\t0\tIN\tA\t0.0.0.0")}) },
+			wantErr: domain.ErrYXDomain,
+		},
+		{
+			name:    "rrset_used_error",
+			build:   func(req *dns.Msg) { req.RRsetUsed([]dns.RR{t.rr("www.test.com.\t0\tIN\tAAAA\t::")}) },
+			wantErr: domain.ErrNXRRSet,
+		},
+		{
+			name:    "rrset_not_used_error",
+			build:   func(req *dns.Msg) { req.RRsetNotUsed([]dns.RR{t.rr("www.test.com.\t0\tIN\tA\t0.0.0.0")}) },
+			wantErr: domain.ErrYXRRSet,
+		},
+		{
+			name: "value_used",
+			build: func(req *dns.Msg) {
+				req.Used([]dns.RR{t.rr("mail.test.com.\t300\tIN\tTXT\t\"v=spf1 -all\"")})
+				req.Used([]dns.RR{t.rr("test.com.\t0\tIN\tNS\tns1.test.com.")})
+			},
+		},
+		{
+			name:    "value_used_error",
+			build:   func(req *dns.Msg) { req.Used([]dns.RR{t.rr("www.test.com.\t0\tIN\tA\t192.0.2.9")}) },
+			wantErr: domain.ErrNXRRSet,
+		},
+		{
+			name:    "not_zone_error",
+			build:   func(req *dns.Msg) { req.NameUsed([]dns.RR{t.rr("www.other.com.\t0\tIN\tA\t0.0.0.0")}) },
+			wantErr: domain.ErrNotZone,
+		},
+		{
+			name:    "sub_zone_error",
+			build:   func(req *dns.Msg) { req.Insert([]dns.RR{t.rr("www.sub.test.com.\t300\tIN\tA\t192.0.2.1")}) },
+			wantErr: domain.ErrNotZone,
+		},
+		{
+			name:    "ttl_error",
+			build:   func(req *dns.Msg) { req.Answer = []dns.RR{t.rr("www.test.com.\t300\tIN\tA\t192.0.2.1")} },
+			wantErr: domain.ErrFormat,
+		},
+		{
+			name: "meta_type_error",
+			build: func(req *dns.Msg) {
+				req.Ns = []dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: "www.test.com.", Rrtype: dns.TypeAXFR, Class: dns.ClassANY}}}
+			},
+			wantErr: domain.ErrFormat,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(
+			c.name, func() {
+				t.SetupTest()
+				err := t.usecase.Update(context.Background(), "update.", t.newUpdate("test.com.", c.build))
+				if c.wantErr == nil {
+					t.Nil(err)
+					return
+				}
+				t.True(errors.Is(err, c.wantErr), err)
+				t.Empty(t.recordUseCase.Calls)
+			},
+		)
+	}
+}
+
+func (t *updateUseCaseTestSuite) TestUpdateZone() {
+	insert := func(req *dns.Msg) {
+		req.Insert([]dns.RR{t.rr("host.test.com.\t300\tIN\tA\t192.0.2.1")})
+	}
+
+	t.Run(
+		"not_auth_error", func() {
+			t.SetupTest()
+			err := t.usecase.Update(context.Background(), "update.", t.newUpdate("other.com.", insert))
+			t.True(errors.Is(err, domain.ErrNotAuth))
+		},
+	)
+
+	t.Run(
+		"secondary_error", func() {
+			t.SetupTest()
+			err := t.usecase.Update(context.Background(), "update.", t.newUpdate("secondary.com.", insert))
+			t.True(errors.Is(err, domain.ErrRefused))
+		},
+	)
+
+	t.Run(
+		"key_not_allowed_error", func() {
+			t.SetupTest()
+			err := t.usecase.Update(context.Background(), "dhcp.", t.newUpdate("test.com.", insert))
+			t.True(errors.Is(err, domain.ErrRefused))
+			t.Empty(t.recordUseCase.Calls)
+		},
+	)
+
+	t.Run(
+		"unknown_key_error", func() {
+			t.SetupTest()
+			err := t.usecase.Update(context.Background(), "other.", t.newUpdate("test.com.", insert))
+			t.True(errors.Is(err, domain.ErrRefused))
+		},
+	)
+
+	t.Run(
+		"question_error", func() {
+			t.SetupTest()
+			req := t.newUpdate("test.com.", insert)
+			req.Question[0].Qtype = dns.TypeA
+			err := t.usecase.Update(context.Background(), "update.", req)
+			t.True(errors.Is(err, domain.ErrFormat))
+		},
+	)
+}
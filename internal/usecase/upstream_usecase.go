@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/do"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/pkg/forwarder"
+)
+
+type upstreamUseCase struct {
+	upstreams *forwarder.Group
+
+	forwardingRuleUseCase domain.ForwardingRuleUseCase
+
+	// probeInterval is 0 when the upstreams aren't probed
+	probeInterval time.Duration
+}
+
+func (u *upstreamUseCase) Run(ctx context.Context) error {
+	if u.probeInterval == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(u.probeInterval)
+	defer ticker.Stop()
+
+	for {
+		u.upstreams.Probe(ctx)
+		// the rules which fail to load are probed at the next tick
+		_ = u.forwardingRuleUseCase.ProbeUpstreams(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (u *upstreamUseCase) ListUpstreams(ctx context.Context) ([]*domain.UpstreamGroup, error) {
+	groups, err := u.forwardingRuleUseCase.ListUpstreams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append([]*domain.UpstreamGroup{newUpstreamGroup("", u.upstreams)}, groups...), nil
+}
+
+// newUpstreamGroup returns the health of the upstream forwarders of the group
+func newUpstreamGroup(suffix string, group *forwarder.Group) *domain.UpstreamGroup {
+	upstreams := make([]*domain.Upstream, 0)
+	for _, status := range group.Statuses() {
+		upstream := &domain.Upstream{
+			Upstream:            status.Upstream,
+			Healthy:             status.Healthy,
+			RttMs:               float64(status.Rtt.Microseconds()) / 1000,
+			Exchanges:           status.Exchanges,
+			Failures:            status.Failures,
+			ConsecutiveFailures: status.ConsecutiveFailures,
+			LastError:           status.LastError,
+		}
+		if !status.Healthy {
+			upstream.EjectedUntil = &status.EjectedUntil
+		}
+		if !status.LastProbe.IsZero() {
+			upstream.LastProbe = &status.LastProbe
+		}
+		upstreams = append(upstreams, upstream)
+	}
+	return &domain.UpstreamGroup{Suffix: suffix, Upstreams: upstreams}
+}
+
+func NewUpstreamUseCase(injector *do.Injector) (domain.UpstreamUseCase, error) {
+	env := do.MustInvoke[*domain.Options](injector)
+	return &upstreamUseCase{
+		do.MustInvoke[*forwarder.Group](injector),
+		do.MustInvoke[domain.ForwardingRuleUseCase](injector),
+		time.Duration(env.UpstreamProbeTime) * time.Second,
+	}, nil
+}
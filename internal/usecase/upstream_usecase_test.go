@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+	"github.com/cewuandy/go-restful-dns/pkg/forwarder"
+)
+
+type upstreamUseCaseTestSuite struct {
+	suite.Suite
+
+	upstream *dns.Server
+	// dead is a closed port, which refuses the queries at once
+	dead string
+}
+
+func TestUpstreamUseCase(t *testing.T) {
+	suite.Run(t, &upstreamUseCaseTestSuite{})
+}
+
+func (t *upstreamUseCaseTestSuite) SetupSuite() {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	t.Nil(err)
+	started := make(chan struct{})
+	t.upstream = &dns.Server{
+		PacketConn: conn,
+		Handler: dns.HandlerFunc(
+			func(w dns.ResponseWriter, req *dns.Msg) {
+				resp := new(dns.Msg)
+				resp.SetReply(req)
+				_ = w.WriteMsg(resp)
+			},
+		),
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() {
+		_ = t.upstream.ActivateAndServe()
+	}()
+	<-started
+
+	dead, err := net.ListenPacket("udp", "127.0.0.1:0")
+	t.Nil(err)
+	t.dead = dead.LocalAddr().String()
+	_ = dead.Close()
+}
+
+func (t *upstreamUseCaseTestSuite) TearDownSuite() {
+	_ = t.upstream.Shutdown()
+}
+
+// newUseCase returns the use case of the default upstreams, and of a rule forwarding corp.example. to the
+// closed port, the rules fail to load by the error if any
+func (t *upstreamUseCaseTestSuite) newUseCase(probeTime uint, rulesErr error) domain.UpstreamUseCase {
+	injector := do.New()
+	forwarders, _ := forwarder.NewPool("", false)
+	upstreams, err := forwarder.NewGroup(
+		forwarders, []string{t.upstream.PacketConn.LocalAddr().String(), t.dead}, forwarder.Sequential, 1, 1,
+		time.Minute,
+	)
+	t.Nil(err)
+	forwardingRuleRepo := &mocks.ForwardingRuleRepo{}
+	forwardingRuleRepo.
+		On("List", mock.Anything).
+		Return([]*domain.ForwardingRule{{Suffix: "corp.example.", Upstreams: []string{t.dead}}}, rulesErr)
+	do.ProvideValue(injector, forwarders)
+	do.ProvideValue(injector, upstreams)
+	do.ProvideValue(
+		injector, &domain.Options{
+			UpstreamProbeTime: probeTime,
+			UpstreamParallel:  1,
+			UpstreamMaxFails:  1,
+			UpstreamEjectTime: 60,
+		},
+	)
+	do.ProvideValue[domain.ForwardingRuleRepo](injector, forwardingRuleRepo)
+	do.ProvideValue[domain.ResolveUseCase](injector, nil)
+	do.Provide(injector, NewForwardingRuleUseCase)
+	upstreamUseCase, err := NewUpstreamUseCase(injector)
+	t.Nil(err)
+	return upstreamUseCase
+}
+
+func (t *upstreamUseCaseTestSuite) TestRun() {
+	t.Run(
+		"probe_success", func() {
+			upstreamUseCase := t.newUseCase(1, nil)
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() {
+				done <- upstreamUseCase.Run(ctx)
+			}()
+
+			t.Eventually(
+				func() bool {
+					groups, _ := upstreamUseCase.ListUpstreams(context.Background())
+					return groups[0].Upstreams[1].LastProbe != nil && groups[1].Upstreams[0].LastProbe != nil
+				}, time.Second, 10*time.Millisecond,
+			)
+			cancel()
+			t.Nil(<-done)
+
+			groups, err := upstreamUseCase.ListUpstreams(context.Background())
+			t.Nil(err)
+			t.Len(groups, 2)
+			t.Empty(groups[0].Suffix)
+			upstreams := groups[0].Upstreams
+			t.Len(upstreams, 2)
+			t.True(upstreams[0].Healthy)
+			t.Greater(upstreams[0].RttMs, float64(0))
+			t.Nil(upstreams[0].EjectedUntil)
+			// the closed port is ejected by a single failure
+			t.False(upstreams[1].Healthy)
+			t.NotNil(upstreams[1].EjectedUntil)
+			t.Equal(uint64(1), upstreams[1].Failures)
+			t.NotEmpty(upstreams[1].LastError)
+
+			// the upstreams of the rules are probed too
+			t.Equal("corp.example.", groups[1].Suffix)
+			t.Len(groups[1].Upstreams, 1)
+			t.False(groups[1].Upstreams[0].Healthy)
+			t.Equal(uint64(1), groups[1].Upstreams[0].Failures)
+		},
+	)
+
+	t.Run(
+		"probe_disabled", func() {
+			upstreamUseCase := t.newUseCase(0, nil)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			t.Nil(upstreamUseCase.Run(ctx))
+
+			groups, err := upstreamUseCase.ListUpstreams(context.Background())
+			t.Nil(err)
+			t.Len(groups, 2)
+			t.True(groups[0].Upstreams[1].Healthy)
+			t.Nil(groups[0].Upstreams[1].LastProbe)
+			t.Equal(uint64(0), groups[0].Upstreams[1].Exchanges)
+			t.Nil(groups[1].Upstreams[0].LastProbe)
+		},
+	)
+
+	t.Run(
+		"rules_error", func() {
+			upstreamUseCase := t.newUseCase(1, &domain.Error{Message: "DB error", StatusCode: http.StatusBadRequest})
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			// the probes go on when the rules fail to load
+			t.Nil(upstreamUseCase.Run(ctx))
+
+			_, err := upstreamUseCase.ListUpstreams(context.Background())
+			t.NotNil(err)
+		},
+	)
+}
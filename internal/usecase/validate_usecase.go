@@ -0,0 +1,757 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+)
+
+// rootAnchors are the DS records of the root KSKs published by IANA
+var rootAnchors = []string{
+	".\t86400\tIN\tDS\t20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBB683457104237C7F8EC8D",
+	".\t86400\tIN\tDS\t38696 8 2 683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16",
+}
+
+// validatedAlgorithms are the algorithms which can be verified, the zones signed by the others are
+// insecure (RFC 4035 5.2)
+var validatedAlgorithms = map[uint8]bool{
+	dns.RSASHA1:          true,
+	dns.RSASHA1NSEC3SHA1: true,
+	dns.RSASHA256:        true,
+	dns.RSASHA512:        true,
+	dns.ECDSAP256SHA256:  true,
+	dns.ECDSAP384SHA384:  true,
+	dns.ED25519:          true,
+}
+
+const (
+	// trustTTL bounds how long the validated keys of a zone are trusted without asking again
+	trustTTL = time.Hour
+	// insecureTTL is how long a zone proven to be unsigned is trusted to be so
+	insecureTTL = 5 * time.Minute
+)
+
+type validateUseCase struct {
+	// forwardingRuleUseCase asks the upstream forwarders of the rules, the default ones, or the
+	// authoritative servers when resolving recursively, the same way as the answers are asked
+	forwardingRuleUseCase domain.ForwardingRuleUseCase
+
+	// anchors are the trusted DS records by the zones
+	anchors map[string][]*dns.DS
+
+	// zones are the validated keys by the zones, an unsigned zone has no keys
+	zones sync.Map
+}
+
+// trustedZone is the validated DNSKEY RRset of a zone
+type trustedZone struct {
+	keys   []*dns.DNSKEY
+	expire time.Time
+}
+
+// cut is what the DS query of a name proves
+type cut int
+
+const (
+	// notCut isn't proven to be a delegation
+	notCut cut = iota
+	// signedCut has a validated DS RRset
+	signedCut
+	// unsignedCut is proven to have no DS RRset, or to be in an unsigned zone
+	unsignedCut
+)
+
+// denial is the NSEC or NSEC3 records of the authority section
+type denial struct {
+	nsecs  []*dns.NSEC
+	nsec3s []*dns.NSEC3
+}
+
+func (v *validateUseCase) Validate(ctx context.Context, resp *dns.Msg) (bool, error) {
+	if len(resp.Question) != 1 || (resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError) {
+		return false, nil
+	}
+	q := resp.Question[0]
+
+	secure := true
+	var wildcards []*dns.RRSIG
+	answer, sigs := rrsets(resp.Answer)
+	for _, rrset := range answer {
+		ok, sig, err := v.verifyRRset(ctx, rrset, sigs)
+		if err != nil {
+			return false, err
+		}
+		secure = secure && ok
+		// the records expanded from a wildcard are signed by fewer labels (RFC 4035 5.3.4)
+		if sig != nil && int(sig.Labels) < dns.CountLabel(rrset[0].Header().Name) {
+			wildcards = append(wildcards, sig)
+		}
+	}
+
+	name, positive := answerTarget(q, resp.Answer)
+	if positive && len(wildcards) == 0 {
+		return secure, nil
+	}
+
+	var proof denial
+	authority, authSigs := rrsets(resp.Ns)
+	for _, rrset := range authority {
+		ok, _, err := v.verifyRRset(ctx, rrset, authSigs)
+		if err != nil {
+			return false, err
+		}
+		secure = secure && ok
+		proof.add(rrset)
+	}
+	if !secure {
+		return false, nil
+	}
+
+	switch {
+	case positive:
+		for _, sig := range wildcards {
+			secure, err := proof.expanded(sig.Hdr.Name, int(sig.Labels))
+			if err != nil || !secure {
+				return false, err
+			}
+		}
+		return true, nil
+	case resp.Rcode == dns.RcodeNameError:
+		return proof.nxdomain(name)
+	default:
+		return proof.nodata(name, q.Qtype)
+	}
+}
+
+// verifyRRset verifies the RRset by its signatures, an unsigned RRset is insecure when it's proven to
+// be outside the signed zones. It returns the signature which verifies the RRset.
+func (v *validateUseCase) verifyRRset(ctx context.Context, rrset []dns.RR,
+	sigs map[string][]*dns.RRSIG) (bool, *dns.RRSIG, error) {
+	header := rrset[0].Header()
+	if len(sigs[rrsetKey(header.Name, header.Rrtype)]) == 0 {
+		return false, nil, v.proveInsecure(ctx, header.Name)
+	}
+	return v.verifySigs(ctx, rrset, sigs, "")
+}
+
+// verifySigs verifies the RRset by the keys of the zones which sign it, the signer should be above the
+// cut when it's given
+func (v *validateUseCase) verifySigs(ctx context.Context, rrset []dns.RR, sigs map[string][]*dns.RRSIG,
+	cut string) (bool, *dns.RRSIG, error) {
+	header := rrset[0].Header()
+	err := bogus(header.Name, "no signature of %s is valid", dns.TypeToString[header.Rrtype])
+	for _, sig := range sigs[rrsetKey(header.Name, header.Rrtype)] {
+		if !dns.IsSubDomain(sig.SignerName, header.Name) ||
+			(cut != "" && (!dns.IsSubDomain(sig.SignerName, cut) || strings.EqualFold(sig.SignerName, cut))) {
+			continue
+		}
+
+		keys, keysErr := v.zoneKeys(ctx, sig.SignerName)
+		if keysErr != nil {
+			err = keysErr
+			continue
+		}
+		if keys == nil {
+			return false, nil, nil
+		}
+		for _, key := range keys {
+			if key.KeyTag() == sig.KeyTag && key.Algorithm == sig.Algorithm &&
+				sig.Verify(key, rrset) == nil && sig.ValidityPeriod(time.Now()) {
+				return true, sig, nil
+			}
+		}
+	}
+	return false, nil, err
+}
+
+// zoneKeys returns the keys of the zone which are validated by its trusted DS records, or nil when the
+// zone is unsigned
+func (v *validateUseCase) zoneKeys(ctx context.Context, zone string) ([]*dns.DNSKEY, error) {
+	zone = dns.CanonicalName(zone)
+	if cached, ok := v.zones.Load(zone); ok && time.Now().Before(cached.(*trustedZone).expire) {
+		return cached.(*trustedZone).keys, nil
+	}
+
+	ds, err := v.trustedDS(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	if ds == nil {
+		v.zones.Store(zone, &trustedZone{expire: time.Now().Add(insecureTTL)})
+		return nil, nil
+	}
+
+	resp, err := v.query(ctx, zone, dns.TypeDNSKEY)
+	if err != nil {
+		return nil, err
+	}
+	answer, sigs := rrsets(resp.Answer)
+	for _, rrset := range answer {
+		header := rrset[0].Header()
+		if header.Rrtype != dns.TypeDNSKEY || !strings.EqualFold(header.Name, zone) {
+			continue
+		}
+
+		// the DNSKEY RRset is signed by a key which matches a trusted DS (RFC 4035 5.2)
+		for _, sig := range sigs[rrsetKey(zone, dns.TypeDNSKEY)] {
+			for _, rr := range rrset {
+				key := rr.(*dns.DNSKEY)
+				if key.Flags&dns.ZONE == 0 || key.KeyTag() != sig.KeyTag || key.Algorithm != sig.Algorithm ||
+					!matchDS(key, ds) || sig.Verify(key, rrset) != nil || !sig.ValidityPeriod(time.Now()) {
+					continue
+				}
+
+				var keys []*dns.DNSKEY
+				for _, rr := range rrset {
+					if key := rr.(*dns.DNSKEY); key.Flags&dns.ZONE != 0 {
+						keys = append(keys, key)
+					}
+				}
+				ttl := min(time.Duration(header.Ttl)*time.Second, trustTTL)
+				v.zones.Store(zone, &trustedZone{keys, time.Now().Add(ttl)})
+				return keys, nil
+			}
+		}
+	}
+	return nil, bogus(zone, "no DNSKEY matches the trusted DS")
+}
+
+// trustedDS returns the DS records of the zone which are validated by its parent zone, or nil when the
+// zone is unsigned
+func (v *validateUseCase) trustedDS(ctx context.Context, zone string) ([]*dns.DS, error) {
+	if anchors, ok := v.anchors[zone]; ok {
+		return anchors, nil
+	}
+	anchored, err := v.anchored(ctx, zone)
+	if err != nil || !anchored {
+		return nil, err
+	}
+
+	resp, err := v.query(ctx, zone, dns.TypeDS)
+	if err != nil {
+		return nil, err
+	}
+	answer, sigs := rrsets(resp.Answer)
+	for _, rrset := range answer {
+		header := rrset[0].Header()
+		if header.Rrtype != dns.TypeDS || !strings.EqualFold(header.Name, zone) ||
+			len(sigs[rrsetKey(zone, dns.TypeDS)]) == 0 {
+			continue
+		}
+
+		secure, _, err := v.verifySigs(ctx, rrset, sigs, zone)
+		if err != nil || !secure {
+			return nil, err
+		}
+		var ds []*dns.DS
+		for _, rr := range rrset {
+			if validatedAlgorithms[rr.(*dns.DS).Algorithm] {
+				ds = append(ds, rr.(*dns.DS))
+			}
+		}
+		// the zone signed by unknown algorithms is treated as unsigned
+		return ds, nil
+	}
+
+	return nil, v.proveInsecure(ctx, zone)
+}
+
+// proveInsecure walks up from the name to the trust anchor for an unsigned delegation (RFC 4035 5.2),
+// the name is bogus when it's in a signed zone instead
+func (v *validateUseCase) proveInsecure(ctx context.Context, name string) error {
+	name = dns.CanonicalName(name)
+	anchored, err := v.anchored(ctx, name)
+	if err != nil || !anchored {
+		return err
+	}
+
+	for ancestor := name; ; ancestor = parentName(ancestor) {
+		if cached, ok := v.zones.Load(ancestor); ok && cached.(*trustedZone).keys == nil &&
+			time.Now().Before(cached.(*trustedZone).expire) {
+			return nil
+		}
+		if _, ok := v.anchors[ancestor]; ok {
+			break
+		}
+
+		resp, err := v.query(ctx, ancestor, dns.TypeDS)
+		if err != nil {
+			return err
+		}
+		c, err := v.delegation(ctx, ancestor, resp)
+		if err != nil {
+			return err
+		}
+		if c == signedCut {
+			break
+		}
+		if c == unsignedCut {
+			v.zones.Store(ancestor, &trustedZone{expire: time.Now().Add(insecureTTL)})
+			return nil
+		}
+	}
+	return bogus(name, "the records are unsigned in a signed zone")
+}
+
+// delegation tells what the DS response proves about the name
+func (v *validateUseCase) delegation(ctx context.Context, name string, resp *dns.Msg) (cut, error) {
+	answer, sigs := rrsets(resp.Answer)
+	for _, rrset := range answer {
+		header := rrset[0].Header()
+		if header.Rrtype != dns.TypeDS || !strings.EqualFold(header.Name, name) ||
+			len(sigs[rrsetKey(name, dns.TypeDS)]) == 0 {
+			continue
+		}
+		secure, _, err := v.verifySigs(ctx, rrset, sigs, name)
+		if err != nil {
+			return notCut, err
+		}
+		if secure {
+			return signedCut, nil
+		}
+		return unsignedCut, nil
+	}
+	if resp.Rcode != dns.RcodeSuccess || len(resp.Answer) > 0 {
+		return notCut, nil
+	}
+
+	var proof denial
+	authority, authSigs := rrsets(resp.Ns)
+	for _, rrset := range authority {
+		header := rrset[0].Header()
+		if header.Rrtype != dns.TypeNSEC && header.Rrtype != dns.TypeNSEC3 {
+			continue
+		}
+		if len(authSigs[rrsetKey(header.Name, header.Rrtype)]) == 0 {
+			return notCut, nil
+		}
+		secure, _, err := v.verifySigs(ctx, rrset, authSigs, name)
+		if err != nil {
+			return notCut, err
+		}
+		if !secure {
+			return unsignedCut, nil
+		}
+		proof.add(rrset)
+	}
+
+	// the delegation has NS but neither DS nor SOA (RFC 4035 5.2, RFC 5155 8.9)
+	for _, nsec := range proof.nsecs {
+		if strings.EqualFold(nsec.Hdr.Name, name) && hasType(nsec.TypeBitMap, dns.TypeNS) &&
+			!hasType(nsec.TypeBitMap, dns.TypeDS) && !hasType(nsec.TypeBitMap, dns.TypeSOA) {
+			return unsignedCut, nil
+		}
+	}
+	for _, nsec3 := range proof.nsec3s {
+		if nsec3.Match(name) && hasType(nsec3.TypeBitMap, dns.TypeNS) &&
+			!hasType(nsec3.TypeBitMap, dns.TypeDS) && !hasType(nsec3.TypeBitMap, dns.TypeSOA) {
+			return unsignedCut, nil
+		}
+	}
+	if _, _, optOut, ok := proof.closestEncloser(name); ok && optOut {
+		return unsignedCut, nil
+	}
+	return notCut, nil
+}
+
+// anchored reports whether a trust anchor is at or above the name. The suffixes of the forwarding rules
+// are negative trust anchors (RFC 7646) unless a trust anchor is at or below them, since their names
+// are served by the private zones outside the chain of trust of the public ones.
+func (v *validateUseCase) anchored(ctx context.Context, name string) (bool, error) {
+	suffix, err := v.forwardingRuleUseCase.MatchSuffix(ctx, name)
+	if err != nil {
+		return false, domain.Error{
+			Message: fmt.Sprintf("cannot match the forwarding rule of %s: %s", name, err.Error()),
+			Err:     domain.ErrNoUpstream,
+		}
+	}
+	for anchor := range v.anchors {
+		if dns.IsSubDomain(anchor, name) && (suffix == "" || suffix == "." || dns.IsSubDomain(suffix, anchor)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// query asks the upstream forwarders matching the name, or the authoritative servers when resolving
+// recursively, for the signed records, the bogus ones are returned as well
+func (v *validateUseCase) query(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	req := new(dns.Msg)
+	req.SetQuestion(name, qtype)
+	req.CheckingDisabled = true
+	req.SetEdns0(dns.DefaultMsgSize, true)
+
+	resp, err := v.forwardingRuleUseCase.Forward(
+		ctx, req, func(resp *dns.Msg) bool {
+			return resp.Rcode == dns.RcodeSuccess || resp.Rcode == dns.RcodeNameError
+		},
+	)
+	if err != nil {
+		return nil, domain.Error{
+			Message: fmt.Sprintf("cannot get %s %s from upstream forwarder", name, dns.TypeToString[qtype]),
+			Err:     domain.ErrNoUpstream,
+		}
+	}
+	return resp, nil
+}
+
+func (d *denial) add(rrset []dns.RR) {
+	for _, rr := range rrset {
+		switch rr := rr.(type) {
+		case *dns.NSEC:
+			d.nsecs = append(d.nsecs, rr)
+		case *dns.NSEC3:
+			d.nsec3s = append(d.nsec3s, rr)
+		}
+	}
+}
+
+// nxdomain proves that neither the name nor the wildcard of its closest encloser exists
+// (RFC 4035 5.4, RFC 5155 8.4)
+func (d *denial) nxdomain(name string) (bool, error) {
+	if len(d.nsec3s) > 0 {
+		if d.weakNsec3() {
+			return false, nil
+		}
+		encloser, _, optOut, ok := d.closestEncloser(name)
+		if !ok || !d.coverNsec3("*."+encloser) {
+			return false, bogus(name, "NXDOMAIN isn't proven by NSEC3")
+		}
+		// an opt-out span may hide an unsigned delegation
+		return !optOut, nil
+	}
+
+	nsec := d.coverNsec(name)
+	if nsec == nil || d.coverNsec("*."+nsecEncloser(name, nsec)) == nil {
+		return false, bogus(name, "NXDOMAIN isn't proven by NSEC")
+	}
+	return true, nil
+}
+
+// nodata proves that the name has no records of the type, the name may be an empty non-terminal or
+// match a wildcard of other types (RFC 4035 5.4, RFC 5155 8.5-8.7)
+func (d *denial) nodata(name string, qtype uint16) (bool, error) {
+	lacks := func(types []uint16) bool {
+		return !hasType(types, qtype) && !hasType(types, dns.TypeCNAME)
+	}
+
+	if len(d.nsec3s) > 0 {
+		if d.weakNsec3() {
+			return false, nil
+		}
+		for _, nsec3 := range d.nsec3s {
+			if nsec3.Match(name) {
+				if lacks(nsec3.TypeBitMap) {
+					return true, nil
+				}
+				return false, bogus(name, "the NSEC3 has the type %s", dns.TypeToString[qtype])
+			}
+		}
+		encloser, _, optOut, ok := d.closestEncloser(name)
+		if ok && optOut && qtype == dns.TypeDS {
+			return false, nil
+		}
+		for _, nsec3 := range d.nsec3s {
+			if ok && nsec3.Match("*."+encloser) && lacks(nsec3.TypeBitMap) {
+				return true, nil
+			}
+		}
+		return false, bogus(name, "NODATA isn't proven by NSEC3")
+	}
+
+	for _, nsec := range d.nsecs {
+		if strings.EqualFold(nsec.Hdr.Name, name) {
+			if lacks(nsec.TypeBitMap) {
+				return true, nil
+			}
+			return false, bogus(name, "the NSEC has the type %s", dns.TypeToString[qtype])
+		}
+	}
+	if nsec := d.coverNsec(name); nsec != nil {
+		if dns.IsSubDomain(name, nsec.NextDomain) {
+			return true, nil
+		}
+		wildcard := "*." + nsecEncloser(name, nsec)
+		for _, nsec := range d.nsecs {
+			if strings.EqualFold(nsec.Hdr.Name, wildcard) && lacks(nsec.TypeBitMap) {
+				return true, nil
+			}
+		}
+	}
+	return false, bogus(name, "NODATA isn't proven by NSEC")
+}
+
+// expanded proves that the name doesn't exist, so that the wildcard of the labels applies to it
+// (RFC 4035 5.3.4, RFC 5155 8.8)
+func (d *denial) expanded(name string, labels int) (bool, error) {
+	if len(d.nsec3s) > 0 {
+		if d.weakNsec3() {
+			return false, nil
+		}
+		nextCloser := lastLabels(name, labels+1)
+		for _, nsec3 := range d.nsec3s {
+			if nsec3.Cover(nextCloser) && !nsec3.Match(nextCloser) {
+				return nsec3.Flags&1 == 0, nil
+			}
+		}
+	} else if d.coverNsec(name) != nil {
+		return true, nil
+	}
+	return false, bogus(name, "the wildcard expansion isn't proven")
+}
+
+// closestEncloser returns the closest encloser of the name which has a matching NSEC3, and the next
+// closer name which is covered (RFC 5155 8.3)
+func (d *denial) closestEncloser(name string) (string, string, bool, bool) {
+	nextCloser := name
+	for encloser := parentName(name); ; encloser = parentName(encloser) {
+		for _, match := range d.nsec3s {
+			if !match.Match(encloser) {
+				continue
+			}
+			for _, cover := range d.nsec3s {
+				if cover.Cover(nextCloser) && !cover.Match(nextCloser) {
+					return encloser, nextCloser, cover.Flags&1 != 0, true
+				}
+			}
+			return "", "", false, false
+		}
+		if encloser == "." {
+			return "", "", false, false
+		}
+		nextCloser = encloser
+	}
+}
+
+func (d *denial) coverNsec3(name string) bool {
+	for _, nsec3 := range d.nsec3s {
+		if nsec3.Cover(name) && !nsec3.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// weakNsec3 reports whether the NSEC3 records have more iterations than validators should
+// calculate, which are treated as insecure (RFC 9276 3.2)
+func (d *denial) weakNsec3() bool {
+	for _, nsec3 := range d.nsec3s {
+		if nsec3.Iterations > maxNsec3Iterations {
+			return true
+		}
+	}
+	return false
+}
+
+// coverNsec returns the NSEC whose span covers the name
+func (d *denial) coverNsec(name string) *dns.NSEC {
+	for _, nsec := range d.nsecs {
+		owner, next := nsec.Hdr.Name, nsec.NextDomain
+		if compareCanonical(owner, name) >= 0 {
+			continue
+		}
+		// the last NSEC of the zone wraps around to the apex
+		if compareCanonical(name, next) < 0 || (compareCanonical(next, owner) <= 0 && dns.IsSubDomain(next, name)) {
+			return nsec
+		}
+	}
+	return nil
+}
+
+// nsecEncloser returns the closest encloser of the name which the NSEC covers, which is the longest
+// common ancestor of the name with the ends of the NSEC
+func nsecEncloser(name string, nsec *dns.NSEC) string {
+	labels := max(dns.CompareDomainName(name, nsec.Hdr.Name), dns.CompareDomainName(name, nsec.NextDomain))
+	return lastLabels(name, labels)
+}
+
+// lastLabels returns the ancestor of the name of the labels
+func lastLabels(name string, labels int) string {
+	indexes := dns.Split(name)
+	if labels <= 0 {
+		return "."
+	}
+	if labels >= len(indexes) {
+		return name
+	}
+	return name[indexes[len(indexes)-labels]:]
+}
+
+// compareCanonical compares the names in the canonical order (RFC 4034 6.1), the NSEC proofs, the
+// NSEC chains and the exports all share it
+func compareCanonical(a string, b string) int {
+	la, lb := wireLabels(a), wireLabels(b)
+	for i := 1; i <= len(la) && i <= len(lb); i++ {
+		if c := bytes.Compare(la[len(la)-i], lb[len(lb)-i]); c != 0 {
+			return c
+		}
+	}
+	return len(la) - len(lb)
+}
+
+// wireLabels returns the lower cased octets of the labels of the name
+func wireLabels(name string) [][]byte {
+	wire := make([]byte, 256)
+	n, err := dns.PackDomainName(dns.CanonicalName(name), wire, 0, nil, false)
+	if err != nil {
+		return nil
+	}
+	var labels [][]byte
+	for i := 0; i < n && wire[i] != 0; i += int(wire[i]) + 1 {
+		labels = append(labels, lowerLabel(wire[i+1:i+1+int(wire[i])]))
+	}
+	return labels
+}
+
+// lowerLabel returns a copy of the label with the ASCII upper cases lowered, the other octets are kept
+// as they are since the labels are not UTF-8
+func lowerLabel(label []byte) []byte {
+	lower := make([]byte, len(label))
+	for i, c := range label {
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		lower[i] = c
+	}
+	return lower
+}
+
+// rrsets groups the records into RRsets in order, and the signatures by the RRsets they cover
+func rrsets(rrs []dns.RR) ([][]dns.RR, map[string][]*dns.RRSIG) {
+	var (
+		keys   []string
+		groups = make(map[string][]dns.RR)
+		sigs   = make(map[string][]*dns.RRSIG)
+	)
+	for _, rr := range rrs {
+		header := rr.Header()
+		switch rr := rr.(type) {
+		case *dns.OPT:
+			continue
+		case *dns.RRSIG:
+			key := rrsetKey(header.Name, rr.TypeCovered)
+			sigs[key] = append(sigs[key], rr)
+			continue
+		}
+
+		key := rrsetKey(header.Name, header.Rrtype)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], rr)
+	}
+
+	var result [][]dns.RR
+	for _, key := range keys {
+		result = append(result, groups[key])
+	}
+	return result, sigs
+}
+
+func rrsetKey(name string, rrtype uint16) string {
+	return fmt.Sprintf("%s %d", dns.CanonicalName(name), rrtype)
+}
+
+func hasType(types []uint16, rrtype uint16) bool {
+	for _, t := range types {
+		if t == rrtype {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDS reports whether the key is one of the DS records
+func matchDS(key *dns.DNSKEY, records []*dns.DS) bool {
+	for _, ds := range records {
+		if ds.KeyTag != key.KeyTag() || ds.Algorithm != key.Algorithm {
+			continue
+		}
+		digest := key.ToDS(ds.DigestType)
+		if digest != nil && strings.EqualFold(digest.Digest, ds.Digest) {
+			return true
+		}
+	}
+	return false
+}
+
+func bogus(name string, format string, args ...interface{}) error {
+	return domain.Error{
+		Message: fmt.Sprintf("bogus DNSSEC of %s: %s", name, fmt.Sprintf(format, args...)),
+		Err:     domain.ErrDnssecBogus,
+	}
+}
+
+// loadTrustAnchors reads the DS or DNSKEY records of the trust anchors from the master file, the
+// anchors are the root KSKs when no file is given
+func loadTrustAnchors(file string) (map[string][]*dns.DS, error) {
+	var rrs []dns.RR
+	if file == "" {
+		for _, s := range rootAnchors {
+			rr, err := dns.NewRR(s)
+			if err != nil {
+				return nil, err
+			}
+			rrs = append(rrs, rr)
+		}
+	} else {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+
+		parser := dns.NewZoneParser(f, ".", file)
+		for rr, ok := parser.Next(); ok; rr, ok = parser.Next() {
+			rrs = append(rrs, rr)
+		}
+		if err = parser.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	anchors := make(map[string][]*dns.DS)
+	for _, rr := range rrs {
+		var ds *dns.DS
+		switch rr := rr.(type) {
+		case *dns.DS:
+			ds = rr
+		case *dns.DNSKEY:
+			ds = rr.ToDS(dns.SHA256)
+		}
+		if ds == nil {
+			continue
+		}
+		name := dns.CanonicalName(ds.Hdr.Name)
+		anchors[name] = append(anchors[name], ds)
+	}
+	if len(anchors) == 0 {
+		return nil, fmt.Errorf("no DS or DNSKEY record of trust anchors in %s", file)
+	}
+	return anchors, nil
+}
+
+// NewValidateUseCase returns nil when the validation isn't enabled
+func NewValidateUseCase(injector *do.Injector) (domain.ValidateUseCase, error) {
+	env := do.MustInvoke[*domain.Options](injector)
+	if !env.DnssecValidation {
+		return nil, nil
+	}
+
+	anchors, err := loadTrustAnchors(env.TrustAnchorFile)
+	if err != nil {
+		return nil, err
+	}
+	return &validateUseCase{
+		forwardingRuleUseCase: do.MustInvoke[domain.ForwardingRuleUseCase](injector),
+		anchors:               anchors,
+	}, nil
+}
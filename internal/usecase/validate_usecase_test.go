@@ -0,0 +1,466 @@
+package usecase
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+	"github.com/cewuandy/go-restful-dns/pkg/forwarder"
+)
+
+type validateUseCaseTestSuite struct {
+	suite.Suite
+
+	usecase domain.ValidateUseCase
+
+	upstream *dns.Server
+
+	// answers are the responses of the upstream by the questions
+	answers map[string]*dns.Msg
+	// queries count the questions which the upstream is asked
+	queries sync.Map
+
+	anchorFile string
+}
+
+func TestValidateUseCase(t *testing.T) {
+	suite.Run(t, &validateUseCaseTestSuite{})
+}
+
+func (t *validateUseCaseTestSuite) SetupSuite() {
+	t.answers = make(map[string]*dns.Msg)
+	t.signZones()
+	t.upstream = t.startSignedUpstream()
+
+	t.usecase = t.newUseCase(nil)
+}
+
+func (t *validateUseCaseTestSuite) TearDownSuite() {
+	t.Nil(t.upstream.Shutdown())
+}
+
+// newUseCase validates by the signed upstream, which serves the corp.test. rule as well
+func (t *validateUseCaseTestSuite) newUseCase(rulesErr error) domain.ValidateUseCase {
+	addr := t.upstream.PacketConn.LocalAddr().String()
+	forwardingRuleRepo := &mocks.ForwardingRuleRepo{}
+	forwardingRuleRepo.
+		On("List", mock.Anything).
+		Return([]*domain.ForwardingRule{{Suffix: "corp.test.", Upstreams: []string{addr}}}, rulesErr)
+
+	injector := do.New()
+	do.ProvideValue(
+		injector, &domain.Options{
+			DnssecValidation:  true,
+			TrustAnchorFile:   t.anchorFile,
+			UpstreamParallel:  1,
+			UpstreamMaxFails:  3,
+			UpstreamEjectTime: 60,
+		},
+	)
+	forwarders, _ := forwarder.NewPool("", false)
+	upstreams, _ := forwarder.NewGroup(forwarders, []string{addr}, forwarder.Sequential, 1, 3, time.Minute)
+	do.ProvideValue(injector, forwarders)
+	do.ProvideValue(injector, upstreams)
+	do.ProvideValue[domain.ForwardingRuleRepo](injector, forwardingRuleRepo)
+	do.ProvideValue[domain.ResolveUseCase](injector, nil)
+	do.Provide(injector, NewForwardingRuleUseCase)
+	usecase, err := NewValidateUseCase(injector)
+	t.Nil(err)
+	return usecase
+}
+
+func (t *validateUseCaseTestSuite) rr(s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	t.Nil(err)
+	return rr
+}
+
+func (t *validateUseCaseTestSuite) newKey(zone string) (*dns.DNSKEY, crypto.Signer) {
+	key := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: zone, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     dns.ZONE | dns.SEP,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	private, err := key.Generate(256)
+	t.Nil(err)
+	return key, private.(crypto.Signer)
+}
+
+// sign appends the signature of the RRset which is valid from an hour ago to an hour later
+func (t *validateUseCaseTestSuite) sign(key *dns.DNSKEY, signer crypto.Signer, rrset ...dns.RR) []dns.RR {
+	return t.signAt(key, signer, time.Now().Add(time.Hour), rrset...)
+}
+
+func (t *validateUseCaseTestSuite) signAt(key *dns.DNSKEY, signer crypto.Signer, expiration time.Time,
+	rrset ...dns.RR) []dns.RR {
+	sig := &dns.RRSIG{
+		Hdr:        dns.RR_Header{Ttl: rrset[0].Header().Ttl},
+		Algorithm:  key.Algorithm,
+		KeyTag:     key.KeyTag(),
+		SignerName: key.Hdr.Name,
+		Inception:  uint32(time.Now().Add(-time.Hour).Unix()),
+		Expiration: uint32(expiration.Unix()),
+	}
+	t.Nil(sig.Sign(signer, rrset))
+	return append(rrset, sig)
+}
+
+func (t *validateUseCaseTestSuite) answer(name string, qtype uint16, rcode int, answer []dns.RR, ns ...[]dns.RR) {
+	msg := &dns.Msg{MsgHdr: dns.MsgHdr{Rcode: rcode}, Answer: answer}
+	for _, rrs := range ns {
+		msg.Ns = append(msg.Ns, rrs...)
+	}
+	t.answers[fmt.Sprintf("%s %d", name, qtype)] = msg
+}
+
+// signZones signs test. as the trust anchor, secure.test. and nsec3.test. as its signed children and
+// insecure.test. as its unsigned child, corp.test. is the unsigned zone of a forwarding rule
+func (t *validateUseCaseTestSuite) signZones() {
+	testKey, testSigner := t.newKey("test.")
+	secureKey, secureSigner := t.newKey("secure.test.")
+	nsec3Key, nsec3Signer := t.newKey("nsec3.test.")
+
+	t.anchorFile = filepath.Join(t.T().TempDir(), "anchors.zone")
+	t.Nil(os.WriteFile(t.anchorFile, []byte(testKey.String()+"\n"), 0o600))
+
+	testSOA := t.sign(testKey, testSigner, t.rr("test.\t300\tIN\tSOA\tns.test. admin.test. 1 7200 3600 86400 300"))
+	secureSOA := t.sign(
+		secureKey, secureSigner,
+		t.rr("secure.test.\t300\tIN\tSOA\tns.secure.test. admin.secure.test. 1 7200 3600 86400 300"),
+	)
+	nsec3SOA := t.sign(
+		nsec3Key, nsec3Signer,
+		t.rr("nsec3.test.\t300\tIN\tSOA\tns.nsec3.test. admin.nsec3.test. 1 7200 3600 86400 300"),
+	)
+	nsec := func(owner string, next string, types ...uint16) *dns.NSEC {
+		return &dns.NSEC{
+			Hdr:        dns.RR_Header{Name: owner, Rrtype: dns.TypeNSEC, Class: dns.ClassINET, Ttl: 300},
+			NextDomain: next,
+			TypeBitMap: types,
+		}
+	}
+	apexNSEC := t.sign(
+		secureKey, secureSigner,
+		nsec("secure.test.", "www.secure.test.", dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG, dns.TypeNSEC, dns.TypeDNSKEY),
+	)
+	wwwNSEC := t.sign(
+		secureKey, secureSigner, nsec("www.secure.test.", "\\000.www.secure.test.", dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC),
+	)
+
+	// the keys and the delegations
+	t.answer("test.", dns.TypeDNSKEY, dns.RcodeSuccess, t.sign(testKey, testSigner, testKey))
+	t.answer("secure.test.", dns.TypeDNSKEY, dns.RcodeSuccess, t.sign(secureKey, secureSigner, secureKey))
+	t.answer("secure.test.", dns.TypeDS, dns.RcodeSuccess, t.sign(testKey, testSigner, secureKey.ToDS(dns.SHA256)))
+	t.answer("nsec3.test.", dns.TypeDNSKEY, dns.RcodeSuccess, t.sign(nsec3Key, nsec3Signer, nsec3Key))
+	t.answer("nsec3.test.", dns.TypeDS, dns.RcodeSuccess, t.sign(testKey, testSigner, nsec3Key.ToDS(dns.SHA256)))
+	t.answer(
+		"insecure.test.", dns.TypeDS, dns.RcodeSuccess, nil, testSOA,
+		t.sign(testKey, testSigner, nsec("insecure.test.", "nsec3.test.", dns.TypeNS, dns.TypeRRSIG, dns.TypeNSEC)),
+	)
+	t.answer(
+		"www.insecure.test.", dns.TypeDS, dns.RcodeSuccess, nil,
+		[]dns.RR{t.rr("insecure.test.\t300\tIN\tSOA\tns.insecure.test. admin.insecure.test. 1 7200 3600 86400 300")},
+	)
+	t.answer(
+		"unsigned.secure.test.", dns.TypeDS, dns.RcodeSuccess, nil, secureSOA,
+		t.sign(
+			secureKey, secureSigner,
+			nsec("unsigned.secure.test.", "\\000.unsigned.secure.test.", dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC),
+		),
+	)
+
+	// the answers
+	www := t.rr("www.secure.test.\t300\tIN\tA\t192.0.2.1")
+	t.answer("www.secure.test.", dns.TypeA, dns.RcodeSuccess, t.sign(secureKey, secureSigner, www))
+	t.answer(
+		"alias.secure.test.", dns.TypeA, dns.RcodeSuccess, append(
+			t.sign(secureKey, secureSigner, t.rr("alias.secure.test.\t300\tIN\tCNAME\twww.secure.test.")),
+			t.sign(secureKey, secureSigner, www)...,
+		),
+	)
+	forged := t.sign(secureKey, secureSigner, t.rr("bogus.secure.test.\t300\tIN\tA\t192.0.2.99"))
+	forged[0].(*dns.A).A = net.ParseIP("192.0.2.2")
+	t.answer("bogus.secure.test.", dns.TypeA, dns.RcodeSuccess, forged)
+	t.answer(
+		"expired.secure.test.", dns.TypeA, dns.RcodeSuccess,
+		t.signAt(secureKey, secureSigner, time.Now().Add(-time.Minute), t.rr("expired.secure.test.\t300\tIN\tA\t192.0.2.3")),
+	)
+	t.answer(
+		"unsigned.secure.test.", dns.TypeA, dns.RcodeSuccess, []dns.RR{t.rr("unsigned.secure.test.\t300\tIN\tA\t192.0.2.4")},
+	)
+	t.answer(
+		"www.insecure.test.", dns.TypeA, dns.RcodeSuccess, []dns.RR{t.rr("www.insecure.test.\t300\tIN\tA\t192.0.2.5")},
+	)
+
+	// the wildcard expansions are signed by the wildcards
+	expand := func(name string) []dns.RR {
+		rrs := t.sign(secureKey, secureSigner, t.rr("*.secure.test.\t300\tIN\tA\t192.0.2.6"))
+		for _, rr := range rrs {
+			rr.Header().Name = name
+		}
+		return rrs
+	}
+	t.answer("wild.secure.test.", dns.TypeA, dns.RcodeSuccess, expand("wild.secure.test."), apexNSEC)
+	t.answer("nowild.secure.test.", dns.TypeA, dns.RcodeSuccess, expand("nowild.secure.test."))
+
+	// the denials by NSEC
+	t.answer("nx.secure.test.", dns.TypeA, dns.RcodeNameError, nil, secureSOA, apexNSEC)
+	t.answer("nxbad.secure.test.", dns.TypeA, dns.RcodeNameError, nil, secureSOA, wwwNSEC)
+	t.answer("www.secure.test.", dns.TypeAAAA, dns.RcodeSuccess, nil, secureSOA, wwwNSEC)
+	t.answer("www.secure.test.", dns.TypeMX, dns.RcodeSuccess, nil, secureSOA)
+	t.answer(
+		"www.secure.test.", dns.TypeTXT, dns.RcodeSuccess, nil, secureSOA,
+		t.sign(
+			secureKey, secureSigner,
+			nsec("www.secure.test.", "\\000.www.secure.test.", dns.TypeTXT, dns.TypeRRSIG, dns.TypeNSEC),
+		),
+	)
+
+	// the denials by NSEC3
+	zone := &signedZone{&domain.Zone{Name: "nsec3.test."}, &domain.Dnssec{Nsec3: true}}
+	apexTypes := []uint16{dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG, dns.TypeDNSKEY, dns.TypeNSEC3PARAM}
+	apexNSEC3 := t.sign(nsec3Key, nsec3Signer, zone.matchNSEC3("nsec3.test.", apexTypes, 300))
+	t.answer(
+		"nx.nsec3.test.", dns.TypeA, dns.RcodeNameError, nil, nsec3SOA, apexNSEC3,
+		t.sign(nsec3Key, nsec3Signer, zone.coverNSEC3("nx.nsec3.test.", 300)),
+		t.sign(nsec3Key, nsec3Signer, zone.coverNSEC3("*.nsec3.test.", 300)),
+	)
+	t.answer(
+		"nxbad.nsec3.test.", dns.TypeA, dns.RcodeNameError, nil, nsec3SOA, apexNSEC3,
+		t.sign(nsec3Key, nsec3Signer, zone.coverNSEC3("nxbad.nsec3.test.", 300)),
+	)
+	optOut := zone.coverNSEC3("optout.nsec3.test.", 300)
+	optOut.Flags = 1
+	t.answer(
+		"optout.nsec3.test.", dns.TypeA, dns.RcodeNameError, nil, nsec3SOA, apexNSEC3,
+		t.sign(nsec3Key, nsec3Signer, optOut),
+		t.sign(nsec3Key, nsec3Signer, zone.coverNSEC3("*.nsec3.test.", 300)),
+	)
+	t.answer(
+		"nsec3.test.", dns.TypeA, dns.RcodeSuccess, nil, nsec3SOA, apexNSEC3,
+	)
+
+	t.answer("www.corp.test.", dns.TypeA, dns.RcodeSuccess, []dns.RR{t.rr("www.corp.test.\t300\tIN\tA\t192.0.2.9")})
+}
+
+// startSignedUpstream answers the signed zones, and SERVFAIL for the questions it doesn't know
+func (t *validateUseCaseTestSuite) startSignedUpstream() *dns.Server {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	t.Nil(err)
+
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn:        pc,
+		NotifyStartedFunc: func() { close(started) },
+		Handler: dns.HandlerFunc(
+			func(w dns.ResponseWriter, req *dns.Msg) {
+				q := req.Question[0]
+				key := fmt.Sprintf("%s %d", q.Name, q.Qtype)
+				count, _ := t.queries.LoadOrStore(key, new(int))
+				*count.(*int)++
+
+				resp := new(dns.Msg)
+				resp.SetReply(req)
+				msg, ok := t.answers[key]
+				if !ok {
+					resp.Rcode = dns.RcodeServerFailure
+				} else {
+					resp.Rcode = msg.Rcode
+					resp.Answer = msg.Answer
+					resp.Ns = msg.Ns
+				}
+				_ = w.WriteMsg(resp)
+			},
+		),
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	<-started
+
+	return server
+}
+
+// exchange returns the response of the upstream to be validated
+func (t *validateUseCaseTestSuite) exchange(name string, qtype uint16) *dns.Msg {
+	req := new(dns.Msg)
+	req.SetQuestion(name, qtype)
+	req.CheckingDisabled = true
+	req.SetEdns0(dns.DefaultMsgSize, true)
+
+	resp, _, err := new(dns.Client).Exchange(req, t.upstream.PacketConn.LocalAddr().String())
+	t.Nil(err)
+	return resp
+}
+
+func (t *validateUseCaseTestSuite) queried(name string, qtype uint16) int {
+	count, ok := t.queries.Load(fmt.Sprintf("%s %d", name, qtype))
+	if !ok {
+		return 0
+	}
+	return *count.(*int)
+}
+
+func (t *validateUseCaseTestSuite) TestValidate() {
+	cases := []struct {
+		name   string
+		qname  string
+		qtype  uint16
+		secure bool
+		bogus  string
+	}{
+		{"answer_secure", "www.secure.test.", dns.TypeA, true, ""},
+		{"cname_secure", "alias.secure.test.", dns.TypeA, true, ""},
+		{"wildcard_secure", "wild.secure.test.", dns.TypeA, true, ""},
+		{"nsec_nxdomain_secure", "nx.secure.test.", dns.TypeA, true, ""},
+		{"nsec_nodata_secure", "www.secure.test.", dns.TypeAAAA, true, ""},
+		{"nsec3_nxdomain_secure", "nx.nsec3.test.", dns.TypeA, true, ""},
+		{"nsec3_nodata_secure", "nsec3.test.", dns.TypeA, true, ""},
+		{"unsigned_delegation_insecure", "www.insecure.test.", dns.TypeA, false, ""},
+		{"nsec3_opt_out_insecure", "optout.nsec3.test.", dns.TypeA, false, ""},
+		{"rule_suffix_insecure", "www.corp.test.", dns.TypeA, false, ""},
+		{"forged_bogus", "bogus.secure.test.", dns.TypeA, false, "no signature of A is valid"},
+		{"expired_bogus", "expired.secure.test.", dns.TypeA, false, "no signature of A is valid"},
+		{"unsigned_bogus", "unsigned.secure.test.", dns.TypeA, false, "unsigned in a signed zone"},
+		{"wildcard_bogus", "nowild.secure.test.", dns.TypeA, false, "wildcard expansion isn't proven"},
+		{"nsec_nxdomain_bogus", "nxbad.secure.test.", dns.TypeA, false, "NXDOMAIN isn't proven by NSEC"},
+		{"nsec_nodata_bogus", "www.secure.test.", dns.TypeMX, false, "NODATA isn't proven by NSEC"},
+		{"nsec_type_bogus", "www.secure.test.", dns.TypeTXT, false, "the NSEC has the type TXT"},
+		{"nsec3_nxdomain_bogus", "nxbad.nsec3.test.", dns.TypeA, false, "NXDOMAIN isn't proven by NSEC3"},
+	}
+	for _, c := range cases {
+		t.Run(
+			c.name, func() {
+				secure, err := t.usecase.Validate(context.Background(), t.exchange(c.qname, c.qtype))
+				t.Equal(c.secure, secure)
+				if c.bogus == "" {
+					t.Nil(err)
+				} else {
+					t.NotNil(err)
+					t.Contains(err.Error(), c.bogus)
+				}
+			},
+		)
+	}
+
+	t.Run(
+		"cached_keys", func() {
+			usecase := t.newUseCase(nil)
+			before := t.queried("secure.test.", dns.TypeDNSKEY)
+			for i := 0; i < 2; i++ {
+				secure, err := usecase.Validate(context.Background(), t.exchange("www.secure.test.", dns.TypeA))
+				t.Nil(err)
+				t.True(secure)
+			}
+			t.Equal(before+1, t.queried("secure.test.", dns.TypeDNSKEY))
+
+			before = t.queried("insecure.test.", dns.TypeDS)
+			for i := 0; i < 2; i++ {
+				secure, err := usecase.Validate(context.Background(), t.exchange("www.insecure.test.", dns.TypeA))
+				t.Nil(err)
+				t.False(secure)
+			}
+			t.Equal(before+1, t.queried("insecure.test.", dns.TypeDS))
+		},
+	)
+
+	t.Run(
+		"rules_error", func() {
+			usecase := t.newUseCase(errors.New("DB error"))
+			_, err := usecase.Validate(context.Background(), t.exchange("www.corp.test.", dns.TypeA))
+			t.NotNil(err)
+		},
+	)
+
+	t.Run(
+		"servfail_ignored", func() {
+			secure, err := t.usecase.Validate(
+				context.Background(), &dns.Msg{
+					MsgHdr:   dns.MsgHdr{Rcode: dns.RcodeServerFailure},
+					Question: []dns.Question{{Name: "www.secure.test.", Qtype: dns.TypeA, Qclass: dns.ClassINET}},
+				},
+			)
+			t.Nil(err)
+			t.False(secure)
+		},
+	)
+}
+
+func (t *validateUseCaseTestSuite) TestNewValidateUseCase() {
+	t.Run(
+		"disabled_success", func() {
+			injector := do.New()
+			do.ProvideValue(injector, &domain.Options{})
+			usecase, err := NewValidateUseCase(injector)
+			t.Nil(err)
+			t.Nil(usecase)
+		},
+	)
+
+	t.Run(
+		"root_anchors_success", func() {
+			anchors, err := loadTrustAnchors("")
+			t.Nil(err)
+			t.Len(anchors["."], 2)
+			t.Equal(uint16(20326), anchors["."][0].KeyTag)
+		},
+	)
+
+	t.Run(
+		"file_error", func() {
+			_, err := loadTrustAnchors(filepath.Join(t.T().TempDir(), "missing.zone"))
+			t.NotNil(err)
+
+			empty := filepath.Join(t.T().TempDir(), "empty.zone")
+			t.Nil(os.WriteFile(empty, []byte("test.\t300\tIN\tA\t192.0.2.1\n"), 0o600))
+			_, err = loadTrustAnchors(empty)
+			t.NotNil(err)
+		},
+	)
+}
+
+func (t *validateUseCaseTestSuite) TestCompareCanonical() {
+	// the example of RFC 4034 6.1, the labels are compared as octets of the wire format
+	names := []string{
+		"example.",
+		"a.example.",
+		"yljkjljk.a.example.",
+		"Z.a.example.",
+		"zABC.a.EXAMPLE.",
+		"z.example.",
+		"\\001.z.example.",
+		"*.z.example.",
+		"\\200.z.example.",
+	}
+	for i := 1; i < len(names); i++ {
+		t.Negative(compareCanonical(names[i-1], names[i]), names[i])
+		t.Positive(compareCanonical(names[i], names[i-1]), names[i])
+	}
+	t.Zero(compareCanonical("Z.a.example.", "z.A.EXAMPLE."))
+	// the octets above 0x7F are not UTF-8
+	t.Negative(compareCanonical("\\200.example.", "\\255.example."))
+	t.Negative(compareCanonical("\\200.example.", "\\201.example."))
+
+	var rrs []dns.RR
+	for i := len(names) - 1; i >= 0; i-- {
+		rrs = append(rrs, t.rr(names[i]+"\t300\tIN\tA\t192.0.2.1"))
+	}
+	sortCanonical(rrs)
+	for i, rr := range rrs {
+		t.Equal(dns.CanonicalName(names[i]), dns.CanonicalName(rr.Header().Name))
+	}
+}
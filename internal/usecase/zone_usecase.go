@@ -0,0 +1,774 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/samber/do"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/utils"
+)
+
+type zoneUseCase struct {
+	redisRepo domain.RedisRepo
+
+	zoneRepo domain.ZoneRepo
+
+	recordRepo domain.RecordRepo
+
+	journalRepo domain.JournalRepo
+
+	dnssecRepo domain.DnssecRepo
+
+	tsigKeyRepo domain.TsigKeyRepo
+
+	notifyUseCase domain.NotifyUseCase
+}
+
+// zoneEntry is an entry of a master file, which spans several lines when it has parentheses
+type zoneEntry struct {
+	line int
+	text string
+}
+
+// zoneRRset is a RRset of a master file, line is where its first record is
+type zoneRRset struct {
+	line int
+	rrs  []dns.RR
+}
+
+func (z *zoneUseCase) CreateZone(ctx context.Context, zone *domain.Zone) error {
+	zone.Name = utils.GetFQDNFromDomainName(zone.Name)
+	zone.SetDefaults()
+	zone.Notify = notifyAddrs(zone.Notify)
+	if zone.IsSecondary() {
+		primary, err := primaryAddr(zone.Primary)
+		if err != nil {
+			return err
+		}
+		tsigKey, err := z.tsigKeyName(ctx, zone.TsigKey)
+		if err != nil {
+			return err
+		}
+		// the SOA of a secondary zone is unknown until it's transferred from the primary
+		zone.Primary = primary
+		zone.TsigKey = tsigKey
+		zone.Serial = 0
+		zone.Refreshed = nil
+	} else {
+		zone.Primary = ""
+		zone.TsigKey = ""
+		zone.Serial = nextSerial(zone.Serial)
+	}
+
+	existed, _ := z.zoneRepo.Get(ctx, zone.Name)
+	if existed != nil {
+		return &domain.Error{
+			Message:    "the zone is already existed.",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	err := z.zoneRepo.Create(ctx, zone)
+	if err != nil || zone.IsSecondary() {
+		return err
+	}
+
+	return cacheZone(ctx, z.redisRepo, zone)
+}
+
+func (z *zoneUseCase) GetZone(ctx context.Context, name string) (*domain.Zone, error) {
+	return z.zoneRepo.Get(ctx, utils.GetFQDNFromDomainName(name))
+}
+
+func (z *zoneUseCase) ListZones(ctx context.Context) ([]*domain.Zone, error) {
+	return z.zoneRepo.List(ctx)
+}
+
+func (z *zoneUseCase) UpdateZone(ctx context.Context, zone *domain.Zone) error {
+	zone.Name = utils.GetFQDNFromDomainName(zone.Name)
+	existed, err := z.zoneRepo.Get(ctx, zone.Name)
+	if err != nil {
+		return err
+	}
+	if zone.Type == "" {
+		zone.Type = existed.Type
+	}
+	if zone.Type != existed.Type {
+		return &domain.Error{
+			Message:    fmt.Sprintf("the type of the zone %s cannot be changed", zone.Name),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	// only the primary of a secondary zone and its TSIG key are configured, the rest comes from the primary
+	if existed.IsSecondary() {
+		primary, err := primaryAddr(zone.Primary)
+		if err != nil {
+			return err
+		}
+		tsigKey, err := z.tsigKeyName(ctx, zone.TsigKey)
+		if err != nil {
+			return err
+		}
+		*zone = *existed
+		zone.Primary = primary
+		zone.TsigKey = tsigKey
+		return z.zoneRepo.Update(ctx, zone)
+	}
+
+	zone.TsigKey = ""
+	zone.SetDefaults()
+	zone.Notify = notifyAddrs(zone.Notify)
+	zone.Serial = nextSerial(existed.Serial)
+	err = z.zoneRepo.Update(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	err = uncacheZone(ctx, z.redisRepo, existed)
+	if err != nil {
+		return err
+	}
+	err = cacheZone(ctx, z.redisRepo, zone)
+	if err != nil {
+		return err
+	}
+	return z.notifyUseCase.Notify(ctx, zone.Name)
+}
+
+func (z *zoneUseCase) DeleteZone(ctx context.Context, name string) error {
+	name = utils.GetFQDNFromDomainName(name)
+	zone, err := z.zoneRepo.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	err = z.zoneRepo.Delete(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	// a zone created again restarts its serials, the old journals would be misleading
+	err = z.journalRepo.Delete(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	// the keys of a deleted zone must not sign a zone created again
+	err = z.dnssecRepo.Delete(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return uncacheZone(ctx, z.redisRepo, zone)
+}
+
+func (z *zoneUseCase) ImportZone(ctx context.Context, name string, file io.Reader,
+	mode domain.ImportMode) (*domain.ZoneImport, error) {
+	name = utils.GetFQDNFromDomainName(name)
+	if mode == "" {
+		mode = domain.ImportMerge
+	}
+	if mode != domain.ImportMerge && mode != domain.ImportReplace {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("the import mode should be %s or %s", domain.ImportMerge, domain.ImportReplace),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	entries, err := splitZoneFile(file)
+	if err != nil {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("Read zone file error: %s", err.Error()),
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+
+	soa, nameservers, rrsets, errs := groupZoneFile(name, entries)
+	errs = append(errs, z.checkImportedCNAME(ctx, name, rrsets, mode)...)
+
+	existed, _ := z.zoneRepo.Get(ctx, name)
+	err = checkWritable(existed)
+	if err != nil {
+		return nil, err
+	}
+	zone, err := importedZone(name, existed, soa, nameservers)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return nil, &domain.Error{
+			Message:    fmt.Sprintf("the zone file of %s has %d errors", name, len(errs)),
+			StatusCode: http.StatusBadRequest,
+			Details:    errs,
+		}
+	}
+
+	stale, err := z.staleRRsets(ctx, name, rrsets, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.ZoneImport{Zone: name, Mode: mode, RRsets: len(rrsets)}
+	deleted := make([]*domain.Record, 0, len(stale)+len(rrsets))
+	for _, rr := range stale {
+		deleted = append(deleted, domain.NewRecord(rr))
+	}
+	var records []*domain.Record
+	for _, rrset := range rrsets {
+		deleted = append(deleted, domain.NewRecord(rrset.rrs[0]))
+		for _, rr := range rrset.rrs {
+			records = append(records, domain.NewRecord(rr))
+		}
+	}
+	result.Records = len(records)
+	result.Deleted = len(stale)
+
+	err = z.zoneRepo.Import(ctx, zone, deleted, records)
+	if err != nil {
+		return nil, err
+	}
+
+	err = z.cacheImport(ctx, zone, stale, rrsets)
+	if err != nil {
+		// the cache may be half old and half new, so the imported RRsets are cached again from the DB
+		rebuildErr := z.rebuildCache(ctx, zone.Name, deleted)
+		if rebuildErr != nil {
+			return nil, &domain.Error{
+				Message:    fmt.Sprintf("the zone %s is imported but its cache is stale: %s", name, err.Error()),
+				StatusCode: http.StatusInternalServerError,
+				Err:        errors.Join(err, rebuildErr),
+			}
+		}
+	}
+
+	err = z.notifyUseCase.Notify(ctx, zone.Name)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// cacheImport caches the imported zone and RRsets, and removes the stale RRsets from the cache
+func (z *zoneUseCase) cacheImport(ctx context.Context, zone *domain.Zone, stale []dns.RR,
+	rrsets []*zoneRRset) error {
+	err := cacheZone(ctx, z.redisRepo, zone)
+	if err != nil {
+		return err
+	}
+	for _, rr := range stale {
+		header := rr.Header()
+		q := dns.Question{Name: header.Name, Qtype: header.Rrtype, Qclass: header.Class}
+		err = z.redisRepo.HDel(ctx, q.String())
+		if err != nil {
+			return err
+		}
+	}
+	for _, rrset := range rrsets {
+		header := rrset.rrs[0].Header()
+		q := dns.Question{Name: header.Name, Qtype: header.Rrtype, Qclass: header.Class}
+		err = cacheRRset(ctx, z.redisRepo, q, rrset.rrs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebuildCache caches the zone and the RRsets of the records from the DB again
+func (z *zoneUseCase) rebuildCache(ctx context.Context, name string, records []*domain.Record) error {
+	zone, err := z.zoneRepo.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	err = cacheZone(ctx, z.redisRepo, zone)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		q := dns.Question{Name: record.Name, Qtype: record.RrType, Qclass: record.Class}
+		raws, err := z.recordRepo.GetRRset(ctx, q.Name, q.Qtype, q.Qclass)
+		if err != nil {
+			return err
+		}
+		if len(raws) == 0 {
+			err = z.redisRepo.HDel(ctx, q.String())
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		rrs := make([]dns.RR, 0, len(raws))
+		for _, raw := range raws {
+			rr, err := dns.NewRR(raw.Record)
+			if err != nil {
+				return err
+			}
+			rrs = append(rrs, rr)
+		}
+		err = cacheRRset(ctx, z.redisRepo, q, rrs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkImportedCNAME applies the CNAME rules of the record API to the imported RRsets, the
+// existed records which are kept by the merge mode are taken into account as well
+func (z *zoneUseCase) checkImportedCNAME(ctx context.Context, name string, rrsets []*zoneRRset,
+	mode domain.ImportMode) []string {
+	var (
+		errs   []string
+		owners []string
+		types  = map[string]map[uint16]int{}
+	)
+
+	for _, rrset := range rrsets {
+		header := rrset.rrs[0].Header()
+		if header.Rrtype == dns.TypeCNAME && header.Name == name {
+			errs = append(errs, fmt.Sprintf("line %d: the zone apex %s cannot have a CNAME record", rrset.line, name))
+		}
+		if _, ok := types[header.Name]; !ok {
+			owners = append(owners, header.Name)
+			types[header.Name] = map[uint16]int{}
+		}
+		types[header.Name][header.Rrtype] = rrset.line
+	}
+
+	for _, owner := range owners {
+		if mode == domain.ImportMerge {
+			records, err := z.recordRepo.ListByName(ctx, owner, dns.ClassINET)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			for _, record := range records {
+				if _, ok := types[owner][record.RrType]; !ok {
+					types[owner][record.RrType] = 0
+				}
+			}
+		}
+
+		line, ok := types[owner][dns.TypeCNAME]
+		if !ok {
+			continue
+		}
+		for t := range types[owner] {
+			if t != dns.TypeCNAME && !allowedWithCNAME[t] {
+				if line == 0 {
+					line = types[owner][t]
+				}
+				errs = append(errs, fmt.Sprintf("line %d: CNAME and other data cannot coexist at %s", line, owner))
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// staleRRsets returns a record of every RRset which the replace mode removes, these are the
+// RRsets of the zone which the file doesn't have, the ones of the sub zones are kept
+func (z *zoneUseCase) staleRRsets(ctx context.Context, name string, rrsets []*zoneRRset,
+	mode domain.ImportMode) ([]dns.RR, error) {
+	if mode != domain.ImportReplace {
+		return nil, nil
+	}
+
+	zones, err := z.zoneRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records, err := z.recordRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	imported := map[dns.Question]bool{}
+	for _, rrset := range rrsets {
+		header := rrset.rrs[0].Header()
+		imported[dns.Question{Name: header.Name, Qtype: header.Rrtype, Qclass: header.Class}] = true
+	}
+
+	var stale []dns.RR
+	for _, record := range records {
+		q := dns.Question{Name: record.Name, Qtype: record.RrType, Qclass: record.Class}
+		if imported[q] || !dns.IsSubDomain(name, record.Name) || inSubZone(zones, name, record.Name) {
+			continue
+		}
+		rr, err := dns.NewRR(record.Record)
+		if err != nil {
+			return nil, err
+		}
+		imported[q] = true
+		stale = append(stale, rr)
+	}
+	return stale, nil
+}
+
+// inSubZone reports whether the name belongs to a zone which is delegated from the parent
+func inSubZone(zones []*domain.Zone, parent string, name string) bool {
+	for _, zone := range zones {
+		if zone.Name != parent && dns.IsSubDomain(parent, zone.Name) && dns.IsSubDomain(zone.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// importedZone applies the apex SOA and NS records of the file to the zone, a zone which isn't
+// existed is created from them
+func importedZone(name string, existed *domain.Zone, soa *dns.SOA, nameservers []string) (*domain.Zone, error) {
+	zone := &domain.Zone{Name: name}
+	if existed != nil {
+		*zone = *existed
+	}
+	if existed == nil && soa == nil {
+		return nil, fmt.Errorf("the zone %s isn't existed, the zone file should have its SOA record", name)
+	}
+
+	serial := zone.Serial
+	if soa != nil {
+		zone.Mbox = soa.Mbox
+		zone.Refresh = soa.Refresh
+		zone.Retry = soa.Retry
+		zone.Expire = soa.Expire
+		zone.Minttl = soa.Minttl
+		zone.Ttl = soa.Hdr.Ttl
+		if soa.Serial > serial {
+			serial = soa.Serial
+		}
+	}
+	if len(nameservers) > 0 {
+		zone.Nameservers = nameservers
+	}
+	if len(zone.Nameservers) == 0 {
+		return nil, fmt.Errorf("the zone %s should have at least one NS record at its apex", name)
+	}
+
+	zone.SetDefaults()
+	zone.Serial = nextSerial(serial)
+	return zone, nil
+}
+
+// groupZoneFile parses the entries and groups the records into RRsets, the apex SOA and NS
+// records are returned apart since they belong to the zone itself
+func groupZoneFile(name string, entries []zoneEntry) (*dns.SOA, []string, []*zoneRRset, []string) {
+	var (
+		soa         *dns.SOA
+		nameservers []string
+		rrsets      []*zoneRRset
+		indexes     = map[dns.Question]*zoneRRset{}
+	)
+
+	parsed, errs := parseZoneFile(name, entries)
+	for _, entry := range parsed {
+		header := entry.rr.Header()
+		switch {
+		case !dns.IsSubDomain(name, header.Name):
+			errs = append(errs, fmt.Sprintf("line %d: %s is out of the zone %s", entry.line, header.Name, name))
+			continue
+		case header.Class != dns.ClassINET:
+			errs = append(errs, fmt.Sprintf("line %d: only the IN class is supported", entry.line))
+			continue
+		case header.Rrtype == dns.TypeSOA:
+			if header.Name != name {
+				errs = append(errs, fmt.Sprintf("line %d: the SOA record should be at the zone apex", entry.line))
+			} else if soa != nil {
+				errs = append(errs, fmt.Sprintf("line %d: the zone can only have one SOA record", entry.line))
+			} else {
+				soa = entry.rr.(*dns.SOA)
+			}
+			continue
+		case header.Rrtype == dns.TypeNS && header.Name == name:
+			nameservers = append(nameservers, entry.rr.(*dns.NS).Ns)
+			continue
+		}
+
+		q := dns.Question{Name: header.Name, Qtype: header.Rrtype, Qclass: header.Class}
+		rrset, ok := indexes[q]
+		if !ok {
+			rrset = &zoneRRset{line: entry.line}
+			indexes[q] = rrset
+			rrsets = append(rrsets, rrset)
+		}
+
+		duplicated := false
+		for _, member := range rrset.rrs {
+			duplicated = duplicated || dns.IsDuplicate(member, entry.rr)
+		}
+		switch {
+		case duplicated:
+		case len(rrset.rrs) > 0 && rrset.rrs[0].Header().Ttl != header.Ttl:
+			errs = append(
+				errs, fmt.Sprintf(
+					"line %d: the TTL should be %d as the other records of the RRset", entry.line,
+					rrset.rrs[0].Header().Ttl,
+				),
+			)
+		case q.Qtype == dns.TypeCNAME && len(rrset.rrs) > 0:
+			errs = append(errs, fmt.Sprintf("line %d: %s can only have one CNAME record", entry.line, q.Name))
+		default:
+			rrset.rrs = append(rrset.rrs, entry.rr)
+		}
+	}
+
+	return soa, nameservers, rrsets, errs
+}
+
+// parsedRR is a record of a master file with the line it's defined
+type parsedRR struct {
+	line int
+	rr   dns.RR
+}
+
+// parseZoneFile parses the entries one by one, so that every broken entry is reported instead
+// of the first one. The $ORIGIN, $TTL and the omitted owners are carried between the entries,
+// the TTL of the previous record is the default until the file gives $TTL (RFC 2308 4).
+func parseZoneFile(origin string, entries []zoneEntry) ([]parsedRR, []string) {
+	var (
+		rrs     []parsedRR
+		errs    []string
+		owner   string
+		ttl     = domain.DefaultZoneTtl
+		withTtl bool
+	)
+
+	for _, entry := range entries {
+		fields := strings.Fields(entry.text)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], ";") {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "$ORIGIN":
+			if len(fields) < 2 {
+				errs = append(errs, fmt.Sprintf("line %d: $ORIGIN should have a domain name", entry.line))
+				continue
+			}
+			if !dns.IsFqdn(fields[1]) {
+				fields[1] = strings.TrimSuffix(fields[1]+"."+origin, ".") + "."
+			}
+			origin = fields[1]
+			continue
+		case "$TTL":
+			parser := dns.NewZoneParser(strings.NewReader(entry.text+"\n. TXT \"\"\n"), ".", "")
+			rr, ok := parser.Next()
+			if !ok {
+				errs = append(errs, fmt.Sprintf("line %d: %s", entry.line, zoneParseError(parser.Err())))
+				continue
+			}
+			ttl, withTtl = rr.Header().Ttl, true
+			continue
+		case "$INCLUDE":
+			errs = append(errs, fmt.Sprintf("line %d: $INCLUDE is not supported", entry.line))
+			continue
+		}
+
+		text := entry.text
+		if text[0] == ' ' || text[0] == '\t' {
+			text = owner + text
+		}
+		text = fmt.Sprintf("$TTL %d\n%s", ttl, text)
+
+		parser := dns.NewZoneParser(strings.NewReader(text), origin, "")
+		for rr, ok := parser.Next(); ok; rr, ok = parser.Next() {
+			rrs = append(rrs, parsedRR{line: entry.line, rr: rr})
+			owner = rr.Header().Name
+			if !withTtl {
+				ttl = rr.Header().Ttl
+			}
+		}
+		if err := parser.Err(); err != nil {
+			errs = append(errs, fmt.Sprintf("line %d: %s", entry.line, zoneParseError(err)))
+		}
+	}
+
+	return rrs, errs
+}
+
+// zoneParseError drops the position of the error, which is relative to the parsed entry
+func zoneParseError(err error) string {
+	msg := err.Error()
+	if i := strings.LastIndex(msg, " at line: "); i >= 0 {
+		msg = msg[:i]
+	}
+	return strings.TrimPrefix(msg, "dns: ")
+}
+
+// splitZoneFile splits the master file into entries, an entry continues on the next lines
+// until its parentheses are closed
+func splitZoneFile(file io.Reader) ([]zoneEntry, error) {
+	var (
+		entries []zoneEntry
+		lines   []string
+		start   int
+		depth   int
+	)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(lines) == 0 {
+			start = line
+		}
+		lines = append(lines, scanner.Text())
+		depth += parenDepth(scanner.Text())
+		if depth > 0 {
+			continue
+		}
+		entries = append(entries, zoneEntry{line: start, text: strings.Join(lines, "\n")})
+		lines, depth = nil, 0
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// the parser reports the parentheses which are never closed
+	if len(lines) > 0 {
+		entries = append(entries, zoneEntry{line: start, text: strings.Join(lines, "\n")})
+	}
+	return entries, nil
+}
+
+// parenDepth counts the parentheses of the line which are not quoted or commented out
+func parenDepth(line string) int {
+	depth, quoted := 0, false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == ';':
+			return depth
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		}
+	}
+	return depth
+}
+
+// primaryAddr returns the address of the primary server with the DNS port by default
+func primaryAddr(primary string) (string, error) {
+	primary = strings.TrimSpace(primary)
+	if primary == "" {
+		return "", &domain.Error{
+			Message:    "a secondary zone should have the address of its primary",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	return serverAddr(primary), nil
+}
+
+// tsigKeyName returns the canonical name of the TSIG key of a secondary zone, which should exist
+func (z *zoneUseCase) tsigKeyName(ctx context.Context, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil
+	}
+	key, err := z.tsigKeyRepo.Get(ctx, dns.CanonicalName(name))
+	if err != nil {
+		return "", &domain.Error{
+			Message:    fmt.Sprintf("the TSIG key %s isn't found", name),
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+	return key.Name, nil
+}
+
+// notifyAddrs returns the addresses of the NOTIFY targets with the DNS port by default
+func notifyAddrs(targets []string) []string {
+	var addrs []string
+	for _, target := range targets {
+		if strings.TrimSpace(target) != "" {
+			addrs = append(addrs, serverAddr(target))
+		}
+	}
+	return addrs
+}
+
+func serverAddr(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), "53")
+	}
+	return addr
+}
+
+// nextSerial follows the YYYYMMDDnn convention and falls back to a plain increment
+// once the serial runs ahead of the date
+func nextSerial(serial uint32) uint32 {
+	date, _ := strconv.ParseUint(time.Now().UTC().Format("20060102"), 10, 32)
+	base := uint32(date * 100)
+	if serial < base {
+		return base
+	}
+	return serial + 1
+}
+
+// cacheZone writes the apex SOA and NS records of the zone into redis, the same way
+// records are cached, so that the dns use case can find the zone by its SOA key
+func cacheZone(ctx context.Context, redisRepo domain.RedisRepo, zone *domain.Zone) error {
+	soa := zone.SOA()
+	q := dns.Question{Name: zone.Name, Qtype: dns.TypeSOA, Qclass: dns.ClassINET}
+	field := fmt.Sprintf("%s-%d", domain.Answer, 0)
+	err := redisRepo.HSet(ctx, q.String(), field, soa.String(), 0)
+	if err != nil {
+		return err
+	}
+
+	q.Qtype = dns.TypeNS
+	err = redisRepo.HDel(ctx, q.String())
+	if err != nil {
+		return err
+	}
+	for i, ns := range zone.NS() {
+		field = fmt.Sprintf("%s-%d", domain.Answer, i)
+		err = redisRepo.HSet(ctx, q.String(), field, ns.String(), 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func uncacheZone(ctx context.Context, redisRepo domain.RedisRepo, zone *domain.Zone) error {
+	for _, t := range []uint16{dns.TypeSOA, dns.TypeNS} {
+		q := dns.Question{Name: zone.Name, Qtype: t, Qclass: dns.ClassINET}
+		err := redisRepo.HDel(ctx, q.String())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewZoneUseCase(injector *do.Injector) (domain.ZoneUseCase, error) {
+	return &zoneUseCase{
+		do.MustInvoke[domain.RedisRepo](injector),
+		do.MustInvoke[domain.ZoneRepo](injector),
+		do.MustInvoke[domain.RecordRepo](injector),
+		do.MustInvoke[domain.JournalRepo](injector),
+		do.MustInvoke[domain.DnssecRepo](injector),
+		do.MustInvoke[domain.TsigKeyRepo](injector),
+		do.MustInvoke[domain.NotifyUseCase](injector),
+	}, nil
+}
@@ -0,0 +1,739 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/internal/domain/mocks"
+)
+
+type zoneUseCaseTestSuite struct {
+	suite.Suite
+
+	usecase domain.ZoneUseCase
+
+	redisRepo   *mocks.RedisRepo
+	zoneRepo    *mocks.ZoneRepo
+	recordRepo  *mocks.RecordRepo
+	journalRepo *mocks.JournalRepo
+	dnssecRepo  *mocks.DnssecRepo
+	tsigKeyRepo *mocks.TsigKeyRepo
+	notify      *mocks.NotifyUseCase
+
+	zone *domain.Zone
+}
+
+func TestZoneUseCase(t *testing.T) {
+	suite.Run(t, &zoneUseCaseTestSuite{})
+}
+
+func (t *zoneUseCaseTestSuite) SetupSuite() {
+	injector := do.New()
+	t.redisRepo = &mocks.RedisRepo{}
+	t.zoneRepo = &mocks.ZoneRepo{}
+	do.ProvideValue[domain.RedisRepo](injector, t.redisRepo)
+	do.ProvideValue[domain.ZoneRepo](injector, t.zoneRepo)
+	t.recordRepo = &mocks.RecordRepo{}
+	do.ProvideValue[domain.RecordRepo](injector, t.recordRepo)
+	t.journalRepo = &mocks.JournalRepo{}
+	do.ProvideValue[domain.JournalRepo](injector, t.journalRepo)
+	t.dnssecRepo = &mocks.DnssecRepo{}
+	do.ProvideValue[domain.DnssecRepo](injector, t.dnssecRepo)
+	t.tsigKeyRepo = &mocks.TsigKeyRepo{}
+	do.ProvideValue[domain.TsigKeyRepo](injector, t.tsigKeyRepo)
+	t.notify = &mocks.NotifyUseCase{}
+	do.ProvideValue[domain.NotifyUseCase](injector, t.notify)
+
+	t.usecase, _ = NewZoneUseCase(injector)
+}
+
+func (t *zoneUseCaseTestSuite) SetupTest() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyZone    = mock.AnythingOfType("*domain.Zone")
+		anyString  = mock.AnythingOfType("string")
+		anyTime    = mock.AnythingOfType("time.Duration")
+		anyUint16  = mock.AnythingOfType("uint16")
+		anyRecords = mock.AnythingOfType("[]*domain.Record")
+	)
+
+	t.zone = &domain.Zone{
+		Name:        "test.com.",
+		Nameservers: []string{"ns1.test.com.", "ns2.test.com."},
+		Mbox:        "admin.test.com.",
+		Type:        domain.ZonePrimary,
+		Serial:      4000000000,
+	}
+
+	t.redisRepo.ExpectedCalls = nil
+	t.zoneRepo.ExpectedCalls = nil
+	t.redisRepo.Calls = nil
+	t.zoneRepo.Calls = nil
+	t.recordRepo.ExpectedCalls = nil
+	t.recordRepo.Calls = nil
+	t.journalRepo.ExpectedCalls = nil
+	t.journalRepo.Calls = nil
+	t.dnssecRepo.ExpectedCalls = nil
+	t.dnssecRepo.Calls = nil
+	t.notify.ExpectedCalls = nil
+	t.notify.Calls = nil
+	t.tsigKeyRepo.ExpectedCalls = nil
+	t.tsigKeyRepo.Calls = nil
+
+	t.tsigKeyRepo.
+		On("Get", anyContext, "transfer.").
+		Return(&domain.TsigKey{Name: "transfer.", Algorithm: "hmac-sha256."}, nil)
+	t.tsigKeyRepo.
+		On("Get", anyContext, anyString).
+		Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+
+	t.notify.
+		On("Notify", anyContext, anyString).
+		Return(nil)
+	t.dnssecRepo.
+		On("Delete", anyContext, anyString).
+		Return(nil)
+
+	t.zoneRepo.
+		On("Create", anyContext, anyZone).
+		Return(nil)
+	t.zoneRepo.
+		On("Get", anyContext, anyString).
+		Return(t.zone, nil)
+	t.zoneRepo.
+		On("List", anyContext).
+		Return([]*domain.Zone{t.zone}, nil)
+	t.zoneRepo.
+		On("Update", anyContext, anyZone).
+		Return(nil)
+	t.zoneRepo.
+		On("Delete", anyContext, anyString).
+		Return(nil)
+	t.redisRepo.
+		On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+		Return(nil)
+	t.redisRepo.
+		On("HDel", anyContext, anyString).
+		Return(nil)
+	t.recordRepo.
+		On("ListByName", anyContext, anyString, anyUint16).
+		Return([]*domain.Record{}, nil)
+	t.recordRepo.
+		On("List", anyContext).
+		Return(
+			[]*domain.Record{
+				{
+					Name:   "old.test.com.",
+					RrType: 1,
+					Class:  1,
+					Rdata:  "3.3.3.3",
+					Record: "old.test.com.\t3600\tIN\tA\t3.3.3.3",
+				},
+				{
+					Name:   "www.sub.test.com.",
+					RrType: 1,
+					Class:  1,
+					Rdata:  "4.4.4.4",
+					Record: "www.sub.test.com.\t3600\tIN\tA\t4.4.4.4",
+				},
+				{
+					Name:   "other.com.",
+					RrType: 1,
+					Class:  1,
+					Rdata:  "5.5.5.5",
+					Record: "other.com.\t3600\tIN\tA\t5.5.5.5",
+				},
+			}, nil,
+		)
+	t.recordRepo.
+		On("ReplaceRRsets", anyContext, anyRecords, anyRecords).
+		Return(nil)
+	t.zoneRepo.
+		On("Import", anyContext, anyZone, anyRecords, anyRecords).
+		Return(nil)
+	t.journalRepo.
+		On("Delete", anyContext, anyString).
+		Return(nil)
+}
+
+func (t *zoneUseCaseTestSuite) TestCreateZone() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyZone    = mock.AnythingOfType("*domain.Zone")
+		anyString  = mock.AnythingOfType("string")
+		anyTime    = mock.AnythingOfType("time.Duration")
+	)
+
+	t.Run(
+		"success", func() {
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("Get", anyContext, anyString).
+				Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+			t.zoneRepo.
+				On("Create", anyContext, anyZone).
+				Return(nil)
+
+			zone := &domain.Zone{
+				Name:        "test.com",
+				Nameservers: []string{"ns1.test.com"},
+				Mbox:        "admin.test.com",
+				Notify:      []string{"192.0.2.2", "[2001:db8::2]:5353"},
+			}
+			err := t.usecase.CreateZone(context.Background(), zone)
+			t.Nil(err)
+			t.Equal("test.com.", zone.Name)
+			t.Equal([]string{"192.0.2.2:53", "[2001:db8::2]:5353"}, zone.Notify)
+			t.NotZero(zone.Serial)
+			t.Equal(domain.DefaultZoneTtl, zone.Ttl)
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";test.com.\tIN\t NS", "Answer-0",
+				"test.com.\t3600\tIN\tNS\tns1.test.com.", anyTime,
+			)
+		},
+	)
+
+	t.Run(
+		"success_secondary", func() {
+			t.SetupTest()
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("Get", anyContext, anyString).
+				Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+			t.zoneRepo.
+				On("Create", anyContext, anyZone).
+				Return(nil)
+
+			zone := &domain.Zone{
+				Name:    "legacy.com",
+				Type:    domain.ZoneSecondary,
+				Primary: "192.0.2.1",
+				TsigKey: "Transfer",
+				Serial:  100,
+			}
+			err := t.usecase.CreateZone(context.Background(), zone)
+			t.Nil(err)
+			t.Equal("legacy.com.", zone.Name)
+			t.Equal("192.0.2.1:53", zone.Primary)
+			t.Equal("transfer.", zone.TsigKey)
+			t.Zero(zone.Serial)
+			t.zoneRepo.AssertCalled(t.T(), "Create", anyContext, zone)
+			// the zone isn't answered until it's transferred
+			t.redisRepo.AssertNotCalled(t.T(), "HSet", anyContext, anyString, anyString, anyString, anyTime)
+		},
+	)
+
+	t.Run(
+		"secondary_without_primary_error", func() {
+			t.SetupTest()
+			err := t.usecase.CreateZone(
+				context.Background(), &domain.Zone{Name: "legacy.com.", Type: domain.ZoneSecondary},
+			)
+			t.NotNil(err)
+			t.Contains(err.Error(), "address of its primary")
+		},
+	)
+
+	t.Run(
+		"secondary_tsig_key_error", func() {
+			t.SetupTest()
+			err := t.usecase.CreateZone(
+				context.Background(), &domain.Zone{
+					Name: "legacy.com.", Type: domain.ZoneSecondary, Primary: "192.0.2.1", TsigKey: "other.",
+				},
+			)
+			t.NotNil(err)
+			t.Equal(http.StatusBadRequest, err.(*domain.Error).StatusCode)
+			t.Contains(err.Error(), "the TSIG key other. isn't found")
+			t.zoneRepo.AssertNotCalled(t.T(), "Create", anyContext, anyZone)
+		},
+	)
+
+	t.Run(
+		"primary_without_tsig_key", func() {
+			t.SetupTest()
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("Get", anyContext, anyString).
+				Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+			t.zoneRepo.
+				On("Create", anyContext, anyZone).
+				Return(nil)
+			zone := &domain.Zone{
+				Name: "new.com.", Nameservers: []string{"ns1.new.com."}, Mbox: "admin.new.com.", TsigKey: "other.",
+			}
+			err := t.usecase.CreateZone(context.Background(), zone)
+			t.Nil(err)
+			// only the transfers of the secondary zones are signed
+			t.Empty(zone.TsigKey)
+		},
+	)
+
+	t.Run(
+		"existed_error", func() {
+			t.SetupTest()
+			err := t.usecase.CreateZone(context.Background(), &domain.Zone{Name: "test.com."})
+			t.NotNil(err)
+			t.Contains(err.Error(), "the zone is already existed.")
+		},
+	)
+
+	t.Run(
+		"HSet_error", func() {
+			t.SetupTest()
+			t.zoneRepo.ExpectedCalls = nil
+			t.redisRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("Get", anyContext, anyString).
+				Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+			t.zoneRepo.
+				On("Create", anyContext, anyZone).
+				Return(nil)
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+				Return(fmt.Errorf("test-error"))
+
+			err := t.usecase.CreateZone(context.Background(), &domain.Zone{Name: "test.com."})
+			t.NotNil(err)
+			t.Equal("test-error", err.Error())
+		},
+	)
+}
+
+func (t *zoneUseCaseTestSuite) TestGetZone() {
+	var anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+
+	t.Run(
+		"success", func() {
+			zone, err := t.usecase.GetZone(context.Background(), "test.com")
+			t.Nil(err)
+			t.Equal("test.com.", zone.Name)
+			t.zoneRepo.AssertCalled(t.T(), "Get", anyContext, "test.com.")
+		},
+	)
+}
+
+func (t *zoneUseCaseTestSuite) TestListZones() {
+	t.Run(
+		"success", func() {
+			zones, err := t.usecase.ListZones(context.Background())
+			t.Nil(err)
+			t.Len(zones, 1)
+		},
+	)
+}
+
+func (t *zoneUseCaseTestSuite) TestUpdateZone() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+	)
+
+	t.Run(
+		"success", func() {
+			zone := &domain.Zone{
+				Name:        "test.com.",
+				Nameservers: []string{"ns3.test.com."},
+				Mbox:        "admin.test.com.",
+			}
+			err := t.usecase.UpdateZone(context.Background(), zone)
+			t.Nil(err)
+			t.Equal(uint32(4000000001), zone.Serial)
+			t.redisRepo.AssertCalled(t.T(), "HDel", anyContext, ";test.com.\tIN\t NS")
+			t.notify.AssertCalled(t.T(), "Notify", anyContext, "test.com.")
+		},
+	)
+
+	t.Run(
+		"success_secondary", func() {
+			t.SetupTest()
+			t.zone.Type = domain.ZoneSecondary
+			t.zone.Primary = "192.0.2.1:53"
+			zone := &domain.Zone{
+				Name:    "test.com.",
+				Primary: "[2001:db8::1]:5353",
+				TsigKey: "transfer.",
+				Mbox:    "other.test.com.",
+			}
+			err := t.usecase.UpdateZone(context.Background(), zone)
+			t.Nil(err)
+			t.Equal("[2001:db8::1]:5353", zone.Primary)
+			t.Equal("transfer.", zone.TsigKey)
+			t.Equal("admin.test.com.", zone.Mbox)
+			t.Equal(uint32(4000000000), zone.Serial)
+			t.zoneRepo.AssertCalled(t.T(), "Update", anyContext, zone)
+			t.redisRepo.AssertNotCalled(t.T(), "HDel", anyContext, anyString)
+			t.notify.AssertNotCalled(t.T(), "Notify", anyContext, anyString)
+		},
+	)
+
+	t.Run(
+		"type_changed_error", func() {
+			t.SetupTest()
+			err := t.usecase.UpdateZone(
+				context.Background(), &domain.Zone{Name: "test.com.", Type: domain.ZoneSecondary},
+			)
+			t.NotNil(err)
+			t.Contains(err.Error(), "cannot be changed")
+		},
+	)
+
+	t.Run(
+		"not_found_error", func() {
+			t.SetupTest()
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("Get", anyContext, anyString).
+				Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+
+			err := t.usecase.UpdateZone(context.Background(), &domain.Zone{Name: "test.com."})
+			t.NotNil(err)
+			t.Contains(err.Error(), "record not found")
+		},
+	)
+}
+
+func (t *zoneUseCaseTestSuite) TestDeleteZone() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyString  = mock.AnythingOfType("string")
+	)
+
+	t.Run(
+		"success", func() {
+			err := t.usecase.DeleteZone(context.Background(), "test.com.")
+			t.Nil(err)
+			t.redisRepo.AssertCalled(t.T(), "HDel", anyContext, ";test.com.\tIN\t SOA")
+			t.journalRepo.AssertCalled(t.T(), "Delete", anyContext, "test.com.")
+			t.dnssecRepo.AssertCalled(t.T(), "Delete", anyContext, "test.com.")
+		},
+	)
+
+	t.Run(
+		"Delete_error", func() {
+			t.SetupTest()
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("Get", anyContext, anyString).
+				Return(t.zone, nil)
+			t.zoneRepo.
+				On("Delete", anyContext, anyString).
+				Return(fmt.Errorf("test-error"))
+
+			err := t.usecase.DeleteZone(context.Background(), "test.com.")
+			t.NotNil(err)
+			t.Equal("test-error", err.Error())
+		},
+	)
+}
+
+func (t *zoneUseCaseTestSuite) TestImportZone() {
+	var (
+		anyContext = mock.MatchedBy(func(ctx context.Context) bool { return true })
+		anyZone    = mock.AnythingOfType("*domain.Zone")
+		anyString  = mock.AnythingOfType("string")
+		anyRecords = mock.AnythingOfType("[]*domain.Record")
+		anyTime    = mock.AnythingOfType("time.Duration")
+		anyUint16  = mock.AnythingOfType("uint16")
+	)
+
+	file := strings.Join(
+		[]string{
+			"$ORIGIN test.com.",
+			"$TTL 1h",
+			"@\tIN\tSOA\tns1 hostmaster (",
+			"\t\t4100000000 ; serial",
+			"\t\t7200 3600 1209600 300 )",
+			"\tIN\tNS\tns1",
+			"\tIN\tNS\tns2.test.com.",
+			"\tIN\tMX\t10 mail",
+			"ns1\tIN\tA\t1.1.1.1",
+			"ns2\t300\tIN\tA\t2.2.2.2",
+			"www\tIN\tCNAME\tns1",
+			"txt\tIN\tTXT\t\"a;b\" \"(c\" ; comment (",
+			"$ORIGIN sub",
+			"host\tIN\tA\t4.4.4.4",
+		}, "\n",
+	)
+
+	findRecord := func(records []*domain.Record, record string) bool {
+		for _, r := range records {
+			if r.Record == record {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run(
+		"success_merge", func() {
+			t.SetupTest()
+			result, err := t.usecase.ImportZone(
+				context.Background(), "test.com", strings.NewReader(file), domain.ImportMerge,
+			)
+			t.Nil(err)
+			t.Equal(&domain.ZoneImport{Zone: "test.com.", Mode: domain.ImportMerge, RRsets: 6, Records: 6}, result)
+			t.zoneRepo.AssertCalled(
+				t.T(), "Import", anyContext, mock.MatchedBy(
+					func(zone *domain.Zone) bool {
+						return zone.Serial == 4100000001 && zone.Mbox == "hostmaster.test.com." &&
+							len(zone.Nameservers) == 2 && zone.Ttl == 3600
+					},
+				), mock.MatchedBy(
+					func(deleted []*domain.Record) bool { return len(deleted) == 6 },
+				), mock.MatchedBy(
+					func(records []*domain.Record) bool {
+						return findRecord(records, "test.com.\t3600\tIN\tMX\t10 mail.test.com.") &&
+							findRecord(records, "ns2.test.com.\t300\tIN\tA\t2.2.2.2") &&
+							findRecord(records, "www.test.com.\t3600\tIN\tCNAME\tns1.test.com.") &&
+							findRecord(records, "txt.test.com.\t3600\tIN\tTXT\t\"a;b\" \"(c\"") &&
+							findRecord(records, "host.sub.test.com.\t3600\tIN\tA\t4.4.4.4")
+					},
+				),
+			)
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";ns1.test.com.\tIN\t A", "Answer-0",
+				"ns1.test.com.\t3600\tIN\tA\t1.1.1.1", anyTime,
+			)
+			t.notify.AssertCalled(t.T(), "Notify", anyContext, "test.com.")
+		},
+	)
+
+	t.Run(
+		"success_replace", func() {
+			t.SetupTest()
+			result, err := t.usecase.ImportZone(
+				context.Background(), "test.com.", strings.NewReader(file), domain.ImportReplace,
+			)
+			t.Nil(err)
+			t.Equal(2, result.Deleted)
+			t.zoneRepo.AssertCalled(
+				t.T(), "Import", anyContext, anyZone, mock.MatchedBy(
+					func(deleted []*domain.Record) bool {
+						return len(deleted) == 8 && deleted[0].Name == "old.test.com."
+					},
+				), anyRecords,
+			)
+			t.redisRepo.AssertCalled(t.T(), "HDel", anyContext, ";old.test.com.\tIN\t A")
+		},
+	)
+
+	t.Run(
+		"secondary_error", func() {
+			t.SetupTest()
+			t.zone.Type = domain.ZoneSecondary
+			_, err := t.usecase.ImportZone(
+				context.Background(), "test.com.", strings.NewReader(file), domain.ImportMerge,
+			)
+			t.NotNil(err)
+			t.Contains(err.Error(), "read-only")
+			t.zoneRepo.AssertNotCalled(t.T(), "Import", anyContext, anyZone, anyRecords, anyRecords)
+		},
+	)
+
+	t.Run(
+		"success_replace_sub_zone", func() {
+			t.SetupTest()
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("Get", anyContext, anyString).
+				Return(t.zone, nil)
+			t.zoneRepo.
+				On("Import", anyContext, anyZone, anyRecords, anyRecords).
+				Return(nil)
+			t.zoneRepo.
+				On("List", anyContext).
+				Return([]*domain.Zone{t.zone, {Name: "sub.test.com."}}, nil)
+			result, err := t.usecase.ImportZone(
+				context.Background(), "test.com.", strings.NewReader("www IN A 1.1.1.1"), domain.ImportReplace,
+			)
+			t.Nil(err)
+			t.Equal(1, result.Deleted)
+			t.redisRepo.AssertNotCalled(t.T(), "HDel", anyContext, ";www.sub.test.com.\tIN\t A")
+		},
+	)
+
+	t.Run(
+		"success_create_zone", func() {
+			t.SetupTest()
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("Get", anyContext, anyString).
+				Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+			t.zoneRepo.
+				On("Import", anyContext, anyZone, anyRecords, anyRecords).
+				Return(nil)
+			_, err := t.usecase.ImportZone(
+				context.Background(), "test.com.", strings.NewReader(file), "",
+			)
+			t.Nil(err)
+			t.zoneRepo.AssertCalled(
+				t.T(), "Import", anyContext, mock.MatchedBy(
+					func(zone *domain.Zone) bool { return zone.Name == "test.com." && zone.Mbox == "hostmaster.test.com." },
+				), anyRecords, anyRecords,
+			)
+		},
+	)
+
+	t.Run(
+		"no_soa_error", func() {
+			t.SetupTest()
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("Get", anyContext, anyString).
+				Return(nil, &domain.Error{Message: "record not found", StatusCode: http.StatusNotFound})
+			_, err := t.usecase.ImportZone(
+				context.Background(), "test.com.", strings.NewReader("www IN A 1.1.1.1"), domain.ImportMerge,
+			)
+			t.NotNil(err)
+			t.Contains(err.Error(), "the zone file should have its SOA record")
+		},
+	)
+
+	t.Run(
+		"per_line_errors", func() {
+			t.SetupTest()
+			broken := strings.Join(
+				[]string{
+					"$ORIGIN test.com.",
+					"www IN A 1.1.1.1",
+					"bad IN A 1.1.1",
+					"out.other.com. IN A 1.1.1.1",
+					"www 100 IN A 2.2.2.2",
+					"alias IN CNAME www",
+					"alias IN TXT \"x\"",
+					"$INCLUDE other.zone",
+					"last IN MX (",
+					"  10 mail",
+				}, "\n",
+			)
+			_, err := t.usecase.ImportZone(
+				context.Background(), "test.com.", strings.NewReader(broken), domain.ImportMerge,
+			)
+			t.NotNil(err)
+			e := err.(*domain.Error)
+			t.Equal(http.StatusBadRequest, e.StatusCode)
+			t.Len(e.Details, 6)
+			t.Contains(e.Details[0], "line 3: bad A A: \"1.1.1\"")
+			t.Contains(e.Details, "line 8: $INCLUDE is not supported")
+			t.Contains(e.Details[2], "line 9: ")
+			t.Contains(e.Details, "line 4: out.other.com. is out of the zone test.com.")
+			t.Contains(e.Details, "line 5: the TTL should be 3600 as the other records of the RRset")
+			t.Contains(e.Details, "line 6: CNAME and other data cannot coexist at alias.test.com.")
+			t.zoneRepo.AssertNotCalled(t.T(), "Import", anyContext, anyZone, anyRecords, anyRecords)
+		},
+	)
+
+	t.Run(
+		"merge_cname_error", func() {
+			t.SetupTest()
+			t.recordRepo.ExpectedCalls = nil
+			t.recordRepo.
+				On("ListByName", anyContext, "www.test.com.", uint16(1)).
+				Return([]*domain.Record{{Name: "www.test.com.", RrType: 5, Class: 1}}, nil)
+			_, err := t.usecase.ImportZone(
+				context.Background(), "test.com.", strings.NewReader("www IN A 1.1.1.1"), domain.ImportMerge,
+			)
+			t.NotNil(err)
+			t.Contains(err.Error(), "line 1: CNAME and other data cannot coexist at www.test.com.")
+		},
+	)
+
+	t.Run(
+		"mode_error", func() {
+			_, err := t.usecase.ImportZone(context.Background(), "test.com.", strings.NewReader(""), "append")
+			t.NotNil(err)
+			t.Contains(err.Error(), "the import mode should be merge or replace")
+		},
+	)
+
+	t.Run(
+		"Import_error", func() {
+			t.SetupTest()
+			t.zoneRepo.ExpectedCalls = nil
+			t.zoneRepo.
+				On("Get", anyContext, anyString).
+				Return(t.zone, nil)
+			t.zoneRepo.
+				On("List", anyContext).
+				Return([]*domain.Zone{t.zone}, nil)
+			t.zoneRepo.
+				On("Import", anyContext, anyZone, anyRecords, anyRecords).
+				Return(fmt.Errorf("test-error"))
+			_, err := t.usecase.ImportZone(
+				context.Background(), "test.com.", strings.NewReader("www IN A 1.1.1.1"), domain.ImportReplace,
+			)
+			t.NotNil(err)
+			t.Equal("test-error", err.Error())
+			t.redisRepo.AssertNotCalled(t.T(), "HSet", anyContext, anyString, anyString, anyString, anyTime)
+			t.redisRepo.AssertNotCalled(t.T(), "HDel", anyContext, anyString)
+			t.notify.AssertNotCalled(t.T(), "Notify", anyContext, anyString)
+		},
+	)
+
+	t.Run(
+		"cache_error_rebuild_success", func() {
+			t.SetupTest()
+			t.redisRepo.ExpectedCalls = nil
+			t.redisRepo.
+				On("HSet", anyContext, ";www.test.com.\tIN\t A", anyString, anyString, anyTime).
+				Return(fmt.Errorf("test-error")).
+				Once()
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+				Return(nil)
+			t.redisRepo.
+				On("HDel", anyContext, anyString).
+				Return(nil)
+			t.recordRepo.
+				On("GetRRset", anyContext, "www.test.com.", uint16(1), uint16(1)).
+				Return(
+					[]*domain.Record{
+						{Name: "www.test.com.", RrType: 1, Class: 1, Record: "www.test.com.\t3600\tIN\tA\t1.1.1.1"},
+					}, nil,
+				)
+			t.recordRepo.
+				On("GetRRset", anyContext, anyString, anyUint16, anyUint16).
+				Return([]*domain.Record{}, nil)
+			_, err := t.usecase.ImportZone(
+				context.Background(), "test.com.", strings.NewReader("www IN A 1.1.1.1"), domain.ImportReplace,
+			)
+			t.Nil(err)
+			t.zoneRepo.AssertCalled(t.T(), "Get", anyContext, "test.com.")
+			t.redisRepo.AssertCalled(
+				t.T(), "HSet", anyContext, ";www.test.com.\tIN\t A", "Answer-0",
+				"www.test.com.\t3600\tIN\tA\t1.1.1.1", anyTime,
+			)
+			t.redisRepo.AssertCalled(t.T(), "HDel", anyContext, ";old.test.com.\tIN\t A")
+			t.notify.AssertCalled(t.T(), "Notify", anyContext, "test.com.")
+		},
+	)
+
+	t.Run(
+		"cache_error_rebuild_error", func() {
+			t.SetupTest()
+			t.redisRepo.ExpectedCalls = nil
+			t.redisRepo.
+				On("HSet", anyContext, anyString, anyString, anyString, anyTime).
+				Return(fmt.Errorf("test-error"))
+			t.redisRepo.
+				On("HDel", anyContext, anyString).
+				Return(nil)
+			_, err := t.usecase.ImportZone(
+				context.Background(), "test.com.", strings.NewReader("www IN A 1.1.1.1"), domain.ImportReplace,
+			)
+			t.NotNil(err)
+			e := err.(*domain.Error)
+			t.Equal(http.StatusInternalServerError, e.StatusCode)
+			t.Contains(e.Message, "the zone test.com. is imported but its cache is stale")
+			t.notify.AssertNotCalled(t.T(), "Notify", anyContext, anyString)
+		},
+	)
+}
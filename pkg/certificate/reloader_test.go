@@ -0,0 +1,143 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type reloaderTestSuite struct {
+	suite.Suite
+
+	certFile string
+	keyFile  string
+	// modTime is the modification time of the last written files, which moves on by a second on every
+	// write so that the rotations are seen whatever the resolution of the file system is
+	modTime time.Time
+
+	reloader *Reloader
+}
+
+func TestReloader(t *testing.T) {
+	suite.Run(t, &reloaderTestSuite{})
+}
+
+func (t *reloaderTestSuite) SetupTest() {
+	dir := t.T().TempDir()
+	t.certFile = filepath.Join(dir, "tls.crt")
+	t.keyFile = filepath.Join(dir, "tls.key")
+	t.modTime = time.Now().Add(-time.Hour)
+
+	certPEM, keyPEM := t.newKeyPair("old.test")
+	t.write(t.certFile, certPEM)
+	t.write(t.keyFile, keyPEM)
+
+	var err error
+	t.reloader, err = NewReloader(t.certFile, t.keyFile)
+	t.Nil(err)
+}
+
+// newKeyPair returns the PEM of a self-signed certificate of the name and its key
+func (t *reloaderTestSuite) newKeyPair(name string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	t.Nil(err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	t.Nil(err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	t.Nil(err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func (t *reloaderTestSuite) write(file string, data []byte) {
+	t.modTime = t.modTime.Add(time.Second)
+	t.Nil(os.WriteFile(file, data, 0o600))
+	t.Nil(os.Chtimes(file, t.modTime, t.modTime))
+}
+
+// commonName returns the name of the certificate served now
+func (t *reloaderTestSuite) commonName() string {
+	cert, err := t.reloader.GetCertificate(&tls.ClientHelloInfo{})
+	t.Nil(err)
+	if err != nil {
+		return ""
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	t.Nil(err)
+	return leaf.Subject.CommonName
+}
+
+func (t *reloaderTestSuite) TestGetCertificate() {
+	t.Run(
+		"rotated_success", func() {
+			t.SetupTest()
+			t.Equal("old.test", t.commonName())
+
+			certPEM, keyPEM := t.newKeyPair("new.test")
+			t.write(t.keyFile, keyPEM)
+			t.write(t.certFile, certPEM)
+			t.Equal("new.test", t.commonName())
+			t.Equal("new.test", t.commonName())
+		},
+	)
+
+	t.Run(
+		"half_written_keep_old", func() {
+			t.SetupTest()
+			certPEM, keyPEM := t.newKeyPair("new.test")
+
+			// the certificate is rotated before its key
+			t.write(t.certFile, certPEM)
+			t.Equal("old.test", t.commonName())
+
+			// the key is truncated while it is written
+			t.write(t.keyFile, keyPEM[:len(keyPEM)/2])
+			t.Equal("old.test", t.commonName())
+
+			t.write(t.keyFile, keyPEM)
+			t.Equal("new.test", t.commonName())
+		},
+	)
+
+	t.Run(
+		"unreadable_keep_old", func() {
+			t.SetupTest()
+			t.Nil(os.Remove(t.keyFile))
+			t.Equal("old.test", t.commonName())
+
+			t.Nil(os.Mkdir(t.keyFile, 0o700))
+			t.Nil(os.Chtimes(t.keyFile, t.modTime.Add(time.Second), t.modTime.Add(time.Second)))
+			t.Equal("old.test", t.commonName())
+		},
+	)
+}
+
+func (t *reloaderTestSuite) TestNewReloader() {
+	_, err := NewReloader(t.certFile, filepath.Join(t.T().TempDir(), "notexisted.key"))
+	t.NotNil(err)
+
+	certPEM, _ := t.newKeyPair("new.test")
+	t.write(t.keyFile, certPEM)
+	_, err = NewReloader(t.certFile, t.keyFile)
+	t.NotNil(err)
+}
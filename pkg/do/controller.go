@@ -18,4 +18,12 @@ func ProvideController(injector *do.Injector) {
 	// http handler
 	do.Provide(injector, middleware.NewErrorHandler)
 	do.Provide(injector, v1.NewRecordHandler)
+	do.Provide(injector, v1.NewZoneHandler)
+	do.Provide(injector, v1.NewDoHHandler)
+	do.Provide(injector, v1.NewExportHandler)
+	do.Provide(injector, v1.NewNotifyHandler)
+	do.Provide(injector, v1.NewTsigKeyHandler)
+	do.Provide(injector, v1.NewDnssecHandler)
+	do.Provide(injector, v1.NewUpstreamHandler)
+	do.Provide(injector, v1.NewForwardingRuleHandler)
 }
@@ -11,4 +11,9 @@ func ProvideRepository(injector *do.Injector) {
 	do.Provide(injector, redis.NewRedisRepo)
 
 	do.Provide(injector, db.NewRecordsRepo)
+	do.Provide(injector, db.NewZoneRepo)
+	do.Provide(injector, db.NewJournalRepo)
+	do.Provide(injector, db.NewTsigKeyRepo)
+	do.Provide(injector, db.NewDnssecRepo)
+	do.Provide(injector, db.NewForwardingRuleRepo)
 }
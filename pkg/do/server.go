@@ -7,33 +7,77 @@ import (
 	"github.com/miekg/dns"
 	"github.com/samber/do"
 	"net/http"
+	"strings"
 
+	dnsHandler "github.com/cewuandy/go-restful-dns/internal/controller/dns"
 	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/pkg/certificate"
 	"github.com/cewuandy/go-restful-dns/pkg/gin/routes"
 )
 
 func ProvideServer(injector *do.Injector) {
-	do.Provide(injector, provideDNSServer)
+	do.Provide(injector, provideDNSServers)
 	do.Provide(injector, provideGinServer)
 	do.Provide(injector, provideHTTPServer)
 }
 
-func provideDNSServer(injector *do.Injector) (*dns.Server, error) {
+func provideDNSServers(injector *do.Injector) ([]*dns.Server, error) {
 	env := do.MustInvoke[*domain.Options](injector)
+	handler := do.MustInvoke[dns.Handler](injector)
+	// the TSIG keys are looked up on every message instead of being copied into TsigSecret, so
+	// that the keys changed over the API take effect at once
+	tsigProvider := do.MustInvoke[domain.TsigKeyUseCase](injector)
+	addr := fmt.Sprintf("%s:%d", env.DnsAddr, env.DnsPort)
 
-	dnsServer := &dns.Server{
-		Addr:    fmt.Sprintf("%s:%d", env.DnsAddr, env.DnsPort),
-		Net:     "udp",
-		Handler: do.MustInvoke[dns.Handler](injector),
+	var dnsServers []*dns.Server
+	for _, network := range []string{"udp", "tcp"} {
+		dnsServer := &dns.Server{
+			Addr:          addr,
+			Net:           network,
+			UDPSize:       int(max(dns.MinMsgSize, env.EdnsUdpSize)),
+			Handler:       handler,
+			TsigProvider:  tsigProvider,
+			MsgAcceptFunc: dnsHandler.AcceptMsg,
+		}
+		dnsServer.NotifyStartedFunc = func() {
+			fmt.Printf("Listen and serve at %s/%s for DNS query\n", dnsServer.Addr, dnsServer.Net)
+		}
+		dnsServers = append(dnsServers, dnsServer)
 	}
-	dnsServer.NotifyStartedFunc = func() {
-		fmt.Printf("Listen and serve at %s for DNS query\n", dnsServer.Addr)
+
+	reloader := do.MustInvoke[*certificate.Reloader](injector)
+	if reloader != nil {
+		dotServer := &dns.Server{
+			Addr:          fmt.Sprintf("%s:%d", env.DnsAddr, env.DotPort),
+			Net:           "tcp-tls",
+			TLSConfig:     reloader.TLSConfig(),
+			Handler:       handler,
+			TsigProvider:  tsigProvider,
+			MsgAcceptFunc: dnsHandler.AcceptMsg,
+		}
+		dotServer.NotifyStartedFunc = func() {
+			fmt.Printf("Listen and serve at %s/%s for DNS query\n", dotServer.Addr, dotServer.Net)
+		}
+		dnsServers = append(dnsServers, dotServer)
 	}
-	return dnsServer, nil
+
+	return dnsServers, nil
 }
 
 func provideGinServer(injector *do.Injector) (*gin.Engine, error) {
+	env := do.MustInvoke[*domain.Options](injector)
 	r := gin.New()
+	// the addresses of the clients come from the forwarded headers of the trusted proxies only
+	var proxies []string
+	for _, proxy := range strings.Split(env.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	err := r.SetTrustedProxies(proxies)
+	if err != nil {
+		return nil, fmt.Errorf("trusted-proxies: %w", err)
+	}
 	// TODO: should assign a real ip:port, that is workaround now
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowAllOrigins = true
@@ -41,6 +85,14 @@ func provideGinServer(injector *do.Injector) (*gin.Engine, error) {
 	r.Use(do.MustInvoke[domain.ErrorHandler](injector).HandleError)
 
 	routes.RegisterRecordRoutes(r, do.MustInvoke[domain.RecordHandler](injector))
+	routes.RegisterZoneRoutes(r, do.MustInvoke[domain.ZoneHandler](injector))
+	routes.RegisterDoHRoutes(r, do.MustInvoke[domain.DoHHandler](injector))
+	routes.RegisterExportRoutes(r, do.MustInvoke[domain.ExportHandler](injector))
+	routes.RegisterNotifyRoutes(r, do.MustInvoke[domain.NotifyHandler](injector))
+	routes.RegisterTsigKeyRoutes(r, do.MustInvoke[domain.TsigKeyHandler](injector))
+	routes.RegisterDnssecRoutes(r, do.MustInvoke[domain.DnssecHandler](injector))
+	routes.RegisterUpstreamRoutes(r, do.MustInvoke[domain.UpstreamHandler](injector))
+	routes.RegisterForwardingRuleRoutes(r, do.MustInvoke[domain.ForwardingRuleHandler](injector))
 
 	return r, nil
 }
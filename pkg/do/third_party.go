@@ -2,12 +2,16 @@ package do
 
 import (
 	"flag"
+	"fmt"
 	"github.com/go-redis/redis/v8"
 	"gorm.io/gorm/logger"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/cewuandy/go-restful-dns/internal/domain"
+	"github.com/cewuandy/go-restful-dns/pkg/certificate"
+	"github.com/cewuandy/go-restful-dns/pkg/forwarder"
 	pkgGorm "github.com/cewuandy/go-restful-dns/pkg/gorm"
 	"github.com/cewuandy/go-restful-dns/pkg/options"
 
@@ -20,7 +24,9 @@ import (
 func ProvideThirdPartyElement(injector *do.Injector) {
 	do.Provide(injector, provideEnv)
 	do.Provide(injector, provideUpstreams)
+	do.Provide(injector, provideForwarderPool)
 	do.Provide(injector, provideRedisClient)
+	do.Provide(injector, provideCertificateReloader)
 	do.Provide[*gorm.DB](injector, provideSqliteClient)
 }
 
@@ -30,12 +36,37 @@ func provideEnv(*do.Injector) (*domain.Options, error) {
 	env := &domain.Options{}
 	_ = options.LoadDefaultConfig(flagSet, env)
 	_ = options.LoadCliFlagConfigs(flagSet)
+	env.Args = flagSet.Args()
 	return env, nil
 }
 
-func provideUpstreams(injector *do.Injector) ([]string, error) {
+func provideUpstreams(injector *do.Injector) (*forwarder.Group, error) {
 	env := do.MustInvoke[*domain.Options](injector)
-	return strings.Split(env.UpstreamForwarders, ","), nil
+	var upstreams []string
+	for _, upstream := range strings.Split(env.UpstreamForwarders, ",") {
+		upstream = strings.TrimSpace(upstream)
+		if _, err := forwarder.Parse(upstream); err != nil {
+			return nil, fmt.Errorf("upstream-forwarders: %w", err)
+		}
+		upstreams = append(upstreams, upstream)
+	}
+	return forwarder.NewGroup(
+		do.MustInvoke[*forwarder.Pool](injector), upstreams, env.UpstreamStrategy, env.UpstreamParallel,
+		env.UpstreamMaxFails, time.Duration(env.UpstreamEjectTime)*time.Second,
+	)
+}
+
+func provideForwarderPool(injector *do.Injector) (*forwarder.Pool, error) {
+	env := do.MustInvoke[*domain.Options](injector)
+	return forwarder.NewPool(env.UpstreamCaFile, env.UpstreamInsecure)
+}
+
+func provideCertificateReloader(injector *do.Injector) (*certificate.Reloader, error) {
+	env := do.MustInvoke[*domain.Options](injector)
+	if env.TlsCertFile == "" || env.TlsKeyFile == "" {
+		return nil, nil
+	}
+	return certificate.NewReloader(env.TlsCertFile, env.TlsKeyFile)
 }
 
 func provideRedisClient(injector *do.Injector) (*redis.Client, error) {
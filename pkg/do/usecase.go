@@ -9,7 +9,31 @@ import (
 func ProvideUseCase(injector *do.Injector) {
 	do.Provide(injector, usecase.NewInitUseCase)
 
+	do.Provide(injector, usecase.NewResolveUseCase)
+
+	do.Provide(injector, usecase.NewValidateUseCase)
+
 	do.Provide(injector, usecase.NewDNSUseCase)
 
 	do.Provide(injector, usecase.NewRecordUseCase)
+
+	do.Provide(injector, usecase.NewZoneUseCase)
+
+	do.Provide(injector, usecase.NewExportUseCase)
+
+	do.Provide(injector, usecase.NewTransferUseCase)
+
+	do.Provide(injector, usecase.NewSecondaryUseCase)
+
+	do.Provide(injector, usecase.NewNotifyUseCase)
+
+	do.Provide(injector, usecase.NewTsigKeyUseCase)
+
+	do.Provide(injector, usecase.NewUpdateUseCase)
+
+	do.Provide(injector, usecase.NewDnssecUseCase)
+
+	do.Provide(injector, usecase.NewUpstreamUseCase)
+
+	do.Provide(injector, usecase.NewForwardingRuleUseCase)
 }
@@ -0,0 +1,355 @@
+package forwarder
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	dialTimeout = time.Second
+	// exchangeTimeout bounds an exchange over DoT and DoH, whose connections take a TLS handshake
+	exchangeTimeout = 5 * time.Second
+
+	dnsMessageContentType = "application/dns-message"
+)
+
+// forwarder exchanges messages with a single upstream forwarder
+type forwarder interface {
+	exchange(ctx context.Context, req *dns.Msg) (*dns.Msg, error)
+}
+
+// Pool keeps a forwarder for every upstream URI, so that the connections of DoT and DoH are reused
+// by the following exchanges
+type Pool struct {
+	tlsConfig *tls.Config
+
+	mu         sync.Mutex
+	forwarders map[string]forwarder
+}
+
+// Exchange sends the request to the upstream forwarder of the URI, e.g. 1.1.1.1:53, tcp://1.1.1.1:53,
+// tls://1.1.1.1:853#cloudflare-dns.com or https://dns.example/dns-query
+func (p *Pool) Exchange(ctx context.Context, req *dns.Msg, upstream string) (*dns.Msg, error) {
+	f, err := p.get(upstream)
+	if err != nil {
+		return nil, err
+	}
+	return f.exchange(ctx, req)
+}
+
+func (p *Pool) get(upstream string) (forwarder, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if f, ok := p.forwarders[upstream]; ok {
+		return f, nil
+	}
+	f, err := p.newForwarder(upstream)
+	if err != nil {
+		return nil, err
+	}
+	p.forwarders[upstream] = f
+	return f, nil
+}
+
+func (p *Pool) newForwarder(upstream string) (forwarder, error) {
+	u, err := Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "udp", "tcp":
+		return &plainForwarder{u.Host, u.Scheme}, nil
+	case "tls":
+		config := p.tlsConfig.Clone()
+		config.ServerName = u.Fragment
+		if config.ServerName == "" {
+			config.ServerName = u.Hostname()
+		}
+		return &tlsForwarder{addr: u.Host, config: config}, nil
+	default:
+		transport := &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     p.tlsConfig.Clone(),
+			ForceAttemptHTTP2:   true,
+			MaxIdleConnsPerHost: 16,
+			IdleConnTimeout:     90 * time.Second,
+		}
+		return &httpsForwarder{u.String(), &http.Client{Transport: transport, Timeout: exchangeTimeout}}, nil
+	}
+}
+
+// Parse parses the URI of an upstream forwarder, an address without a scheme is forwarded over UDP,
+// and the default ports are 53, 853 for DoT and 443 for DoH
+func Parse(upstream string) (*url.URL, error) {
+	upstream = strings.TrimSpace(upstream)
+	if !strings.Contains(upstream, "://") {
+		upstream = "udp://" + upstream
+	}
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("upstream forwarder %s has no host", upstream)
+	}
+
+	ports := map[string]string{"udp": "53", "tcp": "53", "tls": "853"}
+	switch u.Scheme {
+	case "udp", "tcp", "tls":
+		if u.Port() == "" {
+			u.Host = net.JoinHostPort(u.Hostname(), ports[u.Scheme])
+		}
+	case "https":
+		if u.Path == "" {
+			u.Path = "/dns-query"
+		}
+	default:
+		return nil, fmt.Errorf("upstream forwarder %s has an unknown scheme %s", upstream, u.Scheme)
+	}
+	return u, nil
+}
+
+// plainForwarder asks over UDP, and over TCP again when the answer is truncated, or over TCP only
+type plainForwarder struct {
+	addr    string
+	network string
+}
+
+func (f *plainForwarder) exchange(ctx context.Context, req *dns.Msg) (*dns.Msg, error) {
+	client := &dns.Client{Net: f.network, DialTimeout: dialTimeout}
+	resp, _, err := client.ExchangeContext(ctx, req, f.addr)
+	if err == nil && resp.Truncated && f.network == "udp" {
+		client.Net = "tcp"
+		resp, _, err = client.ExchangeContext(ctx, req, f.addr)
+	}
+	return resp, err
+}
+
+// tlsForwarder pipelines the queries over a single DoT connection, whose answers come back in any
+// order and are matched by their IDs (RFC 7766 6.2.1.1), the connection is dialed again once it's
+// closed by either side
+type tlsForwarder struct {
+	addr   string
+	config *tls.Config
+
+	mu   sync.Mutex
+	conn *pipelinedConn
+}
+
+func (f *tlsForwarder) exchange(ctx context.Context, req *dns.Msg) (*dns.Msg, error) {
+	ctx, cancel := context.WithTimeout(ctx, exchangeTimeout)
+	defer cancel()
+
+	conn, err := f.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := conn.exchange(ctx, req)
+	if err == errConnClosed {
+		// the idle connection may be closed by the server at any time, so it's dialed once again
+		conn, err = f.dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp, err = conn.exchange(ctx, req)
+	}
+	return resp, err
+}
+
+func (f *tlsForwarder) dial(ctx context.Context) (*pipelinedConn, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.conn != nil && !f.conn.closed() {
+		return f.conn, nil
+	}
+	client := &dns.Client{Net: "tcp-tls", TLSConfig: f.config, DialTimeout: dialTimeout}
+	conn, err := client.DialContext(ctx, f.addr)
+	if err != nil {
+		return nil, err
+	}
+	f.conn = newPipelinedConn(conn)
+	return f.conn, nil
+}
+
+// errConnClosed fails the queries which aren't answered before the connection is closed, which are
+// asked again over a new connection
+var errConnClosed = errors.New("DoT connection closed")
+
+type pipelinedConn struct {
+	conn *dns.Conn
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[uint16]chan *dns.Msg
+	err     error
+}
+
+func newPipelinedConn(conn *dns.Conn) *pipelinedConn {
+	c := &pipelinedConn{conn: conn, pending: make(map[uint16]chan *dns.Msg)}
+	go c.read()
+	return c
+}
+
+func (c *pipelinedConn) exchange(ctx context.Context, req *dns.Msg) (*dns.Msg, error) {
+	// the queries in flight have unique IDs, the answer gets the ID of the request back
+	msg := req.Copy()
+	answered := make(chan *dns.Msg, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, errConnClosed
+	}
+	msg.Id = dns.Id()
+	for c.pending[msg.Id] != nil {
+		msg.Id = dns.Id()
+	}
+	c.pending[msg.Id] = answered
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		if c.pending[msg.Id] == answered {
+			delete(c.pending, msg.Id)
+		}
+		c.mu.Unlock()
+	}()
+
+	c.writeMu.Lock()
+	deadline, _ := ctx.Deadline()
+	_ = c.conn.SetWriteDeadline(deadline)
+	err := c.conn.WriteMsg(msg)
+	c.writeMu.Unlock()
+	if err != nil {
+		c.close(err)
+		return nil, errConnClosed
+	}
+
+	select {
+	case resp := <-answered:
+		if resp == nil {
+			return nil, errConnClosed
+		}
+		resp.Id = req.Id
+		return resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *pipelinedConn) read() {
+	for {
+		resp, err := c.conn.ReadMsg()
+		if err != nil {
+			c.close(err)
+			return
+		}
+		// the answer is delivered once, a duplicate or late answer is dropped
+		c.mu.Lock()
+		if answered, ok := c.pending[resp.Id]; ok {
+			delete(c.pending, resp.Id)
+			answered <- resp
+		}
+		c.mu.Unlock()
+	}
+}
+
+// close fails the queries in flight, the following queries dial a new connection
+func (c *pipelinedConn) close(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	_ = c.conn.Close()
+	for id, answered := range c.pending {
+		delete(c.pending, id)
+		close(answered)
+	}
+}
+
+func (c *pipelinedConn) closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err != nil
+}
+
+// httpsForwarder posts the queries by RFC 8484, the HTTP transport keeps the connections alive and
+// multiplexes the queries by HTTP/2
+type httpsForwarder struct {
+	url    string
+	client *http.Client
+}
+
+func (f *httpsForwarder) exchange(ctx context.Context, req *dns.Msg) (*dns.Msg, error) {
+	// the ID is 0 so that the answers are cacheable by HTTP (RFC 8484 4.1)
+	msg := req.Copy()
+	msg.Id = 0
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, f.url, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", dnsMessageContentType)
+	httpReq.Header.Set("Accept", dnsMessageContentType)
+
+	httpResp, err := f.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DoH forwarder %s answers HTTP %d", f.url, httpResp.StatusCode)
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(httpResp.Body, dns.MaxMsgSize))
+	if err != nil {
+		return nil, err
+	}
+	resp := new(dns.Msg)
+	err = resp.Unpack(raw)
+	if err != nil {
+		return nil, err
+	}
+	resp.Id = req.Id
+	return resp, nil
+}
+
+// NewPool returns the pool verifying the certificates of DoT and DoH by the CA file, or by the system
+// roots when it isn't given, and skipping the verification when insecure
+func NewPool(caFile string, insecure bool) (*Pool, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: insecure}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate in %s", caFile)
+		}
+	}
+	return &Pool{tlsConfig: config, forwarders: make(map[string]forwarder)}, nil
+}
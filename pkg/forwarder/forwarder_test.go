@@ -0,0 +1,316 @@
+package forwarder
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/suite"
+)
+
+type forwarderTestSuite struct {
+	suite.Suite
+
+	pool *Pool
+
+	caFile string
+
+	dotServer *dns.Server
+	dotAddr   string
+	// dotConns counts the connections which the DoT server accepts
+	dotConns atomic.Int32
+
+	dohServer *httptest.Server
+}
+
+func TestForwarder(t *testing.T) {
+	suite.Run(t, &forwarderTestSuite{})
+}
+
+func (t *forwarderTestSuite) SetupSuite() {
+	cert := t.newCertificate()
+	t.caFile = filepath.Join(t.T().TempDir(), "ca.pem")
+	t.Nil(os.WriteFile(t.caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600))
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	t.Nil(err)
+	t.dotAddr = listener.Addr().String()
+	started := make(chan struct{})
+	t.dotServer = &dns.Server{
+		Listener:          tls.NewListener(&countingListener{listener, &t.dotConns}, config),
+		Net:               "tcp-tls",
+		Handler:           dns.HandlerFunc(t.answer),
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() {
+		_ = t.dotServer.ActivateAndServe()
+	}()
+	<-started
+
+	t.dohServer = httptest.NewUnstartedServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Type") != dnsMessageContentType {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					return
+				}
+				raw, _ := io.ReadAll(r.Body)
+				req := new(dns.Msg)
+				if req.Unpack(raw) != nil || req.Id != 0 {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				writer := &httpWriter{}
+				t.answer(writer, req)
+				packed, _ := writer.msg.Pack()
+				w.Header().Set("Content-Type", dnsMessageContentType)
+				_, _ = w.Write(packed)
+			},
+		),
+	)
+	t.dohServer.TLS = config
+	t.dohServer.StartTLS()
+
+	t.pool, err = NewPool(t.caFile, false)
+	t.Nil(err)
+}
+
+func (t *forwarderTestSuite) TearDownSuite() {
+	t.Nil(t.dotServer.Shutdown())
+	t.dohServer.Close()
+}
+
+// newCertificate makes a self-signed certificate of 127.0.0.1 and dns.test
+func (t *forwarderTestSuite) newCertificate() tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	t.Nil(err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "dns.test"},
+		DNSNames:              []string{"dns.test"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	t.Nil(err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// answer replies the question name as an A record of 192.0.2.1
+func (t *forwarderTestSuite) answer(w dns.ResponseWriter, req *dns.Msg) {
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	rr, _ := dns.NewRR(req.Question[0].Name + "\t300\tIN\tA\t192.0.2.1")
+	resp.Answer = append(resp.Answer, rr)
+	_ = w.WriteMsg(resp)
+}
+
+func (t *forwarderTestSuite) question(name string) *dns.Msg {
+	req := new(dns.Msg)
+	req.SetQuestion(name, dns.TypeA)
+	return req
+}
+
+func (t *forwarderTestSuite) TestParse() {
+	cases := []struct {
+		upstream string
+		uri      string
+	}{
+		{"1.1.1.1:53", "udp://1.1.1.1:53"},
+		{"1.1.1.1", "udp://1.1.1.1:53"},
+		{"tcp://[2001:db8::1]", "tcp://[2001:db8::1]:53"},
+		{"tls://1.1.1.1#cloudflare-dns.com", "tls://1.1.1.1:853#cloudflare-dns.com"},
+		{"https://dns.example", "https://dns.example/dns-query"},
+		{"https://dns.example:8443/resolve", "https://dns.example:8443/resolve"},
+	}
+	for _, c := range cases {
+		u, err := Parse(c.upstream)
+		t.Nil(err, c.upstream)
+		t.Equal(c.uri, u.String())
+	}
+
+	for _, upstream := range []string{"quic://1.1.1.1", "tls://", "https:///dns-query"} {
+		_, err := Parse(upstream)
+		t.NotNil(err, upstream)
+	}
+}
+
+func (t *forwarderTestSuite) TestExchange() {
+	t.Run(
+		"dot_pipelined_success", func() {
+			before := t.dotConns.Load()
+			var wg sync.WaitGroup
+			for i := 0; i < 16; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					req := t.question("www.test.")
+					resp, err := t.pool.Exchange(context.Background(), req, "tls://"+t.dotAddr+"#dns.test")
+					t.Nil(err)
+					t.Equal(req.Id, resp.Id)
+					t.Equal("www.test.", resp.Answer[0].Header().Name)
+				}()
+			}
+			wg.Wait()
+			// the queries share the connection of the first one
+			t.LessOrEqual(t.dotConns.Load()-before, int32(2))
+		},
+	)
+
+	t.Run(
+		"dot_redial_success", func() {
+			upstream := "tls://" + t.dotAddr
+			_, err := t.pool.Exchange(context.Background(), t.question("www.test."), upstream)
+			t.Nil(err)
+
+			f, _ := t.pool.get(upstream)
+			_ = f.(*tlsForwarder).conn.conn.Close()
+			time.Sleep(10 * time.Millisecond)
+			resp, err := t.pool.Exchange(context.Background(), t.question("again.test."), upstream)
+			t.Nil(err)
+			t.Equal("again.test.", resp.Answer[0].Header().Name)
+		},
+	)
+
+	t.Run(
+		"dot_verify_error", func() {
+			_, err := t.pool.Exchange(context.Background(), t.question("www.test."), "tls://"+t.dotAddr+"#other.test")
+			t.NotNil(err)
+
+			pool, err := NewPool("", false)
+			t.Nil(err)
+			_, err = pool.Exchange(context.Background(), t.question("www.test."), "tls://"+t.dotAddr+"#dns.test")
+			t.NotNil(err)
+		},
+	)
+
+	t.Run(
+		"dot_insecure_success", func() {
+			pool, err := NewPool("", true)
+			t.Nil(err)
+			resp, err := pool.Exchange(context.Background(), t.question("www.test."), "tls://"+t.dotAddr+"#other.test")
+			t.Nil(err)
+			t.Len(resp.Answer, 1)
+		},
+	)
+
+	t.Run(
+		"doh_success", func() {
+			req := t.question("doh.test.")
+			resp, err := t.pool.Exchange(context.Background(), req, t.dohServer.URL+"/dns-query")
+			t.Nil(err)
+			t.Equal(req.Id, resp.Id)
+			t.Equal("doh.test.", resp.Answer[0].Header().Name)
+		},
+	)
+
+	t.Run(
+		"doh_verify_error", func() {
+			pool, err := NewPool(t.caFile, false)
+			t.Nil(err)
+			f, _ := pool.get(t.dohServer.URL)
+			f.(*httpsForwarder).client.Transport.(*http.Transport).TLSClientConfig.RootCAs = nil
+			_, err = pool.Exchange(context.Background(), t.question("doh.test."), t.dohServer.URL)
+			t.NotNil(err)
+		},
+	)
+
+	t.Run(
+		"udp_truncated_tcp_fallback_success", func() {
+			handler := dns.HandlerFunc(
+				func(w dns.ResponseWriter, req *dns.Msg) {
+					if w.LocalAddr().Network() == "udp" {
+						resp := new(dns.Msg)
+						resp.SetReply(req)
+						resp.Truncated = true
+						_ = w.WriteMsg(resp)
+						return
+					}
+					t.answer(w, req)
+				},
+			)
+			packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			t.Nil(err)
+			listener, err := net.Listen("tcp", packetConn.LocalAddr().String())
+			t.Nil(err)
+			udpServer := &dns.Server{PacketConn: packetConn, Handler: handler}
+			tcpServer := &dns.Server{Listener: listener, Handler: handler}
+			go func() { _ = udpServer.ActivateAndServe() }()
+			go func() { _ = tcpServer.ActivateAndServe() }()
+			defer func() {
+				_ = udpServer.Shutdown()
+				_ = tcpServer.Shutdown()
+			}()
+
+			resp, err := t.pool.Exchange(context.Background(), t.question("big.test."), packetConn.LocalAddr().String())
+			t.Nil(err)
+			t.False(resp.Truncated)
+			t.Len(resp.Answer, 1)
+
+			resp, err = t.pool.Exchange(context.Background(), t.question("big.test."), "tcp://"+packetConn.LocalAddr().String())
+			t.Nil(err)
+			t.Len(resp.Answer, 1)
+		},
+	)
+
+	t.Run(
+		"unknown_scheme_error", func() {
+			_, err := t.pool.Exchange(context.Background(), t.question("www.test."), "quic://127.0.0.1")
+			t.NotNil(err)
+		},
+	)
+
+	t.Run(
+		"ca_file_error", func() {
+			_, err := NewPool(filepath.Join(t.T().TempDir(), "missing.pem"), false)
+			t.NotNil(err)
+		},
+	)
+}
+
+type countingListener struct {
+	net.Listener
+	accepted *atomic.Int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.accepted.Add(1)
+	}
+	return conn, err
+}
+
+// httpWriter captures the reply of the handler for the DoH server
+type httpWriter struct {
+	dns.ResponseWriter
+	msg *dns.Msg
+}
+
+func (w *httpWriter) WriteMsg(msg *dns.Msg) error {
+	w.msg = msg
+	return nil
+}
@@ -0,0 +1,256 @@
+package forwarder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// the strategies picking the order of the upstream forwarders of a group
+const (
+	// Sequential tries the upstreams in the configured order
+	Sequential = "sequential"
+	// RoundRobin starts from the next upstream on every exchange
+	RoundRobin = "round-robin"
+	// Fastest tries the upstreams by their smoothed RTTs
+	Fastest = "fastest"
+	// Parallel races the fastest upstreams, and tries the others in turn when none of them answers
+	Parallel = "parallel"
+)
+
+// probeTimeout bounds a health probe, which is a query of the NS records of the root
+const probeTimeout = 2 * time.Second
+
+var errNoUpstream = errors.New("no upstream forwarder answers")
+
+// Group picks the upstream forwarders by its strategy, tracks their RTTs and failures, and ejects the
+// ones failing in a row for a while, the ejected ones are still tried last when the others can't answer
+type Group struct {
+	pool      *Pool
+	upstreams []*upstream
+	strategy  string
+	// parallel is the number of the upstreams raced at once by the parallel strategy
+	parallel  int
+	maxFails  int
+	ejectTime time.Duration
+
+	next atomic.Uint32
+}
+
+// Status is the health of an upstream forwarder
+type Status struct {
+	Upstream            string
+	Healthy             bool
+	Rtt                 time.Duration
+	Exchanges           uint64
+	Failures            uint64
+	ConsecutiveFailures int
+	EjectedUntil        time.Time
+	LastProbe           time.Time
+	LastError           string
+}
+
+type upstream struct {
+	uri string
+
+	mu    sync.Mutex
+	stats Status
+}
+
+// Exchange asks the upstreams in the order of the strategy until an answer is accepted, the answers
+// which aren't accepted, e.g. SERVFAIL, don't count as failures of the upstreams
+func (g *Group) Exchange(ctx context.Context, req *dns.Msg, accept func(*dns.Msg) bool) (*dns.Msg, error) {
+	order := g.order(time.Now())
+	if g.strategy == Parallel && g.parallel > 1 && len(order) > 1 {
+		raced := min(g.parallel, len(order))
+		resp, err := g.race(ctx, req, order[:raced], accept)
+		if err == nil {
+			return resp, nil
+		}
+		order = order[raced:]
+	}
+
+	for _, u := range order {
+		resp, err := g.exchange(ctx, req, u)
+		if err == nil && accept(resp) {
+			return resp, nil
+		}
+	}
+	return nil, errNoUpstream
+}
+
+// Probe asks every upstream for the NS records of the root at once, a successful probe brings an
+// ejected upstream back
+func (g *Group) Probe(ctx context.Context) {
+	req := new(dns.Msg)
+	req.SetQuestion(".", dns.TypeNS)
+
+	var wg sync.WaitGroup
+	for _, u := range g.upstreams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+			defer cancel()
+			_, _ = g.exchange(probeCtx, req.Copy(), u)
+
+			u.mu.Lock()
+			u.stats.LastProbe = time.Now()
+			u.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+}
+
+// Statuses returns the health of the upstreams in the configured order
+func (g *Group) Statuses() []Status {
+	now := time.Now()
+	statuses := make([]Status, 0, len(g.upstreams))
+	for _, u := range g.upstreams {
+		u.mu.Lock()
+		status := u.stats
+		u.mu.Unlock()
+		status.Upstream = u.uri
+		status.Healthy = !now.Before(status.EjectedUntil)
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// order returns the healthy upstreams by the strategy, followed by the ejected ones in the configured
+// order
+func (g *Group) order(now time.Time) []*upstream {
+	var healthy, ejected []*upstream
+	rtts := make(map[*upstream]time.Duration)
+	for _, u := range g.upstreams {
+		u.mu.Lock()
+		if now.Before(u.stats.EjectedUntil) {
+			ejected = append(ejected, u)
+		} else {
+			healthy = append(healthy, u)
+			rtts[u] = u.stats.Rtt
+		}
+		u.mu.Unlock()
+	}
+
+	switch g.strategy {
+	case RoundRobin:
+		if len(healthy) > 0 {
+			start := int(g.next.Add(1)-1) % len(healthy)
+			healthy = append(healthy[start:], healthy[:start]...)
+		}
+	case Fastest, Parallel:
+		// the upstreams which haven't answered yet have no RTT, and are tried after the measured ones
+		slices.SortStableFunc(
+			healthy, func(a, b *upstream) int {
+				switch {
+				case rtts[a] == rtts[b]:
+					return 0
+				case rtts[a] == 0:
+					return 1
+				case rtts[b] == 0:
+					return -1
+				case rtts[a] < rtts[b]:
+					return -1
+				default:
+					return 1
+				}
+			},
+		)
+	}
+	return append(healthy, ejected...)
+}
+
+// race asks the upstreams at once, the first accepted answer wins and the other exchanges are
+// cancelled
+func (g *Group) race(ctx context.Context, req *dns.Msg, upstreams []*upstream, accept func(*dns.Msg) bool) (
+	*dns.Msg, error,
+) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	answers := make(chan *dns.Msg, len(upstreams))
+	for _, u := range upstreams {
+		go func() {
+			resp, err := g.exchange(ctx, req.Copy(), u)
+			if err != nil || !accept(resp) {
+				resp = nil
+			}
+			answers <- resp
+		}()
+	}
+	for range upstreams {
+		if resp := <-answers; resp != nil {
+			return resp, nil
+		}
+	}
+	return nil, errNoUpstream
+}
+
+func (g *Group) exchange(ctx context.Context, req *dns.Msg, u *upstream) (*dns.Msg, error) {
+	start := time.Now()
+	resp, err := g.pool.Exchange(ctx, req, u.uri)
+	rtt := time.Since(start)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	// the exchanges cancelled by the caller, e.g. losing a race, say nothing of the upstream
+	if err != nil && ctx.Err() == context.Canceled {
+		return nil, err
+	}
+	u.stats.Exchanges++
+	if err != nil {
+		u.stats.Failures++
+		u.stats.ConsecutiveFailures++
+		u.stats.LastError = err.Error()
+		if u.stats.ConsecutiveFailures >= g.maxFails {
+			u.stats.EjectedUntil = time.Now().Add(g.ejectTime)
+		}
+		return nil, err
+	}
+	u.stats.ConsecutiveFailures = 0
+	u.stats.EjectedUntil = time.Time{}
+	// the RTT is smoothed like the SRTT of TCP (RFC 6298 2.3)
+	if u.stats.Rtt == 0 {
+		u.stats.Rtt = rtt
+	} else {
+		u.stats.Rtt = (7*u.stats.Rtt + rtt) / 8
+	}
+	return resp, nil
+}
+
+// NewGroup returns the group of the upstream URIs, an upstream is ejected for the eject time after
+// failing max fails times in a row
+func NewGroup(pool *Pool, upstreams []string, strategy string, parallel, maxFails uint, ejectTime time.Duration) (
+	*Group, error,
+) {
+	switch strategy {
+	case Sequential, RoundRobin, Fastest, Parallel:
+	default:
+		return nil, fmt.Errorf("unknown strategy %s of upstream forwarders", strategy)
+	}
+	if len(upstreams) == 0 {
+		return nil, errors.New("no upstream forwarder")
+	}
+
+	g := &Group{
+		pool:      pool,
+		strategy:  strategy,
+		parallel:  int(max(parallel, 1)),
+		maxFails:  int(max(maxFails, 1)),
+		ejectTime: ejectTime,
+	}
+	for _, uri := range upstreams {
+		if _, err := Parse(uri); err != nil {
+			return nil, err
+		}
+		g.upstreams = append(g.upstreams, &upstream{uri: uri})
+	}
+	return g, nil
+}
@@ -0,0 +1,212 @@
+package forwarder
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/suite"
+)
+
+type groupTestSuite struct {
+	suite.Suite
+
+	pool *Pool
+
+	fast     string
+	slow     string
+	servfail string
+	// dead is a closed port, which refuses the queries at once
+	dead string
+
+	servers []*dns.Server
+}
+
+func TestGroup(t *testing.T) {
+	suite.Run(t, &groupTestSuite{})
+}
+
+func (t *groupTestSuite) SetupSuite() {
+	var err error
+	t.pool, err = NewPool("", false)
+	t.Nil(err)
+
+	t.fast = t.serve(0, dns.RcodeSuccess)
+	t.slow = t.serve(50*time.Millisecond, dns.RcodeSuccess)
+	t.servfail = t.serve(0, dns.RcodeServerFailure)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	t.Nil(err)
+	t.dead = conn.LocalAddr().String()
+	_ = conn.Close()
+}
+
+func (t *groupTestSuite) TearDownSuite() {
+	for _, server := range t.servers {
+		_ = server.Shutdown()
+	}
+}
+
+// serve starts an upstream answering with the rcode after the delay, the answer names the upstream
+func (t *groupTestSuite) serve(delay time.Duration, rcode int) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	t.Nil(err)
+	addr := conn.LocalAddr().String()
+
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn: conn,
+		Handler: dns.HandlerFunc(
+			func(w dns.ResponseWriter, req *dns.Msg) {
+				time.Sleep(delay)
+				resp := new(dns.Msg)
+				resp.SetRcode(req, rcode)
+				txt, _ := dns.NewRR(req.Question[0].Name + "\t300\tIN\tTXT\t" + addr)
+				resp.Answer = append(resp.Answer, txt)
+				_ = w.WriteMsg(resp)
+			},
+		),
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	<-started
+	t.servers = append(t.servers, server)
+	return addr
+}
+
+func (t *groupTestSuite) newGroup(strategy string, upstreams ...string) *Group {
+	g, err := NewGroup(t.pool, upstreams, strategy, 2, 2, time.Minute)
+	t.Nil(err)
+	return g
+}
+
+// ask returns the upstream which answers
+func (t *groupTestSuite) ask(g *Group) string {
+	req := new(dns.Msg)
+	req.SetQuestion("www.test.", dns.TypeTXT)
+	resp, err := g.Exchange(context.Background(), req, successful)
+	t.Nil(err)
+	if err != nil {
+		return ""
+	}
+	return resp.Answer[0].(*dns.TXT).Txt[0]
+}
+
+func successful(resp *dns.Msg) bool {
+	return resp.Rcode == dns.RcodeSuccess
+}
+
+func (t *groupTestSuite) TestSequential() {
+	t.Run(
+		"success", func() {
+			g := t.newGroup(Sequential, t.slow, t.fast)
+			t.Equal(t.slow, t.ask(g))
+			t.Equal(t.slow, t.ask(g))
+		},
+	)
+
+	t.Run(
+		"not_accepted_next_success", func() {
+			g := t.newGroup(Sequential, t.servfail, t.fast)
+			t.Equal(t.fast, t.ask(g))
+			// a SERVFAIL isn't a failure of the upstream
+			statuses := g.Statuses()
+			t.True(statuses[0].Healthy)
+			t.Equal(uint64(0), statuses[0].Failures)
+		},
+	)
+
+	t.Run(
+		"all_failed_error", func() {
+			g := t.newGroup(Sequential, t.servfail, t.dead)
+			req := new(dns.Msg)
+			req.SetQuestion("www.test.", dns.TypeTXT)
+			_, err := g.Exchange(context.Background(), req, successful)
+			t.NotNil(err)
+		},
+	)
+}
+
+func (t *groupTestSuite) TestRoundRobin() {
+	g := t.newGroup(RoundRobin, t.fast, t.slow)
+	t.Equal(t.fast, t.ask(g))
+	t.Equal(t.slow, t.ask(g))
+	t.Equal(t.fast, t.ask(g))
+}
+
+func (t *groupTestSuite) TestFastest() {
+	g := t.newGroup(Fastest, t.slow, t.fast)
+	// the unmeasured upstreams are tried in the configured order until they are measured
+	t.Equal(t.slow, t.ask(g))
+	g.Probe(context.Background())
+	t.Equal(t.fast, t.ask(g))
+
+	statuses := g.Statuses()
+	t.Greater(statuses[0].Rtt, statuses[1].Rtt)
+	t.False(statuses[1].LastProbe.IsZero())
+}
+
+func (t *groupTestSuite) TestParallel() {
+	t.Run(
+		"race_success", func() {
+			g := t.newGroup(Parallel, t.slow, t.fast)
+			start := time.Now()
+			t.Equal(t.fast, t.ask(g))
+			t.Less(time.Since(start), 50*time.Millisecond)
+		},
+	)
+
+	t.Run(
+		"race_failed_next_success", func() {
+			g := t.newGroup(Parallel, t.servfail, t.dead, t.fast)
+			t.Equal(t.fast, t.ask(g))
+		},
+	)
+}
+
+func (t *groupTestSuite) TestEject() {
+	g := t.newGroup(Sequential, t.dead, t.fast)
+	t.Equal(t.fast, t.ask(g))
+	t.True(g.Statuses()[0].Healthy)
+	t.Equal(t.fast, t.ask(g))
+
+	// the upstream is ejected after failing twice in a row, and isn't tried first any more
+	statuses := g.Statuses()
+	t.False(statuses[0].Healthy)
+	t.Equal(2, statuses[0].ConsecutiveFailures)
+	t.NotEmpty(statuses[0].LastError)
+	t.Equal(t.fast, t.ask(g))
+	t.Equal(uint64(2), g.Statuses()[0].Exchanges)
+
+	// the ejected upstreams are still tried last
+	g.upstreams[1].uri = t.servfail
+	req := new(dns.Msg)
+	req.SetQuestion("www.test.", dns.TypeTXT)
+	_, err := g.Exchange(context.Background(), req, successful)
+	t.NotNil(err)
+	t.Equal(uint64(3), g.Statuses()[0].Exchanges)
+	g.upstreams[1].uri = t.fast
+
+	// a successful probe brings the upstream back
+	g.upstreams[0].uri = t.slow
+	g.Probe(context.Background())
+	statuses = g.Statuses()
+	t.True(statuses[0].Healthy)
+	t.Equal(0, statuses[0].ConsecutiveFailures)
+	t.Equal(t.slow, t.ask(g))
+}
+
+func (t *groupTestSuite) TestNewGroup() {
+	_, err := NewGroup(t.pool, []string{t.fast}, "random", 2, 3, time.Minute)
+	t.NotNil(err)
+
+	_, err = NewGroup(t.pool, nil, Sequential, 2, 3, time.Minute)
+	t.NotNil(err)
+
+	_, err = NewGroup(t.pool, []string{"quic://1.1.1.1"}, Sequential, 2, 3, time.Minute)
+	t.NotNil(err)
+}
@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+)
+
+func RegisterDnssecRoutes(r *gin.Engine, handler domain.DnssecHandler) {
+	group := r.Group(api).Group(v1)
+	routes := []Route{
+		{
+			Name:    "Enable DNSSEC",
+			Group:   zones,
+			Pattern: ":zone/dnssec",
+			Method:  http.MethodPost,
+			Handler: handler.EnableDnssecAPI,
+		},
+		{
+			Name:    "Get DNSSEC",
+			Group:   zones,
+			Pattern: ":zone/dnssec",
+			Method:  http.MethodGet,
+			Handler: handler.GetDnssecAPI,
+		},
+		{
+			Name:    "Disable DNSSEC",
+			Group:   zones,
+			Pattern: ":zone/dnssec",
+			Method:  http.MethodDelete,
+			Handler: handler.DisableDnssecAPI,
+		},
+		{
+			Name:    "List DS Records",
+			Group:   zones,
+			Pattern: ":zone/dnssec/ds",
+			Method:  http.MethodGet,
+			Handler: handler.ListDSAPI,
+		},
+		{
+			Name:    "Rollover DNSSEC Keys",
+			Group:   zones,
+			Pattern: ":zone/dnssec/rollover",
+			Method:  http.MethodPost,
+			Handler: handler.RolloverAPI,
+		},
+	}
+
+	for i := 0; i < len(routes); i++ {
+		routes[i].registerURL(group)
+	}
+}
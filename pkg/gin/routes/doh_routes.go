@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+)
+
+func RegisterDoHRoutes(r *gin.Engine, handler domain.DoHHandler) {
+	group := r.Group("")
+	routes := []Route{
+		{
+			Name:    "DNS-over-HTTPS GET Query",
+			Group:   dnsQuery,
+			Pattern: "",
+			Method:  http.MethodGet,
+			Handler: handler.QueryGetAPI,
+		},
+		{
+			Name:    "DNS-over-HTTPS POST Query",
+			Group:   dnsQuery,
+			Pattern: "",
+			Method:  http.MethodPost,
+			Handler: handler.QueryPostAPI,
+		},
+	}
+
+	for i := 0; i < len(routes); i++ {
+		routes[i].registerURL(group)
+	}
+}
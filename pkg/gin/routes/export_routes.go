@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+)
+
+func RegisterExportRoutes(r *gin.Engine, handler domain.ExportHandler) {
+	group := r.Group(api).Group(v1)
+	routes := []Route{
+		{
+			Name:    "Export DNS Records",
+			Group:   export,
+			Pattern: "",
+			Method:  http.MethodGet,
+			Handler: handler.ExportAPI,
+		},
+	}
+
+	for i := 0; i < len(routes); i++ {
+		routes[i].registerURL(group)
+	}
+}
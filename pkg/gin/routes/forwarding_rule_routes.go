@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+)
+
+func RegisterForwardingRuleRoutes(r *gin.Engine, handler domain.ForwardingRuleHandler) {
+	group := r.Group(api).Group(v1)
+	routes := []Route{
+		{
+			Name:    "Create Forwarding Rule",
+			Group:   forwardingRules,
+			Pattern: "",
+			Method:  http.MethodPost,
+			Handler: handler.CreateForwardingRuleAPI,
+		},
+		{
+			Name:    "Get Forwarding Rule",
+			Group:   forwardingRules,
+			Pattern: ":suffix",
+			Method:  http.MethodGet,
+			Handler: handler.GetForwardingRuleAPI,
+		},
+		{
+			Name:    "List all Forwarding Rules",
+			Group:   forwardingRules,
+			Pattern: "",
+			Method:  http.MethodGet,
+			Handler: handler.ListForwardingRulesAPI,
+		},
+		{
+			Name:    "Update Forwarding Rule",
+			Group:   forwardingRules,
+			Pattern: ":suffix",
+			Method:  http.MethodPut,
+			Handler: handler.UpdateForwardingRuleAPI,
+		},
+		{
+			Name:    "Delete Forwarding Rule",
+			Group:   forwardingRules,
+			Pattern: ":suffix",
+			Method:  http.MethodDelete,
+			Handler: handler.DeleteForwardingRuleAPI,
+		},
+	}
+
+	for i := 0; i < len(routes); i++ {
+		routes[i].registerURL(group)
+	}
+}
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+)
+
+func RegisterNotifyRoutes(r *gin.Engine, handler domain.NotifyHandler) {
+	group := r.Group(api).Group(v1)
+	routes := []Route{
+		{
+			Name:    "Notify Zone",
+			Group:   zones,
+			Pattern: ":zone/notify",
+			Method:  http.MethodPost,
+			Handler: handler.NotifyZoneAPI,
+		},
+		{
+			Name:    "List Notify Status",
+			Group:   zones,
+			Pattern: ":zone/notify",
+			Method:  http.MethodGet,
+			Handler: handler.ListNotifyStatusAPI,
+		},
+	}
+
+	for i := 0; i < len(routes); i++ {
+		routes[i].registerURL(group)
+	}
+}
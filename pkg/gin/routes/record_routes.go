@@ -39,6 +39,20 @@ func RegisterRecordRoutes(r *gin.Engine, handler domain.RecordHandler) {
 			Method:  http.MethodPut,
 			Handler: handler.UpdateRecordAPI,
 		},
+		{
+			Name:    "Replace DNS RRset",
+			Group:   rrset,
+			Pattern: ":recordType",
+			Method:  http.MethodPut,
+			Handler: handler.ReplaceRRsetAPI,
+		},
+		{
+			Name:    "Remove DNS Record from RRset",
+			Group:   record,
+			Pattern: ":recordType",
+			Method:  http.MethodDelete,
+			Handler: handler.RemoveRecordAPI,
+		},
 		{
 			Name:    "Delete DNS Record",
 			Group:   record,
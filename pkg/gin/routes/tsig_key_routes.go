@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+)
+
+func RegisterTsigKeyRoutes(r *gin.Engine, handler domain.TsigKeyHandler) {
+	group := r.Group(api).Group(v1)
+	routes := []Route{
+		{
+			Name:    "Create TSIG Key",
+			Group:   tsigKeys,
+			Pattern: "",
+			Method:  http.MethodPost,
+			Handler: handler.CreateTsigKeyAPI,
+		},
+		{
+			Name:    "Get TSIG Key",
+			Group:   tsigKeys,
+			Pattern: ":name",
+			Method:  http.MethodGet,
+			Handler: handler.GetTsigKeyAPI,
+		},
+		{
+			Name:    "List all TSIG Keys",
+			Group:   tsigKeys,
+			Pattern: "",
+			Method:  http.MethodGet,
+			Handler: handler.ListTsigKeysAPI,
+		},
+		{
+			Name:    "Update TSIG Key",
+			Group:   tsigKeys,
+			Pattern: ":name",
+			Method:  http.MethodPut,
+			Handler: handler.UpdateTsigKeyAPI,
+		},
+		{
+			Name:    "Delete TSIG Key",
+			Group:   tsigKeys,
+			Pattern: ":name",
+			Method:  http.MethodDelete,
+			Handler: handler.DeleteTsigKeyAPI,
+		},
+	}
+
+	for i := 0; i < len(routes); i++ {
+		routes[i].registerURL(group)
+	}
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+)
+
+func RegisterUpstreamRoutes(r *gin.Engine, handler domain.UpstreamHandler) {
+	group := r.Group(api).Group(v1)
+	routes := []Route{
+		{
+			Name:    "List all Upstreams",
+			Group:   upstreams,
+			Pattern: "",
+			Method:  http.MethodGet,
+			Handler: handler.ListUpstreamsAPI,
+		},
+	}
+
+	for i := 0; i < len(routes); i++ {
+		routes[i].registerURL(group)
+	}
+}
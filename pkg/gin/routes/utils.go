@@ -12,7 +12,14 @@ const (
 )
 
 const (
-	record = "record"
+	record          = "record"
+	rrset           = "rrset"
+	zones           = "zones"
+	export          = "export"
+	dnsQuery        = "dns-query"
+	tsigKeys        = "tsig-keys"
+	upstreams       = "upstreams"
+	forwardingRules = "forwarding-rules"
 )
 
 type Route struct {
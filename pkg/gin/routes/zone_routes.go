@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+
+	"github.com/cewuandy/go-restful-dns/internal/domain"
+)
+
+func RegisterZoneRoutes(r *gin.Engine, handler domain.ZoneHandler) {
+	group := r.Group(api).Group(v1)
+	routes := []Route{
+		{
+			Name:    "Create Zone",
+			Group:   zones,
+			Pattern: "",
+			Method:  http.MethodPost,
+			Handler: handler.CreateZoneAPI,
+		},
+		{
+			Name:    "Get Zone",
+			Group:   zones,
+			Pattern: ":zone",
+			Method:  http.MethodGet,
+			Handler: handler.GetZoneAPI,
+		},
+		{
+			Name:    "List all Zones",
+			Group:   zones,
+			Pattern: "",
+			Method:  http.MethodGet,
+			Handler: handler.ListZonesAPI,
+		},
+		{
+			Name:    "Update Zone",
+			Group:   zones,
+			Pattern: ":zone",
+			Method:  http.MethodPut,
+			Handler: handler.UpdateZoneAPI,
+		},
+		{
+			Name:    "Delete Zone",
+			Group:   zones,
+			Pattern: ":zone",
+			Method:  http.MethodDelete,
+			Handler: handler.DeleteZoneAPI,
+		},
+		{
+			Name:    "Import Zone File",
+			Group:   zones,
+			Pattern: ":zone/import",
+			Method:  http.MethodPost,
+			Handler: handler.ImportZoneAPI,
+		},
+	}
+
+	for i := 0; i < len(routes); i++ {
+		routes[i].registerURL(group)
+	}
+}
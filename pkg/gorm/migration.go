@@ -12,5 +12,25 @@ func AutoMigrate(db *gorm.DB) error {
 	if err != nil {
 		return err
 	}
+	err = db.AutoMigrate(&models.Zone{})
+	if err != nil {
+		return err
+	}
+	err = db.AutoMigrate(&models.Journal{})
+	if err != nil {
+		return err
+	}
+	err = db.AutoMigrate(&models.TsigKey{})
+	if err != nil {
+		return err
+	}
+	err = db.AutoMigrate(&models.Dnssec{}, &models.DnssecKey{})
+	if err != nil {
+		return err
+	}
+	err = db.AutoMigrate(&models.ForwardingRule{})
+	if err != nil {
+		return err
+	}
 	return nil
 }
@@ -36,6 +36,13 @@ func LoadDefaultConfig(flagSet *flag.FlagSet, option *domain.Options) error {
 				(*uint)(unsafe.Pointer(field.Addr().Pointer())), strcase.ToKebab(name), uint(v),
 				usage,
 			)
+		case reflect.Bool:
+			v, _ := strconv.ParseBool(value)
+			field.SetBool(v)
+			flagSet.BoolVar(
+				(*bool)(unsafe.Pointer(field.Addr().Pointer())), strcase.ToKebab(name), v,
+				usage,
+			)
 		case reflect.String:
 			field.SetString(value)
 			flagSet.StringVar(